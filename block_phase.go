@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BlockPhase correlates faults with block import: for a window after each new head, requests
+// get extra latency and can fail with errors that never occur otherwise, like a node that is
+// busy importing the block a request races
+type BlockPhase struct {
+	Window       time.Duration `yaml:"window" json:"window"`                                   // Import phase after each new head
+	Latency      time.Duration `yaml:"latency,omitempty" json:"latency,omitempty"`             // Extra latency for requests arriving during the import phase
+	ErrorConfigs []ErrorConfig `yaml:"error_configs,omitempty" json:"error_configs,omitempty"` // Errors only evaluated during the import phase
+}
+
+// headTimes holds the time of the latest head of every chain by chain id
+var headTimes sync.Map // chainId -> *int64 (unix nanoseconds)
+
+// markNewHead records that a chain has just produced a new head
+func markNewHead(chainId string) {
+	at, _ := headTimes.LoadOrStore(chainId, new(int64))
+	atomic.StoreInt64(at.(*int64), time.Now().UnixNano())
+}
+
+// importing returns true while the chain is within the import phase of its latest head
+func (p *BlockPhase) importing(chainId string) bool {
+	if p == nil || p.Window <= 0 {
+		return false
+	}
+	at, ok := headTimes.Load(chainId)
+	if !ok {
+		return false
+	}
+	return time.Since(time.Unix(0, atomic.LoadInt64(at.(*int64)))) < p.Window
+}
+
+// validate checks the window, latency and error configs
+func (p *BlockPhase) validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Window < 0 || p.Latency < 0 {
+		return fmt.Errorf("block phase window and latency must be non-negative")
+	}
+	for i := range p.ErrorConfigs {
+		if p.ErrorConfigs[i].Probability < 0 || p.ErrorConfigs[i].Probability > 1 {
+			return fmt.Errorf("error probability must be between 0 and 1")
+		}
+		if err := p.ErrorConfigs[i].validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleSetBlockPhase configures the block phase faults of a chain; a zero window disables them
+func handleSetBlockPhase(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain        string        `json:"chain"`
+		WindowMs     int64         `json:"window_ms"`
+		LatencyMs    int64         `json:"latency_ms"`
+		ErrorConfigs []ErrorConfig `json:"error_configs"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var phase *BlockPhase
+	if request.WindowMs != 0 {
+		phase = &BlockPhase{
+			Window:       time.Duration(request.WindowMs) * time.Millisecond,
+			Latency:      time.Duration(request.LatencyMs) * time.Millisecond,
+			ErrorConfigs: request.ErrorConfigs,
+		}
+	}
+	if err := phase.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Chain == "solana" {
+		solanaNode.BlockPhase = phase
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.BlockPhase = phase
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	persistChainConfig()
+
+	if phase == nil {
+		log.Printf("Disabled block phase faults for chain %s", request.Chain)
+	} else {
+		log.Printf("Set block phase faults for chain %s: %v window, +%v latency, %d error configs",
+			request.Chain, phase.Window, phase.Latency, len(phase.ErrorConfigs))
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
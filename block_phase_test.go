@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ageHead pretends the latest head of a chain was produced d ago
+func ageHead(chainId string, d time.Duration) {
+	markNewHead(chainId)
+	at, _ := headTimes.Load(chainId)
+	atomic.StoreInt64(at.(*int64), time.Now().Add(-d).UnixNano())
+}
+
+func TestBlockPhaseFaults(t *testing.T) {
+	chain := supportedChains["optimism"]
+	defer func() { chain.BlockPhase = nil }()
+	chain.BlockPhase = &BlockPhase{
+		Window:       time.Second,
+		Latency:      100 * time.Millisecond,
+		ErrorConfigs: []ErrorConfig{{Code: -32000, Message: "header not found", Probability: 1, Methods: []string{"eth_getBlockByNumber"}}},
+	}
+	request := func(method string) (string, time.Duration) {
+		start := time.Now()
+		response, err := handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"`+method+`","params":["latest",false],"id":1}`), NewMockWSConn(), "10")
+		if err != nil {
+			t.Fatalf("Handler error: %v", err)
+		}
+		return string(response), time.Since(start)
+	}
+
+	// Right after a new head
+	subManager.BroadcastNewBlock("10", atomic.LoadUint64(&chain.BlockNumber))
+	subManager.Flush("10")
+	if response, _ := request("eth_getBlockByNumber"); !strings.Contains(response, "header not found") {
+		t.Errorf("Expected an import phase error, got %s", response)
+	}
+	if response, elapsed := request("eth_blockNumber"); strings.Contains(response, "error") || elapsed < 100*time.Millisecond {
+		t.Errorf("Expected a successful, delayed response, got %s after %v", response, elapsed)
+	}
+
+	// Outside the window
+	ageHead("10", 2*time.Second)
+	if response, elapsed := request("eth_getBlockByNumber"); strings.Contains(response, "error") || elapsed >= 100*time.Millisecond {
+		t.Errorf("Expected no block phase faults outside the window, got %s after %v", response, elapsed)
+	}
+}
+
+func TestSetBlockPhase(t *testing.T) {
+	withTempConfig(t)
+	defer func() {
+		supportedChains["optimism"].BlockPhase = nil
+	}()
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"chain": "optimism", "window_ms": 2000, "latency_ms": 500, "error_configs": [{"code": -32000, "message": "header not found", "probability": 0.5}]}`, http.StatusOK},
+		{`{"chain": "optimism", "window_ms": -1}`, http.StatusBadRequest},
+		{`{"chain": "optimism", "window_ms": 2000, "error_configs": [{"code": -32000, "probability": 2}]}`, http.StatusBadRequest},
+		{`{"chain": "unknown", "window_ms": 2000}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handleSetBlockPhase(w, httptest.NewRequest(http.MethodPost, "/control/chain/block-phase", bytes.NewBufferString(tt.body)))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.want, w.Code)
+		}
+	}
+	if phase := supportedChains["optimism"].BlockPhase; phase == nil || phase.Window != 2*time.Second || phase.Latency != 500*time.Millisecond {
+		t.Errorf("Unexpected block phase: %+v", phase)
+	}
+
+	w := httptest.NewRecorder()
+	handleSetBlockPhase(w, httptest.NewRequest(http.MethodPost, "/control/chain/block-phase", bytes.NewBufferString(`{"chain": "optimism", "window_ms": 0}`)))
+	if supportedChains["optimism"].BlockPhase != nil {
+		t.Error("Expected a zero window to disable block phase faults")
+	}
+}
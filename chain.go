@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"reflect"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -14,55 +17,124 @@ import (
 type Chain interface {
 	SetTimeout(duration time.Duration)
 	ClearTimeout()
-	InterruptBlocks()
+	InterruptBlocks() error
 	ResumeBlocks()
 	TriggerReorg(blocks int)
+	RunState() *RunStateMachine
 }
 
 type EVMChain struct {
-	Name                 string        `yaml:"name"`
-	ChainID              string        `yaml:"chain_id"`
-	BlockNumber          uint64        `yaml:"block_number"`          // Latest block number
-	SafeBlockNumber      uint64        `yaml:"safe_block_number"`      // Safe block (typically latest - 32 slots)
-	FinalizedBlockNumber uint64        `yaml:"finalized_block_number"` // Finalized block (typically latest - 64 slots)
-	BlockIncrement       uint32        `yaml:"block_increment"`
-	BlockInterrupt       uint32        `yaml:"block_interrupt"`
-	BlockInterval        time.Duration `yaml:"block_interval"`
-	ResponseTimeout      time.Duration
-	Latency              time.Duration `yaml:"latency"`
-	ErrorProbability      float64       `yaml:"error_probability"` // Deprecated: use ErrorConfigs instead
-	ErrorConfigs          []ErrorConfig `yaml:"error_configs" json:"error_configs"` // Configurable error simulation
-	LogsPerBlock          int           `yaml:"logs_per_block"`    // Number of log events to generate per block
-	LogIndex              uint64        // Incremental counter for log events
-	CustomResponse        string        // JSON response to return instead of normal response
-	CustomResponseEnabled bool          // Whether to use custom response
-	CustomResponseMethods []string      // Specific methods to apply custom response to (empty = all methods)
+	Name                   string                     `yaml:"name"`
+	ChainID                string                     `yaml:"chain_id"`
+	BlockNumber            uint64                     `yaml:"-"` // Latest block number
+	SafeBlockNumber        uint64                     `yaml:"-"` // Safe block (typically latest - 32 slots)
+	FinalizedBlockNumber   uint64                     `yaml:"-"` // Finalized block (typically latest - 64 slots)
+	BlockInterval          time.Duration              `yaml:"block_interval"`
+	ResponseTimeout        time.Duration              `yaml:"-"`
+	Latency                time.Duration              `yaml:"latency"`
+	LatencyDistribution    *LatencyDistribution       `yaml:"latency_distribution,omitempty"`                     // Sampled per request instead of Latency
+	LatencyPlacement       string                     `yaml:"latency_placement,omitempty"`                        // Where latency is applied: pre, post or split (see LatencyPlacements)
+	ErrorProbability       float64                    `yaml:"error_probability"`                                  // Deprecated: use ErrorConfigs instead
+	ErrorConfigs           []ErrorConfig              `yaml:"error_configs" json:"error_configs"`                 // Configurable error simulation
+	LogsPerBlock           int                        `yaml:"logs_per_block"`                                     // Number of log events to generate per block
+	LogFixtures            []LogFixture               `yaml:"log_fixtures,omitempty" json:"log_fixtures"`         // Templates for generated logs (empty = zero-filled logs)
+	LogFixturesFile        string                     `yaml:"log_fixtures_file,omitempty"`                        // YAML/JSON file of log fixtures loaded at startup, e.g. from cmd/genfixtures
+	LogIndex               uint64                     `yaml:"-"`                                                  // Incremental counter for log events
+	CustomResponse         string                     `yaml:"custom_response,omitempty"`                          // JSON response to return instead of normal response
+	CustomResponseEnabled  bool                       `yaml:"custom_response_enabled,omitempty"`                  // Whether to use custom response
+	CustomResponseMethods  []string                   `yaml:"custom_response_methods,omitempty"`                  // Specific methods to apply custom response to (empty = all methods)
+	DisableNewHeadsWithTx  bool                       `yaml:"disable_new_heads_with_tx"`                          // Reject the non-standard includeTransactions newHeads option
+	StrictSubscribeParams  bool                       `yaml:"strict_subscribe_params,omitempty"`                  // Reject extra eth_subscribe arguments and unknown option fields instead of ignoring them
+	IDMangleMode           string                     `yaml:"id_mangle_mode"`                                     // Fault mode that alters response ids (see IDMangleModes)
+	EnvelopeFault          string                     `yaml:"envelope_fault,omitempty"`                           // Fault that breaks the JSON-RPC envelope of responses (see EnvelopeFaults)
+	HealthCheck            *HealthCheckIsolation      `yaml:"health_check,omitempty"`                             // Health-check methods bypass latency and faults (see HealthCheckIsolation)
+	IdentityFastPath       bool                       `yaml:"identity_fast_path,omitempty"`                       // Identity methods bypass latency and faults (see identityMethods)
+	ProtocolStrictness     string                     `yaml:"protocol_strictness"`                                // JSON-RPC 2.0 enforcement level (see StrictnessLevels)
+	FirstNotificationDelay time.Duration              `yaml:"first_notification_delay,omitempty"`                 // Withhold notifications this long after a new subscription
+	CompressionBombSize    int64                      `yaml:"compression_bomb_size,omitempty"`                    // Pad gzip HTTP responses to this decompressed size in bytes (0 = disabled)
+	NotificationPadding    int                        `yaml:"notification_padding,omitempty"`                     // Filler bytes added to newHeads and logs notifications (0 = disabled); read at startup, then see setNotificationPadding
+	MethodOverrides        map[string]*MethodOverride `yaml:"method_overrides,omitempty" json:"method_overrides"` // Per-method latency, error and response overrides
+	IdleTimeout            time.Duration              `yaml:"idle_timeout,omitempty"`                             // Close WebSocket connections without requests for this long (0 = disabled)
+	SlowClientTimeout      time.Duration              `yaml:"slow_client_timeout,omitempty"`                      // A write blocked this long marks the client as slow (0 = disabled)
+	SlowClientPolicy       string                     `yaml:"slow_client_policy,omitempty"`                       // What to do with slow clients: wait, drop or close (see SlowClientPolicies)
+	IdleWarning            time.Duration              `yaml:"idle_warning,omitempty"`                             // Send a connection_warning this long before an idle close (0 = no warning)
+	Middleware             *Middleware                `yaml:"middleware,omitempty" json:"middleware"`             // Request/response hooks from a Go plugin or WASM module
+	WasmMethods            map[string]*WasmMethod     `yaml:"wasm_methods,omitempty" json:"wasm_methods"`         // Custom RPC methods implemented by WASM modules, keyed by method name
+	ManualMining           bool                       `yaml:"manual_mining,omitempty"`                            // Automine off: blocks are only produced by POST /control/block/mine
+	BlockPhase             *BlockPhase                `yaml:"block_phase,omitempty" json:"block_phase"`           // Latency and errors during the import phase after each new head
+	Peers                  *PeerSimulation            `yaml:"peers,omitempty" json:"peers"`                       // Fluctuating net_peerCount and net_listening dropouts (nil = static)
+	Hosts                  []string                   `yaml:"hosts,omitempty" json:"hosts"`                       // Host names (SNI or Host header) routed to this chain, e.g. eth.sim.local
+	fileLogFixtures        []LogFixture               // Fixtures loaded from LogFixturesFile, not persisted
+	miningMu               sync.Mutex                 // Protects txQueue and minedTxs
+	txQueue                []QueuedTransaction        // Injected transactions waiting for a mined block
+	minedTxs               map[uint64][]Transaction   // Injected transactions of recent blocks by block number
+	runState               RunStateMachine            // Block production run state (running/paused/interrupted)
+	discontinuities        int32                      // Upcoming heads broadcast with a broken parentHash (atomic)
+	configMu               sync.Mutex                 // Serializes versioned error config and custom response changes
+	errorConfigsVersion    uint64                     // Incremented on every error config change
+	customResponseVersion  uint64                     // Incremented on every custom response change
+	padding                atomic.Value               // *notificationPadding: the current NotificationPadding with its filler
 }
 
 type SolanaNode struct {
-	SlotNumber      uint64
-	SlotInterval    time.Duration `yaml:"slot_interval"`
-	SlotIncrement   uint32        // 0 = normal, 1 = paused
-	BlockInterrupt  uint32        // 0 = normal, 1 = interrupted
-	ResponseTimeout time.Duration
-	Version         string        `yaml:"version"`
-	FeatureSet      uint32        `yaml:"feature_set"`
-	Latency         time.Duration `yaml:"latency"`
+	SlotNumber             uint64                           `yaml:"-"`
+	SlotInterval           time.Duration                    `yaml:"slot_interval"`
+	ResponseTimeout        time.Duration                    `yaml:"-"`
+	Version                string                           `yaml:"version"`
+	FeatureSet             uint32                           `yaml:"feature_set"`
+	Latency                time.Duration                    `yaml:"latency"`
+	LatencyDistribution    *LatencyDistribution             `yaml:"latency_distribution,omitempty"`                         // Sampled per request instead of Latency
+	LatencyPlacement       string                           `yaml:"latency_placement,omitempty"`                            // Where latency is applied: pre, post or split (see LatencyPlacements)
+	IDMangleMode           string                           `yaml:"id_mangle_mode"`                                         // Fault mode that alters response ids (see IDMangleModes)
+	EnvelopeFault          string                           `yaml:"envelope_fault,omitempty"`                               // Fault that breaks the JSON-RPC envelope of responses (see EnvelopeFaults)
+	HealthCheck            *HealthCheckIsolation            `yaml:"health_check,omitempty"`                                 // Health-check methods bypass latency and faults (see HealthCheckIsolation)
+	IdentityFastPath       bool                             `yaml:"identity_fast_path,omitempty"`                           // Identity methods bypass latency and faults (see identityMethods)
+	ProtocolStrictness     string                           `yaml:"protocol_strictness"`                                    // JSON-RPC 2.0 enforcement level (see StrictnessLevels)
+	LargestAccounts        []LargestAccount                 `yaml:"largest_accounts,omitempty"`                             // Fixture data for getLargestAccounts (empty = defaults)
+	TokenLargestAccounts   map[string][]TokenLargestAccount `yaml:"token_largest_accounts,omitempty"`                       // Fixture data for getTokenLargestAccounts keyed by mint
+	LargestAccountsLimit   int                              `yaml:"largest_accounts_limit,omitempty"`                       // Maximum entries per page (0 = 20)
+	SubscriptionIDReuse    bool                             `yaml:"subscription_id_reuse,omitempty"`                        // Aggressively reuse freed subscription ids
+	UnsubscribeGrace       time.Duration                    `yaml:"unsubscribe_grace,omitempty"`                            // Notifications keep arriving this long after unsubscribe
+	TransactionErrors      []SolanaTxErrorConfig            `yaml:"transaction_errors,omitempty" json:"transaction_errors"` // TransactionError injection for sendTransaction/simulateTransaction
+	FirstNotificationDelay time.Duration                    `yaml:"first_notification_delay,omitempty"`                     // Withhold notifications this long after a new subscription
+	CompressionBombSize    int64                            `yaml:"compression_bomb_size,omitempty"`                        // Pad gzip HTTP responses to this decompressed size in bytes (0 = disabled)
+	MethodOverrides        map[string]*MethodOverride       `yaml:"method_overrides,omitempty" json:"method_overrides"`     // Per-method latency, error and response overrides
+	ProgramLogs            []SolanaProgramLog               `yaml:"program_logs,omitempty" json:"program_logs"`             // Program log templates for logsSubscribe and getTransaction
+	IdleTimeout            time.Duration                    `yaml:"idle_timeout,omitempty"`                                 // Close WebSocket connections without requests for this long (0 = disabled)
+	SlowClientTimeout      time.Duration                    `yaml:"slow_client_timeout,omitempty"`                          // A write blocked this long marks the client as slow (0 = disabled)
+	SlowClientPolicy       string                           `yaml:"slow_client_policy,omitempty"`                           // What to do with slow clients: wait, drop or close (see SlowClientPolicies)
+	IdleWarning            time.Duration                    `yaml:"idle_warning,omitempty"`                                 // Send a connection_warning this long before an idle close (0 = no warning)
+	ProgramLogsFile        string                           `yaml:"program_logs_file,omitempty"`                            // YAML/JSON file of program log templates loaded at startup, e.g. from cmd/genfixtures
+	Middleware             *Middleware                      `yaml:"middleware,omitempty" json:"middleware"`                 // Request/response hooks from a Go plugin or WASM module
+	WasmMethods            map[string]*WasmMethod           `yaml:"wasm_methods,omitempty" json:"wasm_methods"`             // Custom RPC methods implemented by WASM modules, keyed by method name
+	ManualMining           bool                             `yaml:"manual_mining,omitempty"`                                // Automine off: slots are only produced by POST /control/block/mine
+	BlockPhase             *BlockPhase                      `yaml:"block_phase,omitempty" json:"block_phase"`               // Latency and errors during the import phase after each new slot
+	Hosts                  []string                         `yaml:"hosts,omitempty" json:"hosts"`                           // Host names (SNI or Host header) routed to the Solana node
+	fileProgramLogs        []SolanaProgramLog               // Templates loaded from ProgramLogsFile, not persisted
+	runState               RunStateMachine                  // Slot production run state (running/paused/interrupted)
 }
 
 type ChainConfig struct {
 	EVMChains map[string]*EVMChain `yaml:"evm_chains"`
 	Solana    *SolanaNode          `yaml:"solana"`
+	Retention *RetentionConfig     `yaml:"retention,omitempty"`  // Caps for in-memory stores (see RetentionConfig)
+	Mirror    *MirrorConfig        `yaml:"mirror,omitempty"`     // External sink receiving a copy of every request (see MirrorConfig)
+	ErrorSets map[string]*ErrorSet `yaml:"error_sets,omitempty"` // Named error configs applied to several chains at once (see ErrorSet)
+	Regions   map[string]*Region   `yaml:"regions,omitempty"`    // Virtual regions selected by clients for extra latency (see Region)
+	Grafana   *GrafanaConfig       `yaml:"grafana,omitempty"`    // Grafana instance receiving incidents as annotations (see GrafanaConfig)
 }
 
 var (
 	supportedChains map[string]*EVMChain
 	solanaNode      *SolanaNode
+	configFile      = "chains.yaml" // Chain configuration loaded at startup
+	persistConfig   bool            // Write runtime changes back to configFile, enabled by PERSIST_CONFIG
+	persistMu       sync.Mutex      // Serializes writes of configFile
 )
 
 func init() {
 	// Load chain configurations from YAML file
-	data, err := os.ReadFile("chains.yaml")
+	data, err := os.ReadFile(configFile)
 	if err != nil {
 		log.Fatalf("Failed to read chains.yaml: %v", err)
 	}
@@ -75,19 +147,103 @@ func init() {
 	// Initialize global variables
 	supportedChains = config.EVMChains
 	solanaNode = config.Solana
+	retentionConfig = config.Retention
+	logChainConsistency()
+	if err := config.Mirror.validate(); err != nil {
+		log.Fatalf("Invalid mirror configuration: %v", err)
+	}
+	mirror.configure(config.Mirror)
+	if err := config.Grafana.validate(); err != nil {
+		log.Fatalf("Invalid grafana configuration: %v", err)
+	}
+	incidents.configure(config.Grafana)
 
 	// Initialize block numbers for each chain
-	for _, chain := range supportedChains {
+	for name, chain := range supportedChains {
 		chain.BlockNumber = 1
-		chain.BlockIncrement = 0
+		chain.runState.Reset()
+		if err := resolveMethodOverrides(chain.MethodOverrides); err != nil {
+			log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+		}
+		if err := resolveMiddleware(chain.Middleware); err != nil {
+			log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+		}
+		if err := chain.BlockPhase.validate(); err != nil {
+			log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+		}
+		if err := chain.Peers.validate(); err != nil {
+			log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+		}
+		if err := validateNotificationPadding(chain.NotificationPadding); err != nil {
+			log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+		}
+		chain.setNotificationPadding(chain.NotificationPadding)
+		if err := chain.LatencyDistribution.validate(); err != nil {
+			log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+		}
+		if err := chain.HealthCheck.validate(); err != nil {
+			log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+		}
+		if !isValidEnvelopeFault(chain.EnvelopeFault) {
+			log.Fatalf("Invalid configuration for chain %s: unknown envelope fault %q", name, chain.EnvelopeFault)
+		}
+		for i := range chain.ErrorConfigs {
+			if err := chain.ErrorConfigs[i].validate(); err != nil {
+				log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+			}
+		}
+		if err := loadWasmMethods(chainIDForName(name), chain.WasmMethods); err != nil {
+			log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+		}
+		if chain.LogFixturesFile != "" {
+			fixtures, err := loadLogFixtures(chain.LogFixturesFile)
+			if err != nil {
+				log.Fatalf("Invalid configuration for chain %s: %v", name, err)
+			}
+			chain.fileLogFixtures = fixtures
+		}
 		// Set default logs per block if not configured
 		if chain.LogsPerBlock == 0 {
 			chain.LogsPerBlock = 5
 		}
 	}
+	if err := configureErrorSets(config.ErrorSets); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := configureRegions(config.Regions); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	// Initialize Solana slot number
 	solanaNode.SlotNumber = 1
-	solanaNode.SlotIncrement = 0
+	solanaNode.runState.Reset()
+	if err := resolveMethodOverrides(solanaNode.MethodOverrides); err != nil {
+		log.Fatalf("Invalid configuration for Solana: %v", err)
+	}
+	if err := resolveMiddleware(solanaNode.Middleware); err != nil {
+		log.Fatalf("Invalid configuration for Solana: %v", err)
+	}
+	if err := solanaNode.BlockPhase.validate(); err != nil {
+		log.Fatalf("Invalid configuration for Solana: %v", err)
+	}
+	if err := solanaNode.LatencyDistribution.validate(); err != nil {
+		log.Fatalf("Invalid configuration for Solana: %v", err)
+	}
+	if err := solanaNode.HealthCheck.validate(); err != nil {
+		log.Fatalf("Invalid configuration for Solana: %v", err)
+	}
+	if !isValidEnvelopeFault(solanaNode.EnvelopeFault) {
+		log.Fatalf("Invalid configuration for Solana: unknown envelope fault %q", solanaNode.EnvelopeFault)
+	}
+	if err := loadWasmMethods("501", solanaNode.WasmMethods); err != nil {
+		log.Fatalf("Invalid configuration for Solana: %v", err)
+	}
+	if solanaNode.ProgramLogsFile != "" {
+		templates, err := loadSolanaProgramLogs(solanaNode.ProgramLogsFile)
+		if err != nil {
+			log.Fatalf("Invalid configuration for Solana: %v", err)
+		}
+		solanaNode.fileProgramLogs = templates
+	}
 }
 
 // EVMChain methods
@@ -99,16 +255,26 @@ func (c *EVMChain) ClearTimeout() {
 	c.ResponseTimeout = 0
 }
 
-func (c *EVMChain) InterruptBlocks() {
-	atomic.StoreUint32(&c.BlockInterrupt, 1)
+func (c *EVMChain) InterruptBlocks() error {
+	if _, err := c.runState.Transition(RunEventInterrupt); err != nil {
+		return err
+	}
 	log.Printf("Block emissions interrupted for chain %s", c.Name)
+	return nil
 }
 
+// ResumeBlocks ends an interruption; a chain paused in the meantime stays paused
 func (c *EVMChain) ResumeBlocks() {
-	atomic.StoreUint32(&c.BlockInterrupt, 0)
+	if _, err := c.runState.Transition(RunEventInterruptEnd); err != nil {
+		return
+	}
 	log.Printf("Block emissions resumed for chain %s", c.Name)
 }
 
+func (c *EVMChain) RunState() *RunStateMachine {
+	return &c.runState
+}
+
 func (c *EVMChain) TriggerReorg(blocks int) {
 	currentBlock := atomic.LoadUint64(&c.BlockNumber)
 	if currentBlock < uint64(blocks) {
@@ -119,7 +285,7 @@ func (c *EVMChain) TriggerReorg(blocks int) {
 	atomic.StoreUint64(&c.BlockNumber, currentBlock-uint64(blocks))
 
 	// Broadcast the reorg through the subscription manager
-	subManager.BroadcastNewBlock(c.ChainID, currentBlock-uint64(blocks))
+	subManager.BroadcastNewBlock(chainIDForName(c.Name), currentBlock-uint64(blocks))
 }
 
 // SolanaNode methods
@@ -131,16 +297,26 @@ func (n *SolanaNode) ClearTimeout() {
 	n.ResponseTimeout = 0
 }
 
-func (n *SolanaNode) InterruptBlocks() {
-	atomic.StoreUint32(&n.BlockInterrupt, 1)
+func (n *SolanaNode) InterruptBlocks() error {
+	if _, err := n.runState.Transition(RunEventInterrupt); err != nil {
+		return err
+	}
 	log.Printf("Slot emissions interrupted for Solana")
+	return nil
 }
 
+// ResumeBlocks ends an interruption; a node paused in the meantime stays paused
 func (n *SolanaNode) ResumeBlocks() {
-	atomic.StoreUint32(&n.BlockInterrupt, 0)
+	if _, err := n.runState.Transition(RunEventInterruptEnd); err != nil {
+		return
+	}
 	log.Printf("Slot emissions resumed for Solana")
 }
 
+func (n *SolanaNode) RunState() *RunStateMachine {
+	return &n.runState
+}
+
 func (n *SolanaNode) TriggerReorg(blocks int) {
 	currentSlot := atomic.LoadUint64(&n.SlotNumber)
 	if currentSlot < uint64(blocks) {
@@ -168,6 +344,88 @@ func SaveChainConfig(filename string, config *ChainConfig) error {
 	return nil
 }
 
+// persistChainConfig writes the current runtime configuration, including error
+// configs and custom responses, back to the config file so it survives restarts.
+// It does nothing unless persistence is enabled. The file is rewritten from the running
+// configuration, so its comments and formatting are lost.
+func persistChainConfig() {
+	if !persistConfig {
+		return
+	}
+	errorSets.RLock()
+	sets := make(map[string]*ErrorSet, len(errorSets.byName))
+	for name, set := range errorSets.byName {
+		sets[name] = set
+	}
+	errorSets.RUnlock()
+	regions.RLock()
+	defined := make(map[string]*Region, len(regions.byName))
+	for name, region := range regions.byName {
+		defined[name] = region
+	}
+	regions.RUnlock()
+
+	incidents.mu.Lock()
+	grafana := incidents.config
+	incidents.mu.Unlock()
+
+	persistMu.Lock()
+	defer persistMu.Unlock()
+
+	// Hold the chains' config locks while marshaling, in name order like updateErrorConfigsAcross
+	names := make([]string, 0, len(supportedChains))
+	for name := range supportedChains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		supportedChains[name].configMu.Lock()
+	}
+	latencyMu.RLock()
+	chains := make(map[string]*EVMChain, len(supportedChains))
+	for name, chain := range supportedChains {
+		chains[name] = new(EVMChain)
+		copyPersistedFields(chains[name], chain)
+		chains[name].NotificationPadding = chain.notificationPadding().size
+	}
+	solana := new(SolanaNode)
+	copyPersistedFields(solana, solanaNode)
+	latencyMu.RUnlock()
+	mirror.mu.RLock()
+	data, err := yaml.Marshal(&ChainConfig{
+		EVMChains: chains,
+		Solana:    solana,
+		Retention: retentionConfig,
+		Mirror:    mirror.config,
+		ErrorSets: sets,
+		Regions:   defined,
+		Grafana:   grafana,
+	})
+	mirror.mu.RUnlock()
+	for _, name := range names {
+		supportedChains[name].configMu.Unlock()
+	}
+
+	if err == nil {
+		err = os.WriteFile(configFile, data, 0644)
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to save chain configuration: %v", err)
+	}
+}
+
+// copyPersistedFields copies the exported fields written to the config file from the struct
+// src points to into dst. Fields are read one at a time, so the block and slot counters that
+// block production updates atomically (tagged yaml:"-") are never read.
+func copyPersistedFields(dst, src interface{}) {
+	from, to := reflect.ValueOf(src).Elem(), reflect.ValueOf(dst).Elem()
+	for i := 0; i < from.NumField(); i++ {
+		if field := from.Type().Field(i); field.IsExported() && field.Tag.Get("yaml") != "-" {
+			to.Field(i).Set(from.Field(i))
+		}
+	}
+}
+
 // LoadChainConfig loads the chain configuration from a YAML file
 func LoadChainConfig(filename string) (*ChainConfig, error) {
 	data, err := os.ReadFile(filename)
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// isEVMChainID returns true if the chain ID is routed to an EVM chain
+func isEVMChainID(chainId string) bool {
+	_, ok := chainIdToName[chainId]
+	return ok && chainId != "501"
+}
+
+// checkChainConsistency returns the chains that are referenced in code or configuration but
+// can't be reached: configured chains whose chain_id is not routed to them, and routed chain
+// IDs without a configured chain
+func checkChainConsistency(chains map[string]*EVMChain, solana *SolanaNode) []string {
+	var problems []string
+	configured := make(map[string]bool)
+	for name, chain := range chains {
+		if chain.Name != name {
+			problems = append(problems, fmt.Sprintf("chain %s: name is %q", name, chain.Name))
+		}
+		id, err := strconv.ParseUint(strings.TrimPrefix(chain.ChainID, "0x"), 16, 64)
+		if err != nil || !strings.HasPrefix(chain.ChainID, "0x") {
+			problems = append(problems, fmt.Sprintf("chain %s: invalid chain_id %q, expected hex like 0x1", name, chain.ChainID))
+			continue
+		}
+		chainId := strconv.FormatUint(id, 10)
+		switch routed, ok := chainIdToName[chainId]; {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("chain %s: chain ID %s is not routable, add it to chainIdToName", name, chainId))
+		case routed != name:
+			problems = append(problems, fmt.Sprintf("chain %s: chain ID %s routes to %s", name, chainId, routed))
+		default:
+			configured[chainId] = true
+		}
+	}
+	for chainId, name := range chainIdToName {
+		if chainId == "501" {
+			if solana == nil {
+				problems = append(problems, "chain ID 501 routes to solana, which is not configured")
+			}
+			continue
+		}
+		if !configured[chainId] {
+			problems = append(problems, fmt.Sprintf("chain ID %s routes to %s, which is not configured", chainId, name))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// logChainConsistency warns about unreachable chains at startup
+func logChainConsistency() {
+	for _, problem := range checkChainConsistency(supportedChains, solanaNode) {
+		log.Printf("Warning: chain configuration: %s", problem)
+	}
+}
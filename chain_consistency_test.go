@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestChainConfigurationConsistent(t *testing.T) {
+	if problems := checkChainConsistency(supportedChains, solanaNode); len(problems) > 0 {
+		t.Errorf("Expected every chain in chains.yaml to be routable, got:\n%s", strings.Join(problems, "\n"))
+	}
+}
+
+func TestChainConsistencyFlagsUnroutableChains(t *testing.T) {
+	chains := map[string]*EVMChain{
+		"ethereum": {Name: "ethereum", ChainID: "0x1"},
+		"mystery":  {Name: "mystery", ChainID: "0x2a"},
+		"renamed":  {Name: "other", ChainID: "0xa"},
+		"decimal":  {Name: "decimal", ChainID: "56"},
+	}
+	problems := strings.Join(checkChainConsistency(chains, nil), "\n")
+	for _, want := range []string{
+		"chain mystery: chain ID 42 is not routable",
+		`chain renamed: name is "other"`,
+		"chain renamed: chain ID 10 routes to optimism",
+		`chain decimal: invalid chain_id "56"`,
+		"chain ID 130 routes to unichain, which is not configured",
+		"chain ID 501 routes to solana, which is not configured",
+	} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("Expected %q among the problems:\n%s", want, problems)
+		}
+	}
+	if strings.Contains(problems, "chain ethereum") || strings.Contains(problems, "chain ID 1 ") {
+		t.Errorf("Expected ethereum to be consistent:\n%s", problems)
+	}
+}
+
+func TestUnichainAndSonicServed(t *testing.T) {
+	for chainId, want := range map[string]string{"130": "0x82", "146": "0x92"} {
+		w := httptest.NewRecorder()
+		handleChainHTTP(w, httptest.NewRequest(http.MethodPost, "/chain/"+chainId, bytes.NewBufferString(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`)))
+		var response JSONRPCResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil || response.Result != want {
+			t.Errorf("Chain %s: expected eth_chainId %s, got %v (%v)", chainId, want, response.Result, err)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handleChainWebSocket))
+	defer server.Close()
+	for _, chainId := range []string{"130", "146"} {
+		client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/chain/"+chainId, nil)
+		if err != nil {
+			t.Fatalf("Chain %s: dial failed: %v", chainId, err)
+		}
+		client.SetReadDeadline(time.Now().Add(5 * time.Second))
+		client.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"],"id":1}`))
+		var subscribed JSONRPCResponse
+		if err := client.ReadJSON(&subscribed); err != nil || subscribed.Result == nil {
+			t.Fatalf("Chain %s: subscribe failed: %v %+v", chainId, err, subscribed.Error)
+		}
+
+		subManager.BroadcastNewBlock(chainId, 1234)
+		subManager.Flush(chainId)
+		var notification struct {
+			Method string `json:"method"`
+			Params struct {
+				Result struct {
+					Number string `json:"number"`
+				} `json:"result"`
+			} `json:"params"`
+		}
+		for notification.Params.Result.Number != "0x4d2" {
+			if err := client.ReadJSON(&notification); err != nil {
+				t.Fatalf("Chain %s: expected a newHeads notification for block 1234: %v", chainId, err)
+			}
+		}
+		if notification.Method != "eth_subscription" {
+			t.Errorf("Chain %s: expected eth_subscription, got %s", chainId, notification.Method)
+		}
+		client.Close()
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ABIParam is an event input from a contract ABI
+type ABIParam struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Indexed bool   `json:"indexed"`
+}
+
+// ABIEvent is an event entry from a contract ABI
+type ABIEvent struct {
+	Type      string     `json:"type"`
+	Name      string     `json:"name"`
+	Inputs    []ABIParam `json:"inputs"`
+	Anonymous bool       `json:"anonymous"`
+}
+
+// parseABIEvents returns the events of a contract ABI. Both a bare ABI array and
+// a compiler artifact with an "abi" field are accepted.
+func parseABIEvents(data []byte) ([]ABIEvent, error) {
+	var entries []ABIEvent
+	if err := json.Unmarshal(data, &entries); err != nil {
+		var artifact struct {
+			ABI []ABIEvent `json:"abi"`
+		}
+		if err := json.Unmarshal(data, &artifact); err != nil || artifact.ABI == nil {
+			return nil, fmt.Errorf("invalid ABI JSON: %v", err)
+		}
+		entries = artifact.ABI
+	}
+
+	var events []ABIEvent
+	for _, entry := range entries {
+		if entry.Type == "event" {
+			events = append(events, entry)
+		}
+	}
+	return events, nil
+}
+
+// abiType is a parsed Solidity type; only elementary types and arrays of static elementary types are supported
+type abiType struct {
+	base     string // Canonical elementary type, e.g. uint256, bytes32, string
+	size     int    // Bit size for (u)int, byte size for bytesN
+	isArray  bool
+	arrayLen int // -1 for dynamic arrays T[]
+}
+
+var abiTypePattern = regexp.MustCompile(`^([a-z]+)(\d*)(\[(\d*)\])?$`)
+
+// parseABIType parses and canonicalizes a Solidity type
+func parseABIType(t string) (abiType, error) {
+	m := abiTypePattern.FindStringSubmatch(t)
+	if m == nil {
+		return abiType{}, fmt.Errorf("unsupported type %s", t)
+	}
+
+	parsed := abiType{}
+	if m[3] != "" {
+		parsed.isArray = true
+		parsed.arrayLen = -1
+		if m[4] != "" {
+			n, _ := strconv.Atoi(m[4])
+			if n == 0 {
+				return abiType{}, fmt.Errorf("invalid array length in %s", t)
+			}
+			parsed.arrayLen = n
+		}
+	}
+
+	size := 0
+	if m[2] != "" {
+		size, _ = strconv.Atoi(m[2])
+	}
+	switch m[1] {
+	case "uint", "int":
+		if size == 0 {
+			size = 256
+		}
+		if size%8 != 0 || size > 256 {
+			return abiType{}, fmt.Errorf("invalid integer size in %s", t)
+		}
+		parsed.base = fmt.Sprintf("%s%d", m[1], size)
+	case "bytes":
+		if size == 0 {
+			parsed.base = "bytes"
+			break
+		}
+		if size > 32 {
+			return abiType{}, fmt.Errorf("invalid bytes size in %s", t)
+		}
+		parsed.base = fmt.Sprintf("bytes%d", size)
+	case "address", "bool", "string":
+		if size != 0 {
+			return abiType{}, fmt.Errorf("unsupported type %s", t)
+		}
+		parsed.base = m[1]
+	default:
+		return abiType{}, fmt.Errorf("unsupported type %s", t)
+	}
+	parsed.size = size
+
+	if parsed.isArray && parsed.elementDynamic() {
+		return abiType{}, fmt.Errorf("arrays of dynamic types are not supported: %s", t)
+	}
+	return parsed, nil
+}
+
+// String returns the canonical type used in event signatures
+func (t abiType) String() string {
+	switch {
+	case !t.isArray:
+		return t.base
+	case t.arrayLen < 0:
+		return t.base + "[]"
+	default:
+		return fmt.Sprintf("%s[%d]", t.base, t.arrayLen)
+	}
+}
+
+// elementDynamic returns true for string and bytes
+func (t abiType) elementDynamic() bool {
+	return t.base == "string" || t.base == "bytes"
+}
+
+// dynamic returns true if the type is ABI-encoded in the tail
+func (t abiType) dynamic() bool {
+	return t.elementDynamic() || (t.isArray && t.arrayLen < 0)
+}
+
+// eventSignature returns the canonical signature, e.g. Transfer(address,address,uint256)
+func eventSignature(event ABIEvent) (string, []abiType, error) {
+	types := make([]abiType, len(event.Inputs))
+	names := make([]string, len(event.Inputs))
+	for i, input := range event.Inputs {
+		parsed, err := parseABIType(input.Type)
+		if err != nil {
+			return "", nil, fmt.Errorf("event %s: %v", event.Name, err)
+		}
+		types[i] = parsed
+		names[i] = parsed.String()
+	}
+	return fmt.Sprintf("%s(%s)", event.Name, strings.Join(names, ",")), types, nil
+}
+
+// sampleValue generates a realistic random value for an elementary type
+func sampleValue(rng *rand.Rand, t abiType, name string) interface{} {
+	switch {
+	case t.base == "address":
+		addr := make([]byte, 20)
+		rng.Read(addr)
+		return addr
+	case t.base == "bool":
+		return rng.Intn(2) == 1
+	case strings.HasPrefix(t.base, "uint"):
+		bitsLimit := t.size
+		if bitsLimit > 96 {
+			bitsLimit = 96 // Token-amount sized values
+		}
+		return new(big.Int).Rand(rng, new(big.Int).Lsh(big.NewInt(1), uint(bitsLimit)))
+	case strings.HasPrefix(t.base, "int"):
+		bitsLimit := t.size - 1
+		if bitsLimit > 95 {
+			bitsLimit = 95
+		}
+		v := new(big.Int).Rand(rng, new(big.Int).Lsh(big.NewInt(1), uint(bitsLimit)))
+		if rng.Intn(2) == 1 {
+			v.Neg(v)
+		}
+		return v
+	case t.base == "string":
+		return fmt.Sprintf("%s-%d", name, rng.Intn(10000))
+	case t.base == "bytes":
+		b := make([]byte, rng.Intn(64)+1)
+		rng.Read(b)
+		return b
+	default: // bytesN
+		b := make([]byte, t.size)
+		rng.Read(b)
+		return b
+	}
+}
+
+// sampleParam generates a value for a parameter, as a slice of values for arrays
+func sampleParam(rng *rand.Rand, t abiType, name string) interface{} {
+	if !t.isArray {
+		return sampleValue(rng, t, name)
+	}
+	n := t.arrayLen
+	if n < 0 {
+		n = rng.Intn(3) + 1
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		values[i] = sampleValue(rng, t, name)
+	}
+	return values
+}
+
+// encodeWord encodes a static elementary value as a 32-byte word
+func encodeWord(t abiType, value interface{}) []byte {
+	word := make([]byte, 32)
+	switch v := value.(type) {
+	case bool:
+		if v {
+			word[31] = 1
+		}
+	case *big.Int:
+		if v.Sign() < 0 {
+			// Two's complement over 256 bits
+			v = new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 256), v)
+		}
+		v.FillBytes(word)
+	case []byte:
+		if t.base == "address" {
+			copy(word[12:], v) // Left padded
+		} else {
+			copy(word, v) // bytesN are right padded
+		}
+	}
+	return word
+}
+
+// dynamicBytes returns the raw bytes of a string/bytes value
+func dynamicBytes(value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		return []byte(v)
+	case []byte:
+		return v
+	}
+	return nil
+}
+
+// encodeDynamicBytes encodes string/bytes contents padded to a multiple of 32 bytes, without the length word
+func encodeDynamicBytes(value interface{}) []byte {
+	raw := dynamicBytes(value)
+	padded := make([]byte, (len(raw)+31)/32*32)
+	copy(padded, raw)
+	return padded
+}
+
+// encodeArrayElements encodes the elements of a static elementary array
+func encodeArrayElements(t abiType, value interface{}) []byte {
+	var out []byte
+	for _, element := range value.([]interface{}) {
+		out = append(out, encodeWord(t, element)...)
+	}
+	return out
+}
+
+// encodeTopic encodes an indexed parameter; dynamic values are hashed as the ABI specifies
+func encodeTopic(t abiType, value interface{}) []byte {
+	switch {
+	case t.isArray:
+		hash := keccak256(encodeArrayElements(t, value))
+		return hash[:]
+	case t.elementDynamic():
+		hash := keccak256(dynamicBytes(value))
+		return hash[:]
+	default:
+		return encodeWord(t, value)
+	}
+}
+
+// encodeData ABI-encodes the non-indexed parameters as log data
+func encodeData(types []abiType, values []interface{}) []byte {
+	headSize := 0
+	for _, t := range types {
+		if t.isArray && !t.dynamic() {
+			headSize += 32 * t.arrayLen
+		} else {
+			headSize += 32
+		}
+	}
+
+	var head, tail []byte
+	for i, t := range types {
+		switch {
+		case t.dynamic():
+			offset := new(big.Int).SetInt64(int64(headSize + len(tail)))
+			head = append(head, encodeWord(abiType{base: "uint256"}, offset)...)
+			var length int
+			var body []byte
+			if t.isArray {
+				length = len(values[i].([]interface{}))
+				body = encodeArrayElements(t, values[i])
+			} else {
+				length = len(dynamicBytes(values[i]))
+				body = encodeDynamicBytes(values[i])
+			}
+			tail = append(tail, encodeWord(abiType{base: "uint256"}, big.NewInt(int64(length)))...)
+			tail = append(tail, body...)
+		case t.isArray:
+			head = append(head, encodeArrayElements(t, values[i])...)
+		default:
+			head = append(head, encodeWord(t, values[i])...)
+		}
+	}
+	return append(head, tail...)
+}
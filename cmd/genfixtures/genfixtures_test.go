@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestKeccak256(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"Transfer(address,address,uint256)", "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+		{"Approval(address,address,uint256)", "8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"},
+		// Longer than one rate block
+		{strings.Repeat("a", 200), ""},
+	}
+
+	for _, tt := range tests {
+		hash := keccak256([]byte(tt.input))
+		if tt.expected != "" && hex.EncodeToString(hash[:]) != tt.expected {
+			t.Errorf("keccak256(%q) = %x, expected %s", tt.input, hash, tt.expected)
+		}
+	}
+}
+
+const testABI = `[
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]},
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[
+		{"name":"from","type":"address","indexed":true},
+		{"name":"to","type":"address","indexed":true},
+		{"name":"value","type":"uint","indexed":false}]},
+	{"type":"event","name":"Memo","anonymous":false,"inputs":[
+		{"name":"tag","type":"string","indexed":true},
+		{"name":"text","type":"string","indexed":false},
+		{"name":"ids","type":"uint256[]","indexed":false}]},
+	{"type":"event","name":"Swap","inputs":[
+		{"name":"pair","type":"tuple","indexed":false}]}
+]`
+
+func TestLoadEventTemplates(t *testing.T) {
+	templates, err := loadEventTemplates([]byte(testABI), nil)
+	if err != nil {
+		t.Fatalf("Failed to load events: %v", err)
+	}
+	// The tuple event is skipped
+	if len(templates) != 2 {
+		t.Fatalf("Expected 2 templates, got %d", len(templates))
+	}
+	if templates[0].signature != "Transfer(address,address,uint256)" {
+		t.Errorf("Unexpected signature %s", templates[0].signature)
+	}
+	if templates[1].signature != "Memo(string,string,uint256[])" {
+		t.Errorf("Unexpected signature %s", templates[1].signature)
+	}
+
+	only, err := loadEventTemplates([]byte(testABI), []string{"Memo"})
+	if err != nil || len(only) != 1 || only[0].event.Name != "Memo" {
+		t.Errorf("Expected only Memo, got %v (%v)", only, err)
+	}
+}
+
+func TestGenerateTransferFixture(t *testing.T) {
+	templates, _ := loadEventTemplates([]byte(testABI), []string{"Transfer"})
+	address := "0x" + strings.Repeat("ab", 20)
+	fixture := templates[0].generate(rand.New(rand.NewSource(1)), address)
+
+	if len(fixture.Topics) != 3 {
+		t.Fatalf("Expected 3 topics, got %d", len(fixture.Topics))
+	}
+	if fixture.Topics[0] != "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" {
+		t.Errorf("Unexpected topic0 %s", fixture.Topics[0])
+	}
+	// Indexed addresses are left padded to 32 bytes
+	for _, topic := range fixture.Topics[1:] {
+		if len(topic) != 66 || !strings.HasPrefix(topic, "0x000000000000000000000000") {
+			t.Errorf("Expected left-padded address topic, got %s", topic)
+		}
+	}
+	// A single uint256 in data
+	if len(fixture.Data) != 2+64 {
+		t.Errorf("Expected 32 bytes of data, got %s", fixture.Data)
+	}
+	if fixture.Address != address {
+		t.Errorf("Expected address %s, got %s", address, fixture.Address)
+	}
+}
+
+func TestEncodeDataDynamic(t *testing.T) {
+	stringType, _ := parseABIType("string")
+	arrayType, _ := parseABIType("uint256[]")
+	data := encodeData(
+		[]abiType{stringType, arrayType},
+		[]interface{}{"hello", []interface{}{big.NewInt(1), big.NewInt(2)}},
+	)
+
+	expected := "" +
+		"0000000000000000000000000000000000000000000000000000000000000040" + // offset of string
+		"0000000000000000000000000000000000000000000000000000000000000080" + // offset of array
+		"0000000000000000000000000000000000000000000000000000000000000005" + // string length
+		"68656c6c6f000000000000000000000000000000000000000000000000000000" + // "hello"
+		"0000000000000000000000000000000000000000000000000000000000000002" + // array length
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002"
+	if hex.EncodeToString(data) != expected {
+		t.Errorf("Unexpected encoding:\n%x\nexpected:\n%s", data, expected)
+	}
+}
+
+func TestEncodeIndexedDynamicTopic(t *testing.T) {
+	stringType, _ := parseABIType("string")
+	topic := encodeTopic(stringType, "hello")
+	expected := keccak256([]byte("hello"))
+	if hex.EncodeToString(topic) != hex.EncodeToString(expected[:]) {
+		t.Errorf("Expected indexed string to be hashed, got %x", topic)
+	}
+
+	intType, _ := parseABIType("int8")
+	word := encodeWord(intType, big.NewInt(-1))
+	if hex.EncodeToString(word) != strings.Repeat("ff", 32) {
+		t.Errorf("Expected two's complement encoding of -1, got %x", word)
+	}
+}
+
+func TestGenerateProgramLogs(t *testing.T) {
+	idl := `{"version":"0.1.0","name":"counter","instructions":[{"name":"initialize"},{"name":"increment_counter"}],"metadata":{"address":"Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"}}`
+	programID, names, err := parseAnchorIDL([]byte(idl))
+	if err != nil {
+		t.Fatalf("Failed to parse IDL: %v", err)
+	}
+	if programID != "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS" || len(names) != 2 {
+		t.Fatalf("Unexpected IDL contents: %s %v", programID, names)
+	}
+
+	programLog := programLogFor(rand.New(rand.NewSource(1)), programID, names[1])
+	if programLog.Instruction != "IncrementCounter" {
+		t.Errorf("Expected PascalCase instruction name, got %s", programLog.Instruction)
+	}
+	if programLog.Logs[1] != "Program log: Instruction: IncrementCounter" {
+		t.Errorf("Unexpected instruction log line %s", programLog.Logs[1])
+	}
+	if !strings.HasSuffix(programLog.Logs[3], " success") {
+		t.Errorf("Expected success line, got %s", programLog.Logs[3])
+	}
+}
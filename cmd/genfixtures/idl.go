@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+// ProgramLog mirrors the simulator's Solana program log template format
+type ProgramLog struct {
+	ProgramID   string   `yaml:"program_id"`
+	Instruction string   `yaml:"instruction"`
+	Logs        []string `yaml:"logs"`
+}
+
+// anchorIDL is the subset of an Anchor IDL needed for program logs. Both the
+// legacy format (metadata.address) and the 0.30+ format (address) are accepted.
+type anchorIDL struct {
+	Address      string `json:"address"`
+	Instructions []struct {
+		Name string `json:"name"`
+	} `json:"instructions"`
+	Metadata struct {
+		Address string `json:"address"`
+	} `json:"metadata"`
+}
+
+// parseAnchorIDL returns the program id and instruction names of an Anchor IDL
+func parseAnchorIDL(data []byte) (string, []string, error) {
+	var idl anchorIDL
+	if err := json.Unmarshal(data, &idl); err != nil {
+		return "", nil, fmt.Errorf("invalid IDL JSON: %v", err)
+	}
+	if len(idl.Instructions) == 0 {
+		return "", nil, fmt.Errorf("no instructions found in IDL")
+	}
+
+	programID := idl.Address
+	if programID == "" {
+		programID = idl.Metadata.Address
+	}
+	names := make([]string, len(idl.Instructions))
+	for i, instruction := range idl.Instructions {
+		names[i] = instruction.Name
+	}
+	return programID, names, nil
+}
+
+// anchorInstructionName converts an IDL instruction name to the PascalCase name Anchor logs
+func anchorInstructionName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// programLogFor renders the log lines of a successful top-level Anchor instruction
+func programLogFor(rng *rand.Rand, programID, instruction string) ProgramLog {
+	name := anchorInstructionName(instruction)
+	consumed := 2000 + rng.Intn(40000)
+	return ProgramLog{
+		ProgramID:   programID,
+		Instruction: name,
+		Logs: []string{
+			fmt.Sprintf("Program %s invoke [1]", programID),
+			fmt.Sprintf("Program log: Instruction: %s", name),
+			fmt.Sprintf("Program %s consumed %d of 200000 compute units", programID, consumed),
+			fmt.Sprintf("Program %s success", programID),
+		},
+	}
+}
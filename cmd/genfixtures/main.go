@@ -0,0 +1,220 @@
+// Command genfixtures generates realistic log fixtures for the simulator.
+//
+// With -abi it generates EVM log fixtures from a contract ABI, loadable through the
+// log_fixtures_file chain option or POST /control/chain/log-fixtures. Every fixture
+// carries the correct topic0 hash, ABI-encoded indexed topics and ABI-encoded data.
+//
+// With -idl it generates Solana program log templates from an Anchor IDL, loadable
+// through the program_logs_file option or POST /control/solana/program-logs.
+//
+// Usage:
+//
+//	go run ./cmd/genfixtures -abi erc20.json -address 0x... -count 20 -out fixtures.yaml
+//	go run ./cmd/genfixtures -idl my_program.json -out program_logs.yaml
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LogFixture mirrors the simulator's log fixture format
+type LogFixture struct {
+	Event   string   `yaml:"event,omitempty"`
+	Address string   `yaml:"address"`
+	Topics  []string `yaml:"topics"`
+	Data    string   `yaml:"data"`
+}
+
+// eventTemplate is a parsed event ready for fixture generation
+type eventTemplate struct {
+	event     ABIEvent
+	signature string
+	types     []abiType
+}
+
+// loadEventTemplates parses the ABI events, skipping events with unsupported types
+func loadEventTemplates(abiJSON []byte, only []string) ([]eventTemplate, error) {
+	events, err := parseABIEvents(abiJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range only {
+		wanted[name] = true
+	}
+
+	var templates []eventTemplate
+	for _, event := range events {
+		if len(wanted) > 0 && !wanted[event.Name] {
+			continue
+		}
+		signature, types, err := eventSignature(event)
+		if err != nil {
+			log.Printf("Skipping %v", err)
+			continue
+		}
+		indexed := 0
+		for _, input := range event.Inputs {
+			if input.Indexed {
+				indexed++
+			}
+		}
+		if (!event.Anonymous && indexed > 3) || indexed > 4 {
+			log.Printf("Skipping event %s: too many indexed parameters", event.Name)
+			continue
+		}
+		templates = append(templates, eventTemplate{event: event, signature: signature, types: types})
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no supported events found in ABI")
+	}
+	return templates, nil
+}
+
+// generate builds a fixture for the template with random parameter values
+func (t eventTemplate) generate(rng *rand.Rand, address string) LogFixture {
+	fixture := LogFixture{Event: t.signature, Address: address, Topics: []string{}}
+	if !t.event.Anonymous {
+		topic0 := keccak256([]byte(t.signature))
+		fixture.Topics = append(fixture.Topics, "0x"+hex.EncodeToString(topic0[:]))
+	}
+
+	var dataTypes []abiType
+	var dataValues []interface{}
+	for i, input := range t.event.Inputs {
+		value := sampleParam(rng, t.types[i], input.Name)
+		if input.Indexed {
+			fixture.Topics = append(fixture.Topics, "0x"+hex.EncodeToString(encodeTopic(t.types[i], value)))
+		} else {
+			dataTypes = append(dataTypes, t.types[i])
+			dataValues = append(dataValues, value)
+		}
+	}
+	fixture.Data = "0x" + hex.EncodeToString(encodeData(dataTypes, dataValues))
+	return fixture
+}
+
+func main() {
+	abiFile := flag.String("abi", "", "Path to the contract ABI JSON (bare ABI array or compiler artifact)")
+	idlFile := flag.String("idl", "", "Path to an Anchor IDL JSON for Solana program logs")
+	program := flag.String("program", "", "Solana program id (default: address from the IDL)")
+	instructions := flag.String("instructions", "", "Comma-separated IDL instruction names to include (default: all)")
+	address := flag.String("address", "", "Contract address for the logs (default: random)")
+	count := flag.Int("count", 10, "Number of fixtures to generate")
+	seed := flag.Int64("seed", 1, "Random seed for parameter values")
+	events := flag.String("events", "", "Comma-separated event names to include (default: all)")
+	out := flag.String("out", "", "Output file (default: stdout)")
+	flag.Parse()
+
+	log.SetFlags(0)
+	if (*abiFile == "") == (*idlFile == "") {
+		log.Printf("Exactly one of -abi or -idl is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *idlFile != "" {
+		programLogs, err := generateProgramLogs(*idlFile, *program, *instructions, *seed)
+		if err != nil {
+			log.Fatalf("Failed to generate program logs: %v", err)
+		}
+		writeYAML(*out, programLogs, len(programLogs))
+		return
+	}
+	if *count <= 0 {
+		log.Fatalf("count must be positive")
+	}
+
+	abiJSON, err := os.ReadFile(*abiFile)
+	if err != nil {
+		log.Fatalf("Failed to read ABI: %v", err)
+	}
+	var only []string
+	if *events != "" {
+		only = strings.Split(*events, ",")
+	}
+	templates, err := loadEventTemplates(abiJSON, only)
+	if err != nil {
+		log.Fatalf("Failed to load events: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	if *address == "" {
+		addr := make([]byte, 20)
+		rng.Read(addr)
+		*address = "0x" + hex.EncodeToString(addr)
+	}
+	*address = strings.ToLower(*address)
+	if decoded, err := hex.DecodeString(strings.TrimPrefix(*address, "0x")); err != nil || len(decoded) != 20 || !strings.HasPrefix(*address, "0x") {
+		log.Fatalf("address must be 20 bytes of 0x-prefixed hex")
+	}
+
+	fixtures := make([]LogFixture, *count)
+	for i := range fixtures {
+		fixtures[i] = templates[i%len(templates)].generate(rng, *address)
+	}
+
+	writeYAML(*out, fixtures, len(fixtures))
+}
+
+// generateProgramLogs builds one program log template per IDL instruction
+func generateProgramLogs(idlFile, programID, only string, seed int64) ([]ProgramLog, error) {
+	idlJSON, err := os.ReadFile(idlFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IDL: %v", err)
+	}
+	idlProgramID, names, err := parseAnchorIDL(idlJSON)
+	if err != nil {
+		return nil, err
+	}
+	if programID == "" {
+		programID = idlProgramID
+	}
+	if programID == "" {
+		return nil, fmt.Errorf("IDL has no address, use -program")
+	}
+
+	wanted := make(map[string]bool)
+	if only != "" {
+		for _, name := range strings.Split(only, ",") {
+			wanted[name] = true
+		}
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	var programLogs []ProgramLog
+	for _, name := range names {
+		if len(wanted) > 0 && !wanted[name] {
+			continue
+		}
+		programLogs = append(programLogs, programLogFor(rng, programID, name))
+	}
+	if len(programLogs) == 0 {
+		return nil, fmt.Errorf("no matching instructions found in IDL")
+	}
+	return programLogs, nil
+}
+
+// writeYAML writes v as YAML to the output file, or stdout if none is given
+func writeYAML(out string, v interface{}, count int) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		log.Fatalf("Failed to marshal fixtures: %v", err)
+	}
+	if out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		log.Fatalf("Failed to write fixtures: %v", err)
+	}
+	log.Printf("Wrote %d fixtures to %s", count, out)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMetrics = `# HELP rpc_request_duration_seconds Request latency
+# TYPE rpc_request_duration_seconds histogram
+rpc_request_duration_seconds_bucket{chain="ethereum",method="eth_call",region="eu",le="0.05"} 10
+rpc_request_duration_seconds_bucket{chain="ethereum",method="eth_call",region="eu",le="0.1"} 30
+rpc_request_duration_seconds_bucket{chain="ethereum",method="eth_call",region="eu",le="+Inf"} 40
+rpc_request_duration_seconds_bucket{chain="ethereum",method="eth_call",region="us",le="0.05"} 5
+rpc_request_duration_seconds_bucket{chain="ethereum",method="eth_call",region="us",le="0.1"} 5
+rpc_request_duration_seconds_bucket{chain="ethereum",method="eth_call",region="us",le="+Inf"} 5
+rpc_request_duration_seconds_bucket{chain="ethereum",method="eth_getLogs",le="0.05"} 0
+rpc_request_duration_seconds_bucket{chain="ethereum",method="eth_getLogs",le="0.1"} 0
+rpc_request_duration_seconds_bucket{chain="ethereum",method="eth_getLogs",le="+Inf"} 5
+rpc_request_duration_seconds_count{chain="ethereum",method="eth_call"} 45
+other_metric_bucket{chain="polygon",le="1"} 3
+`
+
+func TestImportProfile(t *testing.T) {
+	profile, err := importProfile(strings.NewReader(testMetrics), "rpc_request_duration_seconds", "chain", "method", "", 1000)
+	if err != nil {
+		t.Fatalf("importProfile failed: %v", err)
+	}
+	if len(profile.Chains) != 1 || profile.Chains["ethereum"] == nil {
+		t.Fatalf("Expected a profile for ethereum only, got %v", profile.Chains)
+	}
+
+	// Regions are summed; +Inf becomes twice the largest finite bound
+	call := profile.Chains["ethereum"].Methods["eth_call"]
+	expected := []LatencyBucket{{LeMs: 50, Weight: 15}, {LeMs: 100, Weight: 20}, {LeMs: 200, Weight: 10}}
+	if call == nil || len(call.Buckets) != len(expected) {
+		t.Fatalf("Expected %d eth_call buckets, got %v", len(expected), call)
+	}
+	for i, bucket := range call.Buckets {
+		if bucket != expected[i] {
+			t.Errorf("Bucket %d: expected %v, got %v", i, expected[i], bucket)
+		}
+	}
+
+	// The default distribution covers all methods of the chain
+	total := 0.0
+	for _, bucket := range profile.Chains["ethereum"].Default.Buckets {
+		total += bucket.Weight
+	}
+	if total != 50 {
+		t.Errorf("Expected the default distribution to cover 50 requests, got %v", total)
+	}
+}
+
+func TestImportProfileErrors(t *testing.T) {
+	tests := map[string]string{
+		"no series":     `other_bucket{chain="ethereum",le="1"} 1`,
+		"no chain":      `m_bucket{method="eth_call",le="1"} 1`,
+		"invalid le":    `m_bucket{chain="ethereum",le="fast"} 1`,
+		"only infinite": `m_bucket{chain="ethereum",le="+Inf"} 1`,
+		"bad labels":    `m_bucket{chain="ethereum} 1`,
+	}
+	for name, input := range tests {
+		if _, err := importProfile(strings.NewReader(input), "m", "chain", "method", "", 1000); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// -chain names the chain of series without a chain label
+	profile, err := importProfile(strings.NewReader(`m_bucket{le="1"} 1`), "m", "chain", "method", "base", 1)
+	if err != nil || profile.Chains["base"] == nil || profile.Chains["base"].Default.Buckets[0].LeMs != 1 {
+		t.Errorf("Expected a default distribution for base, got %v, %v", profile, err)
+	}
+}
@@ -0,0 +1,298 @@
+// Command importlatency converts latency histograms measured on a production gateway into a
+// simulator latency profile, loadable through POST /control/latency/profile.
+//
+// The input is a Prometheus text exposition of a histogram, e.g. the output of a gateway's
+// /metrics endpoint. Series are grouped by the chain and method labels and summed over all
+// other labels. Every chain gets a default distribution over all its requests and one
+// distribution per method. Chain label values may be chain names or chain IDs.
+//
+// Usage:
+//
+//	curl -s http://gateway:9090/metrics > metrics.txt
+//	go run ./cmd/importlatency -metric rpc_request_duration_seconds -in metrics.txt -out profile.json
+//	curl -X POST http://localhost:8545/control/latency/profile -d @profile.json
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LatencyBucket mirrors the simulator's latency bucket format
+type LatencyBucket struct {
+	LeMs   float64 `json:"le_ms"`
+	Weight float64 `json:"weight"`
+}
+
+// LatencyDistribution mirrors the simulator's latency distribution format
+type LatencyDistribution struct {
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// ChainLatencyProfile mirrors the simulator's per-chain profile format
+type ChainLatencyProfile struct {
+	Default *LatencyDistribution            `json:"default,omitempty"`
+	Methods map[string]*LatencyDistribution `json:"methods,omitempty"`
+}
+
+// LatencyProfile mirrors the simulator's latency profile format
+type LatencyProfile struct {
+	Chains map[string]*ChainLatencyProfile `json:"chains"`
+}
+
+// histogram holds cumulative bucket counts by upper bound
+type histogram map[float64]float64
+
+// methodHistograms holds the histograms of one chain by method
+type methodHistograms map[string]histogram
+
+// add sums a bucket count into the method's histogram
+func (m methodHistograms) add(method string, le, count float64) {
+	if m[method] == nil {
+		m[method] = make(histogram)
+	}
+	m[method][le] += count
+}
+
+func main() {
+	metric := flag.String("metric", "", "Histogram metric name, without the _bucket suffix")
+	chainLabel := flag.String("chain-label", "chain", "Label holding the chain name or ID")
+	methodLabel := flag.String("method-label", "method", "Label holding the RPC method")
+	chain := flag.String("chain", "", "Chain for series without the chain label")
+	unit := flag.String("unit", "seconds", "Unit of the histogram bounds: seconds or milliseconds")
+	in := flag.String("in", "", "Input file (default: stdin)")
+	out := flag.String("out", "", "Output file (default: stdout)")
+	flag.Parse()
+
+	log.SetFlags(0)
+	if *metric == "" {
+		log.Printf("-metric is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	scale := 1000.0
+	switch *unit {
+	case "seconds":
+	case "milliseconds":
+		scale = 1
+	default:
+		log.Fatalf("unit must be seconds or milliseconds")
+	}
+
+	input := io.Reader(os.Stdin)
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			log.Fatalf("Failed to open input: %v", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	profile, err := importProfile(input, *metric, *chainLabel, *methodLabel, *chain, scale)
+	if err != nil {
+		log.Fatalf("Failed to import latency histograms: %v", err)
+	}
+
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode profile: %v", err)
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		log.Fatalf("Failed to write output: %v", err)
+	}
+	log.Printf("Wrote latency profile for %d chains to %s", len(profile.Chains), *out)
+}
+
+// importProfile reads the metric's bucket series and builds a latency profile from them.
+// scale converts the histogram's bounds to milliseconds.
+func importProfile(r io.Reader, metric, chainLabel, methodLabel, defaultChain string, scale float64) (*LatencyProfile, error) {
+	// chain -> method -> histogram; the empty method collects all requests of the chain
+	series := make(map[string]methodHistograms)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, labels, value, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		if name != metric+"_bucket" {
+			continue
+		}
+
+		le, err := parseBound(labels["le"])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		chain := labels[chainLabel]
+		if chain == "" {
+			chain = defaultChain
+		}
+		if chain == "" {
+			return nil, fmt.Errorf("line %d: no %s label, use -chain", lineNumber, chainLabel)
+		}
+		if series[chain] == nil {
+			series[chain] = make(methodHistograms)
+		}
+		series[chain].add("", le, value)
+		if method := labels[methodLabel]; method != "" {
+			series[chain].add(method, le, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return nil, fmt.Errorf("no %s_bucket series found", metric)
+	}
+
+	profile := &LatencyProfile{Chains: make(map[string]*ChainLatencyProfile)}
+	for chain, methods := range series {
+		chainProfile := &ChainLatencyProfile{}
+		for method, h := range methods {
+			distribution, err := h.distribution(scale)
+			if err != nil {
+				return nil, fmt.Errorf("chain %s, method %q: %v", chain, method, err)
+			}
+			if distribution == nil {
+				continue
+			}
+			if method == "" {
+				chainProfile.Default = distribution
+				continue
+			}
+			if chainProfile.Methods == nil {
+				chainProfile.Methods = make(map[string]*LatencyDistribution)
+			}
+			chainProfile.Methods[method] = distribution
+		}
+		profile.Chains[chain] = chainProfile
+	}
+	return profile, nil
+}
+
+// distribution turns cumulative bucket counts into per-bucket weights in milliseconds. The
+// +Inf bucket becomes a bucket up to twice the largest finite bound. Histograms without
+// requests yield nil.
+func (h histogram) distribution(scale float64) (*LatencyDistribution, error) {
+	bounds := make([]float64, 0, len(h))
+	for le := range h {
+		bounds = append(bounds, le)
+	}
+	sort.Float64s(bounds)
+	if len(bounds) == 0 || math.IsInf(bounds[0], 1) {
+		return nil, fmt.Errorf("no finite bucket bounds")
+	}
+
+	distribution := &LatencyDistribution{}
+	previous, total := 0.0, 0.0
+	for _, le := range bounds {
+		// Counters reset independently per series, so cumulative counts may dip
+		weight := math.Max(h[le]-previous, 0)
+		previous = math.Max(h[le], previous)
+		total += weight
+
+		leMs := le * scale
+		if math.IsInf(le, 1) {
+			leMs = 2 * bounds[len(bounds)-2] * scale
+		}
+		distribution.Buckets = append(distribution.Buckets, LatencyBucket{LeMs: leMs, Weight: weight})
+	}
+	if total == 0 {
+		return nil, nil
+	}
+	return distribution, nil
+}
+
+// parseBound parses the le label of a bucket
+func parseBound(le string) (float64, error) {
+	if le == "" {
+		return 0, fmt.Errorf("bucket without le label")
+	}
+	bound, err := strconv.ParseFloat(le, 64)
+	if err != nil || bound < 0 || math.IsNaN(bound) {
+		return 0, fmt.Errorf("invalid le label %q", le)
+	}
+	return bound, nil
+}
+
+// parseSample parses one sample line of the Prometheus text format:
+// name{label="value",...} value [timestamp]
+func parseSample(line string) (string, map[string]string, float64, error) {
+	labels := make(map[string]string)
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return "", nil, 0, fmt.Errorf("invalid sample %q", line)
+	}
+	name, rest := line[:end], line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, " \t,")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+			eq := strings.Index(rest, "=")
+			if eq <= 0 || len(rest) < eq+2 || rest[eq+1] != '"' {
+				return "", nil, 0, fmt.Errorf("invalid labels in %q", line)
+			}
+			key := strings.TrimSpace(rest[:eq])
+			rest = rest[eq+2:]
+
+			var value strings.Builder
+			closed := false
+			for i := 0; i < len(rest); i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+					switch rest[i] {
+					case 'n':
+						value.WriteByte('\n')
+					default:
+						value.WriteByte(rest[i])
+					}
+					continue
+				}
+				if rest[i] == '"' {
+					rest = rest[i+1:]
+					closed = true
+					break
+				}
+				value.WriteByte(rest[i])
+			}
+			if !closed {
+				return "", nil, 0, fmt.Errorf("unterminated label value in %q", line)
+			}
+			labels[key] = value.String()
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, fmt.Errorf("sample without value %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("invalid value in %q", line)
+	}
+	return name, labels, value, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxCompressionBombSize caps the decompressed size of compression bomb responses (1 GiB)
+const maxCompressionBombSize = 1 << 30
+
+// paddingMemberSizes are the sizes of the whitespace gzip members a compression bomb is padded
+// with, largest first
+var paddingMemberSizes = []int{1 << 20, 64 << 10}
+
+var (
+	paddingMembersOnce sync.Once
+	paddingMembers     [][]byte // Gzip members of paddingMemberSizes whitespace, compressed once
+)
+
+// compressedPadding returns the cached gzip members of whitespace, compressing them on first use
+func compressedPadding() [][]byte {
+	paddingMembersOnce.Do(func() {
+		for _, size := range paddingMemberSizes {
+			var buf bytes.Buffer
+			gz, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+			gz.Write(bytes.Repeat([]byte(" "), size))
+			gz.Close()
+			paddingMembers = append(paddingMembers, buf.Bytes())
+		}
+	})
+	return paddingMembers
+}
+
+// compressionBombSize returns the configured decompressed size of HTTP responses for a chain (0 = disabled)
+func compressionBombSize(chainId string) int64 {
+	var size int64
+	if chainId == "501" {
+		size = solanaNode.CompressionBombSize
+	} else if chain, ok := supportedChains[chainIdToName[chainId]]; ok {
+		size = chain.CompressionBombSize
+	}
+	if size > maxCompressionBombSize {
+		return maxCompressionBombSize
+	}
+	return size
+}
+
+// acceptsGzip returns true if the request allows a gzip encoded response
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.SplitN(encoding, ";", 2)[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCompressionBomb writes body gzip encoded and padded with whitespace until it
+// decompresses to size bytes. Trailing whitespace keeps the JSON valid, so only
+// clients enforcing a decompression limit fail. The padding is written as a series of
+// cached gzip members, which decoders concatenate, so only the body and the padding
+// short of a whole member are compressed per response.
+func writeCompressionBomb(w io.Writer, body []byte, size int64) error {
+	members := compressedPadding()
+	remaining := size - int64(len(body))
+	counts := make([]int64, len(members))
+	for i, memberSize := range paddingMemberSizes {
+		if remaining > 0 {
+			counts[i] = remaining / int64(memberSize)
+			remaining -= counts[i] * int64(memberSize)
+		}
+	}
+
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := gz.Write(body); err != nil {
+		return err
+	}
+	if remaining > 0 {
+		if _, err := gz.Write(bytes.Repeat([]byte(" "), int(remaining))); err != nil {
+			return err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	for i, member := range members {
+		for n := int64(0); n < counts[i]; n++ {
+			if _, err := w.Write(member); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
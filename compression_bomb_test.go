@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompressionBomb(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer func() { chain.CompressionBombSize = 0 }()
+	chain.CompressionBombSize = 1 << 20
+
+	request := func(acceptEncoding string) *httptest.ResponseRecorder {
+		body := bytes.NewBufferString(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`)
+		req := httptest.NewRequest(http.MethodPost, "/chain/1", body)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		handleChainHTTP(w, req)
+		return w
+	}
+
+	w := request("deflate, gzip;q=0.8")
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip content encoding, got %q", w.Header().Get("Content-Encoding"))
+	}
+	compressedSize := w.Body.Len()
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Failed to open gzip body: %v", err)
+	}
+	decompressed, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Failed to decompress body: %v", err)
+	}
+	if len(decompressed) != 1<<20 {
+		t.Errorf("Expected %d decompressed bytes, got %d", 1<<20, len(decompressed))
+	}
+	if compressedSize*100 > len(decompressed) {
+		t.Errorf("Expected a high compression ratio, got %d compressed bytes", compressedSize)
+	}
+	var resp JSONRPCResponse
+	if err := json.Unmarshal(decompressed, &resp); err != nil || resp.Result == nil {
+		t.Errorf("Expected padded body to remain a valid response: %v", err)
+	}
+
+	// Clients that don't accept gzip get the plain response
+	w = request("")
+	if w.Header().Get("Content-Encoding") != "" || w.Body.Len() > 1024 {
+		t.Errorf("Expected plain response without Accept-Encoding, got %d bytes", w.Body.Len())
+	}
+}
+
+func TestCompressionBombPaddingMembers(t *testing.T) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`)
+	size := int64(3<<20 + 70<<10 + 123)
+	var buf bytes.Buffer
+	if err := writeCompressionBomb(&buf, body, size); err != nil {
+		t.Fatalf("Failed to write compression bomb: %v", err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to open gzip body: %v", err)
+	}
+	decompressed, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Failed to decompress body: %v", err)
+	}
+	if int64(len(decompressed)) != size || !bytes.HasPrefix(decompressed, body) {
+		t.Errorf("Expected %d bytes starting with the body, got %d", size, len(decompressed))
+	}
+}
+
+func TestCompressionBombSizeLimit(t *testing.T) {
+	defer func() { supportedChains["ethereum"].CompressionBombSize = 0 }()
+	for body, want := range map[string]int{
+		`{"chain": "ethereum", "decompressed_bytes": 1073741824}`: http.StatusOK,
+		`{"chain": "ethereum", "decompressed_bytes": 1073741825}`: http.StatusBadRequest,
+		`{"chain": "ethereum", "decompressed_bytes": -1}`:         http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		handleSetCompressionBomb(w, httptest.NewRequest(http.MethodPost, "/control/chain/compression-bomb", bytes.NewBufferString(body)))
+		if w.Code != want {
+			t.Errorf("%s: expected status %d, got %d", body, want, w.Code)
+		}
+	}
+
+	// Sizes from the config file are capped as well
+	supportedChains["ethereum"].CompressionBombSize = 4 << 30
+	if size := compressionBombSize("1"); size != maxCompressionBombSize {
+		t.Errorf("Expected the size capped at 1 GiB, got %d", size)
+	}
+}
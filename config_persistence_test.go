@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withTempConfig enables config persistence into a temporary file for the duration of the
+// test and returns the file's path
+func withTempConfig(t *testing.T) string {
+	t.Helper()
+	originalFile, originalPersist := configFile, persistConfig
+	configFile, persistConfig = filepath.Join(t.TempDir(), "chains.yaml"), true
+	t.Cleanup(func() { configFile, persistConfig = originalFile, originalPersist })
+	return configFile
+}
+
+func TestRuntimeFaultsPersistToConfig(t *testing.T) {
+	path := withTempConfig(t)
+	chain := supportedChains["ethereum"]
+	originalErrors := chain.ErrorConfigs
+	originalTxErrors := solanaNode.TransactionErrors
+	defer func() {
+		chain.ErrorConfigs = originalErrors
+		chain.CustomResponse = ""
+		chain.CustomResponseEnabled = false
+		chain.CustomResponseMethods = nil
+		solanaNode.TransactionErrors = originalTxErrors
+	}()
+
+	requests := []struct {
+		handler http.HandlerFunc
+		body    string
+	}{
+		{handleAddErrorConfig, `{"chain": "ethereum", "error_config": {"code": -32005, "message": "limit exceeded", "probability": 0.5, "methods": ["eth_getLogs"], "delay_ms": 100}}`},
+		{handleSetCustomResponse, `{"chain": "ethereum", "custom_response": "{\"result\": \"0x0\"}", "enabled": true, "methods": ["eth_call"]}`},
+		{handleAddSolanaTxError, `{"type": "BlockhashNotFound", "probability": 0.2}`},
+	}
+	for _, req := range requests {
+		w := httptest.NewRecorder()
+		req.handler(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(req.body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	config, err := LoadChainConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load persisted config: %v", err)
+	}
+
+	eth := config.EVMChains["ethereum"]
+	if len(eth.ErrorConfigs) != len(chain.ErrorConfigs) {
+		t.Fatalf("Expected %d persisted error configs, got %d", len(chain.ErrorConfigs), len(eth.ErrorConfigs))
+	}
+	last := eth.ErrorConfigs[len(eth.ErrorConfigs)-1]
+	if last.Code != -32005 || last.DelayMs != 100 || len(last.Methods) != 1 || last.Methods[0] != "eth_getLogs" {
+		t.Errorf("Unexpected persisted error config: %+v", last)
+	}
+	if !eth.CustomResponseEnabled || eth.CustomResponse != `{"result": "0x0"}` ||
+		len(eth.CustomResponseMethods) != 1 || eth.CustomResponseMethods[0] != "eth_call" {
+		t.Errorf("Unexpected persisted custom response: %q enabled=%t methods=%v",
+			eth.CustomResponse, eth.CustomResponseEnabled, eth.CustomResponseMethods)
+	}
+	txErrors := config.Solana.TransactionErrors
+	if len(txErrors) == 0 || txErrors[len(txErrors)-1].Type != TxErrorBlockhashNotFound {
+		t.Errorf("Unexpected persisted transaction errors: %+v", txErrors)
+	}
+}
+
+func TestConfigPersistenceOptIn(t *testing.T) {
+	path := withTempConfig(t)
+	persistConfig = false
+	persistChainConfig()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected no config written without PERSIST_CONFIG, got %v", err)
+	}
+
+	// Block numbers are runtime state and never written
+	persistConfig = true
+	persistChainConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read persisted config: %v", err)
+	}
+	for _, key := range []string{"block_number", "safe_block_number", "finalized_block_number"} {
+		if strings.Contains(string(data), key+":") {
+			t.Errorf("Expected %s not to be persisted", key)
+		}
+	}
+}
+
+func TestCopyPersistedFieldsSkipsRuntimeState(t *testing.T) {
+	chain := &EVMChain{Name: "test", BlockNumber: 42, LogIndex: 7, LogsPerBlock: 3, ErrorConfigs: []ErrorConfig{{Code: -32000}}}
+	snapshot := new(EVMChain)
+	copyPersistedFields(snapshot, chain)
+	if snapshot.Name != "test" || snapshot.LogsPerBlock != 3 || len(snapshot.ErrorConfigs) != 1 {
+		t.Errorf("Expected persisted fields to be copied, got %+v", snapshot)
+	}
+	if snapshot.BlockNumber != 0 || snapshot.LogIndex != 0 {
+		t.Errorf("Expected runtime counters to be skipped, got block %d and log index %d", snapshot.BlockNumber, snapshot.LogIndex)
+	}
+}
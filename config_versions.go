@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Error configs and the custom response of EVM chains are versioned, so test suites
+// sharing one simulator instance detect concurrent changes instead of silently clobbering
+// each other. Every change increments the version; a change that names an expected version
+// through If-Match or a "version" body field is rejected with 409 Conflict if the version
+// moved on. Changes without an expected version always apply.
+
+// VersionConflict is returned when an expected version does not match the current one
+type VersionConflict struct {
+	Expected uint64
+	Current  uint64
+}
+
+func (e *VersionConflict) Error() string {
+	return fmt.Sprintf("version conflict: expected %d, current version is %d", e.Expected, e.Current)
+}
+
+// expectedVersion returns the version a request expects, from the If-Match header or the
+// body's version field, or nil for unconditional requests
+func expectedVersion(r *http.Request, bodyVersion *uint64) (*uint64, error) {
+	match := strings.TrimSpace(r.Header.Get("If-Match"))
+	if match == "" || match == "*" {
+		return bodyVersion, nil
+	}
+	version, err := strconv.ParseUint(strings.Trim(strings.TrimPrefix(match, "W/"), `"`), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match header: %s", match)
+	}
+	return &version, nil
+}
+
+// updateErrorConfigs replaces the chain's error configs with the result of fn if the
+// expected version (nil = any) matches, and returns the new version
+func (c *EVMChain) updateErrorConfigs(expected *uint64, fn func([]ErrorConfig) ([]ErrorConfig, error)) (uint64, error) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	if expected != nil && *expected != c.errorConfigsVersion {
+		return c.errorConfigsVersion, &VersionConflict{Expected: *expected, Current: c.errorConfigsVersion}
+	}
+	configs, err := fn(c.ErrorConfigs)
+	if err != nil {
+		return c.errorConfigsVersion, err
+	}
+	c.ErrorConfigs = configs
+	c.errorConfigsVersion++
+	return c.errorConfigsVersion, nil
+}
+
+// errorConfigsSnapshot returns the chain's error configs and their version
+func (c *EVMChain) errorConfigsSnapshot() ([]ErrorConfig, uint64) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	return c.ErrorConfigs, c.errorConfigsVersion
+}
+
+// updateCustomResponse runs fn to change the chain's custom response if the expected
+// version (nil = any) matches, and returns the new version
+func (c *EVMChain) updateCustomResponse(expected *uint64, fn func()) (uint64, error) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	if expected != nil && *expected != c.customResponseVersion {
+		return c.customResponseVersion, &VersionConflict{Expected: *expected, Current: c.customResponseVersion}
+	}
+	fn()
+	c.customResponseVersion++
+	return c.customResponseVersion, nil
+}
+
+// writeVersioned reports the result of a versioned change: the new version as ETag and in
+// the body, 409 Conflict with the current version, or 400 for other errors
+func writeVersioned(w http.ResponseWriter, version uint64, err error, body map[string]interface{}) {
+	if conflict, ok := err.(*VersionConflict); ok {
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+		jsonResponse(w, http.StatusConflict, map[string]interface{}{
+			"status":  "conflict",
+			"message": conflict.Error(),
+			"version": conflict.Current,
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+	body["version"] = version
+	jsonResponse(w, http.StatusOK, body)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func versionedRequest(handler http.HandlerFunc, method, body, ifMatch string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/control", bytes.NewBufferString(body))
+	if ifMatch != "" {
+		r.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestErrorConfigVersions(t *testing.T) {
+	withTempConfig(t)
+	chain := supportedChains["polygon"]
+	defer func() {
+		chain.ErrorConfigs = nil
+	}()
+
+	list := versionedRequest(handleListErrorConfigs, http.MethodPost, `{"chain": "polygon"}`, "")
+	etag := list.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag on the error config list")
+	}
+
+	add := `{"chain": "polygon", "error_config": {"code": -32000, "message": "boom", "probability": 0.5}}`
+	w := versionedRequest(handleAddErrorConfig, http.MethodPost, add, etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 with the current version, got %d", w.Code)
+	}
+	newEtag := w.Header().Get("ETag")
+
+	// A second client still holding the old version is told about the change
+	w = versionedRequest(handleRemoveErrorConfig, http.MethodPost, `{"chain": "polygon", "index": 0}`, etag)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409 for a stale version, got %d", w.Code)
+	}
+	var conflict struct {
+		Version uint64 `json:"version"`
+	}
+	json.NewDecoder(w.Body).Decode(&conflict)
+	if w.Header().Get("ETag") != newEtag || len(chain.ErrorConfigs) != 1 {
+		t.Errorf("Expected the conflict to report version %s and change nothing, got %s", newEtag, w.Header().Get("ETag"))
+	}
+
+	// The version can also be passed in the body
+	body := `{"chain": "polygon", "index": 0, "version": ` + jsonNumber(conflict.Version) + `}`
+	if w := versionedRequest(handleRemoveErrorConfig, http.MethodPost, body, ""); w.Code != http.StatusOK || len(chain.ErrorConfigs) != 0 {
+		t.Errorf("Expected the removal with the current version to apply, got %d", w.Code)
+	}
+
+	// Unconditional changes always apply; invalid indexes are still 400
+	if w := versionedRequest(handleClearErrorConfigs, http.MethodPost, `{"chain": "polygon"}`, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected an unconditional clear to apply, got %d", w.Code)
+	}
+	if w := versionedRequest(handleRemoveErrorConfig, http.MethodPost, `{"chain": "polygon", "index": 3}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid index, got %d", w.Code)
+	}
+	if w := versionedRequest(handleClearErrorConfigs, http.MethodPost, `{"chain": "polygon"}`, "abc"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid If-Match header, got %d", w.Code)
+	}
+}
+
+func TestConcurrentVersionedChangesConflict(t *testing.T) {
+	withTempConfig(t)
+	chain := supportedChains["polygon"]
+	defer func() {
+		chain.CustomResponse = ""
+		chain.CustomResponseEnabled = false
+	}()
+
+	w := httptest.NewRecorder()
+	handleSetCustomResponse(w, httptest.NewRequest(http.MethodGet, "/control/response/custom?chain=polygon", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected the custom response with an ETag, got %d", w.Code)
+	}
+
+	var wg sync.WaitGroup
+	codes := make([]int, 10)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := `{"chain": "polygon", "custom_response": "{\"result\": ` + jsonNumber(uint64(i)) + `}", "enabled": true}`
+			codes[i] = versionedRequest(handleSetCustomResponse, http.MethodPost, body, etag).Code
+		}(i)
+	}
+	wg.Wait()
+
+	applied := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			applied++
+		case http.StatusConflict:
+		default:
+			t.Errorf("Unexpected status %d", code)
+		}
+	}
+	if applied != 1 {
+		t.Errorf("Expected exactly one change to apply, %d did", applied)
+	}
+}
+
+func jsonNumber(n uint64) string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
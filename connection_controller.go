@@ -1,6 +1,9 @@
 package main
 
 import (
+	"math"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -24,3 +27,90 @@ func BlockConnections(duration time.Duration) {
 func IsBlocked() bool {
 	return atomic.LoadUint32(&connectionBlocked) == 1
 }
+
+// ReconnectLimit simulates provider protection against reconnection storms: after a mass
+// drop, new WebSocket connections beyond Rate per second are rejected with 429 until the
+// cool-down window ends.
+type ReconnectLimit struct {
+	Rate     float64       `json:"rate"`     // Reconnections accepted per second during the cool-down
+	Burst    int           `json:"burst"`    // Reconnections accepted at once right after the drop (0 = ceil(rate))
+	Cooldown time.Duration `json:"cooldown"` // How long after a drop the limit applies
+}
+
+// reconnectLimiter is a token bucket that is only active during the cool-down after a drop
+type reconnectLimiter struct {
+	mu       sync.Mutex
+	limit    *ReconnectLimit
+	until    time.Time
+	tokens   float64
+	last     time.Time
+	rejected uint64
+}
+
+var reconnects = &reconnectLimiter{}
+
+// configure replaces the limit; nil disables it and ends any cool-down in progress
+func (l *reconnectLimiter) configure(limit *ReconnectLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.until = time.Time{}
+}
+
+// startCooldown opens the cool-down window after a mass drop
+func (l *reconnectLimiter) startCooldown(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit == nil {
+		return
+	}
+	l.until = now.Add(l.limit.Cooldown)
+	l.tokens = float64(l.limit.burst())
+	l.last = now
+}
+
+// allow takes a token for a new connection. When none is left, it returns false with
+// the time until the next one.
+func (l *reconnectLimiter) allow(now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit == nil || !now.Before(l.until) {
+		return true, 0
+	}
+
+	l.tokens = math.Min(float64(l.limit.burst()), l.tokens+now.Sub(l.last).Seconds()*l.limit.Rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	l.rejected++
+	return false, time.Duration((1 - l.tokens) / l.limit.Rate * float64(time.Second))
+}
+
+func (r *ReconnectLimit) burst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return int(math.Ceil(r.Rate))
+}
+
+// status reports the limit, the remaining cool-down and the rejected connections
+func (l *reconnectLimiter) status(now time.Time) map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var remaining time.Duration
+	if now.Before(l.until) {
+		remaining = l.until.Sub(now)
+	}
+	return map[string]interface{}{
+		"limit":       l.limit,
+		"cooldown_ms": remaining.Milliseconds(),
+		"rejected":    l.rejected,
+	}
+}
+
+// retryAfterSeconds rounds a wait up to the whole seconds of a Retry-After header
+func retryAfterSeconds(wait time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(wait.Seconds()))))
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 func TestConnectionBlocking(t *testing.T) {
@@ -45,3 +51,74 @@ func TestConcurrentBlocking(t *testing.T) {
 		time.Sleep(10 * time.Millisecond)
 	}
 }
+
+func TestReconnectLimiter(t *testing.T) {
+	l := &reconnectLimiter{}
+	now := time.Now()
+	if ok, _ := l.allow(now); !ok {
+		t.Fatal("Expected connections to be accepted without a limit")
+	}
+
+	l.configure(&ReconnectLimit{Rate: 2, Burst: 3, Cooldown: 10 * time.Second})
+	if ok, _ := l.allow(now); !ok {
+		t.Fatal("Expected connections to be accepted before a drop")
+	}
+
+	l.startCooldown(now)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow(now); !ok {
+			t.Fatalf("Expected the burst of 3 to be accepted, rejected connection %d", i+1)
+		}
+	}
+	ok, wait := l.allow(now)
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("Expected a rejection with a 500ms wait, got %t %v", ok, wait)
+	}
+	if ok, _ := l.allow(now.Add(500 * time.Millisecond)); !ok {
+		t.Error("Expected a token to be refilled after 500ms")
+	}
+	if ok, _ := l.allow(now.Add(10 * time.Second)); !ok {
+		t.Error("Expected connections to be accepted after the cool-down")
+	}
+	if status := l.status(now); status["rejected"] != uint64(1) {
+		t.Errorf("Expected 1 rejected connection, got %v", status["rejected"])
+	}
+}
+
+func TestReconnectStormRejectedWith429(t *testing.T) {
+	defer reconnects.configure(nil)
+
+	w := httptest.NewRecorder()
+	handleReconnectLimit(w, httptest.NewRequest(http.MethodPost, "/control/connections/reconnect-limit", bytes.NewBufferString(`{"rate": 1, "cooldown_seconds": 30}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handleChainWebSocket))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/chain/1"
+
+	handleDropConnections(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/control/connections/drop", nil))
+
+	first, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Expected the first reconnection to be accepted: %v", err)
+	}
+	defer first.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 for the second reconnection, got %v %v", resp, err)
+	}
+	if resp.Header.Get("Retry-After") != "1" {
+		t.Errorf("Expected Retry-After: 1, got %q", resp.Header.Get("Retry-After"))
+	}
+
+	for _, body := range []string{`{"rate": -1}`, `{"rate": 5}`} {
+		w := httptest.NewRecorder()
+		handleReconnectLimit(w, httptest.NewRequest(http.MethodPost, "/control/connections/reconnect-limit", bytes.NewBufferString(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, w.Code)
+		}
+	}
+}
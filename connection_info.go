@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// ConnectionInfo is the result of sim_connectionInfo: the server's view of the calling
+// connection, so client tests can check which simulated endpoint and state they talk to
+type ConnectionInfo struct {
+	ConnectionID  uint64                   `json:"connection_id,omitempty"` // WebSocket connection id (absent over HTTP)
+	Transport     string                   `json:"transport"`               // ws or http
+	Chain         string                   `json:"chain"`
+	ChainID       string                   `json:"chain_id"`
+	Subscriptions []ConnectionSubscription `json:"subscriptions"`
+	Faults        []string                 `json:"faults"`        // Chain faults plus connection-level overrides
+	MessagesSent  uint64                   `json:"messages_sent"` // Messages written to the connection before this response
+}
+
+// ConnectionSubscription is an active subscription of the calling connection
+type ConnectionSubscription struct {
+	ID     interface{} `json:"id"` // Formatted like the subscribe response: hex for EVM, numeric for Solana
+	Method string      `json:"method"`
+}
+
+// ConnectionSubscriptions returns the active subscriptions of a connection, sorted by id
+func (sm *SubscriptionManager) ConnectionSubscriptions(conn WSConn) []*Subscription {
+	var subs []*Subscription
+	for _, shard := range sm.allShards() {
+		shard.mu.RLock()
+		for _, sub := range shard.subscriptions {
+			if sub.Conn == conn {
+				subs = append(subs, sub)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].ID < subs[j].ID
+	})
+	return subs
+}
+
+// connectionInfo describes a connection of a chain
+func connectionInfo(conn WSConn, chainId string) ConnectionInfo {
+	info := ConnectionInfo{
+		Transport:     "http",
+		Chain:         chainIdToName[chainId],
+		ChainID:       chainId,
+		Subscriptions: []ConnectionSubscription{},
+	}
+	if chainId == "501" {
+		info.Faults = solanaActiveFaults(solanaNode)
+	} else if chain, ok := supportedChains[info.Chain]; ok {
+		info.Faults = evmActiveFaults(chain)
+	}
+	if info.Faults == nil {
+		info.Faults = []string{}
+	}
+
+	if sc, ok := conn.(strictnessConn); ok && sc.Strictness() != "" {
+		info.Faults = append(info.Faults, "connection strictness "+sc.Strictness())
+	}
+	if ws, ok := conn.(*wsConnWrapper); ok {
+		info.ConnectionID = ws.id
+		info.Transport = "ws"
+		info.MessagesSent = atomic.LoadUint64(&ws.sent)
+		if atomic.LoadInt32(&ws.slow) == 1 {
+			info.Faults = append(info.Faults, "slow_client")
+		}
+	}
+
+	for _, sub := range subManager.ConnectionSubscriptions(conn) {
+		var id interface{} = fmt.Sprintf("0x%x", sub.ID)
+		if chainId == "501" {
+			id = sub.ID
+		}
+		info.Subscriptions = append(info.Subscriptions, ConnectionSubscription{ID: id, Method: sub.Method})
+	}
+	return info
+}
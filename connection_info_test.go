@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestConnectionInfoOverWebSocket(t *testing.T) {
+	chain := supportedChains["optimism"]
+	defer func() {
+		setLatency("10", 0, nil)
+		chain.ErrorConfigs = nil
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(handleChainWebSocket))
+	defer server.Close()
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/chain/10", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	client.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"],"id":1}`))
+	var subscribed struct {
+		Result string `json:"result"`
+	}
+	if err := client.ReadJSON(&subscribed); err != nil || subscribed.Result == "" {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	// The method answers even while every other request is slow and failing
+	setLatency("10", 10*time.Second, nil)
+	chain.ErrorConfigs = []ErrorConfig{{Code: -32000, Message: "boom", Probability: 1}}
+	client.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"sim_connectionInfo","params":[],"id":"info"}`))
+
+	var response struct {
+		ID     string         `json:"id"`
+		Result ConnectionInfo `json:"result"`
+	}
+	// Skip head notifications that arrive in between
+	for response.ID != "info" {
+		if err := client.ReadJSON(&response); err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+
+	info := response.Result
+	if info.Transport != "ws" || info.ConnectionID == 0 || info.Chain != "optimism" || info.ChainID != "10" {
+		t.Errorf("Unexpected connection identity: %+v", info)
+	}
+	if len(info.Subscriptions) != 1 || info.Subscriptions[0].ID != subscribed.Result || info.Subscriptions[0].Method != "newHeads" {
+		t.Errorf("Expected the newHeads subscription %s, got %+v", subscribed.Result, info.Subscriptions)
+	}
+	if !containsString(info.Faults, "error_configs 1") {
+		t.Errorf("Expected the chain's faults, got %v", info.Faults)
+	}
+	if info.MessagesSent < 1 {
+		t.Errorf("Expected the subscribe response to be counted, got %d", info.MessagesSent)
+	}
+}
+
+func TestConnectionInfoOverHTTP(t *testing.T) {
+	conn := &strictnessOverrideConn{WSConn: NewMockWSConn(), strictness: StrictnessStrict}
+	response, err := handleRPCRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"sim_connectionInfo","id":1}`), conn, "501")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	var resp struct {
+		Result ConnectionInfo `json:"result"`
+	}
+	if err := json.Unmarshal(response, &resp); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	info := resp.Result
+	if info.Transport != "http" || info.ConnectionID != 0 || info.Chain != "solana" || len(info.Subscriptions) != 0 {
+		t.Errorf("Unexpected HTTP connection info: %+v", info)
+	}
+	if !containsString(info.Faults, "connection strictness "+StrictnessStrict) {
+		t.Errorf("Expected the connection's strictness override in faults, got %v", info.Faults)
+	}
+}
@@ -7,6 +7,7 @@ import (
 // ConnectionTracker keeps track of active connections per chain
 type ConnectionTracker struct {
 	connections sync.Map // maps chainId to connection count (string -> int)
+	conns       sync.Map // maps open WebSocket connections to their chainId (WSConn -> string)
 }
 
 // NewConnectionTracker creates a new connection tracker
@@ -78,3 +79,26 @@ func (ct *ConnectionTracker) HasConnections(chainId string) bool {
 	_, exists := ct.connections.Load(chainId)
 	return exists
 }
+
+// TrackConn registers an open WebSocket connection so faults can target it
+func (ct *ConnectionTracker) TrackConn(conn WSConn, chainId string) {
+	ct.conns.Store(conn, chainId)
+}
+
+// UntrackConn removes a closed WebSocket connection
+func (ct *ConnectionTracker) UntrackConn(conn WSConn) {
+	ct.conns.Delete(conn)
+}
+
+// Conns returns the open WebSocket connections of a chain, or of all chains if chainId
+// is empty, mapped to their chainId
+func (ct *ConnectionTracker) Conns(chainId string) map[WSConn]string {
+	conns := make(map[WSConn]string)
+	ct.conns.Range(func(key, value interface{}) bool {
+		if chainId == "" || value.(string) == chainId {
+			conns[key.(WSConn)] = value.(string)
+		}
+		return true
+	})
+	return conns
+}
@@ -6,6 +6,9 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -26,8 +29,9 @@ type TimeoutRequest struct {
 }
 
 type InterruptRequest struct {
-	Chain           string  `json:"chain"`
-	DurationSeconds float64 `json:"duration_seconds"`
+	Chain           string   `json:"chain"`  // Empty with no chains interrupts all chains
+	Chains          []string `json:"chains"` // Chain group to interrupt, each with its own resume timer
+	DurationSeconds float64  `json:"duration_seconds"`
 }
 
 type ReorgRequest struct {
@@ -36,7 +40,25 @@ type ReorgRequest struct {
 }
 
 func handleControlEndpoints(mux *http.ServeMux) {
+	mux.HandleFunc("/control/state", handleState)
+	mux.HandleFunc("/control/metrics/notifications", handleNotificationMetrics)
+	mux.HandleFunc("/control/metrics/notifications/reset", handleResetNotificationMetrics)
+	mux.HandleFunc("/control/metrics/retention", handleRetentionMetrics)
+	mux.HandleFunc("/control/mirror", handleMirror)
+	mux.HandleFunc("/control/grafana", handleGrafana)
+	mux.HandleFunc("/control/grafana/annotations", handleGrafanaAnnotations)
+	mux.HandleFunc("/control/hash-namespace", handleHashNamespace)
+	mux.HandleFunc("/control/self-check", handleSelfCheck)
+	mux.HandleFunc("/control/status", handleStatusOverride)
+	mux.HandleFunc("/control/sse/faults", handleSSEFaults)
 	mux.HandleFunc("/control/connections/drop", handleDropConnections)
+	mux.HandleFunc("/control/connections/reconnect-limit", handleReconnectLimit)
+	mux.HandleFunc("/control/connections/unsolicited", handleSendUnsolicited)
+	mux.HandleFunc("/control/connections/subscribe-race", handleSubscribeRace)
+	mux.HandleFunc("/control/connections/message-log", handleMessageLogConfig)
+	mux.HandleFunc("/control/connections/slow", handleSlowClients)
+	mux.HandleFunc("/control/connections/slow/reset", handleResetSlowClients)
+	mux.HandleFunc("/control/subscriptions/", handleReplayNotifications)
 	mux.HandleFunc("/control/block/set", handleSetBlock)
 	mux.HandleFunc("/control/block/pause", handlePauseBlock)
 	mux.HandleFunc("/control/block/resume", handleResumeBlock)
@@ -44,20 +66,64 @@ func handleControlEndpoints(mux *http.ServeMux) {
 	mux.HandleFunc("/control/block/resume_updates", handleResumeUpdates)
 	mux.HandleFunc("/control/block/interval", handleSetBlockInterval)
 	mux.HandleFunc("/control/block/interrupt", handleInterruptBlocks)
+	mux.HandleFunc("/control/block/mine", handleMineBlock)
+	mux.HandleFunc("/control/block/automine", handleSetAutomine)
+	mux.HandleFunc("/control/tx/queue", handleQueueTransactions)
 	mux.HandleFunc("/control/timeout/set", handleSetTimeout)
 	mux.HandleFunc("/control/timeout/clear", handleClearTimeout)
 	mux.HandleFunc("/control/chain/reorg", handleChainReorg)
+	mux.HandleFunc("/control/scenario/run", handleRunScenario)
+	mux.HandleFunc("/control/scenario/list", handleListScenarios)
+	mux.HandleFunc("/control/scenario/cancel", handleCancelScenario)
 	mux.HandleFunc("/control/latency", handleSetLatency)
+	mux.HandleFunc("/control/latency/profile", handleLatencyProfile)
+	mux.HandleFunc("/control/overload", handleOverload)
+	mux.HandleFunc("/control/watchdog", handleWatchdog)
+	mux.HandleFunc("/control/regions", handleRegions)
+	mux.HandleFunc("/control/regions/remove", handleRemoveRegion)
 	mux.HandleFunc("/control/chain/error-probability", handleSetErrorProbability)
 	mux.HandleFunc("/control/chain/logs-per-block", handleSetLogsPerBlock)
+	mux.HandleFunc("/control/chain/log-fixtures", handleSetLogFixtures)
+	mux.HandleFunc("/control/chain/new-heads-with-tx", handleSetNewHeadsWithTx)
+	mux.HandleFunc("/control/chain/subscribe-params", handleSetSubscribeParams)
+	mux.HandleFunc("/control/chain/discontinuity", handleSetDiscontinuity)
+	mux.HandleFunc("/control/chain/timestamp-fault", handleSetTimestampFault)
+	mux.HandleFunc("/control/chain/id-mangle", handleSetIDMangleMode)
+	mux.HandleFunc("/control/chain/envelope-fault", handleSetEnvelopeFault)
+	mux.HandleFunc("/control/chain/health-check", handleSetHealthCheckIsolation)
+	mux.HandleFunc("/control/chain/fault-propagation", handleSetFaultPropagation)
+	mux.HandleFunc("/control/chain/identity-fast-path", handleSetIdentityFastPath)
+	mux.HandleFunc("/control/chain/strictness", handleSetStrictness)
+	mux.HandleFunc("/control/chain/first-notification-delay", handleSetFirstNotificationDelay)
+	mux.HandleFunc("/control/chain/compression-bomb", handleSetCompressionBomb)
+	mux.HandleFunc("/control/chain/notification-padding", handleSetNotificationPadding)
+	mux.HandleFunc("/control/chain/idle-timeout", handleSetIdleTimeout)
+	mux.HandleFunc("/control/chain/middleware", handleMiddleware)
+	mux.HandleFunc("/control/chain/block-phase", handleSetBlockPhase)
+	mux.HandleFunc("/control/chain/peers", handleSetPeers)
+	mux.HandleFunc("/control/wasm/methods", handleWasmMethods)
+	mux.HandleFunc("/control/wasm/methods/register", handleRegisterWasmMethod)
+	mux.HandleFunc("/control/wasm/methods/remove", handleRemoveWasmMethod)
+	mux.HandleFunc("/control/chain/slow-client", handleSetSlowClient)
+	mux.HandleFunc("/control/chain/", handleChainState)
 	// New error configuration endpoints
 	mux.HandleFunc("/control/errors/add", handleAddErrorConfig)
 	mux.HandleFunc("/control/errors/remove", handleRemoveErrorConfig)
 	mux.HandleFunc("/control/errors/clear", handleClearErrorConfigs)
 	mux.HandleFunc("/control/errors/list", handleListErrorConfigs)
 	mux.HandleFunc("/control/errors/predefined", handleListPredefinedErrors)
+	mux.HandleFunc("/control/errors/sets", handleErrorSets)
+	mux.HandleFunc("/control/errors/sets/apply", handleApplyErrorSet)
+	mux.HandleFunc("/control/errors/sets/remove", handleRemoveErrorSet)
 	// Custom response endpoint
 	mux.HandleFunc("/control/response/custom", handleSetCustomResponse)
+	// Solana fixture endpoints
+	mux.HandleFunc("/control/solana/largest-accounts", handleSetLargestAccounts)
+	mux.HandleFunc("/control/solana/tx-errors/add", handleAddSolanaTxError)
+	mux.HandleFunc("/control/solana/tx-errors/clear", handleClearSolanaTxErrors)
+	mux.HandleFunc("/control/solana/tx-errors/list", handleListSolanaTxErrors)
+	mux.HandleFunc("/control/solana/subscription-ids", handleSetSolanaSubscriptionIDs)
+	mux.HandleFunc("/control/solana/program-logs", handleSetSolanaProgramLogs)
 }
 
 func jsonResponse(w http.ResponseWriter, status int, response interface{}) {
@@ -86,6 +152,7 @@ func handleDropConnections(w http.ResponseWriter, r *http.Request) {
 	if len(bodyBytes) == 0 {
 		// No body provided, just drop connections without blocking
 		subManager.DropAllConnections()
+		reconnects.startCooldown(time.Now())
 		jsonResponse(w, http.StatusOK, ControlResponse{
 			Success: true,
 			Message: "Dropped all connections",
@@ -97,6 +164,7 @@ func handleDropConnections(w http.ResponseWriter, r *http.Request) {
 	if err := json.Unmarshal(bodyBytes, &req); err != nil {
 		// Invalid JSON, just drop connections without blocking
 		subManager.DropAllConnections()
+		reconnects.startCooldown(time.Now())
 		jsonResponse(w, http.StatusOK, ControlResponse{
 			Success: true,
 			Message: "Dropped all connections",
@@ -105,6 +173,7 @@ func handleDropConnections(w http.ResponseWriter, r *http.Request) {
 	}
 
 	subManager.DropAllConnections()
+	reconnects.startCooldown(time.Now())
 	if req.BlockDuration > 0 {
 		BlockConnections(time.Duration(req.BlockDuration) * time.Second)
 		log.Printf("Dropped all connections and blocking new connections for %d seconds", req.BlockDuration)
@@ -120,6 +189,117 @@ func handleDropConnections(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleReconnectLimit reports (GET) or configures (POST) reconnection storm protection; a rate of 0 disables it
+func handleReconnectLimit(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jsonResponse(w, http.StatusOK, reconnects.status(time.Now()))
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Rate            float64 `json:"rate"`
+		Burst           int     `json:"burst"`
+		CooldownSeconds float64 `json:"cooldown_seconds"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Rate < 0 || request.Burst < 0 || request.CooldownSeconds < 0 {
+		http.Error(w, "rate, burst and cooldown_seconds must be non-negative", http.StatusBadRequest)
+		return
+	}
+
+	var limit *ReconnectLimit
+	if request.Rate > 0 {
+		if request.CooldownSeconds == 0 {
+			http.Error(w, "cooldown_seconds is required", http.StatusBadRequest)
+			return
+		}
+		limit = &ReconnectLimit{
+			Rate:     request.Rate,
+			Burst:    request.Burst,
+			Cooldown: time.Duration(request.CooldownSeconds * float64(time.Second)),
+		}
+		log.Printf("Limiting reconnections to %.1f/s for %v after each drop", limit.Rate, limit.Cooldown)
+	} else {
+		log.Printf("Disabled reconnection storm protection")
+	}
+	reconnects.configure(limit)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleSendUnsolicited sends unexpected server-initiated messages over open WebSocket connections
+func handleSendUnsolicited(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain string   `json:"chain"` // Chain name; empty targets all chains
+		Kinds []string `json:"kinds"` // Message kinds to send; empty sends every kind
+		Count int      `json:"count"` // Messages of each kind per connection (default 1)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var chainId string
+	if request.Chain != "" {
+		if getChain(request.Chain) == nil {
+			http.Error(w, fmt.Sprintf("Unsupported chain: %s", request.Chain), http.StatusBadRequest)
+			return
+		}
+		chainId = chainIDForName(request.Chain)
+	}
+
+	kinds := request.Kinds
+	if len(kinds) == 0 {
+		kinds = UnsolicitedKinds
+	}
+	for _, kind := range kinds {
+		if !isValidUnsolicitedKind(kind) {
+			http.Error(w, fmt.Sprintf("Invalid kind: %s (supported: %v)", kind, UnsolicitedKinds), http.StatusBadRequest)
+			return
+		}
+	}
+
+	count := request.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	sent := SendUnsolicitedMessages(chainId, kinds, count)
+	log.Printf("Sent %d unsolicited messages (kinds: %v) to chain %q", sent, kinds, request.Chain)
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"sent":   sent,
+	})
+}
+
+// setBlockNumber moves the head of a chain and broadcasts the new block
+func setBlockNumber(name string, blockNumber uint64) {
+	if name == "solana" {
+		atomic.StoreUint64(&solanaNode.SlotNumber, blockNumber)
+		subManager.BroadcastNewBlock("501", blockNumber)
+		return
+	}
+	if chain, ok := supportedChains[name]; ok {
+		atomic.StoreUint64(&chain.BlockNumber, blockNumber)
+		subManager.BroadcastNewBlock(chainIDForName(name), blockNumber)
+	}
+}
+
 func handleSetBlock(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		jsonResponse(w, http.StatusMethodNotAllowed, ControlResponse{
@@ -130,8 +310,10 @@ func handleSetBlock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Chain       string `json:"chain"`
-		BlockNumber uint64 `json:"block_number"`
+		Chain       string  `json:"chain"`
+		BlockNumber uint64  `json:"block_number"`
+		AtTimestamp float64 `json:"at_timestamp"` // Unix time (seconds) at which to apply the jump
+		InSeconds   float64 `json:"in_seconds"`   // Delay before applying the jump
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -142,28 +324,65 @@ func handleSetBlock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Chain == "solana" {
-		atomic.StoreUint64(&solanaNode.SlotNumber, req.BlockNumber)
-		subManager.BroadcastNewBlock("501", req.BlockNumber)
+	if req.Chain != "solana" {
+		if _, ok := supportedChains[req.Chain]; !ok {
+			jsonResponse(w, http.StatusBadRequest, ControlResponse{
+				Success: false,
+				Message: fmt.Sprintf("Unsupported chain: %s", req.Chain),
+			})
+			return
+		}
+	}
+
+	var delay time.Duration
+	switch {
+	case req.AtTimestamp != 0 && req.InSeconds != 0:
+		jsonResponse(w, http.StatusBadRequest, ControlResponse{
+			Success: false,
+			Message: "Specify at most one of at_timestamp and in_seconds",
+		})
+		return
+	case req.InSeconds < 0:
+		jsonResponse(w, http.StatusBadRequest, ControlResponse{
+			Success: false,
+			Message: "in_seconds must be non-negative",
+		})
+		return
+	case req.InSeconds > 0:
+		delay = time.Duration(req.InSeconds * float64(time.Second))
+	case req.AtTimestamp != 0:
+		at := time.Unix(0, int64(req.AtTimestamp*float64(time.Second)))
+		delay = time.Until(at)
+		if delay < 0 {
+			jsonResponse(w, http.StatusBadRequest, ControlResponse{
+				Success: false,
+				Message: "at_timestamp is in the past",
+			})
+			return
+		}
+	}
+
+	if delay > 0 {
+		time.AfterFunc(delay, func() {
+			setBlockNumber(req.Chain, req.BlockNumber)
+			log.Printf("Scheduled block number %d applied for %s", req.BlockNumber, req.Chain)
+		})
 		jsonResponse(w, http.StatusOK, ControlResponse{
 			Success: true,
-			Message: "Slot number updated for Solana",
+			Message: fmt.Sprintf("Block number %d scheduled for %s at %s", req.BlockNumber, req.Chain, time.Now().Add(delay).UTC().Format(time.RFC3339Nano)),
 		})
 		return
 	}
 
-	chain, ok := supportedChains[req.Chain]
-	if !ok {
-		jsonResponse(w, http.StatusBadRequest, ControlResponse{
-			Success: false,
-			Message: fmt.Sprintf("Unsupported chain: %s", req.Chain),
+	setBlockNumber(req.Chain, req.BlockNumber)
+	if req.Chain == "solana" {
+		jsonResponse(w, http.StatusOK, ControlResponse{
+			Success: true,
+			Message: "Slot number updated for Solana",
 		})
 		return
 	}
 
-	atomic.StoreUint64(&chain.BlockNumber, req.BlockNumber)
-	subManager.BroadcastNewBlock(req.Chain, req.BlockNumber)
-
 	jsonResponse(w, http.StatusOK, ControlResponse{
 		Success: true,
 		Message: fmt.Sprintf("Block number updated for chain %s", req.Chain),
@@ -192,7 +411,7 @@ func handlePauseBlock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Chain == "solana" {
-		atomic.StoreUint32(&solanaNode.SlotIncrement, 1)
+		solanaNode.runState.Transition(RunEventPause)
 		jsonResponse(w, http.StatusOK, ControlResponse{
 			Success: true,
 			Message: "Slot increment paused for Solana",
@@ -203,9 +422,9 @@ func handlePauseBlock(w http.ResponseWriter, r *http.Request) {
 	if req.Chain == "" {
 		// Pause all chains including Solana
 		for _, chain := range supportedChains {
-			atomic.StoreUint32(&chain.BlockIncrement, 1)
+			chain.runState.Transition(RunEventPause)
 		}
-		atomic.StoreUint32(&solanaNode.SlotIncrement, 1)
+		solanaNode.runState.Transition(RunEventPause)
 		jsonResponse(w, http.StatusOK, ControlResponse{
 			Success: true,
 			Message: "Block/slot increment paused for all chains",
@@ -222,7 +441,7 @@ func handlePauseBlock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	atomic.StoreUint32(&chain.BlockIncrement, 1)
+	chain.runState.Transition(RunEventPause)
 	jsonResponse(w, http.StatusOK, ControlResponse{
 		Success: true,
 		Message: fmt.Sprintf("Block increment paused for chain %s", req.Chain),
@@ -251,7 +470,7 @@ func handleResumeBlock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Chain == "solana" {
-		atomic.StoreUint32(&solanaNode.SlotIncrement, 0)
+		solanaNode.runState.Transition(RunEventResume)
 		jsonResponse(w, http.StatusOK, ControlResponse{
 			Success: true,
 			Message: "Slot increment resumed for Solana",
@@ -262,9 +481,9 @@ func handleResumeBlock(w http.ResponseWriter, r *http.Request) {
 	if req.Chain == "" {
 		// Resume all chains including Solana
 		for _, chain := range supportedChains {
-			atomic.StoreUint32(&chain.BlockIncrement, 0)
+			chain.runState.Transition(RunEventResume)
 		}
-		atomic.StoreUint32(&solanaNode.SlotIncrement, 0)
+		solanaNode.runState.Transition(RunEventResume)
 		jsonResponse(w, http.StatusOK, ControlResponse{
 			Success: true,
 			Message: "Block/slot increment resumed for all chains",
@@ -281,7 +500,7 @@ func handleResumeBlock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	atomic.StoreUint32(&chain.BlockIncrement, 0)
+	chain.runState.Transition(RunEventResume)
 	jsonResponse(w, http.StatusOK, ControlResponse{
 		Success: true,
 		Message: fmt.Sprintf("Block increment resumed for chain %s", req.Chain),
@@ -306,8 +525,9 @@ func handlePauseUpdates(w http.ResponseWriter, r *http.Request) {
 
 	if request.Chain == "" {
 		// Pause all chains
+		pauses := make(map[*EVMChain]uint32)
 		for _, chain := range supportedChains {
-			atomic.StoreUint32(&chain.BlockIncrement, 1)
+			pauses[chain], _ = chain.runState.Pause()
 		}
 		log.Printf("Block updates paused for all chains")
 
@@ -315,8 +535,8 @@ func handlePauseUpdates(w http.ResponseWriter, r *http.Request) {
 		if request.DurationSeconds > 0 {
 			go func() {
 				time.Sleep(time.Duration(request.DurationSeconds) * time.Second)
-				for _, chain := range supportedChains {
-					atomic.StoreUint32(&chain.BlockIncrement, 0)
+				for chain, pause := range pauses {
+					chain.runState.EndPause(pause)
 				}
 				log.Printf("Block updates resumed for all chains after %d seconds", request.DurationSeconds)
 			}()
@@ -328,14 +548,14 @@ func handlePauseUpdates(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		atomic.StoreUint32(&chain.BlockIncrement, 1)
+		pause, _ := chain.runState.Pause()
 		log.Printf("Block updates paused for chain %s", request.Chain)
 
 		// If duration is specified, schedule resume
 		if request.DurationSeconds > 0 {
 			go func() {
 				time.Sleep(time.Duration(request.DurationSeconds) * time.Second)
-				atomic.StoreUint32(&chain.BlockIncrement, 0)
+				chain.runState.EndPause(pause)
 				log.Printf("Block updates resumed for chain %s after %d seconds", request.Chain, request.DurationSeconds)
 			}()
 		}
@@ -365,7 +585,7 @@ func handleResumeUpdates(w http.ResponseWriter, r *http.Request) {
 	if request.Chain == "" {
 		// Resume all chains
 		for _, chain := range supportedChains {
-			atomic.StoreUint32(&chain.BlockIncrement, 0)
+			chain.runState.Transition(RunEventResume)
 		}
 		log.Printf("Block updates resumed for all chains")
 	} else {
@@ -375,7 +595,7 @@ func handleResumeUpdates(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		atomic.StoreUint32(&chain.BlockIncrement, 0)
+		chain.runState.Transition(RunEventResume)
 		log.Printf("Block updates resumed for chain %s", request.Chain)
 	}
 
@@ -386,6 +606,44 @@ func handleResumeUpdates(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleChainState reports the run state of a chain: GET /control/chain/{id}/state
+// where {id} is a chain ID or chain name
+func handleChainState(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/control/chain/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "state" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := parts[0]
+	if mapped, ok := chainIdToName[name]; ok {
+		name = mapped
+	}
+	chain := getChain(name)
+	if chain == nil {
+		http.Error(w, fmt.Sprintf("Unsupported chain: %s", parts[0]), http.StatusNotFound)
+		return
+	}
+
+	var blockNumber uint64
+	if name == "solana" {
+		blockNumber = atomic.LoadUint64(&solanaNode.SlotNumber)
+	} else {
+		blockNumber = atomic.LoadUint64(&supportedChains[name].BlockNumber)
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"chain":        name,
+		"chain_id":     chainIDForName(name),
+		"state":        chain.RunState().State().String(),
+		"block_number": blockNumber,
+	})
+}
+
 func handleSetBlockInterval(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		jsonResponse(w, http.StatusMethodNotAllowed, ControlResponse{
@@ -511,6 +769,54 @@ func handleClearTimeout(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// interruptTimers holds the pending resume timer of each interrupted chain
+var (
+	interruptTimersMu sync.Mutex
+	interruptTimers   = make(map[string]*time.Timer)
+)
+
+// interruptChain interrupts block emissions for a chain and schedules its resume.
+// Each chain has its own timer; interrupting a chain again replaces its timer.
+func interruptChain(name string, duration time.Duration) error {
+	chain := getChain(name)
+	if chain == nil {
+		return fmt.Errorf("unsupported chain: %s", name)
+	}
+	if err := chain.InterruptBlocks(); err != nil {
+		return err
+	}
+
+	interruptTimersMu.Lock()
+	defer interruptTimersMu.Unlock()
+	if timer, ok := interruptTimers[name]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		interruptTimersMu.Lock()
+		if interruptTimers[name] != timer {
+			interruptTimersMu.Unlock()
+			return
+		}
+		delete(interruptTimers, name)
+		interruptTimersMu.Unlock()
+		chain.ResumeBlocks()
+	})
+	interruptTimers[name] = timer
+	return nil
+}
+
+// allChainNames returns every EVM chain name plus solana, sorted
+func allChainNames() []string {
+	names := make([]string, 0, len(supportedChains)+1)
+	for name := range supportedChains {
+		names = append(names, name)
+	}
+	names = append(names, "solana")
+	sort.Strings(names)
+	return names
+}
+
 func handleInterruptBlocks(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		jsonResponse(w, http.StatusMethodNotAllowed, ControlResponse{
@@ -536,28 +842,63 @@ func handleInterruptBlocks(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	duration := time.Duration(req.DurationSeconds * float64(time.Second))
 
-	chain := getChain(req.Chain)
-	if chain == nil {
-		jsonResponse(w, http.StatusBadRequest, ControlResponse{
-			Success: false,
-			Message: "Invalid chain",
+	if req.Chain != "" && len(req.Chains) == 0 {
+		// Interrupt block emissions for the specified duration
+		if getChain(req.Chain) == nil {
+			jsonResponse(w, http.StatusBadRequest, ControlResponse{
+				Success: false,
+				Message: "Invalid chain",
+			})
+			return
+		}
+		if err := interruptChain(req.Chain, duration); err != nil {
+			jsonResponse(w, http.StatusConflict, ControlResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, ControlResponse{
+			Success: true,
+			Message: fmt.Sprintf("Block emissions interrupted for %s for %.1f seconds", req.Chain, req.DurationSeconds),
 		})
 		return
 	}
 
-	// Interrupt block emissions for the specified duration
-	chain.InterruptBlocks()
+	// A chain group, or all chains when neither chain nor chains is set
+	names := req.Chains
+	if len(names) == 0 {
+		names = allChainNames()
+	}
+	for _, name := range names {
+		if getChain(name) == nil {
+			jsonResponse(w, http.StatusBadRequest, ControlResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid chain: %s", name),
+			})
+			return
+		}
+	}
 
-	// Schedule the resume after the duration
-	go func() {
-		time.Sleep(time.Duration(req.DurationSeconds * float64(time.Second)))
-		chain.ResumeBlocks()
-	}()
+	interrupted := []string{}
+	skipped := map[string]string{}
+	for _, name := range names {
+		if err := interruptChain(name, duration); err != nil {
+			skipped[name] = err.Error()
+			continue
+		}
+		interrupted = append(interrupted, name)
+	}
+	log.Printf("Block emissions interrupted for %v for %.1f seconds", interrupted, req.DurationSeconds)
 
-	jsonResponse(w, http.StatusOK, ControlResponse{
-		Success: true,
-		Message: fmt.Sprintf("Block emissions interrupted for %s for %.1f seconds", req.Chain, req.DurationSeconds),
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"message":     fmt.Sprintf("Block emissions interrupted for %d chains for %.1f seconds", len(interrupted), req.DurationSeconds),
+		"interrupted": interrupted,
+		"skipped":     skipped,
 	})
 }
 
@@ -585,6 +926,79 @@ func handleChainReorg(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// handleRunScenario starts a scenario of time-correlated steps across chains
+func handleRunScenario(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Name  string         `json:"name"`
+		Steps []ScenarioStep `json:"steps"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Steps) == 0 {
+		http.Error(w, "Scenario must have at least one step", http.StatusBadRequest)
+		return
+	}
+
+	scenario, err := scenarioManager.Start(request.Name, request.Steps)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":      "ok",
+		"scenario_id": scenario.ID,
+	})
+}
+
+// handleListScenarios returns all started scenarios and their progress
+func handleListScenarios(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"scenarios": scenarioManager.List(),
+	})
+}
+
+// handleCancelScenario stops all pending steps of a scenario
+func handleCancelScenario(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID uint64 `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := scenarioManager.Cancel(request.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"message": "Scenario cancelled successfully",
+	})
+}
+
 // Helper function to get chain instance
 func getChain(name string) Chain {
 	if name == "solana" {
@@ -603,8 +1017,9 @@ func handleSetLatency(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var request struct {
-		Chain   string `json:"chain"`
-		Latency int64  `json:"latency_ms"` // Latency in milliseconds
+		Chain     string  `json:"chain"`
+		Latency   int64   `json:"latency_ms"` // Latency in milliseconds
+		Placement *string `json:"placement"`  // Optional: pre, post or split; unchanged if omitted
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -612,6 +1027,11 @@ func handleSetLatency(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if request.Placement != nil && !isValidLatencyPlacement(*request.Placement) {
+		http.Error(w, fmt.Sprintf("Invalid placement: %s (supported: %v)", *request.Placement, LatencyPlacements), http.StatusBadRequest)
+		return
+	}
+
 	// Convert chain name to chain ID if a name was provided
 	chainId := request.Chain
 	for id, name := range chainIdToName {
@@ -622,26 +1042,14 @@ func handleSetLatency(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set latency for the specified chain
-	latencyDuration := time.Duration(request.Latency) * time.Millisecond
-	if chainId == "501" {
-		solanaNode.Latency = latencyDuration
-		log.Printf("Set Solana latency to %dms", request.Latency)
-	} else if chain, exists := supportedChains[chainIdToName[chainId]]; exists {
-		chain.Latency = latencyDuration
-		log.Printf("Set %s latency to %dms", chainIdToName[chainId], request.Latency)
-	} else {
+	if !setLatency(chainId, time.Duration(request.Latency)*time.Millisecond, request.Placement) {
 		http.Error(w, fmt.Sprintf("Unknown chain: %s", request.Chain), http.StatusBadRequest)
 		return
 	}
+	log.Printf("Set %s latency to %dms", chainIdToName[chainId], request.Latency)
 
 	// Save the updated configuration to chains.yaml
-	config := ChainConfig{
-		EVMChains: supportedChains,
-		Solana:    solanaNode,
-	}
-	if err := SaveChainConfig("chains.yaml", &config); err != nil {
-		log.Printf("Warning: Failed to save chain configuration: %v", err)
-	}
+	persistChainConfig()
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -731,16 +1139,17 @@ func handleSetLogsPerBlock(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleAddErrorConfig adds a new error configuration to a chain
-func handleAddErrorConfig(w http.ResponseWriter, r *http.Request) {
+// handleSetLogFixtures replaces the log fixtures used for generated logs of a chain
+// handleSetLogFixtures replaces the runtime log fixtures of an EVM chain
+func handleSetLogFixtures(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var request struct {
-		Chain       string      `json:"chain"`
-		ErrorConfig ErrorConfig `json:"error_config"`
+		Chain    string       `json:"chain"`
+		Fixtures []LogFixture `json:"fixtures"` // Empty restores zero-filled logs
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -748,42 +1157,34 @@ func handleAddErrorConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate error probability
-	if request.ErrorConfig.Probability < 0 || request.ErrorConfig.Probability > 1 {
-		http.Error(w, "Error probability must be between 0 and 1", http.StatusBadRequest)
-		return
-	}
-
-	// Validate delay (must be non-negative if provided)
-	if request.ErrorConfig.DelayMs < 0 {
-		http.Error(w, "Error delay must be non-negative", http.StatusBadRequest)
-		return
+	for i, fixture := range request.Fixtures {
+		if err := validateLogFixture(fixture); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid fixture %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
 	}
 
-	// Add error config to the chain
 	if chain, ok := supportedChains[request.Chain]; ok {
-		chain.ErrorConfigs = append(chain.ErrorConfigs, request.ErrorConfig)
-		log.Printf("Added error config (code: %d, probability: %.2f) to chain %s",
-			request.ErrorConfig.Code, request.ErrorConfig.Probability, request.Chain)
-		jsonResponse(w, http.StatusOK, map[string]interface{}{
-			"status":  "ok",
-			"message": "Error configuration added successfully",
-		})
+		chain.LogFixtures = request.Fixtures
+		log.Printf("Set %d log fixtures for chain %s", len(request.Fixtures), request.Chain)
+		persistChainConfig()
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 	} else {
 		http.Error(w, "Chain not found", http.StatusNotFound)
 	}
 }
 
-// handleRemoveErrorConfig removes an error configuration from a chain
-func handleRemoveErrorConfig(w http.ResponseWriter, r *http.Request) {
+// handleSetNewHeadsWithTx enables or disables the non-standard newHeadsWithTx extension for a chain
+func handleSetNewHeadsWithTx(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var request struct {
-		Chain string `json:"chain"`
-		Index int    `json:"index"` // Index of error config to remove
+		Chain   string `json:"chain"`
+		Enabled bool   `json:"enabled"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -791,27 +1192,18 @@ func handleRemoveErrorConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Remove error config from the chain
 	if chain, ok := supportedChains[request.Chain]; ok {
-		if request.Index < 0 || request.Index >= len(chain.ErrorConfigs) {
-			http.Error(w, "Invalid error config index", http.StatusBadRequest)
-			return
-		}
-
-		// Remove the element at index
-		chain.ErrorConfigs = append(chain.ErrorConfigs[:request.Index], chain.ErrorConfigs[request.Index+1:]...)
-		log.Printf("Removed error config at index %d from chain %s", request.Index, request.Chain)
-		jsonResponse(w, http.StatusOK, map[string]interface{}{
-			"status":  "ok",
-			"message": "Error configuration removed successfully",
-		})
+		chain.DisableNewHeadsWithTx = !request.Enabled
+		log.Printf("Set newHeadsWithTx extension enabled=%t for chain %s", request.Enabled, request.Chain)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 	} else {
 		http.Error(w, "Chain not found", http.StatusNotFound)
 	}
 }
 
-// handleClearErrorConfigs clears all error configurations from a chain
-func handleClearErrorConfigs(w http.ResponseWriter, r *http.Request) {
+// handleSetIDMangleMode configures the response id mangling fault for a chain
+func handleSetIDMangleMode(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -819,6 +1211,7 @@ func handleClearErrorConfigs(w http.ResponseWriter, r *http.Request) {
 
 	var request struct {
 		Chain string `json:"chain"`
+		Mode  string `json:"mode"` // Empty string disables mangling
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -826,14 +1219,322 @@ func handleClearErrorConfigs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Clear error configs from the chain
-	if chain, ok := supportedChains[request.Chain]; ok {
-		chain.ErrorConfigs = []ErrorConfig{}
-		log.Printf("Cleared all error configs from chain %s", request.Chain)
-		jsonResponse(w, http.StatusOK, map[string]interface{}{
-			"status":  "ok",
-			"message": "All error configurations cleared successfully",
-		})
+	if !isValidIDMangleMode(request.Mode) {
+		http.Error(w, fmt.Sprintf("Invalid mode: %s (supported: %v)", request.Mode, IDMangleModes), http.StatusBadRequest)
+		return
+	}
+
+	if request.Chain == "solana" {
+		solanaNode.IDMangleMode = request.Mode
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.IDMangleMode = request.Mode
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Set id mangle mode to %q for chain %s", request.Mode, request.Chain)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleSetStrictness configures the JSON-RPC 2.0 enforcement level for a chain
+func handleSetStrictness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain string `json:"chain"`
+		Level string `json:"level"` // "strict", "lenient" or empty for the default
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !isValidStrictness(request.Level) {
+		http.Error(w, fmt.Sprintf("Invalid level: %s (supported: %v)", request.Level, StrictnessLevels), http.StatusBadRequest)
+		return
+	}
+
+	if request.Chain == "solana" {
+		solanaNode.ProtocolStrictness = request.Level
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.ProtocolStrictness = request.Level
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Set protocol strictness to %q for chain %s", request.Level, request.Chain)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleSetFirstNotificationDelay configures how long new subscriptions on a chain wait for their first notification
+func handleSetFirstNotificationDelay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain        string  `json:"chain"`
+		DelaySeconds float64 `json:"delay_seconds"` // 0 disables the delay
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.DelaySeconds < 0 {
+		http.Error(w, "Delay must be non-negative", http.StatusBadRequest)
+		return
+	}
+
+	delay := time.Duration(request.DelaySeconds * float64(time.Second))
+	if request.Chain == "solana" {
+		solanaNode.FirstNotificationDelay = delay
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.FirstNotificationDelay = delay
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Set first notification delay to %v for chain %s", delay, request.Chain)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleSetIdleTimeout configures the WebSocket idle timeout of a chain; open connections are rescheduled immediately
+func handleSetIdleTimeout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain          string  `json:"chain"`
+		TimeoutSeconds float64 `json:"timeout_seconds"` // 0 disables the idle timeout
+		WarningSeconds float64 `json:"warning_seconds"` // Warning lead time before the close (0 = no warning)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.TimeoutSeconds < 0 || request.WarningSeconds < 0 {
+		http.Error(w, "Timeout and warning must be non-negative", http.StatusBadRequest)
+		return
+	}
+	if request.WarningSeconds > 0 && request.WarningSeconds >= request.TimeoutSeconds {
+		http.Error(w, "Warning must be shorter than the timeout", http.StatusBadRequest)
+		return
+	}
+
+	timeout := time.Duration(request.TimeoutSeconds * float64(time.Second))
+	warning := time.Duration(request.WarningSeconds * float64(time.Second))
+	if request.Chain == "solana" {
+		solanaNode.IdleTimeout = timeout
+		solanaNode.IdleWarning = warning
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.IdleTimeout = timeout
+		chain.IdleWarning = warning
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	rearmIdleTrackers(chainIDForName(request.Chain))
+
+	log.Printf("Set idle timeout to %v (warning %v) for chain %s", timeout, warning, request.Chain)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleSetCompressionBomb configures the decompressed size of gzip HTTP responses for a chain
+func handleSetCompressionBomb(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain             string `json:"chain"`
+		DecompressedBytes int64  `json:"decompressed_bytes"` // 0 disables the compression bomb
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.DecompressedBytes < 0 || request.DecompressedBytes > maxCompressionBombSize {
+		http.Error(w, "Decompressed size must be between 0 and 1 GiB", http.StatusBadRequest)
+		return
+	}
+	if request.DecompressedBytes > 0 {
+		compressedPadding()
+	}
+
+	if request.Chain == "solana" {
+		solanaNode.CompressionBombSize = request.DecompressedBytes
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.CompressionBombSize = request.DecompressedBytes
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Set compression bomb size to %d bytes for chain %s", request.DecompressedBytes, request.Chain)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleAddErrorConfig adds a new error configuration to a chain
+func handleAddErrorConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain       string      `json:"chain"`
+		ErrorConfig ErrorConfig `json:"error_config"`
+		Version     *uint64     `json:"version"` // Expected version of the chain's error configs (optional)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate error probability
+	if request.ErrorConfig.Probability < 0 || request.ErrorConfig.Probability > 1 {
+		http.Error(w, "Error probability must be between 0 and 1", http.StatusBadRequest)
+		return
+	}
+
+	// Validate delay (must be non-negative if provided)
+	if request.ErrorConfig.DelayMs < 0 {
+		http.Error(w, "Error delay must be non-negative", http.StatusBadRequest)
+		return
+	}
+	if err := request.ErrorConfig.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expected, err := expectedVersion(r, request.Version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Add error config to the chain
+	if chain, ok := supportedChains[request.Chain]; ok {
+		version, err := chain.updateErrorConfigs(expected, func(configs []ErrorConfig) ([]ErrorConfig, error) {
+			return append(configs, request.ErrorConfig), nil
+		})
+		if err == nil {
+			log.Printf("Added error config (code: %d, probability: %.2f) to chain %s",
+				request.ErrorConfig.Code, request.ErrorConfig.Probability, request.Chain)
+			persistChainConfig()
+		}
+		writeVersioned(w, version, err, map[string]interface{}{
+			"status":  "ok",
+			"message": "Error configuration added successfully",
+		})
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+	}
+}
+
+// handleRemoveErrorConfig removes an error configuration from a chain
+func handleRemoveErrorConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain   string  `json:"chain"`
+		Index   int     `json:"index"`   // Index of error config to remove
+		Version *uint64 `json:"version"` // Expected version of the chain's error configs (optional)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	expected, err := expectedVersion(r, request.Version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Remove error config from the chain
+	if chain, ok := supportedChains[request.Chain]; ok {
+		version, err := chain.updateErrorConfigs(expected, func(configs []ErrorConfig) ([]ErrorConfig, error) {
+			if request.Index < 0 || request.Index >= len(configs) {
+				return nil, fmt.Errorf("Invalid error config index")
+			}
+			// Remove the element at index without touching the previous slice
+			return append(append([]ErrorConfig{}, configs[:request.Index]...), configs[request.Index+1:]...), nil
+		})
+		if err == nil {
+			log.Printf("Removed error config at index %d from chain %s", request.Index, request.Chain)
+			persistChainConfig()
+		}
+		writeVersioned(w, version, err, map[string]interface{}{
+			"status":  "ok",
+			"message": "Error configuration removed successfully",
+		})
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+	}
+}
+
+// handleClearErrorConfigs clears all error configurations from a chain
+func handleClearErrorConfigs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain   string  `json:"chain"`
+		Version *uint64 `json:"version"` // Expected version of the chain's error configs (optional)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	expected, err := expectedVersion(r, request.Version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Clear error configs from the chain
+	if chain, ok := supportedChains[request.Chain]; ok {
+		version, err := chain.updateErrorConfigs(expected, func([]ErrorConfig) ([]ErrorConfig, error) {
+			return []ErrorConfig{}, nil
+		})
+		if err == nil {
+			log.Printf("Cleared all error configs from chain %s", request.Chain)
+			persistChainConfig()
+		}
+		writeVersioned(w, version, err, map[string]interface{}{
+			"status":  "ok",
+			"message": "All error configurations cleared successfully",
+		})
 	} else {
 		http.Error(w, "Chain not found", http.StatusNotFound)
 	}
@@ -867,9 +1568,10 @@ func handleListErrorConfigs(w http.ResponseWriter, r *http.Request) {
 
 	// Get error configs from the chain
 	if chain, ok := supportedChains[chainName]; ok {
-		jsonResponse(w, http.StatusOK, map[string]interface{}{
+		configs, version := chain.errorConfigsSnapshot()
+		writeVersioned(w, version, nil, map[string]interface{}{
 			"chain":         chainName,
-			"error_configs": chain.ErrorConfigs,
+			"error_configs": configs,
 		})
 	} else {
 		http.Error(w, "Chain not found", http.StatusNotFound)
@@ -888,9 +1590,28 @@ func handleListPredefinedErrors(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleSetCustomResponse sets or clears a custom response for a chain
+// handleSetCustomResponse reports (GET ?chain=) or sets or clears (POST) the custom response of a chain
 func handleSetCustomResponse(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+	switch r.Method {
+	case http.MethodGet:
+		chain, ok := supportedChains[r.URL.Query().Get("chain")]
+		if !ok {
+			http.Error(w, "Chain not found", http.StatusNotFound)
+			return
+		}
+		chain.configMu.Lock()
+		body := map[string]interface{}{
+			"chain":           r.URL.Query().Get("chain"),
+			"custom_response": chain.CustomResponse,
+			"enabled":         chain.CustomResponseEnabled,
+			"methods":         chain.CustomResponseMethods,
+		}
+		version := chain.customResponseVersion
+		chain.configMu.Unlock()
+		writeVersioned(w, version, nil, body)
+		return
+	case http.MethodPost:
+	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -900,12 +1621,18 @@ func handleSetCustomResponse(w http.ResponseWriter, r *http.Request) {
 		CustomResponse string   `json:"custom_response"`
 		Enabled        bool     `json:"enabled"`
 		Methods        []string `json:"methods"` // Specific methods to apply custom response to (empty = all)
+		Version        *uint64  `json:"version"` // Expected version of the chain's custom response (optional)
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	expected, err := expectedVersion(r, request.Version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Validate that custom_response is valid JSON if enabled
 	if request.Enabled && request.CustomResponse != "" {
@@ -918,9 +1645,15 @@ func handleSetCustomResponse(w http.ResponseWriter, r *http.Request) {
 
 	// Set custom response for the chain
 	if chain, ok := supportedChains[request.Chain]; ok {
-		chain.CustomResponse = request.CustomResponse
-		chain.CustomResponseEnabled = request.Enabled
-		chain.CustomResponseMethods = request.Methods
+		version, err := chain.updateCustomResponse(expected, func() {
+			chain.CustomResponse = request.CustomResponse
+			chain.CustomResponseEnabled = request.Enabled
+			chain.CustomResponseMethods = request.Methods
+		})
+		if err != nil {
+			writeVersioned(w, version, err, nil)
+			return
+		}
 
 		if request.Enabled {
 			if len(request.Methods) > 0 {
@@ -931,8 +1664,9 @@ func handleSetCustomResponse(w http.ResponseWriter, r *http.Request) {
 		} else {
 			log.Printf("Disabled custom response for chain %s", request.Chain)
 		}
+		persistChainConfig()
 
-		jsonResponse(w, http.StatusOK, map[string]interface{}{
+		writeVersioned(w, version, nil, map[string]interface{}{
 			"status":  "ok",
 			"message": "Custom response configuration updated successfully",
 		})
@@ -940,3 +1674,166 @@ func handleSetCustomResponse(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Chain not found", http.StatusNotFound)
 	}
 }
+
+// handleSetLargestAccounts replaces the fixture data for getLargestAccounts and getTokenLargestAccounts
+func handleSetLargestAccounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		LargestAccounts      []LargestAccount                 `json:"largest_accounts"`
+		TokenLargestAccounts map[string][]TokenLargestAccount `json:"token_largest_accounts"`
+		Limit                int                              `json:"limit"` // Maximum entries per page (0 = 20)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.Limit < 0 {
+		http.Error(w, "Limit must be non-negative", http.StatusBadRequest)
+		return
+	}
+
+	solanaNode.LargestAccounts = request.LargestAccounts
+	solanaNode.TokenLargestAccounts = request.TokenLargestAccounts
+	solanaNode.LargestAccountsLimit = request.Limit
+	log.Printf("Set largest accounts fixtures for Solana (%d accounts, %d mints, limit %d)",
+		len(request.LargestAccounts), len(request.TokenLargestAccounts), request.Limit)
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"message": "Largest accounts fixtures updated successfully",
+	})
+}
+
+// handleSetSolanaProgramLogs replaces the program log templates used for logsSubscribe and getTransaction
+func handleSetSolanaProgramLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Programs []SolanaProgramLog `json:"programs"` // Empty disables generated program logs
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	for i, template := range request.Programs {
+		if err := validateSolanaProgramLog(template); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid program log %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	solanaNode.ProgramLogs = request.Programs
+	log.Printf("Set %d program log templates for Solana", len(request.Programs))
+	persistChainConfig()
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"message": "Program log templates updated successfully",
+	})
+}
+
+// handleAddSolanaTxError adds a TransactionError injection config for sendTransaction/simulateTransaction
+func handleAddSolanaTxError(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request SolanaTxErrorConfig
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !isValidSolanaTxErrorType(request.Type) {
+		http.Error(w, fmt.Sprintf("Unsupported transaction error type: %s", request.Type), http.StatusBadRequest)
+		return
+	}
+
+	// Validate error probability
+	if request.Probability < 0 || request.Probability > 1 {
+		http.Error(w, "Error probability must be between 0 and 1", http.StatusBadRequest)
+		return
+	}
+
+	solanaNode.TransactionErrors = append(solanaNode.TransactionErrors, request)
+	log.Printf("Added Solana transaction error (type: %s, probability: %.2f)", request.Type, request.Probability)
+	persistChainConfig()
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"message": "Transaction error configuration added successfully",
+	})
+}
+
+// handleClearSolanaTxErrors removes all TransactionError injection configs
+func handleClearSolanaTxErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	solanaNode.TransactionErrors = []SolanaTxErrorConfig{}
+	log.Printf("Cleared all Solana transaction error configs")
+	persistChainConfig()
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"message": "All transaction error configurations cleared successfully",
+	})
+}
+
+// handleListSolanaTxErrors returns all TransactionError injection configs
+func handleListSolanaTxErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"transaction_errors": solanaNode.TransactionErrors,
+	})
+}
+
+// handleSetSolanaSubscriptionIDs configures Solana subscription id reuse and the unsubscribe grace period
+func handleSetSolanaSubscriptionIDs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Reuse   bool  `json:"reuse"`
+		GraceMs int64 `json:"grace_ms"` // Notifications keep arriving this long after unsubscribe (0 = disabled)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.GraceMs < 0 {
+		http.Error(w, "Grace period must be non-negative", http.StatusBadRequest)
+		return
+	}
+
+	grace := time.Duration(request.GraceMs) * time.Millisecond
+	solanaNode.SubscriptionIDReuse = request.Reuse
+	solanaNode.UnsubscribeGrace = grace
+	subManager.SetSolanaIDReuse(request.Reuse, grace)
+	log.Printf("Set Solana subscription id reuse=%t, unsubscribe grace=%v", request.Reuse, grace)
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"message": "Solana subscription id behavior updated successfully",
+	})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSeparateControlListener(t *testing.T) {
+	serve := func(mux *http.ServeMux, method, path, body string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return w.Code
+	}
+	rpcCall := `{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`
+
+	shared, adminMux := newServeMuxes(false)
+	if adminMux != nil {
+		t.Fatal("Expected no admin mux without a separate control plane")
+	}
+	if code := serve(shared, http.MethodGet, "/control/state", ""); code != http.StatusOK {
+		t.Errorf("Expected the control API on the shared port, got %d", code)
+	}
+
+	rpcMux, adminMux := newServeMuxes(true)
+	tests := []struct {
+		name   string
+		mux    *http.ServeMux
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"rpc chain", rpcMux, http.MethodPost, "/chain/1", rpcCall, http.StatusOK},
+		{"rpc control", rpcMux, http.MethodGet, "/control/state", "", http.StatusNotFound},
+		{"rpc ui", rpcMux, http.MethodGet, "/", "", http.StatusNotFound},
+		{"rpc sse", rpcMux, http.MethodGet, "/sse/blocks", "", http.StatusNotFound},
+		{"admin control", adminMux, http.MethodGet, "/control/state", "", http.StatusOK},
+		{"admin chain", adminMux, http.MethodPost, "/chain/1", rpcCall, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if code := serve(tt.mux, tt.method, tt.path, tt.body); code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, code)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+// Broadcast heads chain correctly by construction: the parentHash of block N is derived
+// from the same deterministic hash as block N-1, whether the block was produced by the
+// ticker, mined, or follows a pause, resume or block number change. Continuity only breaks
+// on purpose, through a discontinuity fault or a hash namespace change.
+
+// takeDiscontinuity returns a parentHash that does not match the previous head if a
+// discontinuity is pending on the chain, consuming it, and "" otherwise
+func takeDiscontinuity(chainId string, blockNumber uint64) string {
+	chain, ok := supportedChains[chainIdToName[chainId]]
+	if !ok {
+		return ""
+	}
+	for {
+		pending := atomic.LoadInt32(&chain.discontinuities)
+		if pending <= 0 {
+			return ""
+		}
+		if atomic.CompareAndSwapInt32(&chain.discontinuities, pending, pending-1) {
+			log.Printf("Breaking parent hash continuity at block %d on chain %s", blockNumber, chain.Name)
+			return generateBlockHashForSubscription(blockNumber, chainId, "discontinuity")
+		}
+	}
+}
+
+// handleSetDiscontinuity breaks parentHash continuity for the next count heads of an EVM chain
+func handleSetDiscontinuity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain string `json:"chain"`
+		Count int32  `json:"count"` // Heads to break (default 1)
+		Reset bool   `json:"reset"` // Cancel pending breaks instead
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Count < 0 {
+		http.Error(w, "count must be non-negative", http.StatusBadRequest)
+		return
+	}
+
+	chain, ok := supportedChains[request.Chain]
+	if !ok {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+
+	if request.Reset {
+		atomic.StoreInt32(&chain.discontinuities, 0)
+		log.Printf("Cancelled pending discontinuities on chain %s", request.Chain)
+	} else {
+		if request.Count == 0 {
+			request.Count = 1
+		}
+		pending := atomic.AddInt32(&chain.discontinuities, request.Count)
+		log.Printf("Breaking parent hash continuity for the next %d heads on chain %s", pending, request.Chain)
+	}
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"pending": atomic.LoadInt32(&chain.discontinuities),
+	})
+}
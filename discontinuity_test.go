@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+type headNotification struct {
+	Params struct {
+		Result struct {
+			Number     string `json:"number"`
+			Hash       string `json:"hash"`
+			ParentHash string `json:"parentHash"`
+		} `json:"result"`
+	} `json:"params"`
+}
+
+func receivedHeads(t *testing.T, conn *MockWSConn) []headNotification {
+	t.Helper()
+	subManager.Flush("59144")
+	var heads []headNotification
+	for _, message := range conn.GetMessages() {
+		var head headNotification
+		if err := json.Unmarshal(message, &head); err == nil && head.Params.Result.Hash != "" {
+			heads = append(heads, head)
+		}
+	}
+	return heads
+}
+
+// blockHashOf returns the hash eth_getBlockByNumber reports for a block
+func blockHashOf(t *testing.T, number uint64) string {
+	t.Helper()
+	response, _ := handleEVMRequest(context.Background(), []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getBlockByNumber","params":["0x%x"],"id":1}`, number)), NewMockWSConn(), "59144")
+	var resp struct {
+		Result struct {
+			Hash string `json:"hash"`
+		} `json:"result"`
+	}
+	json.Unmarshal(response, &resp)
+	return resp.Result.Hash
+}
+
+func TestHeadsChainAcrossOperations(t *testing.T) {
+	chain := supportedChains["linea"]
+	conn := NewMockWSConn()
+	defer subManager.CleanupConnection(conn)
+	defer chain.runState.Reset()
+	subManager.Subscribe("59144", conn, "newHeads")
+
+	chain.produceBlock("59144", nil)
+	chain.runState.Transition(RunEventPause)
+	chain.runState.Transition(RunEventResume)
+	chain.produceBlock("59144", nil)
+	setBlockNumber("linea", atomic.LoadUint64(&chain.BlockNumber)+10)
+	chain.produceBlock("59144", nil)
+	setBlockNumber("linea", atomic.LoadUint64(&chain.BlockNumber)-5)
+	chain.produceBlock("59144", nil)
+
+	// Block number changes broadcast the new head too
+	heads := receivedHeads(t, conn)
+	if len(heads) != 6 {
+		t.Fatalf("Expected 6 heads, got %d", len(heads))
+	}
+	for _, head := range heads {
+		number, _ := strconv.ParseUint(head.Params.Result.Number[2:], 16, 64)
+		if head.Params.Result.ParentHash != blockHashOf(t, number-1) {
+			t.Errorf("Block %d: parentHash %s does not match block %d", number, head.Params.Result.ParentHash, number-1)
+		}
+		if head.Params.Result.Hash != blockHashOf(t, number) {
+			t.Errorf("Block %d: hash differs from eth_getBlockByNumber", number)
+		}
+	}
+}
+
+func TestDiscontinuityFault(t *testing.T) {
+	chain := supportedChains["linea"]
+	conn := NewMockWSConn()
+	defer subManager.CleanupConnection(conn)
+	subManager.Subscribe("59144", conn, "newHeads")
+
+	setDiscontinuity := func(body string) int {
+		w := httptest.NewRecorder()
+		handleSetDiscontinuity(w, httptest.NewRequest(http.MethodPost, "/control/chain/discontinuity", bytes.NewBufferString(body)))
+		return w.Code
+	}
+
+	chain.produceBlock("59144", nil)
+	subManager.Flush("59144")
+	if code := setDiscontinuity(`{"chain": "linea"}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if faults := evmActiveFaults(chain); faults[len(faults)-1] != "discontinuity 1" {
+		t.Errorf("Expected the pending discontinuity in active faults, got %v", faults)
+	}
+	chain.produceBlock("59144", nil)
+	chain.produceBlock("59144", nil)
+
+	heads := receivedHeads(t, conn)
+	if len(heads) != 3 {
+		t.Fatalf("Expected 3 heads, got %d", len(heads))
+	}
+	if heads[1].Params.Result.ParentHash == heads[0].Params.Result.Hash {
+		t.Error("Expected the head after the fault to break continuity")
+	}
+	if heads[2].Params.Result.ParentHash != heads[1].Params.Result.Hash {
+		t.Error("Expected continuity to resume after one broken head")
+	}
+
+	subManager.Flush("59144")
+	setDiscontinuity(`{"chain": "linea", "count": 3}`)
+	setDiscontinuity(`{"chain": "linea", "reset": true}`)
+	chain.produceBlock("59144", nil)
+	heads = receivedHeads(t, conn)
+	if last := heads[len(heads)-1]; last.Params.Result.ParentHash != heads[len(heads)-2].Params.Result.Hash {
+		t.Error("Expected a reset to cancel pending discontinuities")
+	}
+
+	if code := setDiscontinuity(`{"chain": "solana"}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a non-EVM chain, got %d", code)
+	}
+	if code := setDiscontinuity(`{"chain": "linea", "count": -1}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a negative count, got %d", code)
+	}
+}
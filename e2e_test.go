@@ -68,80 +68,73 @@ func TestE2EChainConnections(t *testing.T) {
 	// Initialize chain configurations for testing
 	supportedChains = map[string]*EVMChain{
 		"ethereum": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"optimism": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"binance": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"gnosis": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"polygon": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"fantom": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"zksync": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"kaia": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"base": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"arbitrum": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"avalanche": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 		"linea": {
-			BlockNumber:    0,
-			BlockInterval:  100 * time.Millisecond,
-			BlockIncrement: 0,
+			BlockNumber:   0,
+			BlockInterval: 100 * time.Millisecond,
 		},
 	}
 
 	solanaNode = &SolanaNode{
-		SlotNumber:    0,
-		SlotInterval:  100 * time.Millisecond,
-		SlotIncrement: 0,
+		SlotNumber:   0,
+		SlotInterval: 100 * time.Millisecond,
 	}
 
 	// Start the test server
 	serverAddr, cleanup := startTestServer(t)
 	defer cleanup()
 
-	// Initialize block incrementers for each chain
+	// Initialize block incrementers for each chain, stopped before the global state is restored
+	done := make(chan struct{})
+	var producers sync.WaitGroup
+	defer producers.Wait()
+	defer close(done)
 	for chainName, chain := range supportedChains {
+		producers.Add(1)
 		go func(chainName string, c *EVMChain) {
+			defer producers.Done()
 			// Find chain ID for this chain
 			var chainId string
 			for id, name := range chainIdToName {
@@ -156,13 +149,12 @@ func TestE2EChainConnections(t *testing.T) {
 			}
 
 			for {
-				time.Sleep(c.BlockInterval)
-				// Check if blocks are interrupted
-				if atomic.LoadUint32(&c.BlockInterrupt) == 1 {
-					continue
+				select {
+				case <-done:
+					return
+				case <-time.After(c.BlockInterval):
 				}
-				// Check if blocks are paused
-				if atomic.LoadUint32(&c.BlockIncrement) == 0 {
+				if c.runState.IsRunning() {
 					newBlock := atomic.AddUint64(&c.BlockNumber, 1)
 					subManager.BroadcastNewBlock(chainId, newBlock)
 				}
@@ -171,15 +163,16 @@ func TestE2EChainConnections(t *testing.T) {
 	}
 
 	// Initialize Solana slot incrementer
+	producers.Add(1)
 	go func() {
+		defer producers.Done()
 		for {
-			time.Sleep(solanaNode.SlotInterval)
-			// Check if slots are interrupted
-			if atomic.LoadUint32(&solanaNode.BlockInterrupt) == 1 {
-				continue
+			select {
+			case <-done:
+				return
+			case <-time.After(solanaNode.SlotInterval):
 			}
-			// Check if slots are paused
-			if atomic.LoadUint32(&solanaNode.SlotIncrement) == 0 {
+			if solanaNode.runState.IsRunning() {
 				newSlot := atomic.AddUint64(&solanaNode.SlotNumber, 1)
 				subManager.BroadcastNewBlock("501", newSlot)
 			}
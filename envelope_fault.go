@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// Envelope faults break the JSON-RPC 2.0 envelope of responses, to check how strictly
+// clients parse them
+const (
+	EnvelopeFaultNone           = ""
+	EnvelopeFaultVersion1       = "jsonrpc_1_0"      // "jsonrpc": "1.0"
+	EnvelopeFaultMissingVersion = "missing_jsonrpc"  // No jsonrpc field
+	EnvelopeFaultResultAndError = "result_and_error" // Both result and error are present
+)
+
+// EnvelopeFaults lists the supported envelope faults
+var EnvelopeFaults = []string{EnvelopeFaultNone, EnvelopeFaultVersion1, EnvelopeFaultMissingVersion, EnvelopeFaultResultAndError}
+
+// isValidEnvelopeFault returns true if the mode is one of EnvelopeFaults
+func isValidEnvelopeFault(mode string) bool {
+	return containsString(EnvelopeFaults, mode)
+}
+
+// envelopeFieldOrder is the order fields are written in, so faulted responses still look
+// like the simulator's normal ones
+var envelopeFieldOrder = []string{"jsonrpc", "result", "error", "id"}
+
+// applyEnvelopeFault rewrites a response's envelope according to the mode. Responses that
+// are not JSON objects are returned unchanged.
+func applyEnvelopeFault(mode string, response []byte) []byte {
+	if mode == EnvelopeFaultNone {
+		return response
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(response, &fields); err != nil {
+		return response
+	}
+
+	switch mode {
+	case EnvelopeFaultVersion1:
+		fields["jsonrpc"] = json.RawMessage(`"1.0"`)
+	case EnvelopeFaultMissingVersion:
+		delete(fields, "jsonrpc")
+	case EnvelopeFaultResultAndError:
+		if _, ok := fields["result"]; !ok {
+			fields["result"] = json.RawMessage(`null`)
+		}
+		if _, ok := fields["error"]; !ok {
+			fields["error"] = json.RawMessage(`{"code":-32603,"message":"Internal error"}`)
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if !containsString(envelopeFieldOrder, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	buf := []byte{'{'}
+	for _, key := range append(append([]string{}, envelopeFieldOrder...), keys...) {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		name, _ := json.Marshal(key)
+		buf = append(append(append(buf, name...), ':'), value...)
+	}
+	return append(buf, '}')
+}
+
+// handleSetEnvelopeFault configures the response envelope fault for a chain
+func handleSetEnvelopeFault(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain string `json:"chain"`
+		Mode  string `json:"mode"` // Empty string disables the fault
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !isValidEnvelopeFault(request.Mode) {
+		http.Error(w, fmt.Sprintf("Invalid mode: %s (supported: %v)", request.Mode, EnvelopeFaults), http.StatusBadRequest)
+		return
+	}
+
+	if request.Chain == "solana" {
+		solanaNode.EnvelopeFault = request.Mode
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.EnvelopeFault = request.Mode
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	persistChainConfig()
+
+	log.Printf("Set envelope fault to %q for chain %s", request.Mode, request.Chain)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
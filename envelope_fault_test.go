@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplyEnvelopeFault(t *testing.T) {
+	result := []byte(`{"jsonrpc":"2.0","result":"0x1","id":1}`)
+	failure := []byte(`{"jsonrpc":"2.0","error":{"code":-32000,"message":"boom"},"id":"a"}`)
+
+	tests := []struct {
+		mode     string
+		response []byte
+		expected string
+	}{
+		{EnvelopeFaultNone, result, `{"jsonrpc":"2.0","result":"0x1","id":1}`},
+		{EnvelopeFaultVersion1, result, `{"jsonrpc":"1.0","result":"0x1","id":1}`},
+		{EnvelopeFaultMissingVersion, failure, `{"error":{"code":-32000,"message":"boom"},"id":"a"}`},
+		{EnvelopeFaultResultAndError, result, `{"jsonrpc":"2.0","result":"0x1","error":{"code":-32603,"message":"Internal error"},"id":1}`},
+		{EnvelopeFaultResultAndError, failure, `{"jsonrpc":"2.0","result":null,"error":{"code":-32000,"message":"boom"},"id":"a"}`},
+		{EnvelopeFaultVersion1, []byte(`not json`), `not json`},
+	}
+	for _, tt := range tests {
+		if got := string(applyEnvelopeFault(tt.mode, tt.response)); got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.mode, tt.expected, got)
+		}
+	}
+}
+
+func TestEnvelopeFaultPerChain(t *testing.T) {
+	withTempConfig(t)
+	defer func() {
+		supportedChains["gnosis"].EnvelopeFault = ""
+	}()
+
+	set := func(body string) int {
+		w := httptest.NewRecorder()
+		handleSetEnvelopeFault(w, httptest.NewRequest(http.MethodPost, "/control/chain/envelope-fault", bytes.NewBufferString(body)))
+		return w.Code
+	}
+	if code := set(`{"chain": "gnosis", "mode": "missing_jsonrpc"}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if code := set(`{"chain": "gnosis", "mode": "jsonrpc_3"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown mode, got %d", code)
+	}
+	if code := set(`{"chain": "unknown", "mode": ""}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown chain, got %d", code)
+	}
+
+	request := []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`)
+	response, _ := handleRPCRequest(context.Background(), request, NewMockWSConn(), "100")
+	if bytes.Contains(response, []byte(`"jsonrpc"`)) {
+		t.Errorf("Expected gnosis responses without jsonrpc, got %s", response)
+	}
+	response, _ = handleRPCRequest(context.Background(), request, NewMockWSConn(), "137")
+	if !bytes.Contains(response, []byte(`"jsonrpc":"2.0"`)) {
+		t.Errorf("Expected other chains unaffected, got %s", response)
+	}
+	if faults := evmActiveFaults(supportedChains["gnosis"]); !containsString(faults, "envelope missing_jsonrpc") {
+		t.Errorf("Expected the fault in the chain state, got %v", faults)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeRevertReason(t *testing.T) {
+	want := "0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000001a" +
+		"4e6f7420656e6f7567682045746865722070726f76696465642e000000000000"
+	if got := encodeRevertReason("Not enough Ether provided."); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestErrorConfigRender(t *testing.T) {
+	revert := encodeRevertReason("paused")
+	tests := []struct {
+		name        string
+		config      ErrorConfig
+		wantMessage string
+		wantData    interface{}
+	}{
+		{"no data", ErrorConfig{Message: "header not found"}, "header not found", nil},
+		{"string", ErrorConfig{Message: "limit exceeded", Data: "too many logs in {{method}}"}, "limit exceeded", "too many logs in eth_getLogs"},
+		{"revert reason only", ErrorConfig{Message: "execution reverted: {{revert_reason}}", RevertReason: "paused"}, "execution reverted: paused", revert},
+		{"raw revert data", ErrorConfig{Message: "execution reverted", RevertData: "0x1425ea42", RevertReason: "ignored"}, "execution reverted", "0x1425ea42"},
+		{"number", ErrorConfig{Message: "rate limited", Data: 42}, "rate limited", 42},
+		{
+			"nested provider format",
+			ErrorConfig{Message: "execution reverted", RevertReason: "paused", Data: map[string]interface{}{
+				"originalError": map[string]interface{}{"code": 3, "data": "{{revert_data}}", "message": "execution reverted: {{revert_reason}}"},
+				"details":       []interface{}{"{{method}}", true},
+			}},
+			"execution reverted",
+			map[string]interface{}{
+				"originalError": map[string]interface{}{"code": 3, "data": revert, "message": "execution reverted: paused"},
+				"details":       []interface{}{"eth_getLogs", true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		message, data := tt.config.render("eth_getLogs")
+		if message != tt.wantMessage {
+			t.Errorf("%s: expected message %q, got %q", tt.name, tt.wantMessage, message)
+		}
+		if !reflect.DeepEqual(data, tt.wantData) {
+			t.Errorf("%s: expected data %v, got %v", tt.name, tt.wantData, data)
+		}
+	}
+}
+
+func TestStructuredErrorDataResponse(t *testing.T) {
+	withTempConfig(t)
+	chain := supportedChains["optimism"]
+	originalErrors := chain.ErrorConfigs
+	defer func() {
+		chain.ErrorConfigs = originalErrors
+	}()
+
+	body := `{"chain": "optimism", "error_config": {"code": 3, "message": "execution reverted: {{revert_reason}}",
+		"revert_reason": "paused", "data": {"originalError": {"data": "{{revert_data}}"}}, "probability": 1, "methods": ["eth_call"]}}`
+	w := httptest.NewRecorder()
+	handleAddErrorConfig(w, httptest.NewRequest(http.MethodPost, "/control/errors/add", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	response, err := handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_call","params":[],"id":1}`), NewMockWSConn(), "10")
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	var resp struct {
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Data    struct {
+				OriginalError struct {
+					Data string `json:"data"`
+				} `json:"originalError"`
+			} `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(response, &resp); err != nil {
+		t.Fatalf("Failed to parse response %s: %v", response, err)
+	}
+	if resp.Error.Code != 3 || resp.Error.Message != "execution reverted: paused" || resp.Error.Data.OriginalError.Data != encodeRevertReason("paused") {
+		t.Errorf("Unexpected error response: %s", response)
+	}
+
+	w = httptest.NewRecorder()
+	handleAddErrorConfig(w, httptest.NewRequest(http.MethodPost, "/control/errors/add",
+		bytes.NewBufferString(`{"chain": "optimism", "error_config": {"code": 3, "revert_data": "not hex", "probability": 1}}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid revert data, got %d", w.Code)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrorSet is a named group of error configs that is applied to and removed from several
+// EVM chains at once. Applied configs are tagged with the set name, so removing a set does
+// not depend on indexes that shift when other configs are added concurrently.
+type ErrorSet struct {
+	Chains []string      `yaml:"chains,omitempty" json:"chains,omitempty"` // Chains the set applies to by default (empty = all EVM chains)
+	Errors []ErrorConfig `yaml:"errors" json:"errors"`
+}
+
+// validate checks the set's chains and error configs
+func (s *ErrorSet) validate() error {
+	for _, name := range s.Chains {
+		if _, ok := supportedChains[name]; !ok {
+			return fmt.Errorf("unknown EVM chain: %s", name)
+		}
+	}
+	if len(s.Errors) == 0 {
+		return fmt.Errorf("an error set needs at least one error config")
+	}
+	for i := range s.Errors {
+		e := &s.Errors[i]
+		if e.Probability < 0 || e.Probability > 1 {
+			return fmt.Errorf("error %d: probability must be between 0 and 1", i)
+		}
+		if e.DelayMs < 0 {
+			return fmt.Errorf("error %d: delay must be non-negative", i)
+		}
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("error %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// errorSets holds the defined error sets by name; persisted in chains.yaml
+var errorSets = struct {
+	sync.RWMutex
+	byName map[string]*ErrorSet
+}{byName: make(map[string]*ErrorSet)}
+
+// configureErrorSets replaces the defined error sets, e.g. from chains.yaml
+func configureErrorSets(sets map[string]*ErrorSet) error {
+	for name, set := range sets {
+		if err := set.validate(); err != nil {
+			return fmt.Errorf("error set %s: %v", name, err)
+		}
+	}
+	errorSets.Lock()
+	defer errorSets.Unlock()
+	errorSets.byName = make(map[string]*ErrorSet, len(sets))
+	for name, set := range sets {
+		errorSets.byName[name] = set
+	}
+	return nil
+}
+
+// errorSetChains resolves the chains a set is applied to or removed from: the requested
+// chains, else the set's default chains, else all EVM chains. Chains are sorted so locks are
+// always taken in the same order.
+func errorSetChains(requested, defaults []string) ([]string, error) {
+	names := requested
+	if len(names) == 0 {
+		names = defaults
+	}
+	if len(names) == 0 {
+		for name := range supportedChains {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		if _, ok := supportedChains[name]; !ok {
+			return nil, fmt.Errorf("unknown EVM chain: %s", name)
+		}
+	}
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// updateErrorConfigsAcross rewrites the error configs of several chains as one change:
+// no request sees some chains updated and others not. The version of every chain whose
+// configs fn reports as changed increments.
+func updateErrorConfigsAcross(names []string, fn func([]ErrorConfig) ([]ErrorConfig, bool)) {
+	for _, name := range names {
+		supportedChains[name].configMu.Lock()
+	}
+	for _, name := range names {
+		chain := supportedChains[name]
+		if configs, changed := fn(chain.ErrorConfigs); changed {
+			chain.ErrorConfigs = configs
+			chain.errorConfigsVersion++
+		}
+	}
+	for _, name := range names {
+		supportedChains[name].configMu.Unlock()
+	}
+}
+
+// withoutErrorSet returns configs without those applied from the named set, and whether
+// there were any
+func withoutErrorSet(configs []ErrorConfig, name string) ([]ErrorConfig, bool) {
+	kept := []ErrorConfig{}
+	for _, config := range configs {
+		if config.Set != name {
+			kept = append(kept, config)
+		}
+	}
+	return kept, len(kept) != len(configs)
+}
+
+// errorSetAppliedTo returns the chains that currently have configs of the named set
+func errorSetAppliedTo(name string) []string {
+	applied := []string{}
+	for chainName, chain := range supportedChains {
+		configs, _ := chain.errorConfigsSnapshot()
+		for _, config := range configs {
+			if config.Set == name {
+				applied = append(applied, chainName)
+				break
+			}
+		}
+	}
+	sort.Strings(applied)
+	return applied
+}
+
+// handleErrorSets lists (GET) or defines and replaces (POST) named error sets. Redefining
+// an applied set does not change the chains it is applied to until it is applied again.
+func handleErrorSets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		type setInfo struct {
+			*ErrorSet
+			AppliedTo []string `json:"applied_to"`
+		}
+		errorSets.RLock()
+		sets := make(map[string]setInfo, len(errorSets.byName))
+		for name, set := range errorSets.byName {
+			sets[name] = setInfo{ErrorSet: set, AppliedTo: errorSetAppliedTo(name)}
+		}
+		errorSets.RUnlock()
+		jsonResponse(w, http.StatusOK, map[string]interface{}{"sets": sets})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Name string `json:"name"`
+		ErrorSet
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if err := request.ErrorSet.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	set := request.ErrorSet
+	for i := range set.Errors {
+		set.Errors[i].Set = ""
+	}
+	errorSets.Lock()
+	errorSets.byName[request.Name] = &set
+	errorSets.Unlock()
+	persistChainConfig()
+
+	log.Printf("Defined error set %s with %d error configs", request.Name, len(set.Errors))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleApplyErrorSet applies a named error set to several chains at once, replacing
+// configs previously applied from the same set
+func handleApplyErrorSet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Name   string   `json:"name"`
+		Chains []string `json:"chains"` // Chains to apply the set to (empty = the set's chains)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	errorSets.RLock()
+	set, ok := errorSets.byName[request.Name]
+	errorSets.RUnlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("Error set not found: %s", request.Name), http.StatusNotFound)
+		return
+	}
+	chains, err := errorSetChains(request.Chains, set.Chains)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	tagged := make([]ErrorConfig, len(set.Errors))
+	for i, config := range set.Errors {
+		config.Set = request.Name
+		tagged[i] = config
+	}
+	updateErrorConfigsAcross(chains, func(configs []ErrorConfig) ([]ErrorConfig, bool) {
+		kept, _ := withoutErrorSet(configs, request.Name)
+		return append(kept, tagged...), true
+	})
+	persistChainConfig()
+
+	log.Printf("Applied error set %s to chains %v", request.Name, chains)
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"chains": chains,
+	})
+}
+
+// handleRemoveErrorSet removes the configs applied from a named error set from several
+// chains at once, or from all chains when none are given; delete also drops the definition
+func handleRemoveErrorSet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Name   string   `json:"name"`
+		Chains []string `json:"chains"` // Chains to remove the set from (empty = all chains)
+		Delete bool     `json:"delete"` // Also delete the set definition
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	chains, err := errorSetChains(request.Chains, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	updateErrorConfigsAcross(chains, func(configs []ErrorConfig) ([]ErrorConfig, bool) {
+		return withoutErrorSet(configs, request.Name)
+	})
+	if request.Delete {
+		errorSets.Lock()
+		delete(errorSets.byName, request.Name)
+		errorSets.Unlock()
+	}
+	persistChainConfig()
+
+	log.Printf("Removed error set %s from chains %v", request.Name, chains)
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"chains": chains,
+	})
+}
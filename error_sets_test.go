@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func errorSetRequest(t *testing.T, handler http.HandlerFunc, body string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/control/errors/sets", bytes.NewBufferString(body)))
+	return w.Code
+}
+
+func setNames(configs []ErrorConfig) []string {
+	names := []string{}
+	for _, config := range configs {
+		names = append(names, config.Set)
+	}
+	return names
+}
+
+func TestErrorSets(t *testing.T) {
+	path := withTempConfig(t)
+	ethereum, polygon, base := supportedChains["ethereum"], supportedChains["polygon"], supportedChains["base"]
+	defer func() {
+		ethereum.ErrorConfigs, polygon.ErrorConfigs, base.ErrorConfigs = nil, nil, nil
+		configureErrorSets(nil)
+	}()
+
+	ethereum.ErrorConfigs = []ErrorConfig{{Code: -32000, Message: "manual", Probability: 0.1}}
+	define := `{"name": "rate-limited", "chains": ["ethereum", "polygon"], "errors": [{"code": 429, "message": "Too Many Requests", "probability": 0.3}]}`
+	if code := errorSetRequest(t, handleErrorSets, define); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	_, ethereumVersion := ethereum.errorConfigsSnapshot()
+
+	// Applying twice keeps one copy per chain, next to the manual config
+	for i := 0; i < 2; i++ {
+		if code := errorSetRequest(t, handleApplyErrorSet, `{"name": "rate-limited"}`); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+	}
+	if names := setNames(ethereum.ErrorConfigs); len(names) != 2 || names[0] != "" || names[1] != "rate-limited" {
+		t.Errorf("Expected the manual config and the set on ethereum, got %v", names)
+	}
+	if len(polygon.ErrorConfigs) != 1 || len(base.ErrorConfigs) != 0 {
+		t.Errorf("Expected the set on its default chains only, got polygon %d, base %d", len(polygon.ErrorConfigs), len(base.ErrorConfigs))
+	}
+	if _, version := ethereum.errorConfigsSnapshot(); version != ethereumVersion+2 {
+		t.Errorf("Expected each apply to bump the version, got %d after %d", version, ethereumVersion)
+	}
+
+	// An unknown chain rejects the whole operation
+	if code := errorSetRequest(t, handleApplyErrorSet, `{"name": "rate-limited", "chains": ["base", "unknown"]}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown chain, got %d", code)
+	}
+	if len(base.ErrorConfigs) != 0 {
+		t.Error("Expected no chain to change when one chain is unknown")
+	}
+
+	w := httptest.NewRecorder()
+	handleErrorSets(w, httptest.NewRequest(http.MethodGet, "/control/errors/sets", nil))
+	var list struct {
+		Sets map[string]struct {
+			AppliedTo []string `json:"applied_to"`
+		} `json:"sets"`
+	}
+	json.NewDecoder(w.Body).Decode(&list)
+	if applied := list.Sets["rate-limited"].AppliedTo; len(applied) != 2 || applied[0] != "ethereum" || applied[1] != "polygon" {
+		t.Errorf("Expected the set applied to ethereum and polygon, got %v", applied)
+	}
+
+	// Definitions and applied configs survive a restart
+	data, _ := os.ReadFile(path)
+	var persisted ChainConfig
+	if err := yaml.Unmarshal(data, &persisted); err != nil || persisted.ErrorSets["rate-limited"] == nil {
+		t.Errorf("Expected the set to be persisted, got %v", err)
+	}
+	if names := setNames(persisted.EVMChains["polygon"].ErrorConfigs); len(names) != 1 || names[0] != "rate-limited" {
+		t.Errorf("Expected polygon's applied config to keep its set name, got %v", names)
+	}
+
+	if code := errorSetRequest(t, handleRemoveErrorSet, `{"name": "rate-limited", "delete": true}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if names := setNames(ethereum.ErrorConfigs); len(names) != 1 || names[0] != "" || len(polygon.ErrorConfigs) != 0 {
+		t.Errorf("Expected only the manual config to remain, got %v", names)
+	}
+	if code := errorSetRequest(t, handleApplyErrorSet, `{"name": "rate-limited"}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a deleted set, got %d", code)
+	}
+
+	for _, body := range []string{
+		`{"errors": [{"code": 1, "probability": 1}]}`,
+		`{"name": "empty", "errors": []}`,
+		`{"name": "bad", "errors": [{"code": 1, "probability": 2}]}`,
+		`{"name": "bad", "chains": ["solana"], "errors": [{"code": 1, "probability": 1}]}`,
+	} {
+		if code := errorSetRequest(t, handleErrorSets, body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, code)
+		}
+	}
+}
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
 	"math/rand"
+	"strings"
 )
 
 // ErrorConfig defines a configurable error that can be simulated
 type ErrorConfig struct {
-	Code        int      `json:"code" yaml:"code"`
-	Message     string   `json:"message" yaml:"message"`
-	Data        string   `json:"data,omitempty" yaml:"data,omitempty"`
-	Probability float64  `json:"probability" yaml:"probability"`               // 0.0 to 1.0
-	Methods     []string `json:"methods,omitempty" yaml:"methods,omitempty"`   // If empty, applies to all methods
-	DelayMs     int      `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty"` // Delay in milliseconds before returning error (0 = no delay)
+	Code         int         `json:"code" yaml:"code"`
+	Message      string      `json:"message" yaml:"message"`
+	Data         interface{} `json:"data,omitempty" yaml:"data,omitempty"`                   // String, number, object or array; strings may contain placeholders (see render)
+	RevertReason string      `json:"revert_reason,omitempty" yaml:"revert_reason,omitempty"` // Encoded as Error(string) revert data
+	RevertData   string      `json:"revert_data,omitempty" yaml:"revert_data,omitempty"`     // Raw hex revert data, e.g. a custom error; takes precedence over RevertReason
+	Probability  float64     `json:"probability" yaml:"probability"`                         // 0.0 to 1.0
+	Methods      []string    `json:"methods,omitempty" yaml:"methods,omitempty"`             // If empty, applies to all methods
+	DelayMs      int         `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty"`           // Delay in milliseconds before returning error (0 = no delay)
+	Set          string      `json:"set,omitempty" yaml:"set,omitempty"`                     // Name of the error set the config was applied from (see ErrorSet)
 }
 
 // PredefinedErrors contains common Ethereum JSON-RPC errors
@@ -130,6 +137,83 @@ var PredefinedErrors = map[string]ErrorConfig{
 	},
 }
 
+// Placeholders replaced in the message and in every string of the data payload
+const (
+	errorPlaceholderRevertData   = "{{revert_data}}"
+	errorPlaceholderRevertReason = "{{revert_reason}}"
+	errorPlaceholderMethod       = "{{method}}"
+)
+
+// validate checks the revert data is hex
+func (e *ErrorConfig) validate() error {
+	if e.RevertData != "" && !isHexOfLength(e.RevertData, -1) {
+		return fmt.Errorf("revert_data must be 0x-prefixed hex")
+	}
+	return nil
+}
+
+// revertData returns the hex revert data of the error, empty if it has none
+func (e *ErrorConfig) revertData() string {
+	if e.RevertData != "" {
+		return e.RevertData
+	}
+	if e.RevertReason != "" {
+		return encodeRevertReason(e.RevertReason)
+	}
+	return ""
+}
+
+// render returns the message and data payload for a request of the given method. Without
+// data, an error with revert data returns the hex revert data, as geth does.
+func (e *ErrorConfig) render(method string) (string, interface{}) {
+	revert := e.revertData()
+	replacer := strings.NewReplacer(
+		errorPlaceholderRevertData, revert,
+		errorPlaceholderRevertReason, e.RevertReason,
+		errorPlaceholderMethod, method,
+	)
+	message := replacer.Replace(e.Message)
+	if e.Data == nil || e.Data == "" {
+		if revert != "" {
+			return message, revert
+		}
+		return message, nil
+	}
+	return message, renderErrorData(e.Data, replacer)
+}
+
+// renderErrorData copies a data payload, replacing placeholders in its strings
+func renderErrorData(data interface{}, replacer *strings.Replacer) interface{} {
+	switch v := data.(type) {
+	case string:
+		return replacer.Replace(v)
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			rendered[key] = renderErrorData(value, replacer)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, value := range v {
+			rendered[i] = renderErrorData(value, replacer)
+		}
+		return rendered
+	}
+	return data
+}
+
+// encodeRevertReason ABI-encodes reason as Error(string) revert data
+func encodeRevertReason(reason string) string {
+	padded := (len(reason) + 31) / 32 * 32
+	encoded := make([]byte, 4+32+32+padded)
+	copy(encoded, []byte{0x08, 0xc3, 0x79, 0xa0}) // Error(string) selector
+	encoded[4+31] = 0x20                          // Offset of the string
+	binary.BigEndian.PutUint64(encoded[4+56:4+64], uint64(len(reason)))
+	copy(encoded[4+64:], reason)
+	return "0x" + hex.EncodeToString(encoded)
+}
+
 // ShouldSimulateError checks if an error should be simulated for the given method
 // Returns the error config to use, or nil if no error should be simulated
 func ShouldSimulateError(errorConfigs []ErrorConfig, method string) *ErrorConfig {
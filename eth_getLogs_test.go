@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"sync/atomic"
 	"testing"
@@ -111,7 +112,7 @@ func TestEthGetLogsBlockRangeValidation(t *testing.T) {
 			}
 
 			// Call handleEVMRequest
-			response, err := handleEVMRequest(requestBytes, nil, "1")
+			response, err := handleEVMRequest(context.Background(), requestBytes, nil, "1")
 			if err != nil {
 				t.Fatalf("handleEVMRequest returned error: %v", err)
 			}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -16,24 +17,24 @@ func init() {
 	// Initialize block numbers for each chain
 	for _, chain := range supportedChains {
 		chain.BlockNumber = 1
-		chain.BlockIncrement = 0
+		chain.runState.Reset()
 		// Set default error probability to 0
 		chain.ErrorProbability = 0
 	}
 	// Initialize Solana slot number
 	solanaNode.SlotNumber = 1
-	solanaNode.SlotIncrement = 0
+	solanaNode.runState.Reset()
 }
 
 // generateBlockHash creates a deterministic hash based on block number and chain ID
 func generateBlockHash(blockNumber uint64, chainID string, seed string) string {
 	// Create a unique input combining block number, chain ID, and seed
-	input := fmt.Sprintf("%s-%d-%s", chainID, blockNumber, seed)
+	input := hashInput(fmt.Sprintf("%s-%d-%s", chainID, blockNumber, seed))
 	hash := sha256.Sum256([]byte(input))
 	return "0x" + hex.EncodeToString(hash[:])
 }
 
-func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, error) {
+func handleEVMRequest(ctx context.Context, message []byte, conn WSConn, chainId string) ([]byte, error) {
 	// Get chain configuration
 	chainName, exists := chainIdToName[chainId]
 	if !exists {
@@ -45,16 +46,49 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 		return createErrorResponse(-32602, fmt.Sprintf("Unsupported chain: %s", chainName), nil, nil)
 	}
 
-	// Simulate network latency if configured
-	if chain.Latency > 0 {
-		time.Sleep(chain.Latency)
-	}
+	// Fault changes may reach this request's transport late (see FaultPropagation)
+	faults := effectiveFaults(chainId, conn)
 
 	var request JSONRPCRequest
-	if err := json.Unmarshal(message, &request); err != nil {
-		log.Printf("Error unmarshalling message: %s", err)
-		log.Printf("Message: %s", string(message))
-		return createErrorResponse(-32700, "Parse error", nil, nil)
+	rpcErr := parseRequest(message, connStrictness(conn, chain.ProtocolStrictness), &request)
+	override := methodOverride(faults.MethodOverrides, request.Method)
+
+	// Isolated health checks bypass the chain's latency and faults, and so do identity methods
+	// on the fast path; method overrides still apply to both
+	healthCheck := faults.HealthCheck.covers(request.Method)
+	fastPath := !healthCheck && faults.IdentityFastPath && isIdentityMethod(chainId, request.Method)
+	bypass := healthCheck || fastPath
+
+	// Requests arriving during block import are slower and may hit import-only errors
+	importing := !bypass && chain.BlockPhase.importing(chainId)
+
+	// Simulate network latency if configured, before and/or after handling
+	latency := methodLatency(faults.Latency, faults.LatencyDistribution, override)
+	if healthCheck {
+		latency = faults.HealthCheck.latency(override)
+	} else if fastPath {
+		latency = methodLatency(0, nil, override)
+	}
+	preLatency, postLatency := splitLatency(latency, faults.LatencyPlacement)
+	if importing {
+		preLatency += chain.BlockPhase.Latency
+	}
+	load := chainLoads.For(chainId)
+	if !fastPath {
+		preLatency += regionLatency(ctx, chainName)
+		preLatency += load.requestLatency(preLatency + postLatency)
+	}
+	if preLatency > 0 {
+		if err := sleepContext(ctx, preLatency); err != nil {
+			return nil, err
+		}
+	}
+	if postLatency > 0 {
+		defer sleepContext(ctx, postLatency)
+	}
+
+	if rpcErr != nil {
+		return createErrorResponse(rpcErr.Code, rpcErr.Message, rpcErr.Data, request.ID)
 	}
 
 	// Only log non-health check messages
@@ -62,36 +96,53 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 		log.Printf("Incoming EVM message: %s", string(message))
 	}
 
-	// Validate JSON-RPC version
-	if request.JsonRPC != "2.0" {
-		return createErrorResponse(-32600, "Invalid Request", nil, request.ID)
-	}
+	// Apply id mangling fault if configured
+	request.ID = mangleID(faults.IDMangleMode, request.ID)
 
 	// Legacy error probability support (deprecated but maintained for backwards compatibility)
-	if chain.ErrorProbability > 0 && rand.Float64() < chain.ErrorProbability {
+	if !bypass && faults.ErrorProbability > 0 && rand.Float64() < faults.ErrorProbability {
+		load.injectedError()
 		return createErrorResponse(-32000, "header not found", nil, request.ID)
 	}
 
-	// New configurable error simulation
-	if errorConfig := ShouldSimulateError(chain.ErrorConfigs, request.Method); errorConfig != nil {
+	// New configurable error simulation; method override errors take precedence
+	var errorConfig *ErrorConfig
+	if override != nil {
+		errorConfig = ShouldSimulateError(override.errors, request.Method)
+	}
+	if errorConfig == nil && importing {
+		errorConfig = ShouldSimulateError(chain.BlockPhase.ErrorConfigs, request.Method)
+	}
+	if errorConfig == nil && healthCheck {
+		errorConfig = ShouldSimulateError(faults.HealthCheck.ErrorConfigs, request.Method)
+	} else if errorConfig == nil && !fastPath {
+		errorConfig = ShouldSimulateError(faults.ErrorConfigs, request.Method)
+	}
+	if errorConfig != nil {
+		load.injectedError()
 		// Apply delay if configured
 		if errorConfig.DelayMs > 0 {
-			time.Sleep(time.Duration(errorConfig.DelayMs) * time.Millisecond)
-		}
-		var data interface{}
-		if errorConfig.Data != "" {
-			data = errorConfig.Data
+			if err := sleepContext(ctx, time.Duration(errorConfig.DelayMs)*time.Millisecond); err != nil {
+				return nil, err
+			}
 		}
-		return createErrorResponse(errorConfig.Code, errorConfig.Message, data, request.ID)
+		message, data := errorConfig.render(request.Method)
+		return createErrorResponse(errorConfig.Code, message, data, request.ID)
+	}
+
+	// Method-level custom response
+	if override != nil && override.CustomResponse != "" {
+		log.Printf("Returning method override response for chain %s, method %s", chainName, request.Method)
+		return []byte(override.CustomResponse), nil
 	}
 
 	// Custom response override
-	if chain.CustomResponseEnabled && chain.CustomResponse != "" {
+	if !bypass && faults.CustomResponseEnabled && faults.CustomResponse != "" {
 		// Check if we should apply custom response to this method
-		applyCustomResponse := len(chain.CustomResponseMethods) == 0 // Apply to all if no methods specified
+		applyCustomResponse := len(faults.CustomResponseMethods) == 0 // Apply to all if no methods specified
 		if !applyCustomResponse {
 			// Check if current method is in the list
-			for _, method := range chain.CustomResponseMethods {
+			for _, method := range faults.CustomResponseMethods {
 				if method == request.Method {
 					applyCustomResponse = true
 					break
@@ -101,10 +152,15 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 
 		if applyCustomResponse {
 			log.Printf("Returning custom response for chain %s, method %s", chainName, request.Method)
-			return []byte(chain.CustomResponse), nil
+			return []byte(faults.CustomResponse), nil
 		}
 	}
 
+	// Custom methods implemented by WASM modules
+	if method := lookupWasmMethod(chainId, request.Method); method != nil {
+		return method.respond(ctx, message, request.ID)
+	}
+
 	var result interface{}
 	var err error
 
@@ -122,7 +178,11 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 	case "eth_accounts":
 		result = []string{}
 	case "net_listening":
-		result = true
+		_, listening := chain.Peers.state(time.Now())
+		result = listening
+	case "net_peerCount":
+		peers, _ := chain.Peers.state(time.Now())
+		result = fmt.Sprintf("0x%x", peers)
 	case "eth_getBlockByNumber":
 		// Parse block parameter if provided
 		if len(request.Params) > 0 {
@@ -153,6 +213,14 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 				blockNumber = parsedBlock
 			}
 
+			// The second parameter selects full transaction objects instead of hashes
+			fullTx := false
+			if len(request.Params) > 1 {
+				if fullTx, ok = request.Params[1].(bool); !ok {
+					return createErrorResponse(-32602, "Invalid includeTransactions parameter", nil, request.ID)
+				}
+			}
+
 			// Generate unique hashes for this block
 			blockHash := generateBlockHash(blockNumber, chainId, "block")
 			var parentHash string
@@ -167,7 +235,7 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 				"number":          fmt.Sprintf("0x%x", blockNumber),
 				"hash":            blockHash,
 				"parentHash":      parentHash,
-				"timestamp":       fmt.Sprintf("0x%x", time.Now().Unix()),
+				"timestamp":       blockTimestamp(chainId, blockNumber),
 				"gasLimit":        "0x" + hex.EncodeToString(make([]byte, 32)),
 				"gasUsed":         "0x" + hex.EncodeToString(make([]byte, 32)),
 				"miner":           "0x" + hex.EncodeToString(make([]byte, 20)),
@@ -178,7 +246,7 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 				"extraData":       "0x",
 				"baseFeePerGas":   "0x" + hex.EncodeToString(make([]byte, 32)),
 				"uncles":          []string{},
-				"transactions":    []interface{}{},
+				"transactions":    blockTransactions(chainId, blockNumber, fullTx),
 			}
 		} else {
 			blockNumber := atomic.LoadUint64(&chain.BlockNumber)
@@ -196,7 +264,7 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 				"number":          fmt.Sprintf("0x%x", blockNumber),
 				"hash":            blockHash,
 				"parentHash":      parentHash,
-				"timestamp":       fmt.Sprintf("0x%x", time.Now().Unix()),
+				"timestamp":       blockTimestamp(chainId, blockNumber),
 				"gasLimit":        "0x" + hex.EncodeToString(make([]byte, 32)),
 				"gasUsed":         "0x" + hex.EncodeToString(make([]byte, 32)),
 				"miner":           "0x" + hex.EncodeToString(make([]byte, 20)),
@@ -207,7 +275,7 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 				"extraData":       "0x",
 				"baseFeePerGas":   "0x" + hex.EncodeToString(make([]byte, 32)),
 				"uncles":          []string{},
-				"transactions":    []interface{}{},
+				"transactions":    blockTransactions(chainId, blockNumber, false),
 			}
 		}
 	case "eth_subscribe":
@@ -219,6 +287,12 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 			return createErrorResponse(-32602, "Invalid subscription type", nil, request.ID)
 		}
 
+		if chain.StrictSubscribeParams {
+			if err := validateStrictSubscribeParams(subscriptionType, request.Params); err != nil {
+				return createErrorResponse(-32602, err.Error(), nil, request.ID)
+			}
+		}
+
 		var subType string
 		switch subscriptionType {
 		case "newHeads":
@@ -231,6 +305,10 @@ func handleEVMRequest(message []byte, conn WSConn, chainId string) ([]byte, erro
 				}
 				includeTx, ok := options["includeTransactions"].(bool)
 				if ok && includeTx {
+					// Chains configured for strict standard behavior don't support the extension
+					if chain.DisableNewHeadsWithTx {
+						return createErrorResponse(-32602, "includeTransactions is not supported for newHeads", nil, request.ID)
+					}
 					// Store the preference in the subscription
 					subType = "newHeadsWithTx"
 				}
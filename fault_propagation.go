@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Transports a fault propagation delay can apply to
+const (
+	TransportWS   = "ws"
+	TransportHTTP = "http"
+)
+
+// faultSnapshotInterval is how often chains with a propagation delay record their faults,
+// and so the resolution of the delay
+const faultSnapshotInterval = 50 * time.Millisecond
+
+// chainFaults is the part of a chain's configuration whose changes a fault propagation delay
+// holds back on one transport
+type chainFaults struct {
+	Latency               time.Duration
+	LatencyDistribution   *LatencyDistribution
+	LatencyPlacement      string
+	ErrorProbability      float64
+	ErrorConfigs          []ErrorConfig
+	MethodOverrides       map[string]*MethodOverride
+	CustomResponse        string
+	CustomResponseEnabled bool
+	CustomResponseMethods []string
+	IDMangleMode          string
+	EnvelopeFault         string
+	HealthCheck           *HealthCheckIsolation
+	IdentityFastPath      bool
+}
+
+// liveFaults returns the chain's current faults
+func liveFaults(chainId string) chainFaults {
+	latency, distribution, placement := latencySettings(chainId)
+	if chainId == "501" {
+		n := solanaNode
+		return chainFaults{
+			Latency:             latency,
+			LatencyDistribution: distribution,
+			LatencyPlacement:    placement,
+			MethodOverrides:     n.MethodOverrides,
+			IDMangleMode:        n.IDMangleMode,
+			EnvelopeFault:       n.EnvelopeFault,
+			HealthCheck:         n.HealthCheck,
+			IdentityFastPath:    n.IdentityFastPath,
+		}
+	}
+	c, ok := supportedChains[chainIdToName[chainId]]
+	if !ok {
+		return chainFaults{}
+	}
+	return chainFaults{
+		Latency:               latency,
+		LatencyDistribution:   distribution,
+		LatencyPlacement:      placement,
+		ErrorProbability:      c.ErrorProbability,
+		ErrorConfigs:          c.ErrorConfigs,
+		MethodOverrides:       c.MethodOverrides,
+		CustomResponse:        c.CustomResponse,
+		CustomResponseEnabled: c.CustomResponseEnabled,
+		CustomResponseMethods: c.CustomResponseMethods,
+		IDMangleMode:          c.IDMangleMode,
+		EnvelopeFault:         c.EnvelopeFault,
+		HealthCheck:           c.HealthCheck,
+		IdentityFastPath:      c.IdentityFastPath,
+	}
+}
+
+// snapshotFaults returns the chain's current faults with method overrides copied, since
+// they are changed in place
+func snapshotFaults(chainId string) chainFaults {
+	faults := liveFaults(chainId)
+	if faults.MethodOverrides != nil {
+		overrides := make(map[string]*MethodOverride, len(faults.MethodOverrides))
+		for method, override := range faults.MethodOverrides {
+			copied := *override
+			overrides[method] = &copied
+		}
+		faults.MethodOverrides = overrides
+	}
+	return faults
+}
+
+// FaultPropagation delays fault changes on one transport of a chain, like a provider fleet
+// in the middle of a rolling deploy: the other transport sees changes immediately
+type FaultPropagation struct {
+	Lagging string        `json:"lagging"` // Transport that sees changes late: ws or http
+	Delay   time.Duration `json:"delay"`
+}
+
+type faultSnapshot struct {
+	at     time.Time
+	faults chainFaults
+}
+
+// propagationState is a chain's propagation delay and its recent fault history
+type propagationState struct {
+	FaultPropagation
+	history []faultSnapshot // Oldest first
+}
+
+// faultPropagation holds the propagation delays by chain ID; runtime only
+var faultPropagation = struct {
+	sync.RWMutex
+	byChain map[string]*propagationState
+	once    sync.Once
+}{byChain: make(map[string]*propagationState)}
+
+// connTransport returns the transport a connection uses
+func connTransport(conn WSConn) string {
+	if _, ok := conn.(*wsConnWrapper); ok {
+		return TransportWS
+	}
+	return TransportHTTP
+}
+
+// effectiveFaults returns the faults a request on conn sees: the chain's current faults, or
+// on a lagging transport, the faults the chain had one propagation delay ago
+func effectiveFaults(chainId string, conn WSConn) chainFaults {
+	faultPropagation.RLock()
+	defer faultPropagation.RUnlock()
+	state, ok := faultPropagation.byChain[chainId]
+	if !ok || state.Lagging != connTransport(conn) || len(state.history) == 0 {
+		return liveFaults(chainId)
+	}
+	cutoff := time.Now().Add(-state.Delay)
+	faults := state.history[0].faults
+	for _, snapshot := range state.history {
+		if snapshot.at.After(cutoff) {
+			break
+		}
+		faults = snapshot.faults
+	}
+	return faults
+}
+
+// recordFaultSnapshots records the faults of every chain with a propagation delay, keeping
+// one snapshot older than the delay
+func recordFaultSnapshots(now time.Time) {
+	faultPropagation.Lock()
+	defer faultPropagation.Unlock()
+	for chainId, state := range faultPropagation.byChain {
+		state.history = append(state.history, faultSnapshot{at: now, faults: snapshotFaults(chainId)})
+		cutoff := now.Add(-state.Delay)
+		drop := 0
+		for drop+1 < len(state.history) && !state.history[drop+1].at.After(cutoff) {
+			drop++
+		}
+		state.history = state.history[drop:]
+	}
+}
+
+// runFaultSnapshots records fault snapshots for the lifetime of the process
+func runFaultSnapshots() {
+	ticker := time.NewTicker(faultSnapshotInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		recordFaultSnapshots(now)
+	}
+}
+
+// setFaultPropagation configures a chain's propagation delay; a zero delay removes it. The
+// lagging transport keeps the faults the chain has now until the delay has passed.
+func setFaultPropagation(chainId string, propagation FaultPropagation) {
+	faultPropagation.Lock()
+	defer faultPropagation.Unlock()
+	if propagation.Delay == 0 {
+		delete(faultPropagation.byChain, chainId)
+		return
+	}
+	state, ok := faultPropagation.byChain[chainId]
+	if !ok || state.Lagging != propagation.Lagging {
+		state = &propagationState{history: []faultSnapshot{{at: time.Now(), faults: snapshotFaults(chainId)}}}
+		faultPropagation.byChain[chainId] = state
+	}
+	state.FaultPropagation = propagation
+	faultPropagation.once.Do(func() { go runFaultSnapshots() })
+}
+
+// faultPropagationFor returns a chain's propagation delay, if it has one
+func faultPropagationFor(chainId string) (FaultPropagation, bool) {
+	faultPropagation.RLock()
+	defer faultPropagation.RUnlock()
+	state, ok := faultPropagation.byChain[chainId]
+	if !ok {
+		return FaultPropagation{}, false
+	}
+	return state.FaultPropagation, true
+}
+
+// handleSetFaultPropagation delays fault changes of a chain on one transport
+func handleSetFaultPropagation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain   string `json:"chain"`
+		Lagging string `json:"lagging"`  // Transport that sees changes late: ws or http
+		DelayMs int64  `json:"delay_ms"` // 0 removes the delay
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	chainId := chainIDForName(request.Chain)
+	if chainId == "" || getChain(request.Chain) == nil {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	if request.DelayMs < 0 {
+		http.Error(w, "delay_ms must be non-negative", http.StatusBadRequest)
+		return
+	}
+	if request.DelayMs > 0 && request.Lagging != TransportWS && request.Lagging != TransportHTTP {
+		http.Error(w, fmt.Sprintf("Invalid lagging transport: %s (supported: %s, %s)", request.Lagging, TransportWS, TransportHTTP), http.StatusBadRequest)
+		return
+	}
+
+	delay := time.Duration(request.DelayMs) * time.Millisecond
+	setFaultPropagation(chainId, FaultPropagation{Lagging: request.Lagging, Delay: delay})
+
+	if delay == 0 {
+		log.Printf("Removed fault propagation delay for chain %s", request.Chain)
+	} else {
+		log.Printf("Fault changes on chain %s reach %s %v late", request.Chain, request.Lagging, delay)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
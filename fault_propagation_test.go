@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFaultPropagationDelay(t *testing.T) {
+	chain := supportedChains["base"]
+	defer func() {
+		setFaultPropagation("8453", FaultPropagation{})
+		chain.ErrorConfigs = nil
+	}()
+
+	set := func(body string) int {
+		w := httptest.NewRecorder()
+		handleSetFaultPropagation(w, httptest.NewRequest(http.MethodPost, "/control/chain/fault-propagation", bytes.NewBufferString(body)))
+		return w.Code
+	}
+	if code := set(`{"chain": "base", "lagging": "http", "delay_ms": 300}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+
+	failed := func(conn WSConn) bool {
+		data, _ := handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`), conn, "8453")
+		var response JSONRPCResponse
+		json.Unmarshal(data, &response)
+		return response.Error != nil
+	}
+
+	chain.ErrorConfigs = []ErrorConfig{{Code: -32000, Message: "boom", Probability: 1}}
+	if !failed(&wsConnWrapper{chainId: "8453"}) {
+		t.Error("Expected WebSocket requests to see the new fault immediately")
+	}
+	if failed(NewMockWSConn()) {
+		t.Error("Expected HTTP requests to see the new fault only after the delay")
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	if !failed(NewMockWSConn()) {
+		t.Error("Expected HTTP requests to see the fault after the delay")
+	}
+
+	// Removing the fault propagates the same way
+	chain.ErrorConfigs = nil
+	if failed(&wsConnWrapper{chainId: "8453"}) {
+		t.Error("Expected WebSocket requests to see the removal immediately")
+	}
+	if !failed(NewMockWSConn()) {
+		t.Error("Expected HTTP requests to keep the fault until the delay has passed")
+	}
+
+	if code := set(`{"chain": "base", "delay_ms": 0}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if _, ok := faultPropagationFor("8453"); ok {
+		t.Error("Expected a zero delay to remove the propagation delay")
+	}
+	if failed(NewMockWSConn()) {
+		t.Error("Expected HTTP requests to see current faults without a delay")
+	}
+}
+
+func TestFaultPropagationValidation(t *testing.T) {
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"chain": "unknown", "lagging": "ws", "delay_ms": 100}`, http.StatusNotFound},
+		{`{"chain": "base", "lagging": "grpc", "delay_ms": 100}`, http.StatusBadRequest},
+		{`{"chain": "base", "lagging": "ws", "delay_ms": -1}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handleSetFaultPropagation(w, httptest.NewRequest(http.MethodPost, "/control/chain/fault-propagation", bytes.NewBufferString(tt.body)))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFirstNotificationDelay(t *testing.T) {
+	defer func() { solanaNode.FirstNotificationDelay = 0 }()
+	solanaNode.FirstNotificationDelay = 100 * time.Millisecond
+
+	sm := NewSubscriptionManager()
+	conn := NewMockWSConn()
+	if _, err := sm.Subscribe("501", conn, "slotNotification"); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	sm.BroadcastNewBlock("501", 10)
+	sm.Flush("501")
+	if n := len(conn.GetMessages()); n != 0 {
+		t.Errorf("Expected no notifications before the delay elapses, got %d", n)
+	}
+
+	// Subscriptions created while no delay is configured are notified immediately
+	solanaNode.FirstNotificationDelay = 0
+	other := NewMockWSConn()
+	sm.Subscribe("501", other, "slotNotification")
+	sm.BroadcastNewBlock("501", 11)
+	sm.Flush("501")
+	if n := len(other.GetMessages()); n != 1 {
+		t.Errorf("Expected 1 notification without delay, got %d", n)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	sm.BroadcastNewBlock("501", 12)
+	sm.Flush("501")
+	if n := len(conn.GetMessages()); n != 1 {
+		t.Errorf("Expected 1 notification after the delay, got %d", n)
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// incidentPollInterval is how often active faults are compared to find incidents starting and ending
+const incidentPollInterval = time.Second
+
+// maxIncidents is how many incidents are kept for export; the oldest ended ones go first
+const maxIncidents = 1000
+
+// GrafanaConfig pushes incidents as Grafana annotations through the HTTP API, so test-run
+// dashboards show when each simulated fault started and ended next to client-side metrics
+type GrafanaConfig struct {
+	URL          string   `yaml:"url" json:"url"`                                         // Grafana base URL, e.g. http://grafana:3000
+	Token        string   `yaml:"token,omitempty" json:"token,omitempty"`                 // Service account token (empty = GRAFANA_TOKEN)
+	DashboardUID string   `yaml:"dashboard_uid,omitempty" json:"dashboard_uid,omitempty"` // Dashboard to annotate (empty = organization-wide)
+	Tags         []string `yaml:"tags,omitempty" json:"tags,omitempty"`                   // Added to every annotation, e.g. the test run id
+}
+
+// validate checks the URL
+func (c *GrafanaConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("grafana url must be an http(s) URL")
+	}
+	return nil
+}
+
+// token returns the configured token, or GRAFANA_TOKEN
+func (c *GrafanaConfig) token() string {
+	if c.Token != "" {
+		return c.Token
+	}
+	return os.Getenv("GRAFANA_TOKEN")
+}
+
+// Incident is one fault that was active on a chain, or simulator-wide for an empty chain,
+// from Start until End
+type Incident struct {
+	ID    uint64     `json:"id"`
+	Chain string     `json:"chain,omitempty"`
+	Fault string     `json:"fault"` // As listed in the chain's active_faults, e.g. "timeout 5s"
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"` // Unset while the fault is active
+
+	annotationID int64 // Grafana annotation of the incident, once pushed
+}
+
+// tags returns the annotation tags of the incident
+func (i *Incident) tags(extra []string) []string {
+	kind, _, _ := strings.Cut(i.Fault, " ")
+	tags := []string{"rpc-simulator", kind}
+	if i.Chain != "" {
+		tags = append(tags, i.Chain)
+	}
+	return append(tags, extra...)
+}
+
+// text returns the annotation text of the incident
+func (i *Incident) text() string {
+	if i.Chain == "" {
+		return i.Fault
+	}
+	return i.Chain + ": " + i.Fault
+}
+
+// incidentKey identifies an active fault across polls
+type incidentKey struct {
+	chain, fault string
+}
+
+// incidentTracker turns changes of the active faults into incidents and pushes them to Grafana
+type incidentTracker struct {
+	mu        sync.Mutex
+	config    *GrafanaConfig
+	nextID    uint64
+	open      map[incidentKey]*Incident
+	incidents []*Incident // Oldest first
+	client    *http.Client
+
+	pushed, failed uint64
+}
+
+var incidents = &incidentTracker{
+	open:   make(map[incidentKey]*Incident),
+	client: &http.Client{Timeout: 5 * time.Second},
+}
+
+// configure replaces the Grafana instance; nil stops pushing annotations
+func (t *incidentTracker) configure(config *GrafanaConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config = config
+}
+
+// activeFaultKeys returns the faults active right now, simulator-wide and per chain
+func activeFaultKeys() map[incidentKey]bool {
+	active := make(map[incidentKey]bool)
+	state := currentState()
+	if state.ConnectionsBlocked {
+		active[incidentKey{fault: "connections_blocked"}] = true
+	}
+	for _, chain := range state.Chains {
+		for _, fault := range chain.ActiveFaults {
+			active[incidentKey{chain: chain.Chain, fault: fault}] = true
+		}
+		if chain.RunState != RunStateRunning.String() {
+			active[incidentKey{chain: chain.Chain, fault: chain.RunState}] = true
+		}
+	}
+	return active
+}
+
+// observe opens incidents for faults that became active and ends those no longer active,
+// then pushes the changes to Grafana
+func (t *incidentTracker) observe(now time.Time, active map[incidentKey]bool) {
+	var changed []*Incident
+	t.mu.Lock()
+	for key := range active {
+		if _, ok := t.open[key]; ok {
+			continue
+		}
+		t.nextID++
+		incident := &Incident{ID: t.nextID, Chain: key.chain, Fault: key.fault, Start: now}
+		t.open[key] = incident
+		t.incidents = append(t.incidents, incident)
+		changed = append(changed, incident)
+	}
+	for key, incident := range t.open {
+		if active[key] {
+			continue
+		}
+		end := now
+		incident.End = &end
+		delete(t.open, key)
+		changed = append(changed, incident)
+	}
+	t.trim()
+	config := t.config
+	t.mu.Unlock()
+
+	if config == nil {
+		return
+	}
+	for _, incident := range changed {
+		t.push(config, incident)
+	}
+}
+
+// trim drops the oldest ended incidents beyond maxIncidents
+// Must be called with t.mu held
+func (t *incidentTracker) trim() {
+	excess := len(t.incidents) - maxIncidents
+	if excess <= 0 {
+		return
+	}
+	kept := t.incidents[:0]
+	for _, incident := range t.incidents {
+		if excess > 0 && incident.End != nil {
+			excess--
+			continue
+		}
+		kept = append(kept, incident)
+	}
+	t.incidents = kept
+}
+
+// push creates the incident's annotation, or sets its end once the incident is over. An
+// incident that ends before it was pushed is created as a complete region.
+func (t *incidentTracker) push(config *GrafanaConfig, incident *Incident) {
+	t.mu.Lock()
+	annotationID := incident.annotationID
+	annotation := map[string]interface{}{}
+	if incident.End != nil {
+		annotation["timeEnd"] = incident.End.UnixMilli()
+	}
+	t.mu.Unlock()
+
+	method, target := http.MethodPatch, fmt.Sprintf("%s/api/annotations/%d", strings.TrimSuffix(config.URL, "/"), annotationID)
+	if annotationID == 0 {
+		method, target = http.MethodPost, strings.TrimSuffix(config.URL, "/")+"/api/annotations"
+		annotation["time"] = incident.Start.UnixMilli()
+		annotation["tags"] = incident.tags(config.Tags)
+		annotation["text"] = incident.text()
+		if config.DashboardUID != "" {
+			annotation["dashboardUID"] = config.DashboardUID
+		}
+	}
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	data, _ := json.Marshal(annotation)
+	req, err := http.NewRequest(method, target, bytes.NewReader(data))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		if token := config.token(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		var resp *http.Response
+		resp, err = t.client.Do(req)
+		if err == nil {
+			if resp.StatusCode >= 300 {
+				err = fmt.Errorf("grafana returned %s", resp.Status)
+			} else if method == http.MethodPost {
+				err = json.NewDecoder(resp.Body).Decode(&created)
+			}
+			resp.Body.Close()
+		}
+	}
+	if err != nil {
+		atomic.AddUint64(&t.failed, 1)
+		log.Printf("Failed to push incident %d to Grafana: %v", incident.ID, err)
+		return
+	}
+	atomic.AddUint64(&t.pushed, 1)
+	if created.ID != 0 {
+		t.mu.Lock()
+		incident.annotationID = created.ID
+		t.mu.Unlock()
+	}
+}
+
+// list returns copies of the incidents overlapping [from, to]; zero bounds are open
+func (t *incidentTracker) list(from, to time.Time) []Incident {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := []Incident{}
+	for _, incident := range t.incidents {
+		if !to.IsZero() && incident.Start.After(to) {
+			continue
+		}
+		if !from.IsZero() && incident.End != nil && incident.End.Before(from) {
+			continue
+		}
+		list = append(list, *incident)
+	}
+	return list
+}
+
+// runIncidentTracker watches the active faults for the lifetime of the process
+func runIncidentTracker() {
+	ticker := time.NewTicker(incidentPollInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		incidents.observe(now, activeFaultKeys())
+	}
+}
+
+// handleGrafana reports (GET) or configures (POST) pushing incidents as Grafana annotations;
+// an empty url disables it
+func handleGrafana(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		incidents.mu.Lock()
+		var config *GrafanaConfig
+		if incidents.config != nil {
+			redacted := *incidents.config
+			if redacted.Token != "" {
+				redacted.Token = "redacted"
+			}
+			config = &redacted
+		}
+		incidents.mu.Unlock()
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"config": config,
+			"pushed": atomic.LoadUint64(&incidents.pushed),
+			"failed": atomic.LoadUint64(&incidents.failed),
+		})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request GrafanaConfig
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var config *GrafanaConfig
+	if request.URL != "" {
+		config = &request
+		if err := config.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	incidents.configure(config)
+	persistChainConfig()
+
+	if config == nil {
+		log.Printf("Disabled Grafana annotations")
+	} else {
+		log.Printf("Pushing incidents to Grafana at %s", config.URL)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleGrafanaAnnotations exports incidents in Grafana's annotation format, for dashboards
+// that query the simulator directly (e.g. with the JSON API or Infinity data source).
+// from and to are optional bounds in Unix milliseconds.
+func handleGrafanaAnnotations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var bounds [2]time.Time
+	for i, param := range []string{"from", "to"} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid %s: must be Unix milliseconds", param), http.StatusBadRequest)
+			return
+		}
+		bounds[i] = time.UnixMilli(ms)
+	}
+
+	type annotation struct {
+		Time    int64    `json:"time"`
+		TimeEnd int64    `json:"timeEnd,omitempty"` // Unset while the incident is ongoing
+		Title   string   `json:"title"`
+		Text    string   `json:"text"`
+		Tags    []string `json:"tags"`
+	}
+	incidents.mu.Lock()
+	var tags []string
+	if incidents.config != nil {
+		tags = incidents.config.Tags
+	}
+	incidents.mu.Unlock()
+
+	annotations := []annotation{}
+	for _, incident := range incidents.list(bounds[0], bounds[1]) {
+		a := annotation{
+			Time:  incident.Start.UnixMilli(),
+			Title: incident.Fault,
+			Text:  incident.text(),
+			Tags:  incident.tags(tags),
+		}
+		if incident.End != nil {
+			a.TimeEnd = incident.End.UnixMilli()
+		}
+		annotations = append(annotations, a)
+	}
+	jsonResponse(w, http.StatusOK, annotations)
+}
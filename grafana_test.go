@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncidentsPushedAsGrafanaAnnotations(t *testing.T) {
+	withTempConfig(t)
+	originalIncidents := incidents
+	incidents = &incidentTracker{open: make(map[incidentKey]*Incident), client: http.DefaultClient}
+	defer func() {
+		incidents = originalIncidents
+	}()
+
+	// A fake Grafana recording annotation calls
+	var mu sync.Mutex
+	var calls []string
+	grafana := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, fmt.Sprintf("%s %s %s %v %v", r.Method, r.URL.Path, r.Header.Get("Authorization"), body["text"], body["timeEnd"] != nil))
+		if r.Method == http.MethodPost {
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": len(calls), "message": "Annotation added"})
+		}
+	}))
+	defer grafana.Close()
+
+	w := httptest.NewRecorder()
+	handleGrafana(w, httptest.NewRequest(http.MethodPost, "/control/grafana", bytes.NewBufferString(`{"url": "`+grafana.URL+`", "token": "secret", "tags": ["run-1"]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	start := time.UnixMilli(1000000)
+	timeout := incidentKey{chain: "ethereum", fault: "timeout 5s"}
+	incidents.observe(start, map[incidentKey]bool{timeout: true})
+	incidents.observe(start.Add(time.Second), map[incidentKey]bool{timeout: true})
+	incidents.observe(start.Add(2*time.Second), map[incidentKey]bool{})
+
+	mu.Lock()
+	got := strings.Join(calls, "\n")
+	mu.Unlock()
+	want := "POST /api/annotations Bearer secret ethereum: timeout 5s false\nPATCH /api/annotations/1 Bearer secret <nil> true"
+	if got != want {
+		t.Errorf("Unexpected annotation calls:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The same incident is exported in Grafana's annotation format
+	w = httptest.NewRecorder()
+	handleGrafanaAnnotations(w, httptest.NewRequest(http.MethodGet, "/control/grafana/annotations?from=1000500", nil))
+	var annotations []struct {
+		Time    int64    `json:"time"`
+		TimeEnd int64    `json:"timeEnd"`
+		Tags    []string `json:"tags"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&annotations); err != nil {
+		t.Fatalf("Failed to decode annotations: %v", err)
+	}
+	if len(annotations) != 1 || annotations[0].Time != 1000000 || annotations[0].TimeEnd != 1002000 {
+		t.Fatalf("Unexpected annotations: %+v", annotations)
+	}
+	if strings.Join(annotations[0].Tags, ",") != "rpc-simulator,timeout,ethereum,run-1" {
+		t.Errorf("Unexpected tags: %v", annotations[0].Tags)
+	}
+
+	w = httptest.NewRecorder()
+	handleGrafanaAnnotations(w, httptest.NewRequest(http.MethodGet, "/control/grafana/annotations?from=1003000", nil))
+	if body := strings.TrimSpace(w.Body.String()); body != "[]" {
+		t.Errorf("Expected no incidents after the bound, got %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	handleGrafana(w, httptest.NewRequest(http.MethodGet, "/control/grafana", nil))
+	if body := w.Body.String(); strings.Contains(body, "secret") || !strings.Contains(body, `"pushed":2`) {
+		t.Errorf("Expected a redacted token and 2 pushed calls, got %s", body)
+	}
+}
+
+func TestIncidentEndedBeforePush(t *testing.T) {
+	originalIncidents := incidents
+	incidents = &incidentTracker{open: make(map[incidentKey]*Incident), client: http.DefaultClient}
+	defer func() { incidents = originalIncidents }()
+
+	var bodies []map[string]interface{}
+	grafana := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		if len(bodies) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 7})
+	}))
+	defer grafana.Close()
+	incidents.configure(&GrafanaConfig{URL: grafana.URL})
+
+	paused := incidentKey{chain: "base", fault: "paused"}
+	incidents.observe(time.UnixMilli(5000), map[incidentKey]bool{paused: true})
+	incidents.observe(time.UnixMilli(9000), map[incidentKey]bool{})
+
+	// The failed start is retried as a complete region once the incident ends
+	if len(bodies) != 2 || bodies[1]["time"] != float64(5000) || bodies[1]["timeEnd"] != float64(9000) {
+		t.Errorf("Expected a complete region after the failed push, got %v", bodies)
+	}
+	if incidents.failed != 1 || incidents.pushed != 1 {
+		t.Errorf("Expected 1 failed and 1 pushed call, got %d and %d", incidents.failed, incidents.pushed)
+	}
+}
+
+func TestActiveFaultKeys(t *testing.T) {
+	chain := supportedChains["polygon"]
+	defer func() { chain.ResponseTimeout = 0 }()
+	chain.ResponseTimeout = 5 * time.Second
+
+	if !activeFaultKeys()[incidentKey{chain: "polygon", fault: "timeout 5s"}] {
+		t.Error("Expected the timeout to be an active fault")
+	}
+}
+
+func TestGrafanaValidation(t *testing.T) {
+	for _, body := range []string{`{"url": "grafana:3000"}`, `not json`} {
+		w := httptest.NewRecorder()
+		handleGrafana(w, httptest.NewRequest(http.MethodPost, "/control/grafana", bytes.NewBufferString(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	handleGrafanaAnnotations(w, httptest.NewRequest(http.MethodGet, "/control/grafana/annotations?from=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid bound, got %d", w.Code)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// hashNamespace salts generated block hashes, transaction hashes and Solana blockhashes and
+// signatures, so they are deterministic within a test run but differ between runs. The empty
+// salt keeps the unsalted hashes of earlier versions.
+var hashNamespace struct {
+	sync.RWMutex
+	salt string
+}
+
+// hashInput prefixes the input of a generated hash with the current salt
+func hashInput(input string) string {
+	hashNamespace.RLock()
+	defer hashNamespace.RUnlock()
+	if hashNamespace.salt == "" {
+		return input
+	}
+	return hashNamespace.salt + "|" + input
+}
+
+// setHashSalt changes the salt for all subsequently generated hashes
+func setHashSalt(salt string) {
+	hashNamespace.Lock()
+	defer hashNamespace.Unlock()
+	hashNamespace.salt = salt
+}
+
+func currentHashSalt() string {
+	hashNamespace.RLock()
+	defer hashNamespace.RUnlock()
+	return hashNamespace.salt
+}
+
+// handleHashNamespace reports (GET) or sets (POST) the hash salt; "random" generates a new one
+func handleHashNamespace(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jsonResponse(w, http.StatusOK, map[string]string{"salt": currentHashSalt()})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Salt   string `json:"salt"`   // Empty restores the unsalted hashes
+		Random bool   `json:"random"` // Generate a random salt instead
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	salt := request.Salt
+	if request.Random {
+		b := make([]byte, 8)
+		rand.Read(b)
+		salt = hex.EncodeToString(b)
+	}
+	setHashSalt(salt)
+
+	log.Printf("Set hash namespace salt to %q", salt)
+	jsonResponse(w, http.StatusOK, map[string]string{
+		"status": "ok",
+		"salt":   salt,
+	})
+}
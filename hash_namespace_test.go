@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHashNamespace(t *testing.T) {
+	defer setHashSalt("")
+
+	unsalted := generateBlockHash(100, "1", "block")
+	unsaltedBlockhash := generateSolanaBlockhash(100)
+
+	setHashSalt("run-a")
+	runA := generateBlockHash(100, "1", "block")
+	if runA == unsalted || generateSolanaBlockhash(100) == unsaltedBlockhash {
+		t.Error("Expected salted hashes to differ from unsalted hashes")
+	}
+	if generateBlockHash(100, "1", "block") != runA {
+		t.Error("Expected hashes to be deterministic within a run")
+	}
+	if generateBlockHashForSubscription(100, "1", "block") != runA {
+		t.Error("Expected request and subscription block hashes to match")
+	}
+
+	setHashSalt("run-b")
+	if generateBlockHash(100, "1", "block") == runA {
+		t.Error("Expected hashes to differ between runs")
+	}
+
+	setHashSalt("")
+	if generateBlockHash(100, "1", "block") != unsalted {
+		t.Error("Expected the empty salt to restore unsalted hashes")
+	}
+}
+
+func TestHandleHashNamespace(t *testing.T) {
+	defer setHashSalt("")
+
+	post := func(body string) map[string]string {
+		w := httptest.NewRecorder()
+		handleHashNamespace(w, httptest.NewRequest(http.MethodPost, "/control/hash-namespace", bytes.NewBufferString(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var resp map[string]string
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	if resp := post(`{"salt": "test-42"}`); resp["salt"] != "test-42" || currentHashSalt() != "test-42" {
+		t.Errorf("Expected salt test-42, got %v", resp)
+	}
+	first := post(`{"random": true}`)["salt"]
+	second := post(`{"random": true}`)["salt"]
+	if len(first) != 16 || first == second {
+		t.Errorf("Expected distinct random salts, got %q and %q", first, second)
+	}
+
+	w := httptest.NewRecorder()
+	handleHashNamespace(w, httptest.NewRequest(http.MethodGet, "/control/hash-namespace", nil))
+	if !bytes.Contains(w.Body.Bytes(), []byte(second)) {
+		t.Errorf("Expected the current salt, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// defaultHealthCheckMethods are the methods load balancers commonly probe nodes with
+var defaultHealthCheckMethods = []string{"getHealth", "eth_blockNumber", "eth_chainId", "eth_syncing", "net_version", "net_listening", "web3_clientVersion"}
+
+// HealthCheckIsolation takes health-check methods off the chain's latency and fault path, to
+// simulate a node whose health check is fine while real traffic is broken. Isolated methods
+// skip the chain's latency, block phase, error configs and custom response, and get the
+// settings below instead; method overrides still apply.
+type HealthCheckIsolation struct {
+	Methods      []string      `yaml:"methods,omitempty" json:"methods,omitempty"`             // Isolated methods (empty = defaultHealthCheckMethods)
+	Latency      time.Duration `yaml:"latency,omitempty" json:"latency,omitempty"`             // Latency of health checks instead of the chain's
+	ErrorConfigs []ErrorConfig `yaml:"error_configs,omitempty" json:"error_configs,omitempty"` // Errors of health checks instead of the chain's
+}
+
+// covers returns true if the method is an isolated health check
+func (h *HealthCheckIsolation) covers(method string) bool {
+	if h == nil {
+		return false
+	}
+	if len(h.Methods) == 0 {
+		return containsString(defaultHealthCheckMethods, method)
+	}
+	return containsString(h.Methods, method)
+}
+
+// latency returns the latency of an isolated health check
+func (h *HealthCheckIsolation) latency(override *MethodOverride) time.Duration {
+	return methodLatency(h.Latency, nil, override)
+}
+
+// validate checks the latency and error configs
+func (h *HealthCheckIsolation) validate() error {
+	if h == nil {
+		return nil
+	}
+	if h.Latency < 0 {
+		return fmt.Errorf("health check latency must be non-negative")
+	}
+	for i := range h.ErrorConfigs {
+		e := &h.ErrorConfigs[i]
+		if e.Probability < 0 || e.Probability > 1 {
+			return fmt.Errorf("health check error %d: probability must be between 0 and 1", i)
+		}
+		if e.DelayMs < 0 {
+			return fmt.Errorf("health check error %d: delay must be non-negative", i)
+		}
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("health check error %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// handleSetHealthCheckIsolation isolates a chain's health-check methods from its latency and
+// faults, or with enabled false puts them back on the normal path
+func handleSetHealthCheckIsolation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain        string        `json:"chain"`
+		Enabled      bool          `json:"enabled"`
+		Methods      []string      `json:"methods"`
+		LatencyMs    int64         `json:"latency_ms"`
+		ErrorConfigs []ErrorConfig `json:"error_configs"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var isolation *HealthCheckIsolation
+	if request.Enabled {
+		isolation = &HealthCheckIsolation{
+			Methods:      request.Methods,
+			Latency:      time.Duration(request.LatencyMs) * time.Millisecond,
+			ErrorConfigs: request.ErrorConfigs,
+		}
+		if err := isolation.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if request.Chain == "solana" {
+		solanaNode.HealthCheck = isolation
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.HealthCheck = isolation
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	persistChainConfig()
+
+	if isolation == nil {
+		log.Printf("Removed health check isolation for chain %s", request.Chain)
+	} else {
+		log.Printf("Isolated health checks for chain %s (latency: %v, %d error configs)", request.Chain, isolation.Latency, len(isolation.ErrorConfigs))
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
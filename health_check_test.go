@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthCheckIsolation(t *testing.T) {
+	withTempConfig(t)
+	chain := supportedChains["arbitrum"]
+	originalLatency := chain.Latency
+	defer func() {
+		setLatency("42161", originalLatency, nil)
+		chain.ErrorConfigs = nil
+		chain.HealthCheck = nil
+	}()
+
+	// Real traffic is slow and failing
+	setLatency("42161", 200*time.Millisecond, nil)
+	chain.ErrorConfigs = []ErrorConfig{{Code: -32000, Message: "boom", Probability: 1}}
+
+	set := func(body string) int {
+		w := httptest.NewRecorder()
+		handleSetHealthCheckIsolation(w, httptest.NewRequest(http.MethodPost, "/control/chain/health-check", bytes.NewBufferString(body)))
+		return w.Code
+	}
+	if code := set(`{"chain": "arbitrum", "enabled": true}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+
+	call := func(method string) (JSONRPCResponse, time.Duration) {
+		start := time.Now()
+		data, _ := handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"`+method+`","params":[],"id":1}`), NewMockWSConn(), "42161")
+		var response JSONRPCResponse
+		json.Unmarshal(data, &response)
+		return response, time.Since(start)
+	}
+
+	if response, elapsed := call("eth_blockNumber"); response.Error != nil || elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected a fast, healthy health check, got %+v after %v", response.Error, elapsed)
+	}
+	if response, elapsed := call("eth_call"); response.Error == nil || elapsed < 200*time.Millisecond {
+		t.Errorf("Expected real traffic to stay slow and failing, got %+v after %v", response.Error, elapsed)
+	}
+
+	// Health checks can be configured separately, here with their own methods and errors
+	if code := set(`{"chain": "arbitrum", "enabled": true, "methods": ["eth_call"], "latency_ms": 10, "error_configs": [{"code": 503, "message": "unhealthy", "probability": 1}]}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if response, elapsed := call("eth_call"); response.Error == nil || response.Error.Code != 503 || elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected the health check's own error after its own latency, got %+v after %v", response.Error, elapsed)
+	}
+	if response, _ := call("eth_blockNumber"); response.Error == nil || response.Error.Code != -32000 {
+		t.Errorf("Expected methods outside the list on the normal path, got %+v", response.Error)
+	}
+
+	if code := set(`{"chain": "arbitrum", "enabled": true, "latency_ms": -1}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a negative latency, got %d", code)
+	}
+	if code := set(`{"chain": "unknown", "enabled": false}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown chain, got %d", code)
+	}
+	if code := set(`{"chain": "arbitrum", "enabled": false}`); code != http.StatusOK || chain.HealthCheck != nil {
+		t.Errorf("Expected the isolation to be removed, got %d", code)
+	}
+}
+
+func TestSolanaHealthCheckIsolation(t *testing.T) {
+	originalLatency := solanaNode.Latency
+	defer func() {
+		setLatency("501", originalLatency, nil)
+		solanaNode.HealthCheck = nil
+	}()
+	setLatency("501", 200*time.Millisecond, nil)
+	solanaNode.HealthCheck = &HealthCheckIsolation{}
+
+	start := time.Now()
+	data, _ := handleSolanaRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"getHealth","id":1}`), NewMockWSConn())
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond || !bytes.Contains(data, []byte(`"ok"`)) {
+		t.Errorf("Expected getHealth to bypass the node latency, got %s after %v", data, elapsed)
+	}
+}
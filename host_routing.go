@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// hostRoutingDomain enables routing by host name: <chain name>.<domain> selects a chain
+// regardless of the path, like provider URLs. Set from HOST_ROUTING_DOMAIN.
+var hostRoutingDomain string
+
+// chainIDForHost returns the chain selected by a host name, empty if the host selects none.
+// Host names configured with hosts on a chain take precedence over the routing domain.
+func chainIDForHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	for name, chain := range supportedChains {
+		if containsHost(chain.Hosts, host) {
+			return chainIDForName(name)
+		}
+	}
+	if solanaNode != nil && containsHost(solanaNode.Hosts, host) {
+		return "501"
+	}
+
+	if hostRoutingDomain == "" {
+		return ""
+	}
+	label, ok := strings.CutSuffix(host, "."+strings.ToLower(hostRoutingDomain))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return chainIDForName(label)
+}
+
+func containsHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostRouter sends requests whose TLS server name (SNI) or Host header selects a chain to that
+// chain: WebSocket upgrades to the WebSocket endpoint, everything else to the HTTP endpoint. The
+// path is ignored, since provider URLs carry API keys there. Control, SSE and status page paths
+// and hosts that select no chain fall through to next.
+func hostRouter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/control/") || strings.HasPrefix(r.URL.Path, "/sse/") || r.URL.Path == "/api/status" || strings.HasPrefix(r.URL.Path, "/api/connections/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		host := r.Host
+		if r.TLS != nil && r.TLS.ServerName != "" {
+			host = r.TLS.ServerName
+		}
+		chainId := chainIDForHost(host)
+		if chainId == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		routed := r.Clone(r.Context())
+		if websocket.IsWebSocketUpgrade(r) {
+			routed.URL.Path = "/ws/chain/" + chainId
+			handleChainWebSocket(w, routed)
+			return
+		}
+		routed.URL.Path = "/chain/" + chainId
+		handleChainHTTP(w, routed)
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestChainIDForHost(t *testing.T) {
+	originalDomain := hostRoutingDomain
+	hostRoutingDomain = "sim.local"
+	supportedChains["ethereum"].Hosts = []string{"eth.sim.local", "eth-mainnet.example.com"}
+	defer func() {
+		hostRoutingDomain = originalDomain
+		supportedChains["ethereum"].Hosts = nil
+	}()
+
+	tests := map[string]string{
+		"eth.sim.local":               "1",
+		"ETH-Mainnet.example.com:443": "1",
+		"polygon.sim.local":           "137",
+		"solana.sim.local.":           "501",
+		"unknown.sim.local":           "",
+		"a.polygon.sim.local":         "",
+		"polygon.other.local":         "",
+		"localhost:8545":              "",
+	}
+	for host, want := range tests {
+		if got := chainIDForHost(host); got != want {
+			t.Errorf("%s: expected chain %q, got %q", host, want, got)
+		}
+	}
+}
+
+func TestHostRouter(t *testing.T) {
+	originalDomain := hostRoutingDomain
+	hostRoutingDomain = "sim.local"
+	defer func() { hostRoutingDomain = originalDomain }()
+
+	mux, _ := newServeMuxes(false)
+	server := httptest.NewServer(hostRouter(mux))
+	defer server.Close()
+
+	post := func(host, path string) (int, string) {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+path, bytes.NewBufferString(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`))
+		req.Host = host
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	// The path of a provider URL is ignored
+	if code, body := post("polygon.sim.local", "/v2/api-key"); code != http.StatusOK || !strings.Contains(body, `"result":"0x89"`) {
+		t.Errorf("Expected polygon's chain id, got %d %s", code, body)
+	}
+	// Other hosts keep path routing
+	if code, body := post("localhost", "/chain/1"); code != http.StatusOK || !strings.Contains(body, `"result":"0x1"`) {
+		t.Errorf("Expected path routing for other hosts, got %d %s", code, body)
+	}
+	// The control API stays reachable on chain hosts
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/control/state", nil)
+	req.Host = "polygon.sim.local"
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the control API on a chain host, got %v %v", resp, err)
+	}
+
+	// WebSocket upgrades go to the WebSocket endpoint
+	header := http.Header{"Host": []string{"optimism.sim.local"}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/v3/key", header)
+	if err != nil {
+		t.Fatalf("WebSocket dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`))
+	_, message, err := conn.ReadMessage()
+	if err != nil || !strings.Contains(string(message), `"result":"0xa"`) {
+		t.Errorf("Expected optimism's chain id over WebSocket, got %s %v", message, err)
+	}
+}
+
+func TestHostRouterUsesSNI(t *testing.T) {
+	originalDomain := hostRoutingDomain
+	hostRoutingDomain = "sim.local"
+	defer func() { hostRoutingDomain = originalDomain }()
+
+	mux, _ := newServeMuxes(false)
+	server := httptest.NewTLSServer(hostRouter(mux))
+	defer server.Close()
+
+	// The Host header names the server's address; only the TLS server name selects the chain
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{ServerName: "gnosis.sim.local", InsecureSkipVerify: true}}}
+	resp, err := client.Post(server.URL+"/", "application/json", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"result":"0x64"`) {
+		t.Errorf("Expected gnosis' chain id, got %s", body)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// rawResponseID extracts the id field of a response as raw JSON
+func rawResponseID(t *testing.T, response []byte) string {
+	var resp struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(response, &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return string(resp.ID)
+}
+
+func TestRequestIDPreservation(t *testing.T) {
+	ids := []string{
+		`1`,
+		`0`,
+		`-7`,
+		`"abc"`,
+		`"1"`,
+		`null`,
+		`9007199254740993`,     // 2^53 + 1
+		`18446744073709551615`, // 2^64 - 1
+		`1.5`,
+	}
+
+	for _, id := range ids {
+		message := []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":` + id + `}`)
+
+		response, err := handleEVMRequest(context.Background(), message, NewMockWSConn(), "1")
+		if err != nil {
+			t.Fatalf("EVM handler error: %v", err)
+		}
+		if got := rawResponseID(t, response); got != id {
+			t.Errorf("EVM: expected id %s, got %s", id, got)
+		}
+
+		message = []byte(`{"jsonrpc":"2.0","method":"getHealth","id":` + id + `}`)
+		response, err = handleSolanaRequest(context.Background(), message, NewMockWSConn())
+		if err != nil {
+			t.Fatalf("Solana handler error: %v", err)
+		}
+		if got := rawResponseID(t, response); got != id {
+			t.Errorf("Solana: expected id %s, got %s", id, got)
+		}
+	}
+
+	// Error responses must echo the id as well
+	message := []byte(`{"jsonrpc":"2.0","method":"no_such_method","id":9007199254740993}`)
+	response, _ := handleEVMRequest(context.Background(), message, NewMockWSConn(), "1")
+	if got := rawResponseID(t, response); got != "9007199254740993" {
+		t.Errorf("Expected error response id 9007199254740993, got %s", got)
+	}
+
+	// Missing id is echoed as null
+	message = []byte(`{"jsonrpc":"2.0","method":"eth_chainId"}`)
+	response, _ = handleEVMRequest(context.Background(), message, NewMockWSConn(), "1")
+	if !bytes.Contains(response, []byte(`"id":null`)) {
+		t.Errorf("Expected null id, got %s", response)
+	}
+}
+
+func TestRequestIDMangling(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer func() { chain.IDMangleMode = IDMangleNone }()
+
+	tests := []struct {
+		mode     string
+		id       string
+		expected string
+	}{
+		{IDMangleStringify, `42`, `"42"`},
+		{IDMangleStringify, `"abc"`, `"abc"`},
+		{IDMangleFloat64, `9007199254740993`, `9007199254740992`},
+		{IDMangleNull, `42`, `null`},
+		{IDMangleNull, `"abc"`, `null`},
+		{IDMangleIncrement, `42`, `43`},
+		{IDMangleIncrement, `"abc"`, `"abc-1"`},
+	}
+
+	for _, tt := range tests {
+		chain.IDMangleMode = tt.mode
+		message := []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":` + tt.id + `}`)
+		response, err := handleEVMRequest(context.Background(), message, NewMockWSConn(), "1")
+		if err != nil {
+			t.Fatalf("Handler error: %v", err)
+		}
+		if got := rawResponseID(t, response); got != tt.expected {
+			t.Errorf("Mode %s: expected id %s for %s, got %s", tt.mode, tt.expected, tt.id, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// identityMethods are the cheap methods clients call on every (re)connect to identify an EVM node
+var identityMethods = []string{"eth_chainId", "net_version", "web3_clientVersion"}
+
+// solanaIdentityMethods are the identity methods of the Solana node
+var solanaIdentityMethods = []string{"getVersion", "getGenesisHash", "getIdentity"}
+
+// isIdentityMethod returns true if the method identifies the node of the chain
+func isIdentityMethod(chainId, method string) bool {
+	if chainId == "501" {
+		return containsString(solanaIdentityMethods, method)
+	}
+	return containsString(identityMethods, method)
+}
+
+// handleSetIdentityFastPath takes a chain's identity methods off its latency and fault path,
+// or with enabled false puts them back on it
+func handleSetIdentityFastPath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain   string `json:"chain"`
+		Enabled bool   `json:"enabled"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.Chain == "solana" {
+		solanaNode.IdentityFastPath = request.Enabled
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.IdentityFastPath = request.Enabled
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	persistChainConfig()
+
+	log.Printf("Identity fast path for chain %s: %v", request.Chain, request.Enabled)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdentityFastPath(t *testing.T) {
+	withTempConfig(t)
+	chain := supportedChains["optimism"]
+	originalLatency, originalSolanaLatency := chain.Latency, solanaNode.Latency
+	defer func() {
+		setLatency("10", originalLatency, nil)
+		chain.ErrorConfigs = nil
+		chain.IdentityFastPath = false
+		setLatency("501", originalSolanaLatency, nil)
+		solanaNode.IdentityFastPath = false
+	}()
+
+	// The chain is slow and failing
+	setLatency("10", 200*time.Millisecond, nil)
+	chain.ErrorConfigs = []ErrorConfig{{Code: -32000, Message: "boom", Probability: 1}}
+	setLatency("501", 200*time.Millisecond, nil)
+
+	set := func(body string) int {
+		w := httptest.NewRecorder()
+		handleSetIdentityFastPath(w, httptest.NewRequest(http.MethodPost, "/control/chain/identity-fast-path", bytes.NewBufferString(body)))
+		return w.Code
+	}
+	call := func(method string) (JSONRPCResponse, time.Duration) {
+		start := time.Now()
+		data, _ := handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"`+method+`","params":[],"id":1}`), NewMockWSConn(), "10")
+		var response JSONRPCResponse
+		json.Unmarshal(data, &response)
+		return response, time.Since(start)
+	}
+
+	// Off by default
+	if response, elapsed := call("eth_chainId"); response.Error == nil || elapsed < 200*time.Millisecond {
+		t.Errorf("Expected eth_chainId on the normal path, got %+v after %v", response.Error, elapsed)
+	}
+
+	if code := set(`{"chain": "optimism", "enabled": true}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if response, elapsed := call("eth_chainId"); response.Error != nil || response.Result != "0xa" || elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected a fast eth_chainId, got %+v %v after %v", response.Error, response.Result, elapsed)
+	}
+	if response, elapsed := call("eth_call"); response.Error == nil || elapsed < 200*time.Millisecond {
+		t.Errorf("Expected other methods to stay slow and failing, got %+v after %v", response.Error, elapsed)
+	}
+
+	if code := set(`{"chain": "solana", "enabled": true}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	start := time.Now()
+	data, _ := handleSolanaRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"getVersion","params":[],"id":1}`), NewMockWSConn())
+	var response JSONRPCResponse
+	if err := json.Unmarshal(data, &response); err != nil || response.Error != nil || time.Since(start) >= 200*time.Millisecond {
+		t.Errorf("Expected a fast getVersion, got %s after %v", data, time.Since(start))
+	}
+
+	if code := set(`{"chain": "unknown", "enabled": true}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown chain, got %d", code)
+	}
+}
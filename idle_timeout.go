@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// idleTimeoutSettings returns the idle timeout of a chain and how long before it a warning is sent
+func idleTimeoutSettings(chainId string) (timeout, warning time.Duration) {
+	if chainId == "501" {
+		return solanaNode.IdleTimeout, solanaNode.IdleWarning
+	}
+	if chain, ok := supportedChains[chainIdToName[chainId]]; ok {
+		return chain.IdleTimeout, chain.IdleWarning
+	}
+	return 0, 0
+}
+
+// idleTracker closes a WebSocket connection that hasn't sent a request within the
+// chain's idle timeout, regardless of its active subscriptions
+type idleTracker struct {
+	mu           sync.Mutex
+	conn         WSConn
+	chainId      string
+	lastActivity time.Time
+	warnTimer    *time.Timer
+	closeTimer   *time.Timer
+	stopped      bool
+}
+
+// idleTrackers holds the trackers of all open connections so config changes apply immediately
+var idleTrackers sync.Map // *idleTracker -> struct{}
+
+// startIdleTracker starts tracking a new connection; the connection counts as active from now
+func startIdleTracker(conn WSConn, chainId string) *idleTracker {
+	t := &idleTracker{conn: conn, chainId: chainId, lastActivity: time.Now()}
+	idleTrackers.Store(t, struct{}{})
+	t.mu.Lock()
+	t.arm()
+	t.mu.Unlock()
+	return t
+}
+
+// Touch records a request from the client and restarts the idle timeout
+func (t *idleTracker) Touch() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastActivity = time.Now()
+	t.arm()
+}
+
+// Stop cancels the pending warning and close
+func (t *idleTracker) Stop() {
+	idleTrackers.Delete(t)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	t.stopTimers()
+}
+
+// rearm reschedules the timers after the chain's idle settings changed
+func (t *idleTracker) rearm() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.arm()
+}
+
+func (t *idleTracker) stopTimers() {
+	if t.warnTimer != nil {
+		t.warnTimer.Stop()
+		t.warnTimer = nil
+	}
+	if t.closeTimer != nil {
+		t.closeTimer.Stop()
+		t.closeTimer = nil
+	}
+}
+
+// arm schedules the warning and close relative to the last activity
+// Must be called with t.mu held
+func (t *idleTracker) arm() {
+	t.stopTimers()
+	if t.stopped {
+		return
+	}
+	timeout, warning := idleTimeoutSettings(t.chainId)
+	if timeout <= 0 {
+		return
+	}
+
+	remaining := time.Until(t.lastActivity.Add(timeout))
+	if warning > 0 && warning < timeout && remaining > warning {
+		t.warnTimer = time.AfterFunc(remaining-warning, func() { t.warn(warning) })
+	}
+	t.closeTimer = time.AfterFunc(remaining, func() { t.close(timeout) })
+}
+
+// warn notifies the client that the connection is about to be closed
+func (t *idleTracker) warn(closingIn time.Duration) {
+	data, _ := json.Marshal(JSONRPCNotification{
+		JsonRPC: "2.0",
+		Method:  "connection_warning",
+		Params: map[string]interface{}{
+			"reason":        "idle_timeout",
+			"message":       "Connection idle, closing soon unless a request is sent",
+			"closing_in_ms": closingIn.Milliseconds(),
+		},
+	})
+	if err := t.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		log.Printf("Failed to send idle warning on chain %s: %v", t.chainId, err)
+	}
+}
+
+// close sends a close frame and closes the connection; the read loop then cleans up
+func (t *idleTracker) close(timeout time.Duration) {
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+
+	log.Printf("Closing connection on chain %s after %v without requests", t.chainId, timeout)
+	t.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "idle timeout"))
+	t.conn.Close()
+}
+
+// rearmIdleTrackers applies changed idle settings to the open connections of a chain
+func rearmIdleTrackers(chainId string) {
+	idleTrackers.Range(func(key, _ interface{}) bool {
+		if t := key.(*idleTracker); t.chainId == chainId {
+			t.rearm()
+		}
+		return true
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdleTimeoutClosesConnection(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer func() {
+		chain.IdleTimeout = 0
+		chain.IdleWarning = 0
+	}()
+	chain.IdleTimeout = 200 * time.Millisecond
+	chain.IdleWarning = 50 * time.Millisecond
+
+	conn := NewMockWSConn()
+	idle := startIdleTracker(conn, "1")
+	defer idle.Stop()
+
+	// Requests keep the connection open
+	for i := 0; i < 3; i++ {
+		time.Sleep(80 * time.Millisecond)
+		idle.Touch()
+	}
+	if conn.IsClosed() || len(conn.GetMessages()) != 0 {
+		t.Fatal("Expected an active connection to stay open without warnings")
+	}
+
+	time.Sleep(170 * time.Millisecond)
+	messages := conn.GetMessages()
+	if len(messages) != 1 || !strings.Contains(string(messages[0]), `"reason":"idle_timeout"`) {
+		t.Fatalf("Expected an idle warning, got %q", messages)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if !conn.IsClosed() {
+		t.Error("Expected the idle connection to be closed")
+	}
+}
+
+func TestSetIdleTimeoutAppliesToOpenConnections(t *testing.T) {
+	defer func() { solanaNode.IdleTimeout = 0 }()
+
+	conn := NewMockWSConn()
+	idle := startIdleTracker(conn, "501")
+	defer idle.Stop()
+
+	w := httptest.NewRecorder()
+	handleSetIdleTimeout(w, httptest.NewRequest(http.MethodPost, "/control/chain/idle-timeout", bytes.NewBufferString(`{"chain": "solana", "timeout_seconds": 0.05}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if !conn.IsClosed() {
+		t.Error("Expected the already open idle connection to be closed")
+	}
+
+	w = httptest.NewRecorder()
+	handleSetIdleTimeout(w, httptest.NewRequest(http.MethodPost, "/control/chain/idle-timeout", bytes.NewBufferString(`{"chain": "solana", "timeout_seconds": 1, "warning_seconds": 2}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a warning longer than the timeout, got %d", w.Code)
+	}
+}
@@ -0,0 +1,545 @@
+package wasm
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Instance is the runtime state of a single call
+type Instance struct {
+	module      *Module
+	ctx         context.Context
+	memory      []byte
+	maxPages    uint32
+	globals     []uint64
+	table       []uint64
+	droppedData []bool
+	stack       []uint64
+	fuel        int64
+	steps       int
+	depth       int
+	locals      int // Locals of all active calls, bounded like the value stack
+}
+
+// Instantiate creates an instance and runs active segments and the start function
+func (m *Module) Instantiate(ctx context.Context, maxPages uint32, fuel int64) (in *Instance, err error) {
+	in = &Instance{module: m, ctx: ctx, maxPages: maxPages, fuel: fuel, droppedData: make([]bool, len(m.data))}
+	defer recoverTrap(&err)
+
+	if m.memory != nil {
+		if m.memory.hasMax && m.memory.max < in.maxPages {
+			in.maxPages = m.memory.max
+		}
+		if m.memory.min > in.maxPages {
+			return nil, fmt.Errorf("module needs %d memory pages, the limit is %d", m.memory.min, in.maxPages)
+		}
+		in.memory = make([]byte, int(m.memory.min)*pageSize)
+	}
+	for _, g := range m.globals {
+		in.globals = append(in.globals, in.evalConst(g.init))
+	}
+	if m.table != nil {
+		if m.table.min > 1<<16 {
+			return nil, fmt.Errorf("table too large")
+		}
+		in.table = make([]uint64, m.table.min)
+		for i := range in.table {
+			in.table[i] = nullRef
+		}
+	}
+	for _, seg := range m.elements {
+		if !seg.active {
+			continue
+		}
+		offset := uint64(uint32(in.evalConst(seg.offset)))
+		if offset+uint64(len(seg.funcs)) > uint64(len(in.table)) {
+			trap("element segment out of bounds")
+		}
+		for i, f := range seg.funcs {
+			in.table[offset+uint64(i)] = uint64(f)
+		}
+	}
+	for i, seg := range m.data {
+		if !seg.active {
+			continue
+		}
+		offset := uint64(uint32(in.evalConst(seg.offset)))
+		if offset+uint64(len(seg.data)) > uint64(len(in.memory)) {
+			trap("data segment out of bounds")
+		}
+		copy(in.memory[offset:], seg.data)
+		in.droppedData[i] = true
+	}
+	if m.start >= 0 {
+		in.call(uint32(m.start))
+	}
+	return in, nil
+}
+
+func (in *Instance) evalConst(expr []byte) uint64 {
+	r := &reader{buf: expr}
+	var v uint64
+	for {
+		op, _ := r.byte()
+		switch op {
+		case 0x0B:
+			return v
+		case 0x41:
+			n, _ := r.sleb(32)
+			v = uint64(uint32(n))
+		case 0x42:
+			n, _ := r.sleb(64)
+			v = uint64(n)
+		case 0x43:
+			b, _ := r.bytes(4)
+			v = uint64(binary.LittleEndian.Uint32(b))
+		case 0x44:
+			b, _ := r.bytes(8)
+			v = binary.LittleEndian.Uint64(b)
+		case 0x23:
+			idx, _ := r.u32()
+			if int(idx) >= len(in.globals) {
+				trap("global index out of range")
+			}
+			v = in.globals[idx]
+		case 0xD0:
+			r.byte()
+			v = nullRef
+		case 0xD2:
+			idx, _ := r.u32()
+			v = uint64(idx)
+		}
+	}
+}
+
+// recoverTrap turns a trap into an error. Any other panic is an interpreter bug; it is
+// reported as an error too, so that a bad module can't take down the simulator.
+func recoverTrap(err *error) {
+	if r := recover(); r != nil {
+		if t, ok := r.(Trap); ok {
+			*err = t
+			return
+		}
+		*err = fmt.Errorf("wasm: internal error: %v", r)
+	}
+}
+
+// Invoke calls an exported function
+func (in *Instance) Invoke(name string, args ...uint64) (results []uint64, err error) {
+	defer recoverTrap(&err)
+	export, ok := in.module.exports[name]
+	if !ok || export.kind != 0 {
+		return nil, fmt.Errorf("module does not export function %s", name)
+	}
+	typ := in.module.types[in.module.funcs[export.index].typeIdx]
+	if len(args) != len(typ.Params) {
+		return nil, fmt.Errorf("function %s takes %d parameters", name, len(typ.Params))
+	}
+	in.stack = append(in.stack[:0], args...)
+	in.call(export.index)
+	return append([]uint64(nil), in.stack...), nil
+}
+
+// Memory returns the instance memory, which must be exported as "memory"
+func (in *Instance) Memory() ([]byte, error) {
+	if export, ok := in.module.exports["memory"]; !ok || export.kind != 2 {
+		return nil, fmt.Errorf("module does not export its memory")
+	}
+	return in.memory, nil
+}
+
+type label struct {
+	height int // Stack height below the block's values
+	arity  int // Values carried by a branch to the label
+	target int // pc to continue at after a branch; a loop's own opcode, which re-enters it
+}
+
+func (in *Instance) push(v uint64) {
+	if len(in.stack) >= maxStackHeight {
+		trap("value stack exhausted")
+	}
+	in.stack = append(in.stack, v)
+}
+
+func (in *Instance) pop() uint64 {
+	v := in.stack[len(in.stack)-1]
+	in.stack = in.stack[:len(in.stack)-1]
+	return v
+}
+
+// blockArity returns the parameter and result counts of a block type
+func (in *Instance) blockArity(bt int64) (params, results int) {
+	switch {
+	case bt == -64: // 0x40, empty
+		return 0, 0
+	case bt < 0: // Single value type
+		return 0, 1
+	default:
+		if int(bt) >= len(in.module.types) {
+			trap("block type index out of range")
+		}
+		t := in.module.types[bt]
+		return len(t.Params), len(t.Results)
+	}
+}
+
+func (in *Instance) addr(r *reader, size uint64) uint64 {
+	r.u32() // alignment hint
+	offset, _ := r.u32()
+	ea := uint64(uint32(in.pop())) + uint64(offset)
+	if ea+size > uint64(len(in.memory)) {
+		trap("out of bounds memory access")
+	}
+	return ea
+}
+
+// call runs a function with its arguments on the stack, leaving its results there
+func (in *Instance) call(idx uint32) {
+	if int(idx) >= len(in.module.funcs) {
+		trap("function index out of range")
+	}
+	in.depth++
+	if in.depth > maxCallDepth {
+		trap("call stack exhausted")
+	}
+	defer func() { in.depth-- }()
+
+	f := &in.module.funcs[idx]
+	typ := in.module.types[f.typeIdx]
+	nparams := len(typ.Params)
+	if len(in.stack) < nparams {
+		trap("stack underflow")
+	}
+	in.locals += nparams + len(f.locals)
+	if in.locals > maxStackHeight {
+		trap("call stack exhausted")
+	}
+	defer func() { in.locals -= nparams + len(f.locals) }()
+	locals := make([]uint64, nparams+len(f.locals))
+	copy(locals, in.stack[len(in.stack)-nparams:])
+	in.stack = in.stack[:len(in.stack)-nparams]
+	base := len(in.stack)
+
+	labels := []label{{height: base, arity: len(typ.Results), target: len(f.code)}}
+	r := &reader{buf: f.code}
+
+	// branch unwinds to the label depth levels up, returning false when it leaves the function
+	branch := func(depth uint32) bool {
+		if int(depth) >= len(labels) {
+			trap("branch depth out of range")
+		}
+		l := labels[len(labels)-1-int(depth)]
+		values := in.stack[len(in.stack)-l.arity:]
+		in.stack = append(in.stack[:l.height], values...)
+		labels = labels[:len(labels)-1-int(depth)]
+		r.pos = l.target
+		return len(labels) > 0
+	}
+
+	for {
+		in.fuel--
+		if in.fuel < 0 {
+			trap("fuel exhausted")
+		}
+		in.steps++
+		if in.steps%ctxCheckEvery == 0 && in.ctx.Err() != nil {
+			trap("cancelled: %v", in.ctx.Err())
+		}
+
+		pc := r.pos
+		op, err := r.byte()
+		if err != nil {
+			trap("unexpected end of function")
+		}
+		switch op {
+		case 0x00:
+			trap("unreachable")
+		case 0x01:
+		case 0x02, 0x03, 0x04:
+			bt, _ := r.sleb(33)
+			params, results := in.blockArity(bt)
+			b := f.blocks[pc]
+			if op == 0x04 && uint32(in.pop()) == 0 {
+				if b.elsePC < 0 {
+					r.pos = b.endPC + 1
+					continue
+				}
+				r.pos = b.elsePC + 1
+			}
+			l := label{height: len(in.stack) - params, arity: results, target: b.endPC + 1}
+			if op == 0x03 {
+				l.arity, l.target = params, pc
+			}
+			if l.height < 0 {
+				trap("stack underflow")
+			}
+			labels = append(labels, l)
+		case 0x05: // End of a taken then branch
+			r.pos = labels[len(labels)-1].target
+			labels = labels[:len(labels)-1]
+		case 0x0B:
+			if len(labels) == 1 {
+				branch(0)
+				return
+			}
+			labels = labels[:len(labels)-1]
+		case 0x0C:
+			depth, _ := r.u32()
+			if !branch(depth) {
+				return
+			}
+		case 0x0D:
+			depth, _ := r.u32()
+			if uint32(in.pop()) != 0 && !branch(depth) {
+				return
+			}
+		case 0x0E:
+			targets, _ := decodeU32Vec(r)
+			def, _ := r.u32()
+			i := uint32(in.pop())
+			if int(i) < len(targets) {
+				def = targets[i]
+			}
+			if !branch(def) {
+				return
+			}
+		case 0x0F:
+			branch(uint32(len(labels) - 1))
+			return
+		case 0x10:
+			fn, _ := r.u32()
+			in.call(fn)
+		case 0x11:
+			typeIdx, _ := r.u32()
+			r.u32()
+			i := uint32(in.pop())
+			if int(i) >= len(in.table) {
+				trap("undefined element")
+			}
+			fn := in.table[i]
+			if fn == nullRef {
+				trap("uninitialized element")
+			}
+			if int(fn) >= len(in.module.funcs) || int(typeIdx) >= len(in.module.types) ||
+				!in.module.types[in.module.funcs[fn].typeIdx].Equal(in.module.types[typeIdx]) {
+				trap("indirect call type mismatch")
+			}
+			in.call(uint32(fn))
+		case 0x1A:
+			in.pop()
+		case 0x1B, 0x1C:
+			if op == 0x1C {
+				n, _ := r.u32()
+				r.bytes(int(n))
+			}
+			c := uint32(in.pop())
+			b := in.pop()
+			if c == 0 {
+				in.stack[len(in.stack)-1] = b
+			}
+		case 0x20:
+			i, _ := r.u32()
+			in.push(locals[i])
+		case 0x21:
+			i, _ := r.u32()
+			locals[i] = in.pop()
+		case 0x22:
+			i, _ := r.u32()
+			locals[i] = in.stack[len(in.stack)-1]
+		case 0x23:
+			i, _ := r.u32()
+			in.push(in.globals[i])
+		case 0x24:
+			i, _ := r.u32()
+			if !in.module.globals[i].mutable {
+				trap("global is immutable")
+			}
+			in.globals[i] = in.pop()
+		case 0x25:
+			r.u32()
+			i := uint32(in.pop())
+			if int(i) >= len(in.table) {
+				trap("out of bounds table access")
+			}
+			in.push(in.table[i])
+		case 0x26:
+			r.u32()
+			v := in.pop()
+			i := uint32(in.pop())
+			if int(i) >= len(in.table) {
+				trap("out of bounds table access")
+			}
+			in.table[i] = v
+		case 0x28:
+			ea := in.addr(r, 4)
+			in.push(uint64(binary.LittleEndian.Uint32(in.memory[ea:])))
+		case 0x29:
+			ea := in.addr(r, 8)
+			in.push(binary.LittleEndian.Uint64(in.memory[ea:]))
+		case 0x2A:
+			ea := in.addr(r, 4)
+			in.push(uint64(binary.LittleEndian.Uint32(in.memory[ea:])))
+		case 0x2B:
+			ea := in.addr(r, 8)
+			in.push(binary.LittleEndian.Uint64(in.memory[ea:]))
+		case 0x2C:
+			ea := in.addr(r, 1)
+			in.push(uint64(uint32(int32(int8(in.memory[ea])))))
+		case 0x2D:
+			ea := in.addr(r, 1)
+			in.push(uint64(in.memory[ea]))
+		case 0x2E:
+			ea := in.addr(r, 2)
+			in.push(uint64(uint32(int32(int16(binary.LittleEndian.Uint16(in.memory[ea:]))))))
+		case 0x2F:
+			ea := in.addr(r, 2)
+			in.push(uint64(binary.LittleEndian.Uint16(in.memory[ea:])))
+		case 0x30:
+			ea := in.addr(r, 1)
+			in.push(uint64(int64(int8(in.memory[ea]))))
+		case 0x31:
+			ea := in.addr(r, 1)
+			in.push(uint64(in.memory[ea]))
+		case 0x32:
+			ea := in.addr(r, 2)
+			in.push(uint64(int64(int16(binary.LittleEndian.Uint16(in.memory[ea:])))))
+		case 0x33:
+			ea := in.addr(r, 2)
+			in.push(uint64(binary.LittleEndian.Uint16(in.memory[ea:])))
+		case 0x34:
+			ea := in.addr(r, 4)
+			in.push(uint64(int64(int32(binary.LittleEndian.Uint32(in.memory[ea:])))))
+		case 0x35:
+			ea := in.addr(r, 4)
+			in.push(uint64(binary.LittleEndian.Uint32(in.memory[ea:])))
+		case 0x36, 0x38, 0x3E:
+			v := in.pop()
+			ea := in.addr(r, 4)
+			binary.LittleEndian.PutUint32(in.memory[ea:], uint32(v))
+		case 0x37, 0x39:
+			v := in.pop()
+			ea := in.addr(r, 8)
+			binary.LittleEndian.PutUint64(in.memory[ea:], v)
+		case 0x3A, 0x3C:
+			v := in.pop()
+			ea := in.addr(r, 1)
+			in.memory[ea] = byte(v)
+		case 0x3B, 0x3D:
+			v := in.pop()
+			ea := in.addr(r, 2)
+			binary.LittleEndian.PutUint16(in.memory[ea:], uint16(v))
+		case 0x3F:
+			r.byte()
+			in.push(uint64(len(in.memory) / pageSize))
+		case 0x40:
+			r.byte()
+			delta := uint32(in.pop())
+			pages := uint32(len(in.memory) / pageSize)
+			if uint64(pages)+uint64(delta) > uint64(in.maxPages) {
+				in.push(uint64(uint32(math.MaxUint32))) // -1
+				break
+			}
+			in.memory = append(in.memory, make([]byte, int(delta)*pageSize)...)
+			in.push(uint64(pages))
+		case 0x41:
+			v, _ := r.sleb(32)
+			in.push(uint64(uint32(v)))
+		case 0x42:
+			v, _ := r.sleb(64)
+			in.push(uint64(v))
+		case 0x43:
+			b, _ := r.bytes(4)
+			in.push(uint64(binary.LittleEndian.Uint32(b)))
+		case 0x44:
+			b, _ := r.bytes(8)
+			in.push(binary.LittleEndian.Uint64(b))
+		case 0xD0:
+			r.byte()
+			in.push(nullRef)
+		case 0xD1:
+			in.push(boolValue(in.pop() == nullRef))
+		case 0xD2:
+			fn, _ := r.u32()
+			in.push(uint64(fn))
+		case 0xFC:
+			sub, _ := r.u32()
+			in.execMisc(r, sub)
+		default:
+			if op >= 0x45 && op <= 0xC4 {
+				in.execNumeric(op)
+				continue
+			}
+			trap("unsupported instruction 0x%x", op)
+		}
+	}
+}
+
+func boolValue(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// execMisc runs the 0xFC prefixed saturating truncation and bulk memory instructions
+func (in *Instance) execMisc(r *reader, sub uint32) {
+	switch sub {
+	case 0:
+		in.push(uint64(uint32(satI32(float64(math.Float32frombits(uint32(in.pop())))))))
+	case 1:
+		in.push(uint64(satU32(float64(math.Float32frombits(uint32(in.pop()))))))
+	case 2:
+		in.push(uint64(uint32(satI32(math.Float64frombits(in.pop())))))
+	case 3:
+		in.push(uint64(satU32(math.Float64frombits(in.pop()))))
+	case 4:
+		in.push(uint64(satI64(float64(math.Float32frombits(uint32(in.pop()))))))
+	case 5:
+		in.push(satU64(float64(math.Float32frombits(uint32(in.pop())))))
+	case 6:
+		in.push(uint64(satI64(math.Float64frombits(in.pop()))))
+	case 7:
+		in.push(satU64(math.Float64frombits(in.pop())))
+	case 8: // memory.init
+		seg, _ := r.u32()
+		r.byte()
+		n, src, dst := uint64(uint32(in.pop())), uint64(uint32(in.pop())), uint64(uint32(in.pop()))
+		if int(seg) >= len(in.module.data) {
+			trap("data segment index out of range")
+		}
+		var data []byte
+		if !in.droppedData[seg] {
+			data = in.module.data[seg].data
+		}
+		if src+n > uint64(len(data)) || dst+n > uint64(len(in.memory)) {
+			trap("out of bounds memory access")
+		}
+		copy(in.memory[dst:dst+n], data[src:src+n])
+	case 9: // data.drop
+		seg, _ := r.u32()
+		if int(seg) >= len(in.droppedData) {
+			trap("data segment index out of range")
+		}
+		in.droppedData[seg] = true
+	case 10: // memory.copy
+		r.bytes(2)
+		n, src, dst := uint64(uint32(in.pop())), uint64(uint32(in.pop())), uint64(uint32(in.pop()))
+		if src+n > uint64(len(in.memory)) || dst+n > uint64(len(in.memory)) {
+			trap("out of bounds memory access")
+		}
+		copy(in.memory[dst:dst+n], in.memory[src:src+n])
+	case 11: // memory.fill
+		r.byte()
+		n, v, dst := uint64(uint32(in.pop())), byte(in.pop()), uint64(uint32(in.pop()))
+		if dst+n > uint64(len(in.memory)) {
+			trap("out of bounds memory access")
+		}
+		for i := dst; i < dst+n; i++ {
+			in.memory[i] = v
+		}
+	default:
+		trap("unsupported instruction 0xfc %d", sub)
+	}
+}
@@ -0,0 +1,698 @@
+// Package wasm is a small WebAssembly interpreter for the simulator's custom RPC methods and
+// middleware hooks. It supports the MVP instruction set plus sign extension, saturating
+// truncation and bulk memory, and runs modules fully sandboxed: modules can't import anything,
+// every module is validated before it runs, and every call is bounded by fuel, memory and call
+// depth.
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	pageSize        = 65536
+	maxPages        = 65536 // Largest memory a module may declare
+	maxCallDepth    = 1000
+	maxStackHeight  = 1 << 20
+	maxLocals       = 50000
+	ctxCheckEvery   = 4096    // Instructions between context cancellation checks
+	DefaultMaxPages = 256     // 16 MiB
+	DefaultFuel     = 1 << 24 // Instructions per call
+)
+
+// Value types
+const (
+	I32       byte = 0x7F
+	I64       byte = 0x7E
+	F32       byte = 0x7D
+	F64       byte = 0x7C
+	FuncRef   byte = 0x70
+	ExternRef byte = 0x6F
+)
+
+// nullRef is the stored value of a null reference
+const nullRef = math.MaxUint64
+
+// FuncType is the signature of a function
+type FuncType struct {
+	Params  []byte
+	Results []byte
+}
+
+// Equal returns true if both signatures have the same parameter and result types
+func (t FuncType) Equal(other FuncType) bool {
+	return string(t.Params) == string(other.Params) && string(t.Results) == string(other.Results)
+}
+
+type limits struct {
+	min    uint32
+	max    uint32
+	hasMax bool
+}
+
+// blockInfo records where a block, loop or if continues
+type blockInfo struct {
+	elsePC int // -1 without else
+	endPC  int
+}
+
+type function struct {
+	typeIdx uint32
+	locals  []byte // Types of the declared locals, after the parameters
+	code    []byte
+	blocks  map[int]blockInfo // Keyed by the pc of the block, loop or if opcode
+}
+
+type globalDef struct {
+	typ     byte
+	mutable bool
+	init    []byte
+}
+
+type exportDesc struct {
+	kind  byte
+	index uint32
+}
+
+type segment struct {
+	active bool
+	offset []byte   // Constant offset expression of active segments
+	data   []byte   // Data segments
+	funcs  []uint32 // Element segments
+}
+
+// Module is a decoded and validated module, instantiated afresh for every call
+type Module struct {
+	types    []FuncType
+	funcs    []function
+	table    *limits
+	memory   *limits
+	globals  []globalDef
+	exports  map[string]exportDesc
+	start    int64 // -1 without start function
+	elements []segment
+	data     []segment
+}
+
+// Trap aborts execution; it is raised as a panic and recovered at the call boundary. Validation
+// rules out malformed code, so any other panic there is an interpreter bug, which the call
+// boundary reports as an internal error instead of a trap.
+type Trap struct {
+	msg string
+}
+
+func (t Trap) Error() string { return "wasm trap: " + t.msg }
+
+func trap(format string, args ...interface{}) {
+	panic(Trap{msg: fmt.Sprintf(format, args...)})
+}
+
+// reader decodes the binary format
+type reader struct {
+	buf []byte
+	pos int
+}
+
+var errEOF = errors.New("unexpected end of module")
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errEOF
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.buf) {
+		return nil, errEOF
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) u32() (uint32, error) {
+	v, err := r.uleb(32)
+	return uint32(v), err
+}
+
+func (r *reader) uleb(size uint) (uint64, error) {
+	var result uint64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint64(b&0x7F) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return result, nil
+		}
+		if shift >= size+7 {
+			return 0, fmt.Errorf("integer representation too long")
+		}
+	}
+}
+
+func (r *reader) sleb(size uint) (int64, error) {
+	var result int64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= int64(b&0x7F) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
+			}
+			return result, nil
+		}
+		if shift >= size+7 {
+			return 0, fmt.Errorf("integer representation too long")
+		}
+	}
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(int(n))
+	return string(b), err
+}
+
+func (r *reader) limits() (*limits, error) {
+	flag, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	l := &limits{}
+	if l.min, err = r.u32(); err != nil {
+		return nil, err
+	}
+	switch flag {
+	case 0:
+	case 1:
+		l.hasMax = true
+		if l.max, err = r.u32(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported limits flag 0x%x", flag)
+	}
+	return l, nil
+}
+
+// constExpr reads a constant expression up to and including its end opcode
+func (r *reader) constExpr() ([]byte, error) {
+	start := r.pos
+	for {
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case 0x0B:
+			return r.buf[start:r.pos], nil
+		case 0x41:
+			_, err = r.sleb(32)
+		case 0x42:
+			_, err = r.sleb(64)
+		case 0x43:
+			_, err = r.bytes(4)
+		case 0x44:
+			_, err = r.bytes(8)
+		case 0x23, 0xD2:
+			_, err = r.u32()
+		case 0xD0:
+			_, err = r.byte()
+		default:
+			return nil, fmt.Errorf("unsupported constant expression opcode 0x%x", op)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sectionOrder is the position of each known section id; the data count section comes
+// before the code section
+var sectionOrder = []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 11, 12, 10}
+
+// Decode decodes a binary module and validates it
+func Decode(binary []byte) (m *Module, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m, err = nil, fmt.Errorf("wasm: internal error: %v", r)
+		}
+	}()
+	if len(binary) < 8 || string(binary[:4]) != "\x00asm" {
+		return nil, fmt.Errorf("not a wasm module")
+	}
+	if v := binary[4:8]; v[0] != 1 || v[1] != 0 || v[2] != 0 || v[3] != 0 {
+		return nil, fmt.Errorf("unsupported wasm version")
+	}
+
+	m = &Module{exports: make(map[string]exportDesc), start: -1}
+	var funcTypes []uint32
+	r := &reader{buf: binary, pos: 8}
+	lastOrder := 0
+	for r.pos < len(r.buf) {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if id != 0 && int(id) < len(sectionOrder) {
+			if sectionOrder[id] <= lastOrder {
+				return nil, fmt.Errorf("section %d out of order or repeated", id)
+			}
+			lastOrder = sectionOrder[id]
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		s := &reader{buf: content}
+		switch id {
+		case 0: // custom
+		case 1:
+			err = m.decodeTypes(s)
+		case 2:
+			n, _ := s.u32()
+			if n > 0 {
+				err = fmt.Errorf("imports are not allowed, modules run without host access")
+			}
+		case 3:
+			funcTypes, err = decodeU32Vec(s)
+		case 4:
+			err = m.decodeTable(s)
+		case 5:
+			err = m.decodeMemory(s)
+		case 6:
+			err = m.decodeGlobals(s)
+		case 7:
+			err = m.decodeExports(s)
+		case 8:
+			var start uint32
+			start, err = s.u32()
+			m.start = int64(start)
+		case 9:
+			err = m.decodeElements(s)
+		case 10:
+			err = m.decodeCode(s, funcTypes)
+		case 11:
+			err = m.decodeData(s)
+		case 12: // data count
+		default:
+			err = fmt.Errorf("unknown section %d", id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %v", id, err)
+		}
+	}
+
+	if len(m.funcs) != len(funcTypes) {
+		return nil, fmt.Errorf("function and code section sizes differ")
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ExportedFunc returns the signature of an exported function
+func (m *Module) ExportedFunc(name string) (FuncType, bool) {
+	export, ok := m.exports[name]
+	if !ok || export.kind != 0 {
+		return FuncType{}, false
+	}
+	return m.types[m.funcs[export.index].typeIdx], true
+}
+
+// ExportsMemory returns true if the module exports its memory under name
+func (m *Module) ExportsMemory(name string) bool {
+	export, ok := m.exports[name]
+	return ok && export.kind == 2
+}
+
+func decodeU32Vec(r *reader) ([]uint32, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	if int(n) > len(r.buf) {
+		return nil, errEOF
+	}
+	v := make([]uint32, n)
+	for i := range v {
+		if v[i], err = r.u32(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (m *Module) decodeTypes(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		if form, err := r.byte(); err != nil || form != 0x60 {
+			return fmt.Errorf("invalid function type")
+		}
+		var t FuncType
+		for _, dst := range []*[]byte{&t.Params, &t.Results} {
+			count, err := r.u32()
+			if err != nil {
+				return err
+			}
+			if *dst, err = r.bytes(int(count)); err != nil {
+				return err
+			}
+		}
+		m.types = append(m.types, t)
+	}
+	return nil
+}
+
+func (m *Module) decodeTable(r *reader) error {
+	n, err := r.u32()
+	if err != nil || n == 0 {
+		return err
+	}
+	if n > 1 {
+		return fmt.Errorf("at most one table is supported")
+	}
+	if typ, err := r.byte(); err != nil || typ != FuncRef {
+		return fmt.Errorf("only funcref tables are supported")
+	}
+	m.table, err = r.limits()
+	return err
+}
+
+func (m *Module) decodeMemory(r *reader) error {
+	n, err := r.u32()
+	if err != nil || n == 0 {
+		return err
+	}
+	if n > 1 {
+		return fmt.Errorf("at most one memory is supported")
+	}
+	m.memory, err = r.limits()
+	return err
+}
+
+func (m *Module) decodeGlobals(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		var g globalDef
+		if g.typ, err = r.byte(); err != nil {
+			return err
+		}
+		mut, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if mut > 1 {
+			return fmt.Errorf("invalid global mutability")
+		}
+		g.mutable = mut == 1
+		if g.init, err = r.constExpr(); err != nil {
+			return err
+		}
+		m.globals = append(m.globals, g)
+	}
+	return nil
+}
+
+func (m *Module) decodeExports(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		var e exportDesc
+		if e.kind, err = r.byte(); err != nil {
+			return err
+		}
+		if e.index, err = r.u32(); err != nil {
+			return err
+		}
+		if _, ok := m.exports[name]; ok {
+			return fmt.Errorf("duplicate export %q", name)
+		}
+		m.exports[name] = e
+	}
+	return nil
+}
+
+func (m *Module) decodeElements(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		flags, err := r.u32()
+		if err != nil {
+			return err
+		}
+		var seg segment
+		switch flags {
+		case 0:
+			seg.active = true
+			if seg.offset, err = r.constExpr(); err != nil {
+				return err
+			}
+		case 1, 3:
+			if _, err = r.byte(); err != nil { // elemkind
+				return err
+			}
+		case 2:
+			seg.active = true
+			if table, err := r.u32(); err != nil || table != 0 {
+				return fmt.Errorf("invalid element table")
+			}
+			if seg.offset, err = r.constExpr(); err != nil {
+				return err
+			}
+			if _, err = r.byte(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported element segment flags %d", flags)
+		}
+		if seg.funcs, err = decodeU32Vec(r); err != nil {
+			return err
+		}
+		m.elements = append(m.elements, seg)
+	}
+	return nil
+}
+
+func (m *Module) decodeData(r *reader) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		flags, err := r.u32()
+		if err != nil {
+			return err
+		}
+		var seg segment
+		switch flags {
+		case 0:
+			seg.active = true
+		case 1:
+		case 2:
+			if mem, err := r.u32(); err != nil || mem != 0 {
+				return fmt.Errorf("invalid data memory")
+			}
+			seg.active = true
+		default:
+			return fmt.Errorf("unsupported data segment flags %d", flags)
+		}
+		if seg.active {
+			if seg.offset, err = r.constExpr(); err != nil {
+				return err
+			}
+		}
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if seg.data, err = r.bytes(int(size)); err != nil {
+			return err
+		}
+		m.data = append(m.data, seg)
+	}
+	return nil
+}
+
+func (m *Module) decodeCode(r *reader, funcTypes []uint32) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if int(n) != len(funcTypes) {
+		return fmt.Errorf("function and code section sizes differ")
+	}
+	for i := uint32(0); i < n; i++ {
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return err
+		}
+		b := &reader{buf: body}
+		groups, err := b.u32()
+		if err != nil {
+			return err
+		}
+		f := function{typeIdx: funcTypes[i]}
+		for g := uint32(0); g < groups; g++ {
+			count, err := b.u32()
+			if err != nil {
+				return err
+			}
+			typ, err := b.byte()
+			if err != nil {
+				return err
+			}
+			if len(f.locals)+int(count) > maxLocals {
+				return fmt.Errorf("too many locals")
+			}
+			for j := uint32(0); j < count; j++ {
+				f.locals = append(f.locals, typ)
+			}
+		}
+		f.code = body[b.pos:]
+		if f.blocks, err = scanBlocks(f.code); err != nil {
+			return fmt.Errorf("function %d: %v", i, err)
+		}
+		m.funcs = append(m.funcs, f)
+	}
+	return nil
+}
+
+// scanBlocks matches every block, loop and if of a function body with its else and end
+func scanBlocks(code []byte) (map[int]blockInfo, error) {
+	blocks := make(map[int]blockInfo)
+	var open []int
+	r := &reader{buf: code}
+	for r.pos < len(code) {
+		pc := r.pos
+		op, _ := r.byte()
+		switch op {
+		case 0x02, 0x03, 0x04:
+			open = append(open, pc)
+			blocks[pc] = blockInfo{elsePC: -1}
+		case 0x05:
+			if len(open) == 0 || code[open[len(open)-1]] != 0x04 {
+				return nil, fmt.Errorf("else without if")
+			}
+			b := blocks[open[len(open)-1]]
+			b.elsePC = pc
+			blocks[open[len(open)-1]] = b
+		case 0x0B:
+			if len(open) == 0 {
+				if r.pos != len(code) {
+					return nil, fmt.Errorf("code after function end")
+				}
+				return blocks, nil
+			}
+			b := blocks[open[len(open)-1]]
+			b.endPC = pc
+			blocks[open[len(open)-1]] = b
+			open = open[:len(open)-1]
+		}
+		if err := skipImmediates(r, op); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("function body without end")
+}
+
+// skipImmediates advances past the immediates of an instruction
+func skipImmediates(r *reader, op byte) error {
+	var err error
+	switch {
+	case op == 0x02 || op == 0x03 || op == 0x04:
+		_, err = r.sleb(33)
+	case op == 0x0C || op == 0x0D || op == 0x10 || (op >= 0x20 && op <= 0x26) || op == 0xD2:
+		_, err = r.u32()
+	case op == 0x0E:
+		if _, err = decodeU32Vec(r); err == nil {
+			_, err = r.u32()
+		}
+	case op == 0x11:
+		if _, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+	case op == 0x1C:
+		var n uint32
+		if n, err = r.u32(); err == nil {
+			_, err = r.bytes(int(n))
+		}
+	case op >= 0x28 && op <= 0x3E:
+		if _, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+	case op == 0x3F || op == 0x40 || op == 0xD0:
+		_, err = r.byte()
+	case op == 0x41:
+		_, err = r.sleb(32)
+	case op == 0x42:
+		_, err = r.sleb(64)
+	case op == 0x43:
+		_, err = r.bytes(4)
+	case op == 0x44:
+		_, err = r.bytes(8)
+	case op == 0xFC:
+		var sub uint32
+		if sub, err = r.u32(); err != nil {
+			return err
+		}
+		switch {
+		case sub <= 7:
+		case sub == 8:
+			if _, err = r.u32(); err == nil {
+				_, err = r.byte()
+			}
+		case sub == 9 || sub == 11:
+			_, err = r.u32()
+		case sub == 10:
+			_, err = r.bytes(2)
+		default:
+			return fmt.Errorf("unsupported instruction 0xfc %d", sub)
+		}
+	case op <= 0x01 || op == 0x05 || op == 0x0B || op == 0x0F || op == 0x1A || op == 0x1B ||
+		(op >= 0x45 && op <= 0xC4) || op == 0xD1:
+	default:
+		return fmt.Errorf("unsupported instruction 0x%x", op)
+	}
+	return err
+}
@@ -0,0 +1,404 @@
+package wasm
+
+import (
+	"math"
+	"math/bits"
+)
+
+func satI32(f float64) int32 {
+	switch {
+	case f != f:
+		return 0
+	case f <= math.MinInt32:
+		return math.MinInt32
+	case f >= math.MaxInt32:
+		return math.MaxInt32
+	}
+	return int32(f)
+}
+
+func satU32(f float64) uint32 {
+	switch {
+	case f != f || f <= 0:
+		return 0
+	case f >= math.MaxUint32:
+		return math.MaxUint32
+	}
+	return uint32(f)
+}
+
+func satI64(f float64) int64 {
+	switch {
+	case f != f:
+		return 0
+	case f <= math.MinInt64:
+		return math.MinInt64
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	}
+	return int64(f)
+}
+
+func satU64(f float64) uint64 {
+	switch {
+	case f != f || f <= 0:
+		return 0
+	case f >= math.MaxUint64:
+		return math.MaxUint64
+	}
+	return uint64(f)
+}
+
+// truncFloat converts f to an integer after checking it fits in [min, max), trapping otherwise
+func truncFloat(f, min, max float64) float64 {
+	if f != f {
+		trap("invalid conversion to integer")
+	}
+	t := math.Trunc(f)
+	if t < min || t >= max {
+		trap("integer overflow")
+	}
+	return t
+}
+
+func fmin(a, b float64) float64 {
+	switch {
+	case a != a || b != b:
+		return math.NaN()
+	case a == 0 && b == 0:
+		if math.Signbit(a) {
+			return a
+		}
+		return b
+	}
+	return math.Min(a, b)
+}
+
+func fmax(a, b float64) float64 {
+	switch {
+	case a != a || b != b:
+		return math.NaN()
+	case a == 0 && b == 0:
+		if math.Signbit(a) {
+			return b
+		}
+		return a
+	}
+	return math.Max(a, b)
+}
+
+// execNumeric runs the comparison, arithmetic and conversion instructions 0x45 to 0xC4
+func (in *Instance) execNumeric(op byte) {
+	switch {
+	case op == 0x45:
+		in.push(boolValue(uint32(in.pop()) == 0))
+	case op <= 0x4F:
+		b, a := uint32(in.pop()), uint32(in.pop())
+		in.push(boolValue(compareInt(op-0x46, uint64(a), uint64(b), int64(int32(a)), int64(int32(b)))))
+	case op == 0x50:
+		in.push(boolValue(in.pop() == 0))
+	case op <= 0x5A:
+		b, a := in.pop(), in.pop()
+		in.push(boolValue(compareInt(op-0x51, a, b, int64(a), int64(b))))
+	case op <= 0x60:
+		b, a := math.Float32frombits(uint32(in.pop())), math.Float32frombits(uint32(in.pop()))
+		in.push(boolValue(compareFloat(op-0x5B, float64(a), float64(b))))
+	case op <= 0x66:
+		b, a := math.Float64frombits(in.pop()), math.Float64frombits(in.pop())
+		in.push(boolValue(compareFloat(op-0x61, a, b)))
+	case op <= 0x69:
+		a := uint32(in.pop())
+		switch op {
+		case 0x67:
+			in.push(uint64(bits.LeadingZeros32(a)))
+		case 0x68:
+			in.push(uint64(bits.TrailingZeros32(a)))
+		default:
+			in.push(uint64(bits.OnesCount32(a)))
+		}
+	case op <= 0x78:
+		b, a := uint32(in.pop()), uint32(in.pop())
+		in.push(uint64(i32Binary(op, a, b)))
+	case op <= 0x7B:
+		a := in.pop()
+		switch op {
+		case 0x79:
+			in.push(uint64(bits.LeadingZeros64(a)))
+		case 0x7A:
+			in.push(uint64(bits.TrailingZeros64(a)))
+		default:
+			in.push(uint64(bits.OnesCount64(a)))
+		}
+	case op <= 0x8A:
+		b, a := in.pop(), in.pop()
+		in.push(i64Binary(op, a, b))
+	case op <= 0x91:
+		a := float64(math.Float32frombits(uint32(in.pop())))
+		in.push(uint64(math.Float32bits(float32(floatUnary(op-0x8B, a)))))
+	case op <= 0x98:
+		b, a := float64(math.Float32frombits(uint32(in.pop()))), float64(math.Float32frombits(uint32(in.pop())))
+		in.push(uint64(math.Float32bits(float32(floatBinary(op-0x92, a, b)))))
+	case op <= 0x9F:
+		a := math.Float64frombits(in.pop())
+		in.push(math.Float64bits(floatUnary(op-0x99, a)))
+	case op <= 0xA6:
+		b, a := math.Float64frombits(in.pop()), math.Float64frombits(in.pop())
+		in.push(math.Float64bits(floatBinary(op-0xA0, a, b)))
+	default:
+		in.push(convert(op, in.pop()))
+	}
+}
+
+// compareInt evaluates eq, ne, lt_s, lt_u, gt_s, gt_u, le_s, le_u, ge_s, ge_u by index
+func compareInt(i byte, ua, ub uint64, sa, sb int64) bool {
+	switch i {
+	case 0:
+		return ua == ub
+	case 1:
+		return ua != ub
+	case 2:
+		return sa < sb
+	case 3:
+		return ua < ub
+	case 4:
+		return sa > sb
+	case 5:
+		return ua > ub
+	case 6:
+		return sa <= sb
+	case 7:
+		return ua <= ub
+	case 8:
+		return sa >= sb
+	default:
+		return ua >= ub
+	}
+}
+
+// compareFloat evaluates eq, ne, lt, gt, le, ge by index
+func compareFloat(i byte, a, b float64) bool {
+	switch i {
+	case 0:
+		return a == b
+	case 1:
+		return a != b
+	case 2:
+		return a < b
+	case 3:
+		return a > b
+	case 4:
+		return a <= b
+	default:
+		return a >= b
+	}
+}
+
+func i32Binary(op byte, a, b uint32) uint32 {
+	switch op {
+	case 0x6A:
+		return a + b
+	case 0x6B:
+		return a - b
+	case 0x6C:
+		return a * b
+	case 0x6D:
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		if int32(a) == math.MinInt32 && int32(b) == -1 {
+			trap("integer overflow")
+		}
+		return uint32(int32(a) / int32(b))
+	case 0x6E:
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		return a / b
+	case 0x6F:
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		if int32(b) == -1 {
+			return 0
+		}
+		return uint32(int32(a) % int32(b))
+	case 0x70:
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		return a % b
+	case 0x71:
+		return a & b
+	case 0x72:
+		return a | b
+	case 0x73:
+		return a ^ b
+	case 0x74:
+		return a << (b & 31)
+	case 0x75:
+		return uint32(int32(a) >> (b & 31))
+	case 0x76:
+		return a >> (b & 31)
+	case 0x77:
+		return bits.RotateLeft32(a, int(b&31))
+	default:
+		return bits.RotateLeft32(a, -int(b&31))
+	}
+}
+
+func i64Binary(op byte, a, b uint64) uint64 {
+	switch op {
+	case 0x7C:
+		return a + b
+	case 0x7D:
+		return a - b
+	case 0x7E:
+		return a * b
+	case 0x7F:
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		if int64(a) == math.MinInt64 && int64(b) == -1 {
+			trap("integer overflow")
+		}
+		return uint64(int64(a) / int64(b))
+	case 0x80:
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		return a / b
+	case 0x81:
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		if int64(b) == -1 {
+			return 0
+		}
+		return uint64(int64(a) % int64(b))
+	case 0x82:
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		return a % b
+	case 0x83:
+		return a & b
+	case 0x84:
+		return a | b
+	case 0x85:
+		return a ^ b
+	case 0x86:
+		return a << (b & 63)
+	case 0x87:
+		return uint64(int64(a) >> (b & 63))
+	case 0x88:
+		return a >> (b & 63)
+	case 0x89:
+		return bits.RotateLeft64(a, int(b&63))
+	default:
+		return bits.RotateLeft64(a, -int(b&63))
+	}
+}
+
+// floatUnary evaluates abs, neg, ceil, floor, trunc, nearest, sqrt by index
+func floatUnary(i byte, a float64) float64 {
+	switch i {
+	case 0:
+		return math.Abs(a)
+	case 1:
+		return -a
+	case 2:
+		return math.Ceil(a)
+	case 3:
+		return math.Floor(a)
+	case 4:
+		return math.Trunc(a)
+	case 5:
+		return math.RoundToEven(a)
+	default:
+		return math.Sqrt(a)
+	}
+}
+
+// floatBinary evaluates add, sub, mul, div, min, max, copysign by index
+func floatBinary(i byte, a, b float64) float64 {
+	switch i {
+	case 0:
+		return a + b
+	case 1:
+		return a - b
+	case 2:
+		return a * b
+	case 3:
+		return a / b
+	case 4:
+		return fmin(a, b)
+	case 5:
+		return fmax(a, b)
+	default:
+		return math.Copysign(a, b)
+	}
+}
+
+// convert runs the conversion and sign extension instructions 0xA7 to 0xC4
+func convert(op byte, v uint64) uint64 {
+	f32 := func() float64 { return float64(math.Float32frombits(uint32(v))) }
+	f64 := func() float64 { return math.Float64frombits(v) }
+	switch op {
+	case 0xA7:
+		return uint64(uint32(v))
+	case 0xA8:
+		return uint64(uint32(int32(truncFloat(f32(), math.MinInt32, -math.MinInt32))))
+	case 0xA9:
+		return uint64(uint32(truncFloat(f32(), -0.5, math.MaxUint32+1)))
+	case 0xAA:
+		return uint64(uint32(int32(truncFloat(f64(), math.MinInt32, -math.MinInt32))))
+	case 0xAB:
+		return uint64(uint32(truncFloat(f64(), -0.5, math.MaxUint32+1)))
+	case 0xAC:
+		return uint64(int64(int32(uint32(v))))
+	case 0xAD:
+		return uint64(uint32(v))
+	case 0xAE:
+		return uint64(int64(truncFloat(f32(), math.MinInt64, -math.MinInt64)))
+	case 0xAF:
+		return uint64(truncFloat(f32(), -0.5, math.MaxUint64))
+	case 0xB0:
+		return uint64(int64(truncFloat(f64(), math.MinInt64, -math.MinInt64)))
+	case 0xB1:
+		return uint64(truncFloat(f64(), -0.5, math.MaxUint64))
+	case 0xB2:
+		return uint64(math.Float32bits(float32(int32(uint32(v)))))
+	case 0xB3:
+		return uint64(math.Float32bits(float32(uint32(v))))
+	case 0xB4:
+		return uint64(math.Float32bits(float32(int64(v))))
+	case 0xB5:
+		return uint64(math.Float32bits(float32(v)))
+	case 0xB6:
+		return uint64(math.Float32bits(float32(f64())))
+	case 0xB7:
+		return math.Float64bits(float64(int32(uint32(v))))
+	case 0xB8:
+		return math.Float64bits(float64(uint32(v)))
+	case 0xB9:
+		return math.Float64bits(float64(int64(v)))
+	case 0xBA:
+		return math.Float64bits(float64(v))
+	case 0xBB:
+		return math.Float64bits(f32())
+	case 0xBC, 0xBE:
+		return uint64(uint32(v))
+	case 0xBD, 0xBF:
+		return v
+	case 0xC0:
+		return uint64(uint32(int32(int8(v))))
+	case 0xC1:
+		return uint64(uint32(int32(int16(v))))
+	case 0xC2:
+		return uint64(int64(int8(v)))
+	case 0xC3:
+		return uint64(int64(int16(v)))
+	default:
+		return uint64(int64(int32(v)))
+	}
+}
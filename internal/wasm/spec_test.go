@@ -0,0 +1,307 @@
+package wasm
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"rpc-simulator/internal/wasm/wasmtest"
+)
+
+// specCase mirrors an assert_return or assert_trap directive of the spec test suite: code runs
+// with args bound to its parameters and either returns want or traps with a message containing trap
+type specCase struct {
+	name   string
+	params []byte
+	result byte
+	code   []byte
+	args   []uint64
+	want   uint64
+	trap   string
+}
+
+func f32(v float32) uint64 { return uint64(math.Float32bits(v)) }
+func f64(v float64) uint64 { return math.Float64bits(v) }
+
+var (
+	negZero32 = uint64(0x80000000)
+	negZero64 = uint64(0x8000000000000000)
+	nan32     = f32(float32(math.NaN()))
+	nan64     = f64(math.NaN())
+)
+
+// binop returns a case applying op to two operands of type t
+func binop(name string, t, result byte, op byte, a, b, want uint64) specCase {
+	return specCase{name: name, params: []byte{t, t}, result: result, code: []byte{0x20, 0x00, 0x20, 0x01, op, 0x0B}, args: []uint64{a, b}, want: want}
+}
+
+// unop returns a case applying op, which may be a multi-byte opcode, to one operand of type t
+func unop(name string, t, result byte, op []byte, a, want uint64) specCase {
+	code := append(append([]byte{0x20, 0x00}, op...), 0x0B)
+	return specCase{name: name, params: []byte{t}, result: result, code: code, args: []uint64{a}, want: want}
+}
+
+func trapping(c specCase, trap string) specCase {
+	c.trap = trap
+	return c
+}
+
+// sameValue compares results bit for bit, except that any NaN matches any NaN as the spec only
+// requires an arithmetic NaN
+func sameValue(t byte, got, want uint64) bool {
+	switch t {
+	case F32:
+		if math.IsNaN(float64(math.Float32frombits(uint32(want)))) {
+			return math.IsNaN(float64(math.Float32frombits(uint32(got))))
+		}
+	case F64:
+		if math.IsNaN(math.Float64frombits(want)) {
+			return math.IsNaN(math.Float64frombits(got))
+		}
+	}
+	return got == want
+}
+
+func runSpecCases(t *testing.T, cases []specCase) {
+	t.Helper()
+	for _, c := range cases {
+		funcs := []wasmtest.Func{{Export: "run", Params: c.params, Results: []byte{c.result}, Code: c.code}}
+		results, err := runTestFunc(t, context.Background(), funcs, "run", c.args...)
+		if c.trap != "" {
+			if err == nil || !strings.Contains(err.Error(), c.trap) {
+				t.Errorf("%s(%#x): expected trap %q, got %v, %v", c.name, c.args, c.trap, results, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s(%#x): unexpected error: %v", c.name, c.args, err)
+			continue
+		}
+		if len(results) != 1 || !sameValue(c.result, results[0], c.want) {
+			t.Errorf("%s(%#x): expected %#x, got %#x", c.name, c.args, c.want, results)
+		}
+	}
+}
+
+func TestSpecIntegerArithmetic(t *testing.T) {
+	const min32, neg1_32 = 0x80000000, 0xffffffff
+	const min64, neg1_64 = 0x8000000000000000, 0xffffffffffffffff
+	runSpecCases(t, []specCase{
+		binop("i32.add", I32, I32, 0x6A, 0x7fffffff, 1, min32),
+		binop("i32.sub", I32, I32, 0x6B, min32, 1, 0x7fffffff),
+		binop("i32.mul", I32, I32, 0x6C, 0x01234567, 0x76543210, 0x358e7470),
+		binop("i32.div_s", I32, I32, 0x6D, 0xfffffff9, 2, 0xfffffffd),
+		trapping(binop("i32.div_s", I32, I32, 0x6D, min32, neg1_32, 0), "integer overflow"),
+		trapping(binop("i32.div_s", I32, I32, 0x6D, 1, 0, 0), "integer divide by zero"),
+		binop("i32.div_u", I32, I32, 0x6E, 0xfffffff9, 2, 0x7ffffffc),
+		binop("i32.rem_s", I32, I32, 0x6F, min32, neg1_32, 0),
+		binop("i32.rem_s", I32, I32, 0x6F, 0xfffffff9, 2, neg1_32),
+		trapping(binop("i32.rem_u", I32, I32, 0x70, 7, 0, 0), "integer divide by zero"),
+		binop("i32.shl", I32, I32, 0x74, 1, 32, 1),
+		binop("i32.shr_s", I32, I32, 0x75, min32, 1, 0xc0000000),
+		binop("i32.shr_u", I32, I32, 0x76, min32, 33, 0x40000000),
+		binop("i32.rotl", I32, I32, 0x77, 0xabcd9876, 1, 0x579b30ed),
+		binop("i32.rotr", I32, I32, 0x78, 0xb0c1d2e3, 5, 0x1d860e97),
+		binop("i32.lt_s", I32, I32, 0x48, min32, 0, 1),
+		binop("i32.lt_u", I32, I32, 0x49, min32, 0, 0),
+		unop("i32.clz", I32, I32, []byte{0x67}, 0, 32),
+		unop("i32.clz", I32, I32, []byte{0x67}, 0x8000, 16),
+		unop("i32.ctz", I32, I32, []byte{0x68}, 0, 32),
+		unop("i32.ctz", I32, I32, []byte{0x68}, min32, 31),
+		unop("i32.popcnt", I32, I32, []byte{0x69}, neg1_32, 32),
+		unop("i32.eqz", I32, I32, []byte{0x45}, 0, 1),
+		unop("i32.extend8_s", I32, I32, []byte{0xC0}, 0x80, 0xffffff80),
+		unop("i32.extend8_s", I32, I32, []byte{0xC0}, 0x17f, 0x7f),
+		unop("i32.extend16_s", I32, I32, []byte{0xC1}, 0x8000, 0xffff8000),
+
+		binop("i64.add", I64, I64, 0x7C, 0x7fffffffffffffff, 1, min64),
+		binop("i64.div_u", I64, I64, 0x80, neg1_64, 2, 0x7fffffffffffffff),
+		trapping(binop("i64.div_s", I64, I64, 0x7F, min64, neg1_64, 0), "integer overflow"),
+		trapping(binop("i64.div_u", I64, I64, 0x80, 1, 0, 0), "integer divide by zero"),
+		binop("i64.rem_s", I64, I64, 0x81, min64, neg1_64, 0),
+		binop("i64.shl", I64, I64, 0x86, 1, 64, 1),
+		binop("i64.shr_s", I64, I64, 0x87, min64, 63, neg1_64),
+		binop("i64.rotl", I64, I64, 0x89, 0xabd1234ef567809c, 0x800000000000003f, 0x55e891a77ab3c04e),
+		binop("i64.lt_s", I64, I32, 0x53, min64, 0, 1),
+		unop("i64.clz", I64, I64, []byte{0x79}, 0, 64),
+		unop("i64.ctz", I64, I64, []byte{0x7A}, 0, 64),
+		unop("i64.popcnt", I64, I64, []byte{0x7B}, 0x8000800080008000, 4),
+		unop("i64.eqz", I64, I32, []byte{0x50}, 0, 1),
+		unop("i64.extend32_s", I64, I64, []byte{0xC4}, min32, 0xffffffff80000000),
+	})
+}
+
+func TestSpecFloatArithmetic(t *testing.T) {
+	inf := math.Inf(1)
+	runSpecCases(t, []specCase{
+		binop("f32.add", F32, F32, 0x92, f32(0.1), f32(0.2), f32(float32(0.1)+float32(0.2))),
+		binop("f32.min", F32, F32, 0x96, negZero32, 0, negZero32),
+		binop("f32.min", F32, F32, 0x96, 0, negZero32, negZero32),
+		binop("f32.max", F32, F32, 0x97, negZero32, 0, 0),
+		binop("f32.min", F32, F32, 0x96, nan32, f32(1), nan32),
+		binop("f32.copysign", F32, F32, 0x98, f32(-2), f32(1), f32(2)),
+		unop("f32.neg", F32, F32, []byte{0x8C}, 0, negZero32),
+		unop("f32.nearest", F32, F32, []byte{0x90}, f32(4.5), f32(4)),
+		unop("f32.sqrt", F32, F32, []byte{0x91}, f32(4), f32(2)),
+
+		binop("f64.min", F64, F64, 0xA4, nan64, f64(1), nan64),
+		binop("f64.max", F64, F64, 0xA5, f64(1), nan64, nan64),
+		binop("f64.max", F64, F64, 0xA5, negZero64, 0, 0),
+		binop("f64.copysign", F64, F64, 0xA6, f64(1), negZero64, f64(-1)),
+		binop("f64.div", F64, F64, 0xA3, f64(1), 0, f64(inf)),
+		binop("f64.div", F64, F64, 0xA3, 0, 0, nan64),
+		binop("f64.eq", F64, I32, 0x61, nan64, nan64, 0),
+		binop("f64.ne", F64, I32, 0x62, nan64, nan64, 1),
+		binop("f64.lt", F64, I32, 0x63, negZero64, 0, 0),
+		unop("f64.abs", F64, F64, []byte{0x99}, negZero64, 0),
+		unop("f64.nearest", F64, F64, []byte{0x9E}, f64(2.5), f64(2)),
+		unop("f64.nearest", F64, F64, []byte{0x9E}, f64(-2.5), f64(-2)),
+		unop("f64.nearest", F64, F64, []byte{0x9E}, f64(3.5), f64(4)),
+		unop("f64.nearest", F64, F64, []byte{0x9E}, f64(-0.5), negZero64),
+		unop("f64.ceil", F64, F64, []byte{0x9B}, f64(-0.5), negZero64),
+		unop("f64.floor", F64, F64, []byte{0x9C}, f64(-0.5), f64(-1)),
+		unop("f64.trunc", F64, F64, []byte{0x9D}, f64(-1.5), f64(-1)),
+		unop("f64.sqrt", F64, F64, []byte{0x9F}, f64(-1), nan64),
+	})
+}
+
+func TestSpecConversions(t *testing.T) {
+	inf, nan := math.Inf(1), math.NaN()
+	runSpecCases(t, []specCase{
+		unop("i32.wrap_i64", I64, I32, []byte{0xA7}, 0x100000005, 5),
+		unop("i64.extend_i32_s", I32, I64, []byte{0xAC}, 0xffffffff, 0xffffffffffffffff),
+		unop("i64.extend_i32_u", I32, I64, []byte{0xAD}, 0xffffffff, 0xffffffff),
+
+		unop("i32.trunc_f32_s", F32, I32, []byte{0xA8}, f32(-2147483648), 0x80000000),
+		trapping(unop("i32.trunc_f32_s", F32, I32, []byte{0xA8}, f32(-2147483904), 0), "integer overflow"),
+		trapping(unop("i32.trunc_f32_s", F32, I32, []byte{0xA8}, f32(2147483648), 0), "integer overflow"),
+		unop("i32.trunc_f32_u", F32, I32, []byte{0xA9}, f32(-0.9), 0),
+		unop("i32.trunc_f32_u", F32, I32, []byte{0xA9}, f32(4294967040), 0xffffff00),
+		trapping(unop("i32.trunc_f32_u", F32, I32, []byte{0xA9}, f32(-1), 0), "integer overflow"),
+		trapping(unop("i32.trunc_f32_u", F32, I32, []byte{0xA9}, f32(4294967296), 0), "integer overflow"),
+		unop("i32.trunc_f64_s", F64, I32, []byte{0xAA}, f64(2147483647.9), 0x7fffffff),
+		unop("i32.trunc_f64_s", F64, I32, []byte{0xAA}, f64(-2147483648.9), 0x80000000),
+		trapping(unop("i32.trunc_f64_s", F64, I32, []byte{0xAA}, f64(-2147483649), 0), "integer overflow"),
+		trapping(unop("i32.trunc_f64_s", F64, I32, []byte{0xAA}, f64(nan), 0), "invalid conversion to integer"),
+		unop("i32.trunc_f64_u", F64, I32, []byte{0xAB}, f64(4294967295.9), 0xffffffff),
+		unop("i64.trunc_f64_s", F64, I64, []byte{0xB0}, f64(-9223372036854775808), 0x8000000000000000),
+		trapping(unop("i64.trunc_f64_s", F64, I64, []byte{0xB0}, f64(9223372036854775808), 0), "integer overflow"),
+		unop("i64.trunc_f64_u", F64, I64, []byte{0xB1}, f64(18446744073709549568), 0xfffffffffffff800),
+		trapping(unop("i64.trunc_f64_u", F64, I64, []byte{0xB1}, f64(18446744073709551616), 0), "integer overflow"),
+
+		unop("i32.trunc_sat_f32_s", F32, I32, []byte{0xFC, 0x00}, f32(float32(nan)), 0),
+		unop("i32.trunc_sat_f32_s", F32, I32, []byte{0xFC, 0x00}, f32(float32(inf)), 0x7fffffff),
+		unop("i32.trunc_sat_f32_s", F32, I32, []byte{0xFC, 0x00}, f32(float32(-inf)), 0x80000000),
+		unop("i32.trunc_sat_f64_u", F64, I32, []byte{0xFC, 0x03}, f64(-1), 0),
+		unop("i32.trunc_sat_f64_u", F64, I32, []byte{0xFC, 0x03}, f64(1e10), 0xffffffff),
+		unop("i64.trunc_sat_f64_s", F64, I64, []byte{0xFC, 0x06}, f64(-inf), 0x8000000000000000),
+		unop("i64.trunc_sat_f64_u", F64, I64, []byte{0xFC, 0x07}, f64(inf), 0xffffffffffffffff),
+
+		unop("f32.convert_i32_u", I32, F32, []byte{0xB3}, 0xffffffff, f32(4294967296)),
+		unop("f32.convert_i64_s", I64, F32, []byte{0xB4}, 9223371761976868863, f32(9223371487098961920)),
+		unop("f32.convert_i64_u", I64, F32, []byte{0xB5}, 0x8000008000000001, 0x5f000001),
+		unop("f64.convert_i64_u", I64, F64, []byte{0xBA}, 0xffffffffffffffff, f64(18446744073709551616)),
+		unop("f32.demote_f64", F64, F32, []byte{0xB6}, f64(1e300), f32(float32(inf))),
+		unop("f64.promote_f32", F32, F64, []byte{0xBB}, negZero32, negZero64),
+		unop("i32.reinterpret_f32", F32, I32, []byte{0xBC}, negZero32, 0x80000000),
+		unop("f64.reinterpret_i64", I64, F64, []byte{0xBF}, 0x3ff0000000000000, f64(1)),
+	})
+}
+
+func TestSpecMemory(t *testing.T) {
+	load := []byte{0x20, 0x00, 0x28, 0x02, 0x00, 0x0B}
+	runSpecCases(t, []specCase{
+		{name: "i32.load16_s", result: I32, want: 0xffff8001, code: []byte{
+			0x41, 0x00, 0x41, 0x81, 0x80, 0x02, 0x3B, 0x01, 0x00, // i32.store16 0x8001 at 0
+			0x41, 0x00, 0x2E, 0x01, 0x00, 0x0B}},
+		{name: "i64.load32_s", result: I64, want: 0xfffffffffffffffe, code: []byte{
+			0x41, 0x00, 0x42, 0x7E, 0x3E, 0x02, 0x00, // i64.store32 -2 at 0
+			0x41, 0x00, 0x34, 0x02, 0x00, 0x0B}},
+		{name: "i64.load32_u", result: I64, want: 0xfffffffe, code: []byte{
+			0x41, 0x00, 0x42, 0x7E, 0x3E, 0x02, 0x00,
+			0x41, 0x00, 0x35, 0x02, 0x00, 0x0B}},
+		{name: "i32.load", params: []byte{I32}, result: I32, code: load, args: []uint64{65532}, want: 0},
+		{name: "i32.load", params: []byte{I32}, result: I32, code: load, args: []uint64{65533}, trap: "out of bounds memory access"},
+		{name: "i32.load offset", result: I32, code: append([]byte{0x41, 0x01, 0x28, 0x02}, append(wasmtest.ULEB(0xffffffff), 0x0B)...), trap: "out of bounds memory access"},
+		{name: "memory.size", result: I32, code: []byte{0x3F, 0x00, 0x0B}, want: 1},
+		{name: "memory.grow", result: I32, code: []byte{0x41, 0x01, 0x40, 0x00, 0x0B}, want: 1},
+		{name: "memory.grow past max", result: I32, code: []byte{0x41, 0x02, 0x40, 0x00, 0x0B}, want: 0xffffffff},
+		{name: "memory.copy overlap", result: I32, want: 0x03020101, code: []byte{
+			0x41, 0x00, 0x41, 0x81, 0x84, 0x8C, 0x18, 0x36, 0x02, 0x00, // i32.store 0x04030201 at 0
+			0x41, 0x01, 0x41, 0x00, 0x41, 0x03, 0xFC, 0x0A, 0x00, 0x00, // memory.copy 1 <- 0, 3 bytes
+			0x41, 0x00, 0x28, 0x02, 0x00, 0x0B}},
+		{name: "memory.fill at end", result: I32, want: 0, code: []byte{
+			0x41, 0x80, 0x80, 0x04, 0x41, 0x00, 0x41, 0x00, 0xFC, 0x0B, 0x00, 0x41, 0x00, 0x0B}},
+		{name: "memory.fill out of bounds", result: I32, trap: "out of bounds memory access", code: []byte{
+			0x41, 0xFF, 0xFF, 0x03, 0x41, 0x00, 0x41, 0x02, 0xFC, 0x0B, 0x00, 0x41, 0x00, 0x0B}},
+	})
+}
+
+func TestSpecControl(t *testing.T) {
+	brTable := []byte{
+		0x02, 0x40, 0x02, 0x40, 0x02, 0x40,
+		0x20, 0x00, 0x0E, 0x02, 0x00, 0x01, 0x02, // br_table 0 1 default 2
+		0x0B, 0x41, 0x0A, 0x0F,
+		0x0B, 0x41, 0x0B, 0x0F,
+		0x0B, 0x41, 0x0C, 0x0B,
+	}
+	for arg, want := range map[uint64]uint64{0: 10, 1: 11, 2: 12, 0xffffffff: 12} {
+		runSpecCases(t, []specCase{{name: "br_table", params: []byte{I32}, result: I32, code: brTable, args: []uint64{arg}, want: want}})
+	}
+	runSpecCases(t, []specCase{
+		{name: "br drops extra operands", result: I32, code: []byte{0x02, 0x7F, 0x41, 0x01, 0x41, 0x02, 0x0C, 0x00, 0x0B, 0x0B}, want: 2},
+		{name: "br_if keeps the value", params: []byte{I32}, result: I32, args: []uint64{0}, want: 7, code: []byte{
+			0x02, 0x7F, 0x41, 0x07, 0x20, 0x00, 0x0D, 0x00, 0x1A, 0x41, 0x07, 0x0B, 0x0B}},
+		{name: "return from a loop", result: I32, code: []byte{0x03, 0x40, 0x41, 0x05, 0x0F, 0x0B, 0x41, 0x00, 0x0B}, want: 5},
+	})
+
+	// Block types referencing a function type: (param i32) (result i32) and (result i32 i32)
+	results, err := runTestFunc(t, context.Background(), []wasmtest.Func{
+		{Params: []byte{I32}, Results: []byte{I32}, Code: []byte{0x20, 0x00, 0x0B}},
+		{Results: []byte{I32, I32}, Code: []byte{0x41, 0x00, 0x41, 0x00, 0x0B}},
+		{Export: "run", Results: []byte{I32}, Code: []byte{
+			0x41, 0x05, 0x02, 0x00, 0x41, 0x02, 0x6C, 0x0B, // 5 -> block (param i32) * 2 end
+			0x02, 0x01, 0x41, 0x03, 0x41, 0x04, 0x0B, 0x6A, // block (result i32 i32) 3 4 end, add
+			0x6A, 0x0B,
+		}},
+	}, "run")
+	if err != nil || len(results) != 1 || results[0] != 17 {
+		t.Errorf("multi-value blocks: expected 17, got %v, %v", results, err)
+	}
+}
+
+// tableModule assembles a module exporting call(i32) that calls through a table holding a
+// () -> i32 function returning 42 and call itself
+func tableModule() []byte {
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	module = append(module, wasmtest.Section(1, wasmtest.Vec(
+		[]byte{0x60, 0x00, 0x01, I32},
+		[]byte{0x60, 0x01, I32, 0x01, I32}))...)
+	module = append(module, wasmtest.Section(3, wasmtest.Vec([]byte{0x00}, []byte{0x01}))...)
+	module = append(module, wasmtest.Section(4, wasmtest.Vec([]byte{FuncRef, 0x00, 0x03}))...)
+	module = append(module, wasmtest.Section(7, wasmtest.Vec(append(wasmtest.Name("call"), 0x00, 0x01)))...)
+	module = append(module, wasmtest.Section(9, wasmtest.Vec([]byte{0x00, 0x41, 0x00, 0x0B, 0x02, 0x00, 0x01}))...)
+	return append(module, wasmtest.Section(10, wasmtest.Vec(
+		[]byte{0x04, 0x00, 0x41, 0x2A, 0x0B},
+		[]byte{0x07, 0x00, 0x20, 0x00, 0x11, 0x00, 0x00, 0x0B}))...)
+}
+
+func TestSpecCallIndirect(t *testing.T) {
+	module, err := Decode(tableModule())
+	if err != nil {
+		t.Fatalf("Failed to decode module: %v", err)
+	}
+	in, err := module.Instantiate(context.Background(), 0, 1000)
+	if err != nil {
+		t.Fatalf("Failed to instantiate module: %v", err)
+	}
+	if results, err := in.Invoke("call", 0); err != nil || results[0] != 42 {
+		t.Errorf("Expected 42, got %v, %v", results, err)
+	}
+	for arg, want := range map[uint64]string{1: "indirect call type mismatch", 2: "uninitialized element", 3: "undefined element"} {
+		if _, err := in.Invoke("call", arg); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("call(%d): expected trap %q, got %v", arg, want, err)
+		}
+	}
+}
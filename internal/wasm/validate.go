@@ -0,0 +1,665 @@
+package wasm
+
+import "fmt"
+
+// unknown is the type of an operand popped from the stack of unreachable code; it matches any type
+const unknown byte = 0
+
+func isValueType(t byte) bool {
+	switch t {
+	case I32, I64, F32, F64, FuncRef, ExternRef:
+		return true
+	}
+	return false
+}
+
+func isRefType(t byte) bool {
+	return t == FuncRef || t == ExternRef
+}
+
+// typeName returns the text format name of a value type
+func typeName(t byte) string {
+	switch t {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	case F32:
+		return "f32"
+	case F64:
+		return "f64"
+	case FuncRef:
+		return "funcref"
+	case ExternRef:
+		return "externref"
+	case unknown:
+		return "unknown"
+	}
+	return fmt.Sprintf("0x%x", t)
+}
+
+// validate checks the module against the validation rules of the spec, so that execution
+// only has to guard against traps: every index is in range, every instruction finds operands
+// of the right type on the stack and every block leaves exactly its results
+func (m *Module) validate() error {
+	for i, t := range m.types {
+		for _, v := range append(append([]byte(nil), t.Params...), t.Results...) {
+			if !isValueType(v) {
+				return fmt.Errorf("type %d: invalid value type 0x%x", i, v)
+			}
+		}
+	}
+	if l := m.memory; l != nil && (l.min > maxPages || l.hasMax && (l.max > maxPages || l.max < l.min)) {
+		return fmt.Errorf("invalid memory limits")
+	}
+	if l := m.table; l != nil && l.hasMax && l.max < l.min {
+		return fmt.Errorf("invalid table limits")
+	}
+	for i, f := range m.funcs {
+		if int(f.typeIdx) >= len(m.types) {
+			return fmt.Errorf("function %d: type index out of range", i)
+		}
+		for _, t := range f.locals {
+			if !isValueType(t) {
+				return fmt.Errorf("function %d: invalid local type 0x%x", i, t)
+			}
+		}
+	}
+	for i, g := range m.globals {
+		if !isValueType(g.typ) {
+			return fmt.Errorf("global %d: invalid type 0x%x", i, g.typ)
+		}
+		if err := m.checkConst(g.init, g.typ, i); err != nil {
+			return fmt.Errorf("global %d: %v", i, err)
+		}
+	}
+	for name, e := range m.exports {
+		var ok bool
+		switch e.kind {
+		case 0:
+			ok = int(e.index) < len(m.funcs)
+		case 1:
+			ok = m.table != nil && e.index == 0
+		case 2:
+			ok = m.memory != nil && e.index == 0
+		case 3:
+			ok = int(e.index) < len(m.globals)
+		}
+		if !ok {
+			return fmt.Errorf("export %s: unknown %s", name, exportKinds[e.kind%4])
+		}
+	}
+	if m.start >= 0 {
+		if m.start >= int64(len(m.funcs)) {
+			return fmt.Errorf("start function index out of range")
+		}
+		if t := m.types[m.funcs[m.start].typeIdx]; len(t.Params) > 0 || len(t.Results) > 0 {
+			return fmt.Errorf("start function must take and return nothing")
+		}
+	}
+	for i, seg := range m.elements {
+		if seg.active {
+			if m.table == nil {
+				return fmt.Errorf("element segment %d: unknown table", i)
+			}
+			if err := m.checkConst(seg.offset, I32, len(m.globals)); err != nil {
+				return fmt.Errorf("element segment %d: %v", i, err)
+			}
+		}
+		for _, f := range seg.funcs {
+			if int(f) >= len(m.funcs) {
+				return fmt.Errorf("element segment %d: function index out of range", i)
+			}
+		}
+	}
+	for i, seg := range m.data {
+		if !seg.active {
+			continue
+		}
+		if m.memory == nil {
+			return fmt.Errorf("data segment %d: unknown memory", i)
+		}
+		if err := m.checkConst(seg.offset, I32, len(m.globals)); err != nil {
+			return fmt.Errorf("data segment %d: %v", i, err)
+		}
+	}
+	for i := range m.funcs {
+		if err := m.validateFunc(&m.funcs[i]); err != nil {
+			return fmt.Errorf("function %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+var exportKinds = []string{"function", "table", "memory", "global"}
+
+// checkConst checks that a constant expression produces a single value of type want. It may
+// only read immutable globals defined before the first of globals.
+func (m *Module) checkConst(expr []byte, want byte, globals int) error {
+	r := &reader{buf: expr}
+	var types []byte
+	for {
+		op, err := r.byte()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case 0x0B:
+			if len(types) != 1 || types[0] != want {
+				return fmt.Errorf("constant expression must produce one %s", typeName(want))
+			}
+			return nil
+		case 0x41:
+			r.sleb(32)
+			types = append(types, I32)
+		case 0x42:
+			r.sleb(64)
+			types = append(types, I64)
+		case 0x43:
+			r.bytes(4)
+			types = append(types, F32)
+		case 0x44:
+			r.bytes(8)
+			types = append(types, F64)
+		case 0x23:
+			idx, _ := r.u32()
+			if int(idx) >= globals || m.globals[idx].mutable {
+				return fmt.Errorf("constant expression reads an unknown or mutable global")
+			}
+			types = append(types, m.globals[idx].typ)
+		case 0xD0:
+			t, _ := r.byte()
+			types = append(types, t)
+		case 0xD2:
+			idx, _ := r.u32()
+			if int(idx) >= len(m.funcs) {
+				return fmt.Errorf("constant expression references an unknown function")
+			}
+			types = append(types, FuncRef)
+		}
+	}
+}
+
+// ctrlFrame is a block, loop, if or else being validated, or the function body itself
+type ctrlFrame struct {
+	op          byte
+	start       []byte // Parameter types
+	end         []byte // Result types
+	height      int    // Operand stack height at the start of the block
+	unreachable bool   // The rest of the block is unreachable, after br, return or unreachable
+}
+
+// validator type-checks a function body with the algorithm of the spec's validation appendix
+type validator struct {
+	m      *Module
+	locals []byte
+	vals   []byte
+	ctrls  []ctrlFrame
+	err    error
+}
+
+func (v *validator) fail(format string, args ...interface{}) {
+	if v.err == nil {
+		v.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (v *validator) push(types ...byte) {
+	v.vals = append(v.vals, types...)
+}
+
+func (v *validator) pop() byte {
+	frame := &v.ctrls[len(v.ctrls)-1]
+	if len(v.vals) == frame.height {
+		if !frame.unreachable {
+			v.fail("type mismatch: operand stack underflow")
+		}
+		return unknown
+	}
+	t := v.vals[len(v.vals)-1]
+	v.vals = v.vals[:len(v.vals)-1]
+	return t
+}
+
+func (v *validator) popExpect(want byte) byte {
+	got := v.pop()
+	if got != want && got != unknown && want != unknown {
+		v.fail("type mismatch: expected %s, got %s", typeName(want), typeName(got))
+	}
+	if got == unknown {
+		return want
+	}
+	return got
+}
+
+// popTypes pops the types in reverse order and returns what was popped, in order
+func (v *validator) popTypes(types []byte) []byte {
+	popped := make([]byte, len(types))
+	for i := len(types) - 1; i >= 0; i-- {
+		popped[i] = v.popExpect(types[i])
+	}
+	return popped
+}
+
+func (v *validator) pushCtrl(op byte, start, end []byte) {
+	v.ctrls = append(v.ctrls, ctrlFrame{op: op, start: start, end: end, height: len(v.vals)})
+	v.push(start...)
+}
+
+func (v *validator) popCtrl() ctrlFrame {
+	frame := v.ctrls[len(v.ctrls)-1]
+	v.popTypes(frame.end)
+	if len(v.vals) != frame.height {
+		v.fail("type mismatch: values remaining on the stack at the end of the block")
+	}
+	v.ctrls = v.ctrls[:len(v.ctrls)-1]
+	return frame
+}
+
+func (v *validator) setUnreachable() {
+	frame := &v.ctrls[len(v.ctrls)-1]
+	v.vals = v.vals[:frame.height]
+	frame.unreachable = true
+}
+
+// labelTypes returns the types a branch to a frame carries: a loop's parameters, otherwise its results
+func labelTypes(frame ctrlFrame) []byte {
+	if frame.op == 0x03 {
+		return frame.start
+	}
+	return frame.end
+}
+
+// label returns the frame a branch of depth targets
+func (v *validator) label(depth uint32) ctrlFrame {
+	if int(depth) >= len(v.ctrls) {
+		v.fail("unknown label %d", depth)
+		return ctrlFrame{}
+	}
+	return v.ctrls[len(v.ctrls)-1-int(depth)]
+}
+
+// blockType returns the parameter and result types of a block type immediate
+func (v *validator) blockType(bt int64) ([]byte, []byte) {
+	switch {
+	case bt == -64: // 0x40, empty
+		return nil, nil
+	case bt < 0:
+		t := byte(bt + 128)
+		if bt < -64 || !isValueType(t) {
+			v.fail("invalid block type")
+			return nil, nil
+		}
+		return nil, []byte{t}
+	case bt >= int64(len(v.m.types)):
+		v.fail("unknown block type %d", bt)
+		return nil, nil
+	default:
+		t := v.m.types[bt]
+		return t.Params, t.Results
+	}
+}
+
+// memarg reads a memory immediate and checks the module has a memory and the alignment
+// doesn't exceed the natural alignment of an access of size bytes
+func (v *validator) memarg(r *reader, size uint32) {
+	align, err := r.u32()
+	if err == nil {
+		_, err = r.u32()
+	}
+	if err != nil {
+		v.fail("%v", err)
+	}
+	if v.m.memory == nil {
+		v.fail("unknown memory")
+	}
+	if align >= 32 || 1<<align > size {
+		v.fail("alignment must not be larger than natural")
+	}
+}
+
+// memoryIndex reads a reserved memory index byte, which must be zero
+func (v *validator) memoryIndex(r *reader) {
+	if b, err := r.byte(); err != nil || b != 0 {
+		v.fail("invalid memory index")
+	}
+	if v.m.memory == nil {
+		v.fail("unknown memory")
+	}
+}
+
+// loadTypes and storeTypes are the value type and access size of loads 0x28 to 0x35 and
+// stores 0x36 to 0x3E
+var (
+	loadTypes = []struct {
+		typ  byte
+		size uint32
+	}{{I32, 4}, {I64, 8}, {F32, 4}, {F64, 8}, {I32, 1}, {I32, 1}, {I32, 2}, {I32, 2}, {I64, 1}, {I64, 1}, {I64, 2}, {I64, 2}, {I64, 4}, {I64, 4}}
+	storeTypes = []struct {
+		typ  byte
+		size uint32
+	}{{I32, 4}, {I64, 8}, {F32, 4}, {F64, 8}, {I32, 1}, {I32, 2}, {I64, 1}, {I64, 2}, {I64, 4}}
+)
+
+// numericSignature returns the operand and result types of instructions 0x45 to 0xC4
+func numericSignature(op byte) ([]byte, byte) {
+	switch {
+	case op == 0x45:
+		return []byte{I32}, I32
+	case op <= 0x4F:
+		return []byte{I32, I32}, I32
+	case op == 0x50:
+		return []byte{I64}, I32
+	case op <= 0x5A:
+		return []byte{I64, I64}, I32
+	case op <= 0x60:
+		return []byte{F32, F32}, I32
+	case op <= 0x66:
+		return []byte{F64, F64}, I32
+	case op <= 0x69:
+		return []byte{I32}, I32
+	case op <= 0x78:
+		return []byte{I32, I32}, I32
+	case op <= 0x7B:
+		return []byte{I64}, I64
+	case op <= 0x8A:
+		return []byte{I64, I64}, I64
+	case op <= 0x91:
+		return []byte{F32}, F32
+	case op <= 0x98:
+		return []byte{F32, F32}, F32
+	case op <= 0x9F:
+		return []byte{F64}, F64
+	case op <= 0xA6:
+		return []byte{F64, F64}, F64
+	}
+	c := conversions[op]
+	return []byte{c[0]}, c[1]
+}
+
+// conversions are the operand and result types of the conversion and sign extension
+// instructions 0xA7 to 0xC4
+var conversions = map[byte][2]byte{
+	0xA7: {I64, I32}, 0xA8: {F32, I32}, 0xA9: {F32, I32}, 0xAA: {F64, I32}, 0xAB: {F64, I32},
+	0xAC: {I32, I64}, 0xAD: {I32, I64}, 0xAE: {F32, I64}, 0xAF: {F32, I64}, 0xB0: {F64, I64}, 0xB1: {F64, I64},
+	0xB2: {I32, F32}, 0xB3: {I32, F32}, 0xB4: {I64, F32}, 0xB5: {I64, F32}, 0xB6: {F64, F32},
+	0xB7: {I32, F64}, 0xB8: {I32, F64}, 0xB9: {I64, F64}, 0xBA: {I64, F64}, 0xBB: {F32, F64},
+	0xBC: {F32, I32}, 0xBD: {F64, I64}, 0xBE: {I32, F32}, 0xBF: {I64, F64},
+	0xC0: {I32, I32}, 0xC1: {I32, I32}, 0xC2: {I64, I64}, 0xC3: {I64, I64}, 0xC4: {I64, I64},
+}
+
+// truncSatTypes are the operand and result types of the saturating truncations 0xFC 0 to 7
+var truncSatTypes = [][2]byte{{F32, I32}, {F32, I32}, {F64, I32}, {F64, I32}, {F32, I64}, {F32, I64}, {F64, I64}, {F64, I64}}
+
+// validateFunc type-checks a function body
+func (m *Module) validateFunc(f *function) error {
+	typ := m.types[f.typeIdx]
+	v := &validator{m: m, locals: append(append([]byte(nil), typ.Params...), f.locals...)}
+	v.pushCtrl(0x02, nil, typ.Results)
+
+	r := &reader{buf: f.code}
+	for v.err == nil {
+		if len(v.ctrls) == 0 {
+			if r.pos != len(f.code) {
+				return fmt.Errorf("code after function end")
+			}
+			return nil
+		}
+		op, err := r.byte()
+		if err != nil {
+			return fmt.Errorf("function body without end")
+		}
+		v.instruction(r, op)
+	}
+	return v.err
+}
+
+// instruction reads the immediates of one instruction and applies its type to the stack
+func (v *validator) instruction(r *reader, op byte) {
+	m := v.m
+	u32 := func() uint32 {
+		n, err := r.u32()
+		if err != nil {
+			v.fail("%v", err)
+		}
+		return n
+	}
+
+	switch {
+	case op == 0x00:
+		v.setUnreachable()
+	case op == 0x01:
+	case op == 0x02 || op == 0x03 || op == 0x04:
+		bt, err := r.sleb(33)
+		if err != nil {
+			v.fail("%v", err)
+			return
+		}
+		params, results := v.blockType(bt)
+		if op == 0x04 {
+			v.popExpect(I32)
+		}
+		v.popTypes(params)
+		v.pushCtrl(op, params, results)
+	case op == 0x05:
+		frame := v.popCtrl()
+		if frame.op != 0x04 {
+			v.fail("else without if")
+			return
+		}
+		v.pushCtrl(0x05, frame.start, frame.end)
+	case op == 0x0B:
+		frame := v.popCtrl()
+		if frame.op == 0x04 && string(frame.start) != string(frame.end) {
+			v.fail("type mismatch: if without else must leave its parameters")
+		}
+		v.push(frame.end...)
+	case op == 0x0C:
+		v.popTypes(labelTypes(v.label(u32())))
+		v.setUnreachable()
+	case op == 0x0D:
+		types := labelTypes(v.label(u32()))
+		v.popExpect(I32)
+		v.push(v.popTypes(types)...)
+	case op == 0x0E:
+		targets, err := decodeU32Vec(r)
+		if err != nil {
+			v.fail("%v", err)
+			return
+		}
+		def := labelTypes(v.label(u32()))
+		v.popExpect(I32)
+		for _, target := range targets {
+			types := labelTypes(v.label(target))
+			if len(types) != len(def) {
+				v.fail("type mismatch: br_table targets differ in arity")
+				return
+			}
+			v.push(v.popTypes(types)...)
+		}
+		v.popTypes(def)
+		v.setUnreachable()
+	case op == 0x0F:
+		v.popTypes(v.ctrls[0].end)
+		v.setUnreachable()
+	case op == 0x10:
+		fn := u32()
+		if int(fn) >= len(m.funcs) {
+			v.fail("unknown function %d", fn)
+			return
+		}
+		t := m.types[m.funcs[fn].typeIdx]
+		v.popTypes(t.Params)
+		v.push(t.Results...)
+	case op == 0x11:
+		typeIdx, table := u32(), u32()
+		if m.table == nil || table != 0 {
+			v.fail("unknown table")
+			return
+		}
+		if int(typeIdx) >= len(m.types) {
+			v.fail("unknown type %d", typeIdx)
+			return
+		}
+		t := m.types[typeIdx]
+		v.popExpect(I32)
+		v.popTypes(t.Params)
+		v.push(t.Results...)
+	case op == 0x1A:
+		v.pop()
+	case op == 0x1B:
+		v.popExpect(I32)
+		t1, t2 := v.pop(), v.pop()
+		if isRefType(t1) || isRefType(t2) || (t1 != t2 && t1 != unknown && t2 != unknown) {
+			v.fail("type mismatch: select operands")
+		}
+		if t1 == unknown {
+			t1 = t2
+		}
+		v.push(t1)
+	case op == 0x1C:
+		if n := u32(); n != 1 {
+			v.fail("invalid result arity of select")
+			return
+		}
+		t, err := r.byte()
+		if err != nil || !isValueType(t) {
+			v.fail("invalid select type")
+			return
+		}
+		v.popExpect(I32)
+		v.popExpect(t)
+		v.popExpect(t)
+		v.push(t)
+	case op >= 0x20 && op <= 0x22:
+		i := u32()
+		if int(i) >= len(v.locals) {
+			v.fail("unknown local %d", i)
+			return
+		}
+		switch op {
+		case 0x20:
+			v.push(v.locals[i])
+		case 0x21:
+			v.popExpect(v.locals[i])
+		default:
+			v.push(v.popExpect(v.locals[i]))
+		}
+	case op == 0x23 || op == 0x24:
+		i := u32()
+		if int(i) >= len(m.globals) {
+			v.fail("unknown global %d", i)
+			return
+		}
+		g := m.globals[i]
+		if op == 0x23 {
+			v.push(g.typ)
+			return
+		}
+		if !g.mutable {
+			v.fail("global %d is immutable", i)
+			return
+		}
+		v.popExpect(g.typ)
+	case op == 0x25 || op == 0x26:
+		if u32() != 0 || m.table == nil {
+			v.fail("unknown table")
+			return
+		}
+		if op == 0x26 {
+			v.popExpect(FuncRef)
+			v.popExpect(I32)
+			return
+		}
+		v.popExpect(I32)
+		v.push(FuncRef)
+	case op >= 0x28 && op <= 0x35:
+		load := loadTypes[op-0x28]
+		v.memarg(r, load.size)
+		v.popExpect(I32)
+		v.push(load.typ)
+	case op >= 0x36 && op <= 0x3E:
+		store := storeTypes[op-0x36]
+		v.memarg(r, store.size)
+		v.popExpect(store.typ)
+		v.popExpect(I32)
+	case op == 0x3F:
+		v.memoryIndex(r)
+		v.push(I32)
+	case op == 0x40:
+		v.memoryIndex(r)
+		v.popExpect(I32)
+		v.push(I32)
+	case op >= 0x41 && op <= 0x44:
+		var err error
+		switch op {
+		case 0x41:
+			_, err = r.sleb(32)
+		case 0x42:
+			_, err = r.sleb(64)
+		case 0x43:
+			_, err = r.bytes(4)
+		default:
+			_, err = r.bytes(8)
+		}
+		if err != nil {
+			v.fail("%v", err)
+		}
+		v.push([]byte{I32, I64, F32, F64}[op-0x41])
+	case op >= 0x45 && op <= 0xC4:
+		operands, result := numericSignature(op)
+		v.popTypes(operands)
+		v.push(result)
+	case op == 0xD0:
+		t, err := r.byte()
+		if err != nil || !isRefType(t) {
+			v.fail("invalid reference type")
+			return
+		}
+		v.push(t)
+	case op == 0xD1:
+		if t := v.pop(); t != unknown && !isRefType(t) {
+			v.fail("type mismatch: ref.is_null expects a reference")
+		}
+		v.push(I32)
+	case op == 0xD2:
+		if fn := u32(); int(fn) >= len(m.funcs) {
+			v.fail("unknown function %d", fn)
+			return
+		}
+		v.push(FuncRef)
+	case op == 0xFC:
+		v.miscInstruction(r, u32())
+	default:
+		v.fail("unsupported instruction 0x%x", op)
+	}
+}
+
+// miscInstruction validates the 0xFC prefixed saturating truncation and bulk memory instructions
+func (v *validator) miscInstruction(r *reader, sub uint32) {
+	switch {
+	case sub <= 7:
+		v.popExpect(truncSatTypes[sub][0])
+		v.push(truncSatTypes[sub][1])
+		return
+	case sub == 8 || sub == 9:
+		seg, err := r.u32()
+		if err != nil || int(seg) >= len(v.m.data) {
+			v.fail("unknown data segment %d", seg)
+			return
+		}
+		if sub == 9 {
+			return
+		}
+		v.memoryIndex(r)
+	case sub == 10:
+		v.memoryIndex(r)
+		v.memoryIndex(r)
+	case sub == 11:
+		v.memoryIndex(r)
+	default:
+		v.fail("unsupported instruction 0xfc %d", sub)
+		return
+	}
+	v.popTypes([]byte{I32, I32, I32})
+}
@@ -0,0 +1,201 @@
+package wasm
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"rpc-simulator/internal/wasm/wasmtest"
+)
+
+// rawModule assembles a module from already encoded sections
+func rawModule(sections ...[]byte) []byte {
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	for _, s := range sections {
+		module = append(module, s...)
+	}
+	return module
+}
+
+func TestValidateFunctionBodies(t *testing.T) {
+	i32 := []byte{I32}
+	tests := []struct {
+		name    string
+		results []byte
+		memory  int
+		code    []byte
+		want    string
+	}{
+		{"operand type", i32, 1, []byte{0x42, 0x00, 0x42, 0x00, 0x6A, 0x0B}, "type mismatch"},
+		{"stack underflow", nil, 1, []byte{0x6A, 0x1A, 0x0B}, "type mismatch"},
+		{"missing result", i32, 1, []byte{0x0B}, "type mismatch"},
+		{"values remaining", nil, 1, []byte{0x41, 0x01, 0x0B}, "type mismatch"},
+		{"wrong result type", i32, 1, []byte{0x42, 0x01, 0x0B}, "type mismatch"},
+		{"unknown label", nil, 1, []byte{0x0C, 0x01, 0x0B}, "unknown label"},
+		{"unknown local", nil, 1, []byte{0x20, 0x05, 0x1A, 0x0B}, "unknown local"},
+		{"unknown global", nil, 1, []byte{0x23, 0x00, 0x1A, 0x0B}, "unknown global"},
+		{"unknown function", nil, 1, []byte{0x10, 0x09, 0x0B}, "unknown function"},
+		{"unknown table", nil, 1, []byte{0x41, 0x00, 0x11, 0x00, 0x00, 0x0B}, "unknown table"},
+		{"unknown block type", nil, 1, []byte{0x02, 0x09, 0x0B, 0x0B}, "block type"},
+		{"load without memory", nil, -1, []byte{0x41, 0x00, 0x28, 0x02, 0x00, 0x1A, 0x0B}, "unknown memory"},
+		{"grow without memory", nil, -1, []byte{0x41, 0x01, 0x40, 0x00, 0x1A, 0x0B}, "unknown memory"},
+		{"alignment", nil, 1, []byte{0x41, 0x00, 0x28, 0x03, 0x00, 0x1A, 0x0B}, "alignment"},
+		{"if without else", i32, 1, []byte{0x41, 0x01, 0x04, 0x7F, 0x41, 0x01, 0x0B, 0x0B}, "type mismatch"},
+		{"br_table arity", nil, 1, []byte{
+			0x02, 0x7F, 0x02, 0x40, 0x41, 0x00, 0x41, 0x00, 0x0E, 0x01, 0x00, 0x01, 0x0B, 0x41, 0x00, 0x0B, 0x1A, 0x0B}, "arity"},
+		{"select operands", nil, 1, []byte{0x41, 0x00, 0x42, 0x00, 0x41, 0x01, 0x1B, 0x1A, 0x0B}, "select"},
+		{"if condition", nil, 1, []byte{0x42, 0x00, 0x04, 0x40, 0x0B, 0x0B}, "type mismatch"},
+		{"unknown data segment", nil, 1, []byte{0xFC, 0x09, 0x00, 0x0B}, "unknown data segment"},
+	}
+	for _, tt := range tests {
+		module := wasmtest.Module([]wasmtest.Func{{Export: "run", Results: tt.results, Code: tt.code}}, tt.memory, nil)
+		if _, err := Decode(module); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestValidateAcceptsPolymorphicStack(t *testing.T) {
+	i32 := []byte{I32}
+	tests := map[string]struct {
+		results []byte
+		code    []byte
+	}{
+		"unreachable operands": {i32, []byte{0x00, 0x6A, 0x0B}},
+		"unreachable select":   {nil, []byte{0x00, 0x1B, 0x1A, 0x0B}},
+		"code after br":        {i32, []byte{0x02, 0x7F, 0x41, 0x01, 0x0C, 0x00, 0x6A, 0x0B, 0x0B}},
+		"code after return":    {i32, []byte{0x41, 0x01, 0x0F, 0x0B}},
+		"br_table unreachable": {i32, []byte{0x41, 0x00, 0x41, 0x00, 0x0E, 0x00, 0x00, 0x0B}},
+		"if with else":         {i32, []byte{0x41, 0x01, 0x04, 0x7F, 0x41, 0x01, 0x05, 0x41, 0x02, 0x0B, 0x0B}},
+	}
+	for name, tt := range tests {
+		module := wasmtest.Module([]wasmtest.Func{{Export: "run", Results: tt.results, Code: tt.code}}, -1, nil)
+		if _, err := Decode(module); err != nil {
+			t.Errorf("%s: expected a valid module, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateModule(t *testing.T) {
+	empty := wasmtest.Func{Export: "f", Code: []byte{0x0B}}
+	voidType := wasmtest.Section(1, wasmtest.Vec([]byte{0x60, 0x00, 0x00}))
+	oneFunc := wasmtest.Section(3, wasmtest.Vec([]byte{0x00}))
+	body := func(code ...byte) []byte {
+		return wasmtest.Section(10, wasmtest.Vec(append([]byte{byte(len(code) + 1), 0x00}, code...)))
+	}
+	global := func(mutable byte, init ...byte) []byte {
+		return wasmtest.Section(6, wasmtest.Vec(append([]byte{I32, mutable}, init...)))
+	}
+
+	tests := map[string]struct {
+		module []byte
+		want   string
+	}{
+		"duplicate export":  {wasmtest.Module([]wasmtest.Func{empty, empty}, -1, nil), "duplicate export"},
+		"data no memory":    {wasmtest.Module([]wasmtest.Func{empty}, -1, map[uint32]string{0: "x"}), "unknown memory"},
+		"memory too large":  {wasmtest.Module([]wasmtest.Func{empty}, 70000, nil), "memory limits"},
+		"immutable global":  {rawModule(voidType, oneFunc, global(0x00, 0x41, 0x00, 0x0B), body(0x41, 0x01, 0x24, 0x00, 0x0B)), "immutable"},
+		"global init type":  {rawModule(voidType, oneFunc, global(0x00, 0x42, 0x00, 0x0B), body(0x0B)), "constant expression"},
+		"global init reads": {rawModule(voidType, oneFunc, global(0x00, 0x23, 0x00, 0x0B), body(0x0B)), "constant expression"},
+		"start with params": {rawModule(
+			wasmtest.Section(1, wasmtest.Vec([]byte{0x60, 0x01, I32, 0x00})), oneFunc,
+			wasmtest.Section(8, []byte{0x00}), body(0x0B)), "start function"},
+		"unknown export": {rawModule(voidType, oneFunc,
+			wasmtest.Section(7, wasmtest.Vec(append(wasmtest.Name("f"), 0x00, 0x05))), body(0x0B)), "unknown"},
+		"section order":   {rawModule(voidType, body(0x0B), oneFunc), ""},
+		"repeated":        {rawModule(voidType, voidType, oneFunc, body(0x0B)), ""},
+		"missing code":    {rawModule(voidType, oneFunc), ""},
+		"bad local count": {rawModule(voidType, oneFunc, wasmtest.Section(10, wasmtest.Vec([]byte{0x06, 0x01, 0xFF, 0xFF, 0x7F, I32, 0x0B}))), ""},
+	}
+	for name, tt := range tests {
+		if _, err := Decode(tt.module); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected %q, got %v", name, tt.want, err)
+		}
+	}
+
+	valid := rawModule(voidType, oneFunc, global(0x01, 0x41, 0x00, 0x0B), body(0x41, 0x01, 0x24, 0x00, 0x0B))
+	if _, err := Decode(valid); err != nil {
+		t.Errorf("Expected a mutable global to be settable, got %v", err)
+	}
+}
+
+// mutationSeeds are valid modules that exercise tables, calls, branches, memory and data
+func mutationSeeds() [][]byte {
+	return [][]byte{
+		tableModule(),
+		wasmtest.Module([]wasmtest.Func{
+			{Export: "fib", Params: []byte{I32}, Results: []byte{I32}, Code: []byte{
+				0x20, 0x00, 0x41, 0x02, 0x48, 0x04, 0x7F, 0x20, 0x00, 0x05,
+				0x20, 0x00, 0x41, 0x01, 0x6B, 0x10, 0x00, 0x20, 0x00, 0x41, 0x02, 0x6B, 0x10, 0x00, 0x6A, 0x0B, 0x0B}},
+			{Export: "mem", Params: []byte{I32}, Results: []byte{I64}, Locals: []byte{I64}, Code: []byte{
+				0x20, 0x00, 0x42, 0x7E, 0x37, 0x03, 0x00, 0x02, 0x40, 0x20, 0x00, 0x0E, 0x01, 0x00, 0x00, 0x0B,
+				0x20, 0x00, 0x29, 0x03, 0x00, 0x20, 0x01, 0x7C, 0x0B}},
+		}, 1, map[uint32]string{8: "seed"}),
+	}
+}
+
+// runExports decodes a module and invokes every exported function with zero arguments. An
+// interpreter bug surfaces as an internal error, which fails the test.
+func runExports(t *testing.T, module []byte) {
+	m, err := Decode(module)
+	if err != nil {
+		if strings.Contains(err.Error(), "internal error") {
+			t.Fatalf("Decode: %v", err)
+		}
+		return
+	}
+	in, err := m.Instantiate(context.Background(), 2, 10000)
+	if err != nil {
+		if strings.Contains(err.Error(), "internal error") {
+			t.Fatalf("Instantiate: %v", err)
+		}
+		return
+	}
+	for name, export := range m.exports {
+		if export.kind != 0 {
+			continue
+		}
+		typ := m.types[m.funcs[export.index].typeIdx]
+		if _, err := in.Invoke(name, make([]uint64, len(typ.Params))...); err != nil && strings.Contains(err.Error(), "internal error") {
+			t.Fatalf("Invoke %s: %v", name, err)
+		}
+	}
+}
+
+// TestMutatedModulesNeverPanic feeds randomly corrupted modules through decoding and execution.
+// Anything the validator accepts must run to a result or a trap, never an interpreter error.
+func TestMutatedModulesNeverPanic(t *testing.T) {
+	seeds := mutationSeeds()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		module := append([]byte(nil), seeds[i%len(seeds)]...)
+		for n := 1 + rng.Intn(3); n > 0; n-- {
+			module[8+rng.Intn(len(module)-8)] = byte(rng.Intn(256))
+		}
+		runExports(t, module)
+	}
+}
+
+// FuzzDecodeAndInvoke is the open-ended version of TestMutatedModulesNeverPanic; run it with
+// go test -fuzz=FuzzDecodeAndInvoke ./internal/wasm
+func FuzzDecodeAndInvoke(f *testing.F) {
+	for _, seed := range mutationSeeds() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, module []byte) {
+		runExports(t, module)
+	})
+}
+
+func TestInterpreterPanicsBecomeErrors(t *testing.T) {
+	// A module that skipped validation points its export at a function that doesn't exist
+	m := &Module{exports: map[string]exportDesc{"run": {kind: 0, index: 3}}, start: -1}
+	in, err := m.Instantiate(context.Background(), 1, 1000)
+	if err != nil {
+		t.Fatalf("Failed to instantiate: %v", err)
+	}
+	if _, err := in.Invoke("run"); err == nil || !strings.Contains(err.Error(), "internal error") {
+		t.Errorf("Expected an internal error, got %v", err)
+	}
+}
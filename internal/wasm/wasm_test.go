@@ -0,0 +1,160 @@
+package wasm
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"rpc-simulator/internal/wasm/wasmtest"
+)
+
+// runTestFunc instantiates the module and invokes one export
+func runTestFunc(t *testing.T, ctx context.Context, funcs []wasmtest.Func, name string, args ...uint64) ([]uint64, error) {
+	t.Helper()
+	module, err := Decode(wasmtest.Module(funcs, 1, nil))
+	if err != nil {
+		t.Fatalf("Failed to decode module: %v", err)
+	}
+	in, err := module.Instantiate(ctx, 2, 100000)
+	if err != nil {
+		t.Fatalf("Failed to instantiate module: %v", err)
+	}
+	return in.Invoke(name, args...)
+}
+
+func TestWasmInterpreter(t *testing.T) {
+	i32, i64, f64 := []byte{I32}, []byte{I64}, []byte{F64}
+	funcs := []wasmtest.Func{
+		{Export: "fib", Params: i32, Results: i32, Code: []byte{
+			0x20, 0x00, 0x41, 0x02, 0x48, 0x04, 0x7F, // if n < 2
+			0x20, 0x00,
+			0x05, // else fib(n-1) + fib(n-2)
+			0x20, 0x00, 0x41, 0x01, 0x6B, 0x10, 0x00,
+			0x20, 0x00, 0x41, 0x02, 0x6B, 0x10, 0x00, 0x6A,
+			0x0B, 0x0B,
+		}},
+		{Export: "factorial", Params: i64, Results: i64, Locals: i64, Code: []byte{
+			0x42, 0x01, 0x21, 0x01,
+			0x02, 0x40, 0x03, 0x40,
+			0x20, 0x00, 0x50, 0x0D, 0x01, // break when n == 0
+			0x20, 0x01, 0x20, 0x00, 0x7E, 0x21, 0x01,
+			0x20, 0x00, 0x42, 0x01, 0x7D, 0x21, 0x00,
+			0x0C, 0x00,
+			0x0B, 0x0B,
+			0x20, 0x01, 0x0B,
+		}},
+		{Export: "switch", Params: i32, Results: i32, Code: []byte{
+			0x02, 0x40, 0x02, 0x40, 0x02, 0x40,
+			0x20, 0x00, 0x0E, 0x02, 0x00, 0x01, 0x02,
+			0x0B, 0x41, 0x0A, 0x0F,
+			0x0B, 0x41, 0x14, 0x0F,
+			0x0B, 0x41, 0x1E, 0x0B,
+		}},
+		{Export: "store", Params: i32, Results: i64, Code: []byte{
+			0x20, 0x00, 0x42, 0x7E, 0x37, 0x03, 0x08, // i64.store offset=8 of -2
+			0x20, 0x00, 0x2D, 0x00, 0x08, 0xAD, 0x0B, // i32.load8_u offset=8, extend
+		}},
+		{Export: "grow", Params: i32, Results: i32, Code: []byte{0x20, 0x00, 0x40, 0x00, 0x0B}},
+		{Export: "sqrt", Params: f64, Results: i64, Code: []byte{0x20, 0x00, 0x9F, 0xB0, 0x0B}},
+	}
+
+	tests := []struct {
+		name string
+		args []uint64
+		want uint64
+	}{
+		{"fib", []uint64{15}, 610},
+		{"factorial", []uint64{20}, 2432902008176640000},
+		{"switch", []uint64{0}, 10},
+		{"switch", []uint64{1}, 20},
+		{"switch", []uint64{7}, 30},
+		{"store", []uint64{100}, 0xFE},
+		{"grow", []uint64{1}, 1},
+		{"grow", []uint64{5}, math.MaxUint32},
+		{"sqrt", []uint64{math.Float64bits(81)}, 9},
+	}
+	for _, tt := range tests {
+		results, err := runTestFunc(t, context.Background(), funcs, tt.name, tt.args...)
+		if err != nil {
+			t.Errorf("%s(%v): unexpected error: %v", tt.name, tt.args, err)
+			continue
+		}
+		if len(results) != 1 || results[0] != tt.want {
+			t.Errorf("%s(%v): expected %d, got %v", tt.name, tt.args, tt.want, results)
+		}
+	}
+}
+
+func TestWasmTraps(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code []byte
+		want string
+	}{
+		{"divide by zero", context.Background(), []byte{0x41, 0x01, 0x41, 0x00, 0x6D, 0x1A, 0x0B}, "integer divide by zero"},
+		{"unreachable", context.Background(), []byte{0x00, 0x0B}, "unreachable"},
+		{"out of bounds", context.Background(), []byte{0x41, 0x7F, 0x28, 0x02, 0x00, 0x1A, 0x0B}, "out of bounds memory access"},
+		{"infinite loop", context.Background(), []byte{0x03, 0x40, 0x0C, 0x00, 0x0B, 0x0B}, "fuel exhausted"},
+		{"infinite recursion", context.Background(), []byte{0x10, 0x00, 0x0B}, "call stack exhausted"},
+		{"cancelled", cancelled, []byte{0x03, 0x40, 0x0C, 0x00, 0x0B, 0x0B}, "cancelled"},
+	}
+	for _, tt := range tests {
+		_, err := runTestFunc(t, tt.ctx, []wasmtest.Func{{Export: "run", Code: tt.code}}, "run")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected trap %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestWasmDecodeRejectsInvalidModules(t *testing.T) {
+	valid := wasmtest.Module([]wasmtest.Func{{Export: "run", Code: []byte{0x0B}}}, -1, nil)
+
+	withImport := append([]byte("\x00asm\x01\x00\x00\x00"), wasmtest.Section(2, wasmtest.Vec(
+		append(append(wasmtest.Name("env"), wasmtest.Name("log")...), 0x00, 0x00)))...)
+
+	tests := map[string][]byte{
+		"not wasm":    []byte("hello world"),
+		"truncated":   valid[:len(valid)-2],
+		"imports":     withImport,
+		"bad opcode":  wasmtest.Module([]wasmtest.Func{{Code: []byte{0xFD, 0x0B}}}, -1, nil),
+		"missing end": wasmtest.Module([]wasmtest.Func{{Code: []byte{0x01}}}, -1, nil),
+		"underflow":   wasmtest.Module([]wasmtest.Func{{Code: []byte{0x1A, 0x0B}}}, -1, nil),
+	}
+	for name, module := range tests {
+		if _, err := Decode(module); err == nil {
+			t.Errorf("%s: expected decode error", name)
+		}
+	}
+	if _, err := Decode(valid); err != nil {
+		t.Errorf("Expected valid module to decode, got %v", err)
+	}
+}
+
+func TestWasmDataSegments(t *testing.T) {
+	funcs := []wasmtest.Func{{Export: "read", Params: []byte{I32}, Results: []byte{I32}, Code: []byte{0x20, 0x00, 0x28, 0x02, 0x00, 0x0B}}}
+	module, err := Decode(wasmtest.Module(funcs, 1, map[uint32]string{16: "\x01\x02\x03\x04"}))
+	if err != nil {
+		t.Fatalf("Failed to decode module: %v", err)
+	}
+	in, err := module.Instantiate(context.Background(), 1, 1000)
+	if err != nil {
+		t.Fatalf("Failed to instantiate module: %v", err)
+	}
+	results, err := in.Invoke("read", 16)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := uint64(binary.LittleEndian.Uint32([]byte{1, 2, 3, 4})); results[0] != want {
+		t.Errorf("Expected %x, got %x", want, results[0])
+	}
+
+	if _, err := module.Instantiate(context.Background(), 0, 1000); err == nil {
+		t.Error("Expected instantiation to fail when the module needs more memory than allowed")
+	}
+}
@@ -0,0 +1,105 @@
+// Package wasmtest assembles small WebAssembly modules for tests of the wasm package and its users
+package wasmtest
+
+// Func describes a function of a module assembled by Module
+type Func struct {
+	Export  string
+	Params  []byte
+	Results []byte
+	Locals  []byte
+	Code    []byte // Instructions including the final end
+}
+
+// ULEB encodes v as an unsigned LEB128 integer
+func ULEB(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7F)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+// SLEB encodes v as a signed LEB128 integer
+func SLEB(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7F)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// Vec encodes a vector of already encoded items
+func Vec(items ...[]byte) []byte {
+	out := ULEB(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+// Section encodes a section with its id and size
+func Section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, ULEB(uint64(len(content)))...), content...)
+}
+
+// Name encodes a length prefixed name
+func Name(s string) []byte {
+	return append(ULEB(uint64(len(s))), s...)
+}
+
+// Module assembles a module with one type per function, an optional exported memory
+// (memoryPages < 0 = none) and active data segments keyed by offset
+func Module(funcs []Func, memoryPages int, data map[uint32]string) []byte {
+	var types, funcIdx, exports, code [][]byte
+	for i, f := range funcs {
+		types = append(types, append(append([]byte{0x60}, Vec(byteItems(f.Params)...)...), Vec(byteItems(f.Results)...)...))
+		funcIdx = append(funcIdx, ULEB(uint64(i)))
+		if f.Export != "" {
+			exports = append(exports, append(append(Name(f.Export), 0x00), ULEB(uint64(i))...))
+		}
+		var locals [][]byte
+		for _, l := range f.Locals {
+			locals = append(locals, []byte{0x01, l})
+		}
+		body := append(Vec(locals...), f.Code...)
+		code = append(code, append(ULEB(uint64(len(body))), body...))
+	}
+
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	module = append(module, Section(1, Vec(types...))...)
+	module = append(module, Section(3, Vec(funcIdx...))...)
+	if memoryPages >= 0 {
+		module = append(module, Section(5, Vec(append([]byte{0x00}, ULEB(uint64(memoryPages))...)))...)
+		exports = append(exports, append(Name("memory"), 0x02, 0x00))
+	}
+	module = append(module, Section(7, Vec(exports...))...)
+	module = append(module, Section(10, Vec(code...))...)
+	if len(data) > 0 {
+		var segments [][]byte
+		for offset, content := range data {
+			seg := append([]byte{0x00, 0x41}, SLEB(int64(offset))...)
+			seg = append(seg, 0x0B)
+			seg = append(seg, Name(content)...)
+			segments = append(segments, seg)
+		}
+		module = append(module, Section(11, Vec(segments...))...)
+	}
+	return module
+}
+
+func byteItems(b []byte) [][]byte {
+	items := make([][]byte, len(b))
+	for i := range b {
+		items[i] = b[i : i+1]
+	}
+	return items
+}
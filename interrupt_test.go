@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func postInterrupt(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/control/block/interrupt", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handleInterruptBlocks(w, req)
+	return w
+}
+
+func resetRunStates() {
+	for _, chain := range supportedChains {
+		chain.runState.Reset()
+	}
+	solanaNode.runState.Reset()
+}
+
+func TestInterruptAllChains(t *testing.T) {
+	defer resetRunStates()
+	supportedChains["polygon"].runState.Transition(RunEventPause)
+
+	w := postInterrupt(t, `{"duration_seconds": 0.1}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Interrupted []string          `json:"interrupted"`
+		Skipped     map[string]string `json:"skipped"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Interrupted) != len(supportedChains) {
+		t.Errorf("Expected %d interrupted chains, got %v", len(supportedChains), resp.Interrupted)
+	}
+	if _, ok := resp.Skipped["polygon"]; !ok {
+		t.Errorf("Expected paused polygon to be skipped, got %v", resp.Skipped)
+	}
+	if solanaNode.runState.State() != RunStateInterrupted {
+		t.Errorf("Expected solana interrupted, got %s", solanaNode.runState.State())
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if state := supportedChains["ethereum"].runState.State(); state != RunStateRunning {
+		t.Errorf("Expected ethereum to resume, got %s", state)
+	}
+	if state := supportedChains["polygon"].runState.State(); state != RunStatePaused {
+		t.Errorf("Expected polygon to stay paused, got %s", state)
+	}
+}
+
+func TestInterruptChainGroupIndependentTimers(t *testing.T) {
+	defer resetRunStates()
+
+	if w := postInterrupt(t, `{"chain": "ethereum", "duration_seconds": 0.3}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w := postInterrupt(t, `{"chains": ["optimism", "solana"], "duration_seconds": 0.1}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if state := supportedChains["polygon"].runState.State(); state != RunStateRunning {
+		t.Errorf("Expected polygon outside the group to keep running, got %s", state)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if state := supportedChains["optimism"].runState.State(); state != RunStateRunning {
+		t.Errorf("Expected optimism to resume, got %s", state)
+	}
+	if state := supportedChains["ethereum"].runState.State(); state != RunStateInterrupted {
+		t.Errorf("Expected ethereum to stay interrupted, got %s", state)
+	}
+
+	if w := postInterrupt(t, `{"chains": ["ethereum", "unknown"], "duration_seconds": 1}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown chain in group, got %d", w.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Latency placements control where configured latency is applied
+const (
+	LatencyPlacementPre   = "pre"   // Before the handler runs (request path); the default
+	LatencyPlacementPost  = "post"  // After the handler runs (response path), also delays notifications
+	LatencyPlacementSplit = "split" // Half before and half after the handler
+)
+
+// LatencyPlacements lists all supported latency placements
+var LatencyPlacements = []string{LatencyPlacementPre, LatencyPlacementPost, LatencyPlacementSplit}
+
+// isValidLatencyPlacement returns true for a supported placement or empty (default)
+func isValidLatencyPlacement(placement string) bool {
+	if placement == "" {
+		return true
+	}
+	for _, p := range LatencyPlacements {
+		if p == placement {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLatency divides latency into the part applied before the handler and the part applied after
+func splitLatency(latency time.Duration, placement string) (pre, post time.Duration) {
+	switch placement {
+	case LatencyPlacementPost:
+		return 0, latency
+	case LatencyPlacementSplit:
+		pre = latency / 2
+		return pre, latency - pre
+	default:
+		return latency, 0
+	}
+}
+
+// latencyMu guards the Latency, LatencyDistribution and LatencyPlacement fields of every chain,
+// which the control API, sim_setLatency, scenarios and the watchdog change while requests and
+// broadcast workers read them
+var latencyMu sync.RWMutex
+
+// latencyFields returns where a chain keeps its latency settings; ok is false for an unknown chain
+func latencyFields(chainId string) (latency *time.Duration, distribution **LatencyDistribution, placement *string, ok bool) {
+	if chainId == "501" {
+		return &solanaNode.Latency, &solanaNode.LatencyDistribution, &solanaNode.LatencyPlacement, true
+	}
+	chain, ok := supportedChains[chainIdToName[chainId]]
+	if !ok {
+		return nil, nil, nil, false
+	}
+	return &chain.Latency, &chain.LatencyDistribution, &chain.LatencyPlacement, true
+}
+
+// latencySettings returns a consistent snapshot of a chain's latency settings
+func latencySettings(chainId string) (latency time.Duration, distribution *LatencyDistribution, placement string) {
+	latencyMu.RLock()
+	defer latencyMu.RUnlock()
+	if l, d, p, ok := latencyFields(chainId); ok {
+		return *l, *d, *p
+	}
+	return 0, nil, ""
+}
+
+// setLatency sets a chain's fixed latency and, unless placement is nil, where it is applied.
+// It returns false for an unknown chain.
+func setLatency(chainId string, latency time.Duration, placement *string) bool {
+	latencyMu.Lock()
+	defer latencyMu.Unlock()
+	l, _, p, ok := latencyFields(chainId)
+	if !ok {
+		return false
+	}
+	*l = latency
+	if placement != nil {
+		*p = *placement
+	}
+	return true
+}
+
+// notificationLatency returns the response-path latency applied to subscription notifications of a chain
+func notificationLatency(chainId string) time.Duration {
+	latency, _, placement := latencySettings(chainId)
+	_, post := splitLatency(latency, placement)
+	return post
+}
+
+// sleepContext sleeps for d, returning early with the context error when ctx is cancelled
+// so simulated delays don't outlive the client that caused them
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
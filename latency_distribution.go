@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// LatencyBucket is one histogram bucket: a share of requests that take at most LeMs
+type LatencyBucket struct {
+	LeMs   float64 `yaml:"le_ms" json:"le_ms"`   // Upper bound in milliseconds
+	Weight float64 `yaml:"weight" json:"weight"` // Relative share of requests in this bucket
+}
+
+// LatencyDistribution samples a latency per request from a histogram, e.g. one measured on
+// a real provider, instead of applying a fixed latency. A sample is uniform within the
+// chosen bucket, between the previous bucket's bound (or 0) and the bucket's own.
+type LatencyDistribution struct {
+	Buckets []LatencyBucket `yaml:"buckets" json:"buckets"`
+}
+
+// validate checks that bounds ascend and weights are usable
+func (d *LatencyDistribution) validate() error {
+	if d == nil {
+		return nil
+	}
+	total, previous := 0.0, 0.0
+	for i, bucket := range d.Buckets {
+		if bucket.LeMs < previous || (i > 0 && bucket.LeMs == previous) {
+			return fmt.Errorf("bucket bounds must be non-negative and ascending")
+		}
+		if bucket.Weight < 0 {
+			return fmt.Errorf("bucket weights must be non-negative")
+		}
+		total += bucket.Weight
+		previous = bucket.LeMs
+	}
+	if total <= 0 {
+		return fmt.Errorf("a latency distribution needs at least one bucket with a positive weight")
+	}
+	return nil
+}
+
+// sample draws a latency from the distribution
+func (d *LatencyDistribution) sample() time.Duration {
+	total := 0.0
+	for _, bucket := range d.Buckets {
+		total += bucket.Weight
+	}
+	pick := rand.Float64() * total
+	lower := 0.0
+	for _, bucket := range d.Buckets {
+		if pick < bucket.Weight {
+			ms := lower + rand.Float64()*(bucket.LeMs-lower)
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+		pick -= bucket.Weight
+		lower = bucket.LeMs
+	}
+	return time.Duration(lower * float64(time.Millisecond))
+}
+
+// LatencyProfile holds measured latency distributions per chain and method, the import
+// format produced by cmd/importlatency from gateway histogram metrics
+type LatencyProfile struct {
+	Chains map[string]*ChainLatencyProfile `yaml:"chains" json:"chains"` // Keyed by chain name or chain ID
+}
+
+// ChainLatencyProfile holds the distributions of one chain
+type ChainLatencyProfile struct {
+	Default *LatencyDistribution            `yaml:"default,omitempty" json:"default,omitempty"` // All methods without their own distribution
+	Methods map[string]*LatencyDistribution `yaml:"methods,omitempty" json:"methods,omitempty"`
+}
+
+// resolveProfileChain returns the chain name for a profile key, which may be a name or an ID
+func resolveProfileChain(key string) (string, bool) {
+	if getChain(key) != nil {
+		return key, true
+	}
+	if name, ok := chainIdToName[key]; ok && getChain(name) != nil {
+		return name, true
+	}
+	return "", false
+}
+
+// validate checks every chain and distribution of the profile
+func (p *LatencyProfile) validate() error {
+	for key, chainProfile := range p.Chains {
+		if _, ok := resolveProfileChain(key); !ok {
+			return fmt.Errorf("unknown chain: %s", key)
+		}
+		if chainProfile == nil {
+			return fmt.Errorf("chain %s: empty profile", key)
+		}
+		if err := chainProfile.Default.validate(); err != nil {
+			return fmt.Errorf("chain %s: %v", key, err)
+		}
+		for method, distribution := range chainProfile.Methods {
+			if distribution == nil {
+				return fmt.Errorf("chain %s, method %s: empty distribution", key, method)
+			}
+			if err := distribution.validate(); err != nil {
+				return fmt.Errorf("chain %s, method %s: %v", key, method, err)
+			}
+		}
+	}
+	return nil
+}
+
+// chainLatencySettings returns where a chain keeps its latency distribution and method overrides
+func chainLatencySettings(name string) (**LatencyDistribution, *map[string]*MethodOverride) {
+	if name == "solana" {
+		return &solanaNode.LatencyDistribution, &solanaNode.MethodOverrides
+	}
+	chain := supportedChains[name]
+	return &chain.LatencyDistribution, &chain.MethodOverrides
+}
+
+// apply sets the distributions of a validated profile on the chains and their method
+// overrides, creating overrides where needed; the caller holds latencyMu
+func (p *LatencyProfile) apply() {
+	for key, chainProfile := range p.Chains {
+		name, _ := resolveProfileChain(key)
+		distribution, overrides := chainLatencySettings(name)
+		if chainProfile.Default != nil {
+			*distribution = chainProfile.Default
+		}
+		if len(chainProfile.Methods) > 0 && *overrides == nil {
+			*overrides = make(map[string]*MethodOverride)
+		}
+		for method, methodDistribution := range chainProfile.Methods {
+			override := (*overrides)[method]
+			if override == nil {
+				override = &MethodOverride{}
+				(*overrides)[method] = override
+			}
+			override.LatencyDistribution = methodDistribution
+		}
+		log.Printf("Imported latency profile for chain %s (%d methods)", name, len(chainProfile.Methods))
+	}
+}
+
+// handleLatencyProfile imports a latency profile; chains listed in reset first lose all
+// their distributions
+func handleLatencyProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		LatencyProfile
+		Reset []string `json:"reset"` // Chains whose distributions are removed instead
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resets := make([]string, len(request.Reset))
+	for i, key := range request.Reset {
+		name, ok := resolveProfileChain(key)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Chain not found: %s", key), http.StatusNotFound)
+			return
+		}
+		resets[i] = name
+	}
+	if err := request.LatencyProfile.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	latencyMu.Lock()
+	for _, name := range resets {
+		distribution, overrides := chainLatencySettings(name)
+		*distribution = nil
+		for _, override := range *overrides {
+			override.LatencyDistribution = nil
+		}
+		log.Printf("Removed latency distributions of chain %s", name)
+	}
+	request.LatencyProfile.apply()
+	latencyMu.Unlock()
+
+	persistChainConfig()
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLatencyDistributionSample(t *testing.T) {
+	distribution := &LatencyDistribution{Buckets: []LatencyBucket{
+		{LeMs: 10, Weight: 0},
+		{LeMs: 20, Weight: 1},
+		{LeMs: 50, Weight: 0},
+	}}
+	if err := distribution.validate(); err != nil {
+		t.Fatalf("Expected a valid distribution, got %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		if latency := distribution.sample(); latency < 10*time.Millisecond || latency > 20*time.Millisecond {
+			t.Fatalf("Expected samples within the only weighted bucket, got %v", latency)
+		}
+	}
+
+	for name, buckets := range map[string][]LatencyBucket{
+		"empty":      nil,
+		"descending": {{LeMs: 20, Weight: 1}, {LeMs: 10, Weight: 1}},
+		"duplicate":  {{LeMs: 10, Weight: 1}, {LeMs: 10, Weight: 1}},
+		"negative":   {{LeMs: 10, Weight: -1}, {LeMs: 20, Weight: 2}},
+		"no weight":  {{LeMs: 10, Weight: 0}},
+	} {
+		if err := (&LatencyDistribution{Buckets: buckets}).validate(); err == nil {
+			t.Errorf("%s: expected an invalid distribution", name)
+		}
+	}
+}
+
+func latencyProfileRequest(body string) int {
+	w := httptest.NewRecorder()
+	handleLatencyProfile(w, httptest.NewRequest(http.MethodPost, "/control/latency/profile", bytes.NewBufferString(body)))
+	return w.Code
+}
+
+func TestLatencyProfileImport(t *testing.T) {
+	withTempConfig(t)
+	chain := supportedChains["base"]
+	originalLatency := chain.Latency
+	defer func() {
+		setLatency("8453", originalLatency, nil)
+		latencyMu.Lock()
+		chain.LatencyDistribution = nil
+		latencyMu.Unlock()
+		delete(chain.MethodOverrides, "eth_call")
+	}()
+	setLatency("8453", time.Second, nil)
+
+	profile := `{"chains": {"8453": {
+		"default": {"buckets": [{"le_ms": 5, "weight": 1}]},
+		"methods": {"eth_call": {"buckets": [{"le_ms": 100, "weight": 0}, {"le_ms": 200, "weight": 1}]}}
+	}}}`
+	if code := latencyProfileRequest(profile); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if latency := methodLatency(chain.Latency, chain.LatencyDistribution, chain.MethodOverrides["eth_blockNumber"]); latency > 5*time.Millisecond {
+		t.Errorf("Expected the chain distribution to replace the fixed latency, got %v", latency)
+	}
+	if latency := methodLatency(chain.Latency, chain.LatencyDistribution, chain.MethodOverrides["eth_call"]); latency < 100*time.Millisecond || latency > 200*time.Millisecond {
+		t.Errorf("Expected eth_call latency from its own distribution, got %v", latency)
+	}
+
+	// An invalid distribution rejects the whole profile, including resets
+	invalid := `{"reset": ["base"], "chains": {"base": {"methods": {"eth_call": {"buckets": []}}}, "polygon": {"default": {"buckets": [{"le_ms": 5, "weight": 1}]}}}}`
+	if code := latencyProfileRequest(invalid); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid distribution, got %d", code)
+	}
+	if chain.LatencyDistribution == nil || supportedChains["polygon"].LatencyDistribution != nil {
+		t.Error("Expected no chain to change when the profile is invalid")
+	}
+	if code := latencyProfileRequest(`{"chains": {"unknown": {}}}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown chain, got %d", code)
+	}
+
+	if code := latencyProfileRequest(`{"reset": ["base"]}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if chain.LatencyDistribution != nil || chain.MethodOverrides["eth_call"].LatencyDistribution != nil {
+		t.Error("Expected reset to remove the chain's distributions")
+	}
+	if latency := methodLatency(chain.Latency, chain.LatencyDistribution, chain.MethodOverrides["eth_call"]); latency != time.Second {
+		t.Errorf("Expected the fixed latency after reset, got %v", latency)
+	}
+}
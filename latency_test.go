@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSplitLatency(t *testing.T) {
+	tests := []struct {
+		placement string
+		wantPre   time.Duration
+		wantPost  time.Duration
+	}{
+		{"", 100 * time.Millisecond, 0},
+		{LatencyPlacementPre, 100 * time.Millisecond, 0},
+		{LatencyPlacementPost, 0, 100 * time.Millisecond},
+		{LatencyPlacementSplit, 50 * time.Millisecond, 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		pre, post := splitLatency(100*time.Millisecond, tt.placement)
+		if pre != tt.wantPre || post != tt.wantPost {
+			t.Errorf("placement %q: expected %v/%v, got %v/%v", tt.placement, tt.wantPre, tt.wantPost, pre, post)
+		}
+	}
+}
+
+func TestPostLatencyDelaysResponsesAndNotifications(t *testing.T) {
+	placement := LatencyPlacementPost
+	defer func() {
+		placement = ""
+		setLatency("1", 0, &placement)
+	}()
+	setLatency("1", 100*time.Millisecond, &placement)
+
+	conn := NewMockWSConn()
+	defer subManager.CleanupConnection(conn)
+
+	request := JSONRPCRequest{
+		JsonRPC: "2.0",
+		Method:  "eth_subscribe",
+		Params:  []interface{}{"newHeads"},
+		ID:      1,
+	}
+	data, _ := json.Marshal(request)
+
+	start := time.Now()
+	if _, err := handleEVMRequest(context.Background(), data, conn, "1"); err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected subscription confirmation to be delayed, took %v", elapsed)
+	}
+
+	hasBlock := func() bool {
+		for _, msg := range conn.GetMessages() {
+			if strings.Contains(string(msg), `"number":"0x2a"`) {
+				return true
+			}
+		}
+		return false
+	}
+
+	subManager.BroadcastNewBlock("1", 42)
+	if hasBlock() {
+		t.Error("Expected notification to be delayed by response-path latency")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if !hasBlock() {
+		t.Error("Expected delayed notification for block 42")
+	}
+}
+
+func TestCancelledContextAbortsLatency(t *testing.T) {
+	defer func() { setLatency("1", 0, nil) }()
+	setLatency("1", 10*time.Second, nil)
+	setLatency("501", 10*time.Second, nil)
+	defer func() { setLatency("501", 0, nil) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	data := []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`)
+	start := time.Now()
+	if _, err := handleEVMRequest(ctx, data, NewMockWSConn(), "1"); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	data = []byte(`{"jsonrpc":"2.0","method":"getSlot","params":[],"id":1}`)
+	if _, err := handleSolanaRequest(ctx, data, NewMockWSConn()); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected latency to be cut short, took %v", elapsed)
+	}
+}
+
+func TestWebSocketDisconnectAbortsLatency(t *testing.T) {
+	defer func() { setLatency("10", 0, nil) }()
+	setLatency("10", 10*time.Second, nil)
+
+	server := httptest.NewServer(http.HandlerFunc(handleChainWebSocket))
+	defer server.Close()
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/chain/10", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	if err := client.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	client.Close()
+
+	// The handler only returns, releasing the connection, once the latency sleep is aborted
+	deadline := time.Now().Add(time.Second)
+	for connTracker.GetConnectionCount("10") > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the in-flight request to be abandoned after the client disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testLogFixtures = `- event: Transfer(address,address,uint256)
+  address: 0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48
+  topics:
+    - 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef
+    - 0x00000000000000000000000052fdfc072182654f163f5f0f9a621d729566c74d
+    - 0x00000000000000000000000010037c4d7bbb0407d1e2c64981855ad8681d0d86
+  data: 0x000000000000000000000000000000000000000018d2fe902811a55810cd9672
+- event: Approval(address,address,uint256)
+  address: 0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48
+  topics:
+    - 0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925
+  data: 0x
+`
+
+func TestLoadLogFixtures(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "fixtures.yaml")
+	if err := os.WriteFile(filename, []byte(testLogFixtures), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures, err := loadLogFixtures(filename)
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+	if len(fixtures) != 2 {
+		t.Fatalf("Expected 2 fixtures, got %d", len(fixtures))
+	}
+
+	chain := &EVMChain{fileLogFixtures: fixtures}
+	for i := uint64(0); i < 4; i++ {
+		logEvent := chain.logEventForBlock(10, 0, i)
+		expected := fixtures[i%2]
+		if logEvent.Topics[0] != expected.Topics[0] || logEvent.Data != expected.Data {
+			t.Errorf("Log %d: expected fixture %s, got topic0 %s", i, expected.Event, logEvent.Topics[0])
+		}
+		if logEvent.BlockNumber != 10 || logEvent.LogIndex != i {
+			t.Errorf("Log %d: expected block 10 and log index %d, got %d/%d", i, i, logEvent.BlockNumber, logEvent.LogIndex)
+		}
+	}
+
+	// Without fixtures logs stay zero-filled
+	empty := (&EVMChain{}).logEventForBlock(10, 0, 0)
+	if empty.Topics[0] != "0x0000000000000000000000000000000000000000000000000000000000000000" {
+		t.Errorf("Expected zero topic, got %s", empty.Topics[0])
+	}
+}
+
+func TestLoadLogFixturesRejectsInvalid(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "fixtures.yaml")
+	os.WriteFile(filename, []byte("- address: 0x1234\n  topics: []\n  data: 0x\n"), 0644)
+	if _, err := loadLogFixtures(filename); err == nil {
+		t.Error("Expected error for short address")
+	}
+}
+
+func TestSetLogFixtures(t *testing.T) {
+	withTempConfig(t)
+	chain := supportedChains["ethereum"]
+	defer func() {
+		chain.LogFixtures = nil
+	}()
+
+	body := `{"chain": "ethereum", "fixtures": [{"address": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", "topics": ["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"], "data": "0x"}]}`
+	w := httptest.NewRecorder()
+	handleSetLogFixtures(w, httptest.NewRequest(http.MethodPost, "/control/chain/log-fixtures", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(chain.LogFixtures) != 1 {
+		t.Fatalf("Expected 1 fixture, got %d", len(chain.LogFixtures))
+	}
+
+	invalid := `{"chain": "ethereum", "fixtures": [{"address": "0xa0b8", "topics": [], "data": "0x"}]}`
+	w = httptest.NewRecorder()
+	handleSetLogFixtures(w, httptest.NewRequest(http.MethodPost, "/control/chain/log-fixtures", bytes.NewBufferString(invalid)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -1,13 +1,14 @@
 package main
 
 import (
-	"encoding/hex"
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -62,95 +63,72 @@ func main() {
 			}
 
 			for {
-				time.Sleep(c.BlockInterval)
-				// Check if blocks are interrupted
-				if atomic.LoadUint32(&c.BlockInterrupt) == 1 {
-					continue
-				}
-				// Check if blocks are paused
-				if atomic.LoadUint32(&c.BlockIncrement) == 0 {
-					newBlock := atomic.AddUint64(&c.BlockNumber, 1)
-
-					// Update safe block (latest - 32)
-					if newBlock > 32 {
-						atomic.StoreUint64(&c.SafeBlockNumber, newBlock-32)
-					} else {
-						atomic.StoreUint64(&c.SafeBlockNumber, 0)
-					}
-
-					// Update finalized block (latest - 64)
-					if newBlock > 64 {
-						atomic.StoreUint64(&c.FinalizedBlockNumber, newBlock-64)
-					} else {
-						atomic.StoreUint64(&c.FinalizedBlockNumber, 0)
-					}
-
-					subManager.BroadcastNewBlock(chainId, newBlock)
-
-					// Generate and broadcast log events per block, spread across the block interval
-					// In a real implementation, you would generate logs based on actual contract events
-					go func(blockNum uint64, interval time.Duration, logsPerBlock int) {
-						if logsPerBlock <= 0 {
-							return
-						}
-						logInterval := interval / time.Duration(logsPerBlock)
-						for i := 0; i < logsPerBlock; i++ {
-							if i > 0 {
-								time.Sleep(logInterval)
-							}
-							logIndex := atomic.AddUint64(&c.LogIndex, 1) - 1
-							logEvent := LogEvent{
-								Address:     "0x" + hex.EncodeToString(make([]byte, 20)),
-								Topics:      []string{"0x" + hex.EncodeToString(make([]byte, 32))},
-								Data:        "0x" + hex.EncodeToString(make([]byte, 32)),
-								BlockNumber: blockNum,
-								TxHash:      "0x" + hex.EncodeToString(make([]byte, 32)),
-								TxIndex:     uint64(i),
-								BlockHash:   "0x" + hex.EncodeToString(make([]byte, 32)),
-								LogIndex:    logIndex,
-								Removed:     false,
-							}
-							subManager.BroadcastNewLog(chainId, logEvent)
-						}
-					}(newBlock, c.BlockInterval, c.LogsPerBlock)
+				interval := c.BlockInterval
+				scheduled := time.Now().Add(interval)
+				time.Sleep(interval)
+				// Only produce blocks while running (not paused or interrupted) and automining
+				if c.runState.IsRunning() && !c.ManualMining {
+					produceMeasured(chainId, interval, scheduled, func() {
+						c.produceBlock(chainId, nil)
+					})
 				}
 			}
 		}(chainName, chain)
 	}
 
+	// Apply Solana subscription id reuse simulation from config
+	subManager.SetSolanaIDReuse(solanaNode.SubscriptionIDReuse, solanaNode.UnsubscribeGrace)
+
 	// Start Solana slot incrementer
 	go func() {
 		for {
-			time.Sleep(solanaNode.SlotInterval)
-			// Check if slots are interrupted
-			if atomic.LoadUint32(&solanaNode.BlockInterrupt) == 1 {
-				continue
-			}
-			// Check if slots are paused
-			if atomic.LoadUint32(&solanaNode.SlotIncrement) == 0 {
-				newSlot := atomic.AddUint64(&solanaNode.SlotNumber, 1)
-				subManager.BroadcastNewBlock("501", newSlot)
+			interval := solanaNode.SlotInterval
+			scheduled := time.Now().Add(interval)
+			time.Sleep(interval)
+			// Only produce slots while running (not paused or interrupted) and automining
+			if solanaNode.runState.IsRunning() && !solanaNode.ManualMining {
+				produceMeasured("501", interval, scheduled, func() {
+					newSlot := atomic.AddUint64(&solanaNode.SlotNumber, 1)
+					subManager.BroadcastNewBlock("501", newSlot)
+					subManager.BroadcastSolanaLogs(newSlot)
+				})
 			}
 		}
 	}()
 
-	// Create a new ServeMux for better route handling
-	mux := http.NewServeMux()
+	// Validate outbound messages against the bundled schemas
+	if enabled, _ := strconv.ParseBool(os.Getenv("SELF_CHECK")); enabled {
+		schemaCheck.setEnabled(true)
+		log.Printf("Schema self-check enabled")
+	}
 
-	// Serve static files for the web UI
-	fs := http.FileServer(http.Dir("static"))
-	mux.Handle("/", fs)
+	// Let test code drive faults through sim_ methods on the chain endpoints
+	if enabled, _ := strconv.ParseBool(os.Getenv("SIM_METHODS")); enabled {
+		simMethodsEnabled = true
+		log.Printf("sim_ control methods enabled on the chain endpoints")
+	}
 
-	// Unified WebSocket and HTTP endpoints
-	mux.HandleFunc("/ws/chain/", handleChainWebSocket)
-	mux.HandleFunc("/chain/", handleChainHTTP)
+	// Write runtime configuration changes back to chains.yaml
+	if enabled, _ := strconv.ParseBool(os.Getenv("PERSIST_CONFIG")); enabled {
+		persistConfig = true
+		log.Printf("Runtime configuration changes are persisted to %s", configFile)
+	}
 
-	// SSE endpoints
-	mux.HandleFunc("/sse/connections", handleConnectionsSSE)
-	mux.HandleFunc("/sse/blocks", handleBlocksSSE)
+	// Signal degradation under overload instead of silently falling behind
+	if enabled, _ := strconv.ParseBool(os.Getenv("OVERLOAD_PROTECTION")); enabled {
+		overloadProtection.config.Enabled = true
+		log.Printf("Overload protection enabled")
+	}
 
-	// Control endpoints
-	handleControlEndpoints(mux)
+	// Keep in-memory stores bounded for long soak runs
+	logRetentionConfig()
+	go runRetentionCompaction()
+
+	// Record when faults start and end, for Grafana annotations
+	go runIncidentTracker()
+
+	// Warn about, and optionally clamp, settings the simulator can't keep up with
+	go runWatchdog()
 
 	// Get port from environment variable or use default
 	port := os.Getenv("RPC_PORT")
@@ -159,14 +137,28 @@ func main() {
 	}
 	port = ":" + port
 
+	// With CONTROL_PORT set, the web UI, SSE streams and control API move to a separate admin port
+	controlPort := os.Getenv("CONTROL_PORT")
+	mux, adminMux := newServeMuxes(controlPort != "")
+	uiPort := port
+	if adminMux != nil {
+		uiPort = ":" + controlPort
+		go func() {
+			log.Printf("Starting control plane on port %s", uiPort)
+			if err := http.ListenAndServe(uiPort, regionRouter(adminMux)); err != nil {
+				log.Fatal("ListenAndServe (control plane):", err)
+			}
+		}()
+	}
+
 	log.Printf("Starting RPC simulator on port %s", port)
-	log.Printf("Web UI: http://localhost%s", port)
+	log.Printf("Web UI: http://localhost%s", uiPort)
 	log.Printf("Chain endpoints:")
 	for chainId, chainName := range chainIdToName {
 		log.Printf("  %s: ws://localhost%s/ws/chain/%s", chainName, port, chainId)
 	}
 	log.Printf("Solana endpoint: ws://localhost%s/ws/chain/501", port)
-	log.Printf("Control endpoints:")
+	log.Printf("Control endpoints (port %s):", uiPort)
 	log.Printf("  POST /control/connections/drop - Drop all connections (optional: block_duration_seconds)")
 	log.Printf("  POST /control/block/set - Set block number")
 	log.Printf("  POST /control/block/pause - Pause block increment")
@@ -177,25 +169,111 @@ func main() {
 	log.Printf("  POST /control/timeout/clear - Clear response timeout")
 	log.Printf("  POST /control/chain/reorg - Trigger chain reorganization")
 
-	if err := http.ListenAndServe(port, mux); err != nil {
+	// Chains can also be selected by host name, with TLS so SNI mirrors provider URLs
+	hostRoutingDomain = os.Getenv("HOST_ROUTING_DOMAIN")
+	if hostRoutingDomain != "" {
+		log.Printf("Host routing: <chain>.%s", hostRoutingDomain)
+	}
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if certFile != "" || keyFile != "" {
+		if err := http.ListenAndServeTLS(port, certFile, keyFile, regionRouter(hostRouter(mux))); err != nil {
+			log.Fatal("ListenAndServeTLS:", err)
+		}
+		return
+	}
+	if err := http.ListenAndServe(port, regionRouter(hostRouter(mux))); err != nil {
 		log.Fatal("ListenAndServe:", err)
 	}
 }
 
+// newServeMuxes builds the mux of the RPC port and, with a separate control plane, the mux of the
+// admin port (nil otherwise). The RPC port then only serves chain traffic; the admin port serves
+// everything, so the web UI can still open chain connections.
+func newServeMuxes(separateControl bool) (*http.ServeMux, *http.ServeMux) {
+	mux := http.NewServeMux()
+	registerChainRoutes(mux)
+	if !separateControl {
+		registerAdminRoutes(mux)
+		return mux, nil
+	}
+	adminMux := http.NewServeMux()
+	registerChainRoutes(adminMux)
+	registerAdminRoutes(adminMux)
+	return mux, adminMux
+}
+
+// registerChainRoutes adds the unified WebSocket and HTTP chain endpoints and the status page
+func registerChainRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/ws/chain/", handleChainWebSocket)
+	mux.HandleFunc("/chain/", handleChainHTTP)
+	mux.HandleFunc("/api/status", handleStatusPage)
+}
+
+// registerAdminRoutes adds the web UI, SSE endpoints and control API
+func registerAdminRoutes(mux *http.ServeMux) {
+	// Serve static files for the web UI
+	fs := http.FileServer(http.Dir("static"))
+	mux.Handle("/", fs)
+
+	// SSE endpoints
+	mux.HandleFunc("/sse/connections", handleConnectionsSSE)
+	mux.HandleFunc("/sse/blocks", handleBlocksSSE)
+
+	// Per-connection message logs
+	mux.HandleFunc("/api/connections/", handleConnectionMessages)
+
+	// Control endpoints
+	handleControlEndpoints(mux)
+}
+
 // wsConnWrapper wraps a *websocket.Conn to implement WSConn
 type wsConnWrapper struct {
 	*websocket.Conn
-	writeMu sync.Mutex // Protects writes to the connection
-	chainId string     // Store the chainId for this connection
+	id         uint64      // Process-unique connection id, used in logs and the control API
+	writeMu    sync.Mutex  // Protects writes to the connection
+	chainId    string      // Store the chainId for this connection
+	strictness string      // Per-connection protocol strictness override (empty = chain level)
+	slow       int32       // 1 while the client is detected as not reading (atomic)
+	sent       uint64      // Messages written to the client (atomic)
+	messages   *messageLog // Recent messages, nil unless message logging is enabled
+	queue      sendQueue   // Writes under the slow client drop policy
+}
+
+// wsMessage is a message read from a WebSocket connection
+type wsMessage struct {
+	messageType int
+	data        []byte
+}
+
+// nextConnID is the last assigned WebSocket connection id
+var nextConnID uint64
+
+// Strictness returns the per-connection protocol strictness override
+func (w *wsConnWrapper) Strictness() string {
+	return w.strictness
 }
 
 func (w *wsConnWrapper) WriteMessage(messageType int, data []byte) error {
+	if timeout, policy := slowClientSettings(w.chainId); timeout > 0 {
+		return w.writeDetectingSlowClient(messageType, data, timeout, policy)
+	}
 	w.writeMu.Lock()
 	defer w.writeMu.Unlock()
-	return w.Conn.WriteMessage(messageType, data)
+	return w.write(messageType, data)
+}
+
+// write sends a message and counts it; the caller holds writeMu
+func (w *wsConnWrapper) write(messageType int, data []byte) error {
+	err := w.Conn.WriteMessage(messageType, data)
+	if err == nil {
+		atomic.AddUint64(&w.sent, 1)
+		w.messages.record("out", messageType, data)
+	}
+	return err
 }
 
 func (w *wsConnWrapper) Close() error {
+	w.queue.close()
 	w.writeMu.Lock()
 	defer w.writeMu.Unlock()
 	return w.Conn.Close()
@@ -230,6 +308,7 @@ func handleConnectionsSSE(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
 		return
 	}
+	stream := newSSEStream(w, flusher, SSEStreamConnections)
 
 	// Send initial connection counts
 	counts := connTracker.GetConnections()
@@ -238,8 +317,9 @@ func handleConnectionsSSE(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	fmt.Fprintf(w, "data: %s\n\n", data)
-	flusher.Flush()
+	if !stream.send(data) {
+		return
+	}
 
 	// Keep sending updates
 	for {
@@ -252,8 +332,9 @@ func handleConnectionsSSE(w http.ResponseWriter, r *http.Request) {
 			if err != nil {
 				continue
 			}
-			fmt.Fprintf(w, "data: %s\n\n", data)
-			flusher.Flush()
+			if !stream.send(data) {
+				return
+			}
 		}
 	}
 }
@@ -275,6 +356,7 @@ func handleBlocksSSE(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
 		return
 	}
+	stream := newSSEStream(w, flusher, SSEStreamBlocks)
 
 	// Send initial block data
 	blocks := make(map[string]map[string]interface{})
@@ -310,8 +392,9 @@ func handleBlocksSSE(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	fmt.Fprintf(w, "data: %s\n\n", data)
-	flusher.Flush()
+	if !stream.send(data) {
+		return
+	}
 
 	// Keep sending updates
 	for {
@@ -351,8 +434,9 @@ func handleBlocksSSE(w http.ResponseWriter, r *http.Request) {
 			if err != nil {
 				continue
 			}
-			fmt.Fprintf(w, "data: %s\n\n", data)
-			flusher.Flush()
+			if !stream.send(data) {
+				return
+			}
 		}
 	}
 }
@@ -367,11 +451,22 @@ func handleChainWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	strictness := r.URL.Query().Get("strictness")
+	if !isValidStrictness(strictness) {
+		http.Error(w, "Invalid strictness level", http.StatusBadRequest)
+		return
+	}
+
 	log.Printf("Client connected to chain %s (chainId: %s)", chainName, chainId)
 	if IsBlocked() {
 		http.Error(w, "Server is temporarily unavailable", http.StatusServiceUnavailable)
 		return
 	}
+	if ok, wait := reconnects.allow(time.Now()); !ok {
+		w.Header().Set("Retry-After", retryAfterSeconds(wait))
+		http.Error(w, "Too many reconnections, retry later", http.StatusTooManyRequests)
+		return
+	}
 
 	wsConn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -379,44 +474,80 @@ func handleChainWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	conn := &wsConnWrapper{
-		Conn:    wsConn,
-		chainId: chainId,
+		Conn:       wsConn,
+		id:         atomic.AddUint64(&nextConnID, 1),
+		chainId:    chainId,
+		strictness: strictness,
 	}
+	conn.messages = openMessageLog(conn.id, chainId)
 
 	// Track the connection
 	connTracker.AddConnection(chainId)
+	connTracker.TrackConn(conn, chainId)
+	idle := startIdleTracker(conn, chainId)
+
+	// ctx is cancelled as soon as the connection goes away, which aborts simulated delays
+	// of the request in flight instead of letting them run to completion
+	ctx, cancel := context.WithCancel(r.Context())
 	defer func() {
+		cancel()
+		idle.Stop()
 		connTracker.RemoveConnection(chainId)
+		connTracker.UntrackConn(conn)
 		count := subManager.CleanupConnection(conn)
-		log.Printf("Cleaned up %d subscriptions for disconnected client (chain: %s)", count, chainName)
+		log.Printf("Cleaned up %d subscriptions for disconnected client (chain: %s, conn: %d)", count, chainName, conn.id)
+		dropAfterResponse(conn) // Forget a pending raced drop if the connection went away first
+		closeMessageLog(conn.messages)
 		conn.Close()
 	}()
 
-	for {
-		messageType, message, err := wsConn.ReadMessage()
-		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("Client disconnected unexpectedly from chain %s: %v", chainName, err)
+	// Read in a separate goroutine so a disconnect is noticed while a request is being handled
+	messages := make(chan wsMessage)
+	go func() {
+		defer close(messages)
+		defer cancel()
+		for {
+			messageType, message, err := wsConn.ReadMessage()
+			if err != nil {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+					log.Printf("Client disconnected unexpectedly from chain %s: %v", chainName, err)
+				}
+				return
+			}
+			idle.Touch()
+			conn.messages.record("in", messageType, message)
+			select {
+			case messages <- wsMessage{messageType: messageType, data: message}:
+			case <-ctx.Done():
+				return
 			}
-			break
 		}
+	}()
 
-		var response []byte
-		if chainId == "501" { // Solana
-			response, err = handleSolanaRequest(message, conn)
-		} else { // EVM chains
-			response, err = handleEVMRequest(message, conn, chainId)
-		}
+	for msg := range messages {
+		mirror.record(chainId, "ws", conn.id, msg.data)
+		response, err := handleRPCRequest(ctx, msg.data, conn, chainId)
 
 		if err != nil {
-			log.Printf("Handler error for chain %s: %v", chainName, err)
+			if ctx.Err() == nil {
+				log.Printf("Handler error for chain %s: %v", chainName, err)
+			}
 			break
 		}
 
-		if err := conn.WriteMessage(messageType, response); err != nil {
+		if response == nil {
+			continue // Notification without a response
+		}
+		if err := conn.WriteMessage(msg.messageType, response); errors.Is(err, errSlowClientDropped) {
+			continue // The client still reads too slowly; the connection stays open
+		} else if err != nil {
 			log.Printf("Write error for chain %s: %v", chainName, err)
 			break
 		}
+		if dropAfterResponse(conn) {
+			log.Printf("Dropping connection %d of chain %s after a raced subscription", conn.id, chainName)
+			break
+		}
 	}
 }
 
@@ -450,21 +581,40 @@ func handleChainHTTP(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Incoming HTTP message for chain %s: %s", chainName, string(message))
 	}
 
-	// Create a mock connection for the request
-	mockConn := NewMockWSConn()
+	strictness := r.URL.Query().Get("strictness")
+	if !isValidStrictness(strictness) {
+		http.Error(w, "Invalid strictness level", http.StatusBadRequest)
+		return
+	}
 
-	var response []byte
-	if chainId == "501" { // Solana
-		response, err = handleSolanaRequest(message, mockConn)
-	} else { // EVM chains
-		response, err = handleEVMRequest(message, mockConn, chainId)
+	// Create a mock connection for the request
+	var mockConn WSConn = NewMockWSConn()
+	if strictness != StrictnessDefault {
+		mockConn = &strictnessOverrideConn{WSConn: mockConn, strictness: strictness}
 	}
 
+	mirror.record(chainId, "http", 0, message)
+	response, err := handleRPCRequest(r.Context(), message, mockConn, chainId)
+
 	if err != nil {
+		if r.Context().Err() != nil {
+			return // Client went away during a simulated delay
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent) // Notification without a response
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if size := compressionBombSize(chainId); size > 0 && acceptsGzip(r) {
+		w.Header().Set("Content-Encoding", "gzip")
+		if err := writeCompressionBomb(w, response, size); err != nil {
+			log.Printf("Failed to write compression bomb for chain %s: %v", chainName, err)
+		}
+		return
+	}
 	w.Write(response)
 }
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+// maxLoggedMessageSize is how much of each message a message log keeps
+const maxLoggedMessageSize = 16 * 1024
+
+// defaultMessageLogSize is how many messages a connection's log keeps by default
+const defaultMessageLogSize = 200
+
+// LoggedMessage is one message received from or sent to a connection
+type LoggedMessage struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"` // in or out
+	Type      string    `json:"type"`      // text, binary, close, ping or pong
+	Size      int       `json:"size"`      // Size of the whole message in bytes
+	Data      string    `json:"data"`      // The message, base64 encoded if it is not valid UTF-8
+	Base64    bool      `json:"base64,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"` // Data holds only the first 16 KiB
+}
+
+// messageLog is a ring buffer of a connection's recent messages
+type messageLog struct {
+	mu       sync.Mutex
+	connID   uint64
+	chainId  string
+	opened   time.Time
+	closed   *time.Time
+	messages []LoggedMessage // Ring buffer of up to size messages
+	next     int             // Position of the next message once the buffer is full
+	size     int
+	total    uint64 // Messages recorded, including those overwritten
+}
+
+// record adds a message, overwriting the oldest one once the log is full
+func (l *messageLog) record(direction string, messageType int, data []byte) {
+	if l == nil {
+		return
+	}
+	message := LoggedMessage{Time: time.Now(), Direction: direction, Type: messageTypeName(messageType), Size: len(data)}
+	if len(data) > maxLoggedMessageSize {
+		data = data[:maxLoggedMessageSize]
+		message.Truncated = true
+	}
+	if utf8.Valid(data) {
+		message.Data = string(data)
+	} else {
+		message.Data = base64.StdEncoding.EncodeToString(data)
+		message.Base64 = true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total++
+	if len(l.messages) < l.size {
+		l.messages = append(l.messages, message)
+		return
+	}
+	l.messages[l.next] = message
+	l.next = (l.next + 1) % l.size
+}
+
+// snapshot returns the logged messages, oldest first, at most limit of the newest (0 = all)
+func (l *messageLog) snapshot(limit int) []LoggedMessage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	messages := make([]LoggedMessage, 0, len(l.messages))
+	messages = append(messages, l.messages[l.next:]...)
+	messages = append(messages, l.messages[:l.next]...)
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages
+}
+
+// messageTypeName returns the name of a WebSocket message type
+func messageTypeName(messageType int) string {
+	switch messageType {
+	case websocket.TextMessage:
+		return "text"
+	case websocket.BinaryMessage:
+		return "binary"
+	case websocket.CloseMessage:
+		return "close"
+	case websocket.PingMessage:
+		return "ping"
+	case websocket.PongMessage:
+		return "pong"
+	}
+	return strconv.Itoa(messageType)
+}
+
+// messageLogs holds the message logs of open and recently closed connections by connection
+// id. Logging is opt-in and applies to connections opened while it is enabled. Runtime only.
+var messageLogs = struct {
+	sync.RWMutex
+	enabled bool
+	size    int                    // Messages kept per connection
+	byConn  map[uint64]*messageLog // Open and closed connections
+	closed  []uint64               // Closed connections, oldest first
+}{size: defaultMessageLogSize, byConn: make(map[uint64]*messageLog)}
+
+// openMessageLog starts the message log of a new connection, or returns nil while logging is disabled
+func openMessageLog(connID uint64, chainId string) *messageLog {
+	messageLogs.Lock()
+	defer messageLogs.Unlock()
+	if !messageLogs.enabled {
+		return nil
+	}
+	l := &messageLog{connID: connID, chainId: chainId, opened: time.Now(), size: messageLogs.size}
+	messageLogs.byConn[connID] = l
+	return l
+}
+
+// closeMessageLog marks a connection's log closed; it is kept until evicted by the retention cap
+func closeMessageLog(l *messageLog) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.closed = &now
+	l.mu.Unlock()
+
+	messageLogs.Lock()
+	messageLogs.closed = append(messageLogs.closed, l.connID)
+	messageLogs.Unlock()
+	recordEvictions(RetentionStoreMessageLogs, compactMessageLogs(retentionLimit(RetentionStoreMessageLogs)))
+}
+
+// compactMessageLogs drops the logs of the oldest closed connections beyond limit and returns
+// the number removed
+func compactMessageLogs(limit int) int {
+	messageLogs.Lock()
+	defer messageLogs.Unlock()
+	excess := len(messageLogs.closed) - limit
+	if excess <= 0 {
+		return 0
+	}
+	for _, connID := range messageLogs.closed[:excess] {
+		delete(messageLogs.byConn, connID)
+	}
+	messageLogs.closed = append([]uint64(nil), messageLogs.closed[excess:]...)
+	return excess
+}
+
+// closedMessageLogCount returns the number of kept logs of closed connections
+func closedMessageLogCount() int {
+	messageLogs.RLock()
+	defer messageLogs.RUnlock()
+	return len(messageLogs.closed)
+}
+
+// handleMessageLogConfig reports (GET) or configures (POST) per-connection message logging
+func handleMessageLogConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		messageLogs.RLock()
+		defer messageLogs.RUnlock()
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"enabled":     messageLogs.enabled,
+			"size":        messageLogs.size,
+			"connections": len(messageLogs.byConn),
+		})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Enabled bool `json:"enabled"`
+		Size    int  `json:"size"` // Messages kept per connection (0 = 200)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Size < 0 {
+		http.Error(w, "size must be non-negative", http.StatusBadRequest)
+		return
+	}
+	if request.Size == 0 {
+		request.Size = defaultMessageLogSize
+	}
+
+	messageLogs.Lock()
+	messageLogs.enabled = request.Enabled
+	messageLogs.size = request.Size
+	messageLogs.Unlock()
+
+	log.Printf("Message logging for new connections: enabled=%v, size=%d", request.Enabled, request.Size)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleConnectionMessages serves GET /api/connections/{id}/messages, the recent messages of
+// one connection; limit returns only the newest messages
+func handleConnectionMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idPart, suffix, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/connections/"), "/")
+	connID, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil || suffix != "messages" {
+		http.NotFound(w, r)
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	messageLogs.RLock()
+	l, ok := messageLogs.byConn[connID]
+	messageLogs.RUnlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("No message log for connection %d", connID), http.StatusNotFound)
+		return
+	}
+
+	messages := l.snapshot(limit)
+	l.mu.Lock()
+	response := map[string]interface{}{
+		"conn_id":  l.connID,
+		"chain":    chainIdToName[l.chainId],
+		"chain_id": l.chainId,
+		"opened":   l.opened,
+		"closed":   l.closed,
+		"total":    l.total,
+		"messages": messages,
+	}
+	l.mu.Unlock()
+	jsonResponse(w, http.StatusOK, response)
+}
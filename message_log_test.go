@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type messageLogResponse struct {
+	ConnID   uint64          `json:"conn_id"`
+	Chain    string          `json:"chain"`
+	Closed   *time.Time      `json:"closed"`
+	Total    uint64          `json:"total"`
+	Messages []LoggedMessage `json:"messages"`
+}
+
+func getMessageLog(t *testing.T, path string) (int, messageLogResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleConnectionMessages(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var response messageLogResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode message log: %v", err)
+		}
+	}
+	return w.Code, response
+}
+
+func TestConnectionMessageLog(t *testing.T) {
+	setLogging := func(body string) {
+		w := httptest.NewRecorder()
+		handleMessageLogConfig(w, httptest.NewRequest(http.MethodPost, "/control/connections/message-log", bytes.NewBufferString(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", body, w.Code)
+		}
+	}
+	setLogging(`{"enabled": true, "size": 3}`)
+	defer setLogging(`{"enabled": false}`)
+
+	server := httptest.NewServer(http.HandlerFunc(handleChainWebSocket))
+	defer server.Close()
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/chain/137", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	client.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"sim_connectionInfo","params":[],"id":1}`))
+	var info struct {
+		Result ConnectionInfo `json:"result"`
+	}
+	if err := client.ReadJSON(&info); err != nil || info.Result.ConnectionID == 0 {
+		t.Fatalf("sim_connectionInfo failed: %v", err)
+	}
+	path := fmt.Sprintf("/api/connections/%d/messages", info.Result.ConnectionID)
+
+	code, response := getMessageLog(t, path)
+	if code != http.StatusOK || response.Chain != "polygon" || len(response.Messages) != 2 {
+		t.Fatalf("Expected the request and response of polygon, got %d %+v", code, response)
+	}
+	if response.Messages[0].Direction != "in" || !strings.Contains(response.Messages[0].Data, "sim_connectionInfo") || response.Messages[1].Direction != "out" {
+		t.Errorf("Expected the request followed by the response, got %+v", response.Messages)
+	}
+
+	// The ring buffer keeps the newest messages
+	client.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":2}`))
+	client.ReadMessage()
+	_, response = getMessageLog(t, path)
+	if response.Total != 4 || len(response.Messages) != 3 || !strings.Contains(response.Messages[1].Data, "eth_chainId") {
+		t.Errorf("Expected the newest 3 of 4 messages, got %+v", response)
+	}
+	if _, response = getMessageLog(t, path+"?limit=1"); len(response.Messages) != 1 || response.Messages[0].Direction != "out" {
+		t.Errorf("Expected only the newest message, got %+v", response.Messages)
+	}
+
+	// The log outlives the connection
+	client.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for response.Closed == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		_, response = getMessageLog(t, path)
+	}
+	if response.Closed == nil || len(response.Messages) != 3 {
+		t.Errorf("Expected the closed connection's log to be kept, got %+v", response)
+	}
+
+	for path, want := range map[string]int{
+		"/api/connections/999999/messages": http.StatusNotFound,
+		"/api/connections/abc/messages":    http.StatusNotFound,
+		path + "?limit=-1":                 http.StatusBadRequest,
+		"/api/connections/1/subscriptions": http.StatusNotFound,
+	} {
+		if code, _ := getMessageLog(t, path); code != want {
+			t.Errorf("%s: expected status %d, got %d", path, want, code)
+		}
+	}
+}
+
+func TestMessageLogRecording(t *testing.T) {
+	l := &messageLog{size: 2}
+	l.record("out", websocket.BinaryMessage, []byte{0xff, 0x00})
+	l.record("out", websocket.TextMessage, bytes.Repeat([]byte("a"), maxLoggedMessageSize+1))
+
+	messages := l.snapshot(0)
+	if !messages[0].Base64 || messages[0].Type != "binary" {
+		t.Errorf("Expected invalid UTF-8 to be base64 encoded, got %+v", messages[0])
+	}
+	if !messages[1].Truncated || messages[1].Size != maxLoggedMessageSize+1 || len(messages[1].Data) != maxLoggedMessageSize {
+		t.Errorf("Expected a truncated message with its full size, got size %d, %d bytes kept", messages[1].Size, len(messages[1].Data))
+	}
+
+	var disabled *messageLog
+	disabled.record("in", websocket.TextMessage, []byte("ignored")) // Must not panic
+}
+
+func TestClosedMessageLogsCapped(t *testing.T) {
+	messageLogs.Lock()
+	originalByConn, originalClosed := messageLogs.byConn, messageLogs.closed
+	messageLogs.byConn, messageLogs.closed = make(map[uint64]*messageLog), nil
+	messageLogs.Unlock()
+	defer func() {
+		messageLogs.Lock()
+		messageLogs.byConn, messageLogs.closed = originalByConn, originalClosed
+		messageLogs.Unlock()
+	}()
+
+	for id := uint64(1); id <= 5; id++ {
+		messageLogs.byConn[id] = &messageLog{connID: id, size: 1}
+		messageLogs.closed = append(messageLogs.closed, id)
+	}
+	if removed := compactMessageLogs(2); removed != 3 {
+		t.Errorf("Expected 3 logs removed, got %d", removed)
+	}
+	if _, ok := messageLogs.byConn[3]; ok || len(messageLogs.byConn) != 2 || closedMessageLogCount() != 2 {
+		t.Errorf("Expected the logs of the 2 most recently closed connections to be kept, got %v", messageLogs.closed)
+	}
+}
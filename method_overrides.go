@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ErrorRef refers to a predefined error by name with its own probability
+type ErrorRef struct {
+	Name        string  `yaml:"name" json:"name"`                             // Key in PredefinedErrors
+	Probability float64 `yaml:"probability" json:"probability"`               // 0.0 to 1.0
+	DelayMs     int     `yaml:"delay_ms,omitempty" json:"delay_ms,omitempty"` // Delay before returning the error
+}
+
+// MethodOverride customizes latency, errors and responses of a single RPC method
+type MethodOverride struct {
+	Latency             *time.Duration       `yaml:"latency,omitempty" json:"latency,omitempty"`                           // Replaces the chain latency for this method
+	ErrorRefs           []ErrorRef           `yaml:"error_refs,omitempty" json:"error_refs,omitempty"`                     // Predefined errors by name
+	ErrorConfigs        []ErrorConfig        `yaml:"error_configs,omitempty" json:"error_configs,omitempty"`               // Inline error configs
+	CustomResponse      string               `yaml:"custom_response,omitempty" json:"custom_response,omitempty"`           // JSON response to return instead of the normal response
+	LatencyDistribution *LatencyDistribution `yaml:"latency_distribution,omitempty" json:"latency_distribution,omitempty"` // Sampled per request instead of Latency
+
+	errors []ErrorConfig // ErrorConfigs plus resolved ErrorRefs
+}
+
+// resolve validates the override and expands its error refs
+func (o *MethodOverride) resolve() error {
+	if o.Latency != nil && *o.Latency < 0 {
+		return fmt.Errorf("latency must be non-negative")
+	}
+	if err := o.LatencyDistribution.validate(); err != nil {
+		return err
+	}
+	if o.CustomResponse != "" && !json.Valid([]byte(o.CustomResponse)) {
+		return fmt.Errorf("custom_response must be valid JSON")
+	}
+
+	errors := make([]ErrorConfig, 0, len(o.ErrorConfigs)+len(o.ErrorRefs))
+	for _, config := range o.ErrorConfigs {
+		if config.Probability < 0 || config.Probability > 1 {
+			return fmt.Errorf("error probability must be between 0 and 1")
+		}
+		if err := config.validate(); err != nil {
+			return err
+		}
+		config.Methods = nil // The override already targets a single method
+		errors = append(errors, config)
+	}
+	for _, ref := range o.ErrorRefs {
+		config, ok := PredefinedErrors[ref.Name]
+		if !ok {
+			return fmt.Errorf("unknown predefined error: %s", ref.Name)
+		}
+		if ref.Probability < 0 || ref.Probability > 1 {
+			return fmt.Errorf("error probability must be between 0 and 1")
+		}
+		config.Probability = ref.Probability
+		config.DelayMs = ref.DelayMs
+		config.Methods = nil
+		errors = append(errors, config)
+	}
+	o.errors = errors
+	return nil
+}
+
+// resolveMethodOverrides validates and resolves every override of a chain
+func resolveMethodOverrides(overrides map[string]*MethodOverride) error {
+	for method, override := range overrides {
+		if override == nil {
+			continue
+		}
+		if err := override.resolve(); err != nil {
+			return fmt.Errorf("method override %s: %v", method, err)
+		}
+	}
+	return nil
+}
+
+// methodOverride returns the override for a method, or nil if there is none
+func methodOverride(overrides map[string]*MethodOverride, method string) *MethodOverride {
+	if override, ok := overrides[method]; ok {
+		return override
+	}
+	return nil
+}
+
+// methodLatency returns the latency for a method, preferring the override if it sets one
+// and a distribution over a fixed latency
+func methodLatency(latency time.Duration, distribution *LatencyDistribution, override *MethodOverride) time.Duration {
+	if override != nil && override.LatencyDistribution != nil {
+		return override.LatencyDistribution.sample()
+	}
+	if override != nil && override.Latency != nil {
+		return *override.Latency
+	}
+	if distribution != nil {
+		return distribution.sample()
+	}
+	return latency
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const methodOverridesYAML = `
+evm_chains:
+  ethereum:
+    name: ethereum
+    chain_id: "0x1"
+    latency: 200ms
+    method_overrides:
+      eth_chainId:
+        latency: 0s
+      eth_getLogs:
+        error_refs:
+          - name: limit_exceeded
+            probability: 1.0
+      eth_call:
+        custom_response: '{"jsonrpc":"2.0","id":1,"result":"0xdead"}'
+`
+
+func TestMethodOverridesFromYAML(t *testing.T) {
+	var config ChainConfig
+	if err := yaml.Unmarshal([]byte(methodOverridesYAML), &config); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	overrides := config.EVMChains["ethereum"].MethodOverrides
+	if err := resolveMethodOverrides(overrides); err != nil {
+		t.Fatalf("Failed to resolve overrides: %v", err)
+	}
+
+	chain := supportedChains["ethereum"]
+	defer func() {
+		setLatency("1", 0, nil)
+		chain.MethodOverrides = nil
+	}()
+	setLatency("1", 200*time.Millisecond, nil)
+	chain.MethodOverrides = overrides
+
+	call := func(method string) (JSONRPCResponse, time.Duration) {
+		data, _ := json.Marshal(JSONRPCRequest{JsonRPC: "2.0", Method: method, Params: []interface{}{}, ID: 1})
+		start := time.Now()
+		response, err := handleEVMRequest(context.Background(), data, NewMockWSConn(), "1")
+		elapsed := time.Since(start)
+		if err != nil {
+			t.Fatalf("Handler error: %v", err)
+		}
+		var resp JSONRPCResponse
+		if err := json.Unmarshal(response, &resp); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return resp, elapsed
+	}
+
+	if _, elapsed := call("eth_chainId"); elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected eth_chainId latency override of 0s, took %v", elapsed)
+	}
+
+	resp, elapsed := call("eth_getLogs")
+	if resp.Error == nil || resp.Error.Code != -32005 {
+		t.Errorf("Expected limit_exceeded error for eth_getLogs, got %+v", resp)
+	}
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("Expected chain latency for methods without a latency override, took %v", elapsed)
+	}
+
+	setLatency("1", 0, nil)
+	if resp, _ := call("eth_call"); resp.Result != "0xdead" {
+		t.Errorf("Expected custom eth_call response, got %+v", resp)
+	}
+	if resp, _ := call("eth_blockNumber"); resp.Error != nil {
+		t.Errorf("Expected methods without overrides to be unaffected, got %+v", resp.Error)
+	}
+}
+
+func TestMethodOverridesValidation(t *testing.T) {
+	err := resolveMethodOverrides(map[string]*MethodOverride{
+		"eth_call": {ErrorRefs: []ErrorRef{{Name: "no_such_error", Probability: 1}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no_such_error") {
+		t.Errorf("Expected unknown error ref to be rejected, got %v", err)
+	}
+
+	err = resolveMethodOverrides(map[string]*MethodOverride{
+		"eth_call": {CustomResponse: "{not json"},
+	})
+	if err == nil {
+		t.Error("Expected invalid custom response to be rejected")
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"plugin"
+	"time"
+)
+
+// defaultHookTimeout bounds a middleware module call when no timeout is configured
+const defaultHookTimeout = time.Second
+
+// Middleware runs user-provided hooks around the requests of a chain, so bespoke behaviors
+// don't need a fork of the simulator. Hooks come from either a Go plugin or a WASM module,
+// and are only loaded from the config file at startup.
+type Middleware struct {
+	Plugin     string                                                `yaml:"plugin,omitempty" json:"plugin,omitempty"`                     // Go plugin (.so) exporting OnRequest and/or OnResponse
+	Module     string                                                `yaml:"module,omitempty" json:"module,omitempty"`                     // Compiled WASM module (.wasm) run in the sandbox with the exchange as JSON input
+	Fuel       int64                                                 `yaml:"fuel,omitempty" json:"fuel,omitempty"`                         // Module instructions per hook call (0 = 16M)
+	MaxPages   uint32                                                `yaml:"max_memory_pages,omitempty" json:"max_memory_pages,omitempty"` // Module memory limit in 64 KiB pages (0 = 256)
+	Methods    []string                                              `yaml:"methods,omitempty" json:"methods,omitempty"`                   // Only hook these methods (empty = all)
+	Timeout    time.Duration                                         `yaml:"timeout,omitempty" json:"timeout,omitempty"`                   // Module timeout (0 = 1s); the request is passed through unchanged on timeout
+	onRequest  func(chainID string, request []byte) []byte           // Resolved plugin request hook
+	onResponse func(chainID string, request, response []byte) []byte // Resolved plugin response hook
+	compiled   *WasmMethod                                           // Compiled module
+}
+
+// hookExchange is the JSON document a middleware module receives as input
+type hookExchange struct {
+	Phase    string          `json:"phase"` // "request" or "response"
+	ChainID  string          `json:"chain_id"`
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// resolveMiddleware validates the middleware configuration and loads its plugin or module
+func resolveMiddleware(m *Middleware) error {
+	if m == nil {
+		return nil
+	}
+	if (m.Plugin == "") == (m.Module == "") {
+		return fmt.Errorf("middleware needs exactly one of plugin or module")
+	}
+	if m.Timeout < 0 {
+		return fmt.Errorf("middleware timeout must be non-negative")
+	}
+	if m.Module != "" {
+		binary, err := os.ReadFile(m.Module)
+		if err != nil {
+			return fmt.Errorf("failed to read middleware module: %v", err)
+		}
+		compiled := &WasmMethod{Module: m.Module, Fuel: m.Fuel, MaxPages: m.MaxPages}
+		if err := compileWasmMethod(compiled, binary); err != nil {
+			return fmt.Errorf("invalid middleware module: %v", err)
+		}
+		m.compiled = compiled
+		return nil
+	}
+
+	p, err := plugin.Open(m.Plugin)
+	if err != nil {
+		return fmt.Errorf("failed to open middleware plugin: %v", err)
+	}
+	if sym, err := p.Lookup("OnRequest"); err == nil {
+		hook, ok := sym.(func(string, []byte) []byte)
+		if !ok {
+			return fmt.Errorf("plugin OnRequest must be func(chainID string, request []byte) []byte")
+		}
+		m.onRequest = hook
+	}
+	if sym, err := p.Lookup("OnResponse"); err == nil {
+		hook, ok := sym.(func(string, []byte, []byte) []byte)
+		if !ok {
+			return fmt.Errorf("plugin OnResponse must be func(chainID string, request, response []byte) []byte")
+		}
+		m.onResponse = hook
+	}
+	if m.onRequest == nil && m.onResponse == nil {
+		return fmt.Errorf("plugin exports neither OnRequest nor OnResponse")
+	}
+	return nil
+}
+
+// chainMiddleware returns the middleware of a chain, nil if none is configured
+func chainMiddleware(chainId string) *Middleware {
+	if chainId == "501" {
+		return solanaNode.Middleware
+	}
+	if chain, ok := supportedChains[chainIdToName[chainId]]; ok {
+		return chain.Middleware
+	}
+	return nil
+}
+
+// applies returns true if the middleware hooks the method
+func (m *Middleware) applies(method string) bool {
+	if len(m.Methods) == 0 {
+		return true
+	}
+	for _, hooked := range m.Methods {
+		if hooked == method {
+			return true
+		}
+	}
+	return false
+}
+
+// request runs the request hook, returning the possibly rewritten request
+func (m *Middleware) request(ctx context.Context, chainId, method string, request []byte) []byte {
+	if m.Plugin != "" {
+		if m.onRequest == nil {
+			return request
+		}
+		return keepIfEmpty(m.onRequest(chainId, request), request)
+	}
+	return m.runModule(ctx, hookExchange{Phase: "request", ChainID: chainId, Method: method, Request: request}, request)
+}
+
+// response runs the response hook, returning the possibly rewritten response
+func (m *Middleware) response(ctx context.Context, chainId, method string, request, response []byte) []byte {
+	if m.Plugin != "" {
+		if m.onResponse == nil {
+			return response
+		}
+		return keepIfEmpty(m.onResponse(chainId, request, response), response)
+	}
+	return m.runModule(ctx, hookExchange{Phase: "response", ChainID: chainId, Method: method, Request: request, Response: response}, response)
+}
+
+// runModule passes the exchange to the middleware module. Its reply replaces current;
+// an empty reply, a trap, a timeout or invalid JSON leaves current unchanged.
+func (m *Middleware) runModule(ctx context.Context, exchange hookExchange, current []byte) []byte {
+	input, err := json.Marshal(exchange)
+	if err != nil {
+		return current
+	}
+
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := m.compiled.call(ctx, input)
+	if err != nil {
+		log.Printf("Middleware %s hook for chain %s failed: %v", exchange.Phase, exchange.ChainID, err)
+		return current
+	}
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return current
+	}
+	if !json.Valid(output) {
+		log.Printf("Middleware %s hook for chain %s returned invalid JSON, ignoring it", exchange.Phase, exchange.ChainID)
+		return current
+	}
+	return output
+}
+
+// keepIfEmpty returns current when a hook returned nothing
+func keepIfEmpty(hooked, current []byte) []byte {
+	if len(hooked) == 0 {
+		return current
+	}
+	return hooked
+}
+
+// requestMethod extracts the method of a JSON-RPC request, empty if it has none
+func requestMethod(message []byte) string {
+	var request struct {
+		Method string `json:"method"`
+	}
+	json.Unmarshal(message, &request)
+	return request.Method
+}
+
+// handleRPCRequest routes a message to the handler of its chain, running the chain's
+// middleware hooks around it. Malformed JSON is never passed to hooks.
+func handleRPCRequest(ctx context.Context, message []byte, conn WSConn, chainId string) ([]byte, error) {
+	method := requestMethod(message)
+	if isSimMethod(method) {
+		return handleSimMethod(message, conn, chainId)
+	}
+	m := chainMiddleware(chainId)
+	hooked := m != nil && m.applies(method) && json.Valid(message)
+	if hooked {
+		message = m.request(ctx, chainId, method, message)
+	}
+
+	var response []byte
+	var err error
+	if chainId == "501" { // Solana
+		response, err = handleSolanaRequest(ctx, message, conn)
+	} else { // EVM chains
+		response, err = handleEVMRequest(ctx, message, conn, chainId)
+	}
+	if err == nil && isStrictNotification(message, connStrictness(conn, chainStrictness(chainId))) {
+		return nil, nil // Notifications are handled but never answered
+	}
+	if err == nil && hooked {
+		response = m.response(ctx, chainId, method, message, response)
+	}
+	if err == nil {
+		schemaCheck.checkResponse(chainId, method, response)
+		response = applyEnvelopeFault(effectiveFaults(chainId, conn).EnvelopeFault, response)
+	}
+	return response, err
+}
+
+// handleMiddleware lists the middleware configured for each chain. Hooks are only loaded
+// from the config file at startup, never over the control API.
+func handleMiddleware(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	chains := make(map[string]*Middleware)
+	for _, name := range allChainNames() {
+		if m := chainMiddleware(chainIDForName(name)); m != nil {
+			chains[name] = m
+		}
+	}
+	jsonResponse(w, http.StatusOK, chains)
+}
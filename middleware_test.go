@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rpc-simulator/internal/wasm"
+	"rpc-simulator/internal/wasm/wasmtest"
+)
+
+// phaseModule builds a middleware module that replies with reply in one phase, identified by
+// the byte at offset 12 of the exchange ('q' for request, 's' for response), and leaves the
+// other phase unchanged
+func phaseModule(phase byte, reply string) []byte {
+	packed := int64(1024)<<32 | int64(len(reply))
+	handle := []byte{0x20, 0x00, 0x2D, 0x00, 0x0C, 0x41} // i32.load8_u offset=12 of ptr
+	handle = append(handle, wasmtest.SLEB(int64(phase))...)
+	handle = append(handle, 0x46, 0x04, 0x7E, 0x42) // i32.eq, if (result i64)
+	handle = append(handle, wasmtest.SLEB(packed)...)
+	handle = append(handle, 0x05, 0x42, 0x00, 0x0B, 0x0B) // else 0 end
+	return wasmtest.Module([]wasmtest.Func{
+		{Export: "alloc", Params: []byte{wasm.I32}, Results: []byte{wasm.I32}, Code: []byte{0x41, 0x80, 0x10, 0x0B}},
+		{Export: "handle", Params: []byte{wasm.I32, wasm.I32}, Results: []byte{wasm.I64}, Code: handle},
+	}, 1, map[uint32]string{1024: reply})
+}
+
+// loopModule builds a middleware module whose handle never returns
+func loopModule() []byte {
+	return wasmtest.Module([]wasmtest.Func{
+		{Export: "alloc", Params: []byte{wasm.I32}, Results: []byte{wasm.I32}, Code: []byte{0x41, 0x80, 0x10, 0x0B}},
+		{Export: "handle", Params: []byte{wasm.I32, wasm.I32}, Results: []byte{wasm.I64}, Code: []byte{0x03, 0x40, 0x0C, 0x00, 0x0B, 0x42, 0x00, 0x0B}},
+	}, 1, nil)
+}
+
+// moduleMiddleware writes module to a file and resolves m with it as the module
+func moduleMiddleware(t *testing.T, module []byte, m Middleware) *Middleware {
+	t.Helper()
+	m.Module = filepath.Join(t.TempDir(), "hook.wasm")
+	if err := os.WriteFile(m.Module, module, 0644); err != nil {
+		t.Fatalf("Failed to write module: %v", err)
+	}
+	if err := resolveMiddleware(&m); err != nil {
+		t.Fatalf("Failed to resolve middleware: %v", err)
+	}
+	return &m
+}
+
+func TestMiddlewareModuleRewritesRequestAndResponse(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer func() { chain.Middleware = nil }()
+
+	chain.Middleware = moduleMiddleware(t, phaseModule('q', `{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`), Middleware{})
+	data := []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`)
+	response, err := handleRPCRequest(context.Background(), data, NewMockWSConn(), "1")
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	if !strings.Contains(string(response), `"result":"0x1"`) {
+		t.Errorf("Expected the rewritten eth_chainId request to be served, got %s", response)
+	}
+
+	chain.Middleware = moduleMiddleware(t, phaseModule('s', `{"jsonrpc":"2.0","id":1,"result":"hooked"}`), Middleware{})
+	response, err = handleRPCRequest(context.Background(), data, NewMockWSConn(), "1")
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	if string(response) != `{"jsonrpc":"2.0","id":1,"result":"hooked"}` {
+		t.Errorf("Expected the hooked response, got %s", response)
+	}
+}
+
+func TestMiddlewareMethodFilter(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer func() { chain.Middleware = nil }()
+	chain.Middleware = moduleMiddleware(t, phaseModule('s', `{"jsonrpc":"2.0","id":1,"result":"hooked"}`), Middleware{Methods: []string{"eth_getBalance"}})
+
+	data := []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`)
+	response, err := handleRPCRequest(context.Background(), data, NewMockWSConn(), "1")
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	if strings.Contains(string(response), "hooked") {
+		t.Errorf("Expected methods outside the filter to pass through, got %s", response)
+	}
+}
+
+func TestMiddlewareModuleFailurePassesThrough(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer func() { chain.Middleware = nil }()
+
+	data := []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`)
+	for name, m := range map[string]*Middleware{
+		"timeout":      moduleMiddleware(t, loopModule(), Middleware{Fuel: 1 << 40, Timeout: 50 * time.Millisecond}),
+		"out of fuel":  moduleMiddleware(t, loopModule(), Middleware{Fuel: 1000}),
+		"invalid json": moduleMiddleware(t, phaseModule('s', "not json"), Middleware{}),
+	} {
+		chain.Middleware = m
+		start := time.Now()
+		response, err := handleRPCRequest(context.Background(), data, NewMockWSConn(), "1")
+		if err != nil {
+			t.Fatalf("%s: handler error: %v", name, err)
+		}
+		if !strings.Contains(string(response), `"result":"0x1"`) {
+			t.Errorf("%s: expected the unmodified response, got %s", name, response)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: expected the hook to be abandoned, took %v", name, elapsed)
+		}
+	}
+}
+
+func TestResolveMiddlewareValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook.wasm")
+	if err := os.WriteFile(path, []byte("not wasm"), 0644); err != nil {
+		t.Fatalf("Failed to write module: %v", err)
+	}
+
+	for name, m := range map[string]*Middleware{
+		"neither":        {Methods: []string{"eth_call"}},
+		"both":           {Plugin: "/nonexistent/hook.so", Module: path},
+		"missing plugin": {Plugin: "/nonexistent/hook.so"},
+		"missing module": {Module: "/nonexistent/hook.wasm"},
+		"invalid module": {Module: path},
+		"timeout":        {Module: path, Timeout: -time.Second},
+	} {
+		if err := resolveMiddleware(m); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMiddlewareControlIsReadOnly(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer func() { chain.Middleware = nil }()
+	chain.Middleware = moduleMiddleware(t, phaseModule('s', `{}`), Middleware{Methods: []string{"eth_call"}})
+
+	w := httptest.NewRecorder()
+	handleMiddleware(w, httptest.NewRequest(http.MethodGet, "/control/chain/middleware", nil))
+	var chains map[string]Middleware
+	if err := json.NewDecoder(w.Body).Decode(&chains); err != nil {
+		t.Fatalf("Failed to decode middleware: %v", err)
+	}
+	if m, ok := chains["ethereum"]; !ok || m.Module != chain.Middleware.Module || m.Methods[0] != "eth_call" {
+		t.Errorf("Expected the ethereum middleware to be listed, got %+v", chains)
+	}
+
+	// Hooks can't be set over the control API
+	w = httptest.NewRecorder()
+	handleMiddleware(w, httptest.NewRequest(http.MethodPost, "/control/chain/middleware", strings.NewReader(`{"chain": "ethereum", "plugin": "hook.so"}`)))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
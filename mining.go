@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxQueuedTransactions = 10000 // Per chain; further injections are rejected until a block is mined
+	minedTxBlocks         = 256   // Blocks whose injected transactions are kept for newHeadsWithTx
+)
+
+// QueuedTransaction is a transaction injected via the control API, included by the next mined block
+type QueuedTransaction struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Value    string `json:"value"`
+	Gas      string `json:"gas"`
+	GasPrice string `json:"gasPrice"`
+	Nonce    string `json:"nonce"`
+	Input    string `json:"input"`
+}
+
+// produceBlock advances the chain by one block, notifies subscribers and emits the block's logs
+func (c *EVMChain) produceBlock(chainId string, txs []QueuedTransaction) uint64 {
+	newBlock := atomic.AddUint64(&c.BlockNumber, 1)
+
+	// Update safe block (latest - 32)
+	if newBlock > 32 {
+		atomic.StoreUint64(&c.SafeBlockNumber, newBlock-32)
+	} else {
+		atomic.StoreUint64(&c.SafeBlockNumber, 0)
+	}
+
+	// Update finalized block (latest - 64)
+	if newBlock > 64 {
+		atomic.StoreUint64(&c.FinalizedBlockNumber, newBlock-64)
+	} else {
+		atomic.StoreUint64(&c.FinalizedBlockNumber, 0)
+	}
+
+	if len(txs) > 0 {
+		c.recordMinedTransactions(chainId, newBlock, txs)
+	}
+	subManager.BroadcastNewBlock(chainId, newBlock)
+
+	// Generate and broadcast log events per block, spread across the block interval
+	// In a real implementation, you would generate logs based on actual contract events
+	go func(blockNum uint64, interval time.Duration, logsPerBlock int) {
+		if logsPerBlock <= 0 {
+			return
+		}
+		logInterval := interval / time.Duration(logsPerBlock)
+		for i := 0; i < logsPerBlock; i++ {
+			if i > 0 {
+				time.Sleep(logInterval)
+			}
+			logIndex := atomic.AddUint64(&c.LogIndex, 1) - 1
+			subManager.BroadcastNewLog(chainId, c.logEventForBlock(blockNum, uint64(i), logIndex))
+		}
+	}(newBlock, c.BlockInterval, c.LogsPerBlock)
+
+	return newBlock
+}
+
+// recordMinedTransactions stores the transactions of a block so newHeadsWithTx can report them
+func (c *EVMChain) recordMinedTransactions(chainId string, blockNumber uint64, txs []QueuedTransaction) {
+	blockHash := generateBlockHashForSubscription(blockNumber, chainId, "block")
+	mined := make([]Transaction, len(txs))
+	for i, tx := range txs {
+		mined[i] = Transaction{
+			Hash:             generateBlockHash(blockNumber, chainId, fmt.Sprintf("tx-%d", i)),
+			Nonce:            hexOrZero(tx.Nonce),
+			BlockHash:        blockHash,
+			BlockNumber:      fmt.Sprintf("0x%x", blockNumber),
+			TransactionIndex: fmt.Sprintf("0x%x", i),
+			From:             addressOrZero(tx.From),
+			To:               addressOrZero(tx.To),
+			Value:            hexOrZero(tx.Value),
+			Gas:              hexOrZero(tx.Gas),
+			GasPrice:         hexOrZero(tx.GasPrice),
+			Input:            hexOrZero(tx.Input),
+			V:                "0x0",
+			R:                "0x0",
+			S:                "0x0",
+		}
+	}
+
+	c.miningMu.Lock()
+	defer c.miningMu.Unlock()
+	if c.minedTxs == nil {
+		c.minedTxs = make(map[uint64][]Transaction)
+	}
+	c.minedTxs[blockNumber] = mined
+	for number := range c.minedTxs {
+		if number+minedTxBlocks <= blockNumber {
+			delete(c.minedTxs, number)
+		}
+	}
+}
+
+// minedTransactions returns the injected transactions of a block, nil if it has none
+func minedTransactions(chainId string, blockNumber uint64) []Transaction {
+	chain, ok := supportedChains[chainIdToName[chainId]]
+	if !ok {
+		return nil
+	}
+	chain.miningMu.Lock()
+	defer chain.miningMu.Unlock()
+	return chain.minedTxs[blockNumber]
+}
+
+// blockTransactionList returns the transactions of a block: the injected transactions of a
+// mined block, otherwise the ones derived from the chain and block number, so that
+// eth_getBlockByNumber and newHeadsWithTx agree on every block
+func blockTransactionList(chainId string, blockNumber uint64) []Transaction {
+	if mined := minedTransactions(chainId, blockNumber); mined != nil {
+		return mined
+	}
+	return derivedTransactions(chainId, blockNumber)
+}
+
+// derivedTransactions returns 1-5 transactions of a block without injected transactions.
+// Every field is a hash of the chain, block number and transaction index, so repeated calls
+// return the same transactions.
+func derivedTransactions(chainId string, blockNumber uint64) []Transaction {
+	seed := func(i int, field string) string {
+		return generateBlockHash(blockNumber, chainId, fmt.Sprintf("tx-%d-%s", i, field))
+	}
+	quantity := func(i int, field string, digits int) string {
+		value, _ := strconv.ParseUint(seed(i, field)[2:2+digits], 16, 64)
+		return fmt.Sprintf("0x%x", value)
+	}
+
+	count, _ := strconv.ParseUint(generateBlockHash(blockNumber, chainId, "tx-count")[2:4], 16, 8)
+	blockHash := generateBlockHash(blockNumber, chainId, "block")
+	transactions := make([]Transaction, count%5+1)
+	for i := range transactions {
+		transactions[i] = Transaction{
+			Hash:             generateBlockHash(blockNumber, chainId, fmt.Sprintf("tx-%d", i)),
+			Nonce:            quantity(i, "nonce", 4),
+			BlockHash:        blockHash,
+			BlockNumber:      fmt.Sprintf("0x%x", blockNumber),
+			TransactionIndex: fmt.Sprintf("0x%x", i),
+			From:             "0x" + seed(i, "from")[2:42],
+			To:               "0x" + seed(i, "to")[2:42],
+			Value:            quantity(i, "value", 15),
+			Gas:              "0x5208", // 21000, a plain transfer
+			GasPrice:         quantity(i, "gasPrice", 9),
+			Input:            "0x",
+			V:                "0x1",
+			R:                seed(i, "r"),
+			S:                seed(i, "s"),
+		}
+	}
+	return transactions
+}
+
+// blockTransactions returns the transactions of a block for eth_getBlockByNumber: full
+// transaction objects with fullTx, otherwise their hashes
+func blockTransactions(chainId string, blockNumber uint64, fullTx bool) []interface{} {
+	list := blockTransactionList(chainId, blockNumber)
+	transactions := make([]interface{}, len(list))
+	for i, tx := range list {
+		if fullTx {
+			transactions[i] = tx
+		} else {
+			transactions[i] = tx.Hash
+		}
+	}
+	return transactions
+}
+
+func hexOrZero(s string) string {
+	if s == "" {
+		return "0x0"
+	}
+	return s
+}
+
+func addressOrZero(s string) string {
+	if s == "" {
+		return "0x0000000000000000000000000000000000000000"
+	}
+	return s
+}
+
+// mineSolanaSlot produces exactly one Solana slot and returns it
+func mineSolanaSlot() uint64 {
+	newSlot := atomic.AddUint64(&solanaNode.SlotNumber, 1)
+	subManager.BroadcastNewBlock("501", newSlot)
+	subManager.BroadcastSolanaLogs(newSlot)
+	log.Printf("Mined slot %d for Solana", newSlot)
+	return newSlot
+}
+
+// handleMineBlock produces exactly one block (slot for Solana), regardless of automine and
+// run state, optionally including the queued injected transactions
+func handleMineBlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResponse(w, http.StatusMethodNotAllowed, ControlResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req struct {
+		Chain         string `json:"chain"`
+		IncludeQueued bool   `json:"include_queued"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		jsonResponse(w, http.StatusBadRequest, ControlResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	if req.Chain == "solana" {
+		newSlot := mineSolanaSlot()
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"success":      true,
+			"block_number": newSlot,
+		})
+		return
+	}
+
+	chain, ok := supportedChains[req.Chain]
+	if !ok {
+		jsonResponse(w, http.StatusBadRequest, ControlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unsupported chain: %s", req.Chain),
+		})
+		return
+	}
+
+	var txs []QueuedTransaction
+	if req.IncludeQueued {
+		chain.miningMu.Lock()
+		txs, chain.txQueue = chain.txQueue, nil
+		chain.miningMu.Unlock()
+	}
+	newBlock := chain.produceBlock(chainIDForName(req.Chain), txs)
+
+	hashes := []string{}
+	for _, tx := range minedTransactions(chainIDForName(req.Chain), newBlock) {
+		hashes = append(hashes, tx.Hash)
+	}
+	log.Printf("Mined block %d with %d transactions for chain %s", newBlock, len(txs), req.Chain)
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"success":      true,
+		"block_number": newBlock,
+		"transactions": hashes,
+	})
+}
+
+// handleSetAutomine turns automatic block production of a chain on or off
+func handleSetAutomine(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResponse(w, http.StatusMethodNotAllowed, ControlResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req struct {
+		Chain   string `json:"chain"`
+		Enabled bool   `json:"enabled"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, http.StatusBadRequest, ControlResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	if req.Chain == "solana" {
+		solanaNode.ManualMining = !req.Enabled
+	} else if chain, ok := supportedChains[req.Chain]; ok {
+		chain.ManualMining = !req.Enabled
+	} else {
+		jsonResponse(w, http.StatusBadRequest, ControlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unsupported chain: %s", req.Chain),
+		})
+		return
+	}
+	persistChainConfig()
+
+	log.Printf("Set automine to %t for chain %s", req.Enabled, req.Chain)
+	jsonResponse(w, http.StatusOK, ControlResponse{
+		Success: true,
+		Message: fmt.Sprintf("Automine set to %t for chain %s", req.Enabled, req.Chain),
+	})
+}
+
+// handleQueueTransactions queues injected transactions for the next block mined with include_queued
+func handleQueueTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResponse(w, http.StatusMethodNotAllowed, ControlResponse{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req struct {
+		Chain        string              `json:"chain"`
+		Transactions []QueuedTransaction `json:"transactions"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonResponse(w, http.StatusBadRequest, ControlResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	chain, ok := supportedChains[req.Chain]
+	if !ok {
+		jsonResponse(w, http.StatusBadRequest, ControlResponse{
+			Success: false,
+			Message: fmt.Sprintf("Unsupported chain: %s", req.Chain),
+		})
+		return
+	}
+
+	chain.miningMu.Lock()
+	if len(chain.txQueue)+len(req.Transactions) > maxQueuedTransactions {
+		chain.miningMu.Unlock()
+		jsonResponse(w, http.StatusBadRequest, ControlResponse{
+			Success: false,
+			Message: fmt.Sprintf("At most %d transactions can be queued", maxQueuedTransactions),
+		})
+		return
+	}
+	chain.txQueue = append(chain.txQueue, req.Transactions...)
+	queued := len(chain.txQueue)
+	chain.miningMu.Unlock()
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"queued":  queued,
+	})
+}
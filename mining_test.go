@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func postMining(t *testing.T, handler http.HandlerFunc, body string) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/control/block/mine", bytes.NewBufferString(body)))
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func TestMineBlockProducesExactlyOneBlock(t *testing.T) {
+	chain := supportedChains["gnosis"]
+	before := atomic.LoadUint64(&chain.BlockNumber)
+
+	code, resp := postMining(t, handleMineBlock, `{"chain": "gnosis"}`)
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if got := atomic.LoadUint64(&chain.BlockNumber); got != before+1 {
+		t.Errorf("Expected block %d, got %d", before+1, got)
+	}
+	if resp["block_number"] != float64(before+1) {
+		t.Errorf("Expected mined block %d in response, got %v", before+1, resp["block_number"])
+	}
+
+	if code, _ := postMining(t, handleMineBlock, `{"chain": "unknown"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown chain, got %d", code)
+	}
+}
+
+func TestMineBlockIncludesQueuedTransactions(t *testing.T) {
+	chain := supportedChains["gnosis"]
+	conn := NewMockWSConn()
+	defer subManager.CleanupConnection(conn)
+
+	request := JSONRPCRequest{
+		JsonRPC: "2.0",
+		Method:  "eth_subscribe",
+		Params:  []interface{}{"newHeads", map[string]interface{}{"includeTransactions": true}},
+		ID:      1,
+	}
+	data, _ := json.Marshal(request)
+	if _, err := handleEVMRequest(context.Background(), data, conn, "100"); err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+
+	if code, resp := postMining(t, handleQueueTransactions, `{"chain": "gnosis", "transactions": [
+		{"from": "0x1111111111111111111111111111111111111111", "to": "0x2222222222222222222222222222222222222222", "value": "0xde0b6b3a7640000"},
+		{"from": "0x3333333333333333333333333333333333333333", "input": "0xa9059cbb"}]}`); code != http.StatusOK || resp["queued"] != float64(2) {
+		t.Fatalf("Expected 2 queued transactions, got %d %v", code, resp)
+	}
+
+	// Without include_queued the queue is kept
+	postMining(t, handleMineBlock, `{"chain": "gnosis"}`)
+	_, resp := postMining(t, handleMineBlock, `{"chain": "gnosis", "include_queued": true}`)
+	hashes, _ := resp["transactions"].([]interface{})
+	if len(hashes) != 2 {
+		t.Fatalf("Expected 2 mined transactions, got %v", resp["transactions"])
+	}
+	subManager.Flush("100")
+
+	messages := conn.GetMessages()
+	last := string(messages[len(messages)-1])
+	for _, want := range []string{hashes[0].(string), "0x2222222222222222222222222222222222222222", "0xa9059cbb"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("Expected %s in the newHeadsWithTx notification, got %s", want, last)
+		}
+	}
+
+	chain.miningMu.Lock()
+	queued := len(chain.txQueue)
+	chain.miningMu.Unlock()
+	if queued != 0 {
+		t.Errorf("Expected the queue to be drained, %d transactions left", queued)
+	}
+}
+
+func TestGetBlockByNumberTransactions(t *testing.T) {
+	postMining(t, handleMineBlock, `{"chain": "gnosis"}`) // A block without transactions
+	postMining(t, handleQueueTransactions, `{"chain": "gnosis", "transactions": [{"from": "0x1111111111111111111111111111111111111111", "value": "0x1"}]}`)
+	_, resp := postMining(t, handleMineBlock, `{"chain": "gnosis", "include_queued": true}`)
+	block := uint64(resp["block_number"].(float64))
+	hash := resp["transactions"].([]interface{})[0].(string)
+
+	getBlock := func(params string) map[string]interface{} {
+		response, err := handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_getBlockByNumber","params":`+params+`,"id":1}`), NewMockWSConn(), "100")
+		if err != nil {
+			t.Fatalf("Handler error: %v", err)
+		}
+		var resp JSONRPCResponse
+		json.Unmarshal(response, &resp)
+		if resp.Error != nil {
+			t.Fatalf("%s: unexpected error %s", params, resp.Error.Message)
+		}
+		return resp.Result.(map[string]interface{})
+	}
+	number := fmt.Sprintf(`"0x%x"`, block)
+
+	hashes := getBlock(`[` + number + `, false]`)["transactions"].([]interface{})
+	if len(hashes) != 1 || hashes[0] != hash {
+		t.Errorf("Expected the transaction hash, got %v", hashes)
+	}
+	full := getBlock(`[` + number + `, true]`)["transactions"].([]interface{})
+	if tx, ok := full[0].(map[string]interface{}); len(full) != 1 || !ok || tx["hash"] != hash || tx["from"] != "0x1111111111111111111111111111111111111111" {
+		t.Errorf("Expected the full transaction object, got %v", full)
+	}
+	if hashes := getBlock(`[` + number + `]`)["transactions"].([]interface{}); len(hashes) != 1 || hashes[0] != hash {
+		t.Errorf("Expected hashes by default, got %v", hashes)
+	}
+
+	// A block without injected transactions reports derived ones, the same on every call and
+	// in its newHeadsWithTx notification
+	derived := getBlock(fmt.Sprintf(`["0x%x", true]`, block-1))["transactions"].([]interface{})
+	if len(derived) < 1 || len(derived) > 5 {
+		t.Fatalf("Expected 1-5 derived transactions, got %v", derived)
+	}
+	if again := getBlock(fmt.Sprintf(`["0x%x", true]`, block-1))["transactions"]; !reflect.DeepEqual(again, derived) {
+		t.Errorf("Expected the same transactions on every call, got %v and %v", derived, again)
+	}
+	conn := NewMockWSConn()
+	defer subManager.CleanupConnection(conn)
+	subManager.Subscribe("100", conn, "newHeadsWithTx")
+	subManager.BroadcastNewBlock("100", block-1)
+	subManager.Flush("100")
+	var notification struct {
+		Params struct {
+			Result struct {
+				Transactions []interface{} `json:"transactions"`
+			} `json:"result"`
+		} `json:"params"`
+	}
+	if messages := conn.GetMessages(); len(messages) == 0 || json.Unmarshal(messages[len(messages)-1], &notification) != nil {
+		t.Fatalf("Expected a newHeadsWithTx notification, got %d messages", len(messages))
+	}
+	if !reflect.DeepEqual(notification.Params.Result.Transactions, derived) {
+		t.Errorf("Expected the notification to carry the block's transactions, got %v", notification.Params.Result.Transactions)
+	}
+
+	response, _ := handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_getBlockByNumber","params":["latest","yes"],"id":1}`), NewMockWSConn(), "100")
+	if !strings.Contains(string(response), "-32602") {
+		t.Errorf("Expected -32602 for a non-boolean flag, got %s", response)
+	}
+}
+
+func TestSetAutomine(t *testing.T) {
+	withTempConfig(t)
+	chain := supportedChains["gnosis"]
+	defer func() {
+		chain.ManualMining = false
+	}()
+
+	if code, _ := postMining(t, handleSetAutomine, `{"chain": "gnosis", "enabled": false}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if !chain.ManualMining {
+		t.Error("Expected manual mining to be enabled")
+	}
+	postMining(t, handleSetAutomine, `{"chain": "gnosis", "enabled": true}`)
+	if chain.ManualMining {
+		t.Error("Expected automine to be enabled")
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Mirror sink formats
+const (
+	MirrorFormatJSON      = "json"       // POST a JSON array of mirrored requests; the default
+	MirrorFormatKafkaREST = "kafka_rest" // Produce to a topic through a Kafka REST Proxy (v2 JSON embedded format)
+)
+
+// MirrorFormats lists all supported mirror sink formats
+var MirrorFormats = []string{MirrorFormatJSON, MirrorFormatKafkaREST}
+
+// MirrorConfig mirrors every received RPC request to an external sink. Mirroring is
+// asynchronous and lossy: responses never wait for the sink, and requests are dropped
+// when the queue is full.
+type MirrorConfig struct {
+	URL           string        `yaml:"url" json:"url"`                                           // Sink endpoint; the Kafka REST Proxy base URL for kafka_rest
+	Format        string        `yaml:"format,omitempty" json:"format,omitempty"`                 // See MirrorFormats
+	Topic         string        `yaml:"topic,omitempty" json:"topic,omitempty"`                   // Kafka topic, required for kafka_rest
+	BatchSize     int           `yaml:"batch_size,omitempty" json:"batch_size,omitempty"`         // Requests per POST (0 = 100)
+	FlushInterval time.Duration `yaml:"flush_interval,omitempty" json:"flush_interval,omitempty"` // Longest time a request waits in a partial batch (0 = 1s)
+	QueueSize     int           `yaml:"queue_size,omitempty" json:"queue_size,omitempty"`         // Requests buffered before dropping (0 = 10000)
+}
+
+// MirroredRequest is one mirrored request as delivered to the sink
+type MirroredRequest struct {
+	Time      time.Time       `json:"time"`
+	ChainID   string          `json:"chain_id"`
+	Chain     string          `json:"chain"`
+	Transport string          `json:"transport"`         // ws or http
+	ConnID    uint64          `json:"conn_id,omitempty"` // WebSocket connection id
+	Request   json.RawMessage `json:"request,omitempty"` // The request as received, when it is valid JSON
+	Raw       string          `json:"raw,omitempty"`     // The request as received, when it is not
+}
+
+// validate checks the URL and format and applies defaults
+func (c *MirrorConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+	if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("mirror url must be an http(s) URL")
+	}
+	switch c.Format {
+	case "":
+		c.Format = MirrorFormatJSON
+	case MirrorFormatJSON:
+	case MirrorFormatKafkaREST:
+		if c.Topic == "" {
+			return fmt.Errorf("mirror topic is required for %s", MirrorFormatKafkaREST)
+		}
+	default:
+		return fmt.Errorf("invalid mirror format: %s (supported: %v)", c.Format, MirrorFormats)
+	}
+	if c.BatchSize < 0 || c.FlushInterval < 0 || c.QueueSize < 0 {
+		return fmt.Errorf("mirror batch size, flush interval and queue size must be non-negative")
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 100
+	}
+	if c.FlushInterval == 0 {
+		c.FlushInterval = time.Second
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = 10000
+	}
+	return nil
+}
+
+// requestMirror delivers mirrored requests to the configured sink in the background
+type requestMirror struct {
+	mu     sync.RWMutex
+	config *MirrorConfig
+	queue  chan MirroredRequest
+	done   chan struct{} // Closed to stop the worker after a final flush
+	client *http.Client
+
+	mirrored, dropped, failed uint64
+}
+
+var mirror = &requestMirror{client: &http.Client{Timeout: 5 * time.Second}}
+
+// configure replaces the sink, flushing requests queued for the previous one; nil disables mirroring
+func (m *requestMirror) configure(config *MirrorConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done != nil {
+		close(m.done)
+		m.queue, m.done = nil, nil
+	}
+	m.config = config
+	if config == nil {
+		return
+	}
+	m.queue = make(chan MirroredRequest, config.QueueSize)
+	m.done = make(chan struct{})
+	go m.run(config, m.queue, m.done)
+}
+
+// record queues a received request for the sink without blocking
+func (m *requestMirror) record(chainId, transport string, connID uint64, message []byte) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.queue == nil {
+		return
+	}
+
+	mirrored := MirroredRequest{
+		Time:      time.Now().UTC(),
+		ChainID:   chainId,
+		Chain:     chainIdToName[chainId],
+		Transport: transport,
+		ConnID:    connID,
+	}
+	if json.Valid(message) {
+		mirrored.Request = append(json.RawMessage(nil), message...)
+	} else {
+		mirrored.Raw = string(message)
+	}
+	select {
+	case m.queue <- mirrored:
+	default:
+		atomic.AddUint64(&m.dropped, 1)
+	}
+}
+
+// run batches queued requests until done is closed
+func (m *requestMirror) run(config *MirrorConfig, queue chan MirroredRequest, done chan struct{}) {
+	ticker := time.NewTicker(config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]MirroredRequest, 0, config.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			m.send(config, batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case request := <-queue:
+			batch = append(batch, request)
+			if len(batch) >= config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-done:
+			for {
+				select {
+				case request := <-queue:
+					batch = append(batch, request)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// send posts one batch to the sink
+func (m *requestMirror) send(config *MirrorConfig, batch []MirroredRequest) {
+	target, contentType := config.URL, "application/json"
+	var body interface{} = batch
+	if config.Format == MirrorFormatKafkaREST {
+		type record struct {
+			Key   string          `json:"key"`
+			Value MirroredRequest `json:"value"`
+		}
+		records := make([]record, len(batch))
+		for i, request := range batch {
+			records[i] = record{Key: request.ChainID, Value: request}
+		}
+		body = map[string]interface{}{"records": records}
+		target = strings.TrimSuffix(config.URL, "/") + "/topics/" + url.PathEscape(config.Topic)
+		contentType = "application/vnd.kafka.json.v2+json"
+	}
+
+	data, err := json.Marshal(body)
+	if err == nil {
+		var resp *http.Response
+		resp, err = m.client.Post(target, contentType, bytes.NewReader(data))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				err = fmt.Errorf("sink returned %s", resp.Status)
+			}
+		}
+	}
+	if err != nil {
+		atomic.AddUint64(&m.failed, uint64(len(batch)))
+		log.Printf("Failed to mirror %d requests: %v", len(batch), err)
+		return
+	}
+	atomic.AddUint64(&m.mirrored, uint64(len(batch)))
+}
+
+// handleMirror reports (GET) or configures (POST) request mirroring; an empty url disables it
+func handleMirror(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mirror.mu.RLock()
+		config := mirror.config
+		mirror.mu.RUnlock()
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"config":   config,
+			"mirrored": atomic.LoadUint64(&mirror.mirrored),
+			"dropped":  atomic.LoadUint64(&mirror.dropped),
+			"failed":   atomic.LoadUint64(&mirror.failed),
+		})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		URL             string `json:"url"`
+		Format          string `json:"format"`
+		Topic           string `json:"topic"`
+		BatchSize       int    `json:"batch_size"`
+		FlushIntervalMs int64  `json:"flush_interval_ms"`
+		QueueSize       int    `json:"queue_size"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var config *MirrorConfig
+	if request.URL != "" {
+		config = &MirrorConfig{
+			URL:           request.URL,
+			Format:        request.Format,
+			Topic:         request.Topic,
+			BatchSize:     request.BatchSize,
+			FlushInterval: time.Duration(request.FlushIntervalMs) * time.Millisecond,
+			QueueSize:     request.QueueSize,
+		}
+		if err := config.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	mirror.configure(config)
+	persistChainConfig()
+
+	if config == nil {
+		log.Printf("Disabled request mirroring")
+	} else {
+		log.Printf("Mirroring requests to %s (%s)", config.URL, config.Format)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mirrorSink records the batches posted to it
+type mirrorSink struct {
+	mu      sync.Mutex
+	paths   []string
+	types   []string
+	batches [][]byte
+}
+
+func (s *mirrorSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths = append(s.paths, r.URL.Path)
+	s.types = append(s.types, r.Header.Get("Content-Type"))
+	s.batches = append(s.batches, body)
+}
+
+func (s *mirrorSink) waitForBatches(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		got := len(s.batches)
+		s.mu.Unlock()
+		if got >= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected %d batches at the sink", n)
+}
+
+func setMirror(t *testing.T, body string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleMirror(w, httptest.NewRequest(http.MethodPost, "/control/mirror", bytes.NewBufferString(body)))
+	return w.Code
+}
+
+func TestMirrorRequests(t *testing.T) {
+	withTempConfig(t)
+	defer func() {
+		mirror.configure(nil)
+	}()
+
+	sink := &mirrorSink{}
+	server := httptest.NewServer(sink)
+	defer server.Close()
+
+	if code := setMirror(t, `{"url": "`+server.URL+`/ingest", "batch_size": 2, "flush_interval_ms": 20}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+
+	for _, body := range []string{`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`, `not json`} {
+		w := httptest.NewRecorder()
+		handleChainHTTP(w, httptest.NewRequest(http.MethodPost, "/chain/1", bytes.NewBufferString(body)))
+	}
+	sink.waitForBatches(t, 1)
+
+	var batch []MirroredRequest
+	if err := json.Unmarshal(sink.batches[0], &batch); err != nil {
+		t.Fatalf("Invalid batch %s: %v", sink.batches[0], err)
+	}
+	if len(batch) != 2 || sink.paths[0] != "/ingest" {
+		t.Fatalf("Expected one batch of 2 requests at /ingest, got %s at %s", sink.batches[0], sink.paths[0])
+	}
+	if batch[0].Chain != "ethereum" || batch[0].Transport != "http" || string(batch[0].Request) != `{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}` {
+		t.Errorf("Unexpected mirrored request: %+v", batch[0])
+	}
+	if batch[1].Raw != "not json" || batch[1].Request != nil {
+		t.Errorf("Expected invalid JSON to be mirrored raw, got %+v", batch[1])
+	}
+}
+
+func TestMirrorKafkaREST(t *testing.T) {
+	withTempConfig(t)
+	defer func() {
+		mirror.configure(nil)
+	}()
+
+	sink := &mirrorSink{}
+	server := httptest.NewServer(sink)
+	defer server.Close()
+
+	setMirror(t, `{"url": "`+server.URL+`", "format": "kafka_rest", "topic": "rpc-requests", "flush_interval_ms": 20}`)
+	mirror.record("501", "ws", 7, []byte(`{"jsonrpc":"2.0","method":"getSlot","id":1}`))
+	sink.waitForBatches(t, 1)
+
+	var produced struct {
+		Records []struct {
+			Key   string          `json:"key"`
+			Value MirroredRequest `json:"value"`
+		} `json:"records"`
+	}
+	json.Unmarshal(sink.batches[0], &produced)
+	if sink.paths[0] != "/topics/rpc-requests" || sink.types[0] != "application/vnd.kafka.json.v2+json" {
+		t.Errorf("Expected a Kafka REST produce request, got %s (%s)", sink.paths[0], sink.types[0])
+	}
+	if len(produced.Records) != 1 || produced.Records[0].Key != "501" || produced.Records[0].Value.ConnID != 7 {
+		t.Errorf("Unexpected records: %s", sink.batches[0])
+	}
+}
+
+func TestMirrorValidation(t *testing.T) {
+	for _, body := range []string{
+		`{"url": "ftp://example.com"}`,
+		`{"url": "http://localhost:9999", "format": "avro"}`,
+		`{"url": "http://localhost:9999", "format": "kafka_rest"}`,
+		`{"url": "http://localhost:9999", "batch_size": -1}`,
+	} {
+		if code := setMirror(t, body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, code)
+		}
+	}
+}
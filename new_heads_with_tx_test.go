@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNewHeadsWithTxPerChainToggle(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	original := chain.DisableNewHeadsWithTx
+	defer func() { chain.DisableNewHeadsWithTx = original }()
+
+	subscribe := func() JSONRPCResponse {
+		conn := NewMockWSConn()
+		defer subManager.CleanupConnection(conn)
+
+		request := JSONRPCRequest{
+			JsonRPC: "2.0",
+			Method:  "eth_subscribe",
+			Params:  []interface{}{"newHeads", map[string]interface{}{"includeTransactions": true}},
+			ID:      1,
+		}
+		data, _ := json.Marshal(request)
+		response, err := handleEVMRequest(context.Background(), data, conn, "1")
+		if err != nil {
+			t.Fatalf("Handler error: %v", err)
+		}
+		var resp JSONRPCResponse
+		if err := json.Unmarshal(response, &resp); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return resp
+	}
+
+	// Extension enabled by default
+	chain.DisableNewHeadsWithTx = false
+	if resp := subscribe(); resp.Error != nil {
+		t.Errorf("Expected subscription to succeed, got error: %v", resp.Error.Message)
+	}
+
+	// Strict chains reject includeTransactions
+	chain.DisableNewHeadsWithTx = true
+	resp := subscribe()
+	if resp.Error == nil {
+		t.Fatal("Expected error when newHeadsWithTx is disabled")
+	}
+	if resp.Error.Code != -32602 {
+		t.Errorf("Expected error code -32602, got %d", resp.Error.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NotificationMetrics counts head notification deliveries for one chain
+type NotificationMetrics struct {
+	attempted     uint64
+	delivered     uint64
+	dropped       uint64
+	writeFailures uint64
+	totalDelayNs  uint64 // Sum of delivery delays of delivered notifications
+}
+
+// NotificationMetricsSnapshot is the JSON representation of NotificationMetrics
+type NotificationMetricsSnapshot struct {
+	Attempted           uint64  `json:"attempted"`
+	Delivered           uint64  `json:"delivered"`
+	Dropped             uint64  `json:"dropped"`        // Never written: the broadcast queue was full, or dropped for a slow client
+	WriteFailures       uint64  `json:"write_failures"` // Writes that failed; the subscription is removed
+	MeanDeliveryDelayMs float64 `json:"mean_delivery_delay_ms"`
+}
+
+// Record accounts for one notification write that started at produced
+func (m *NotificationMetrics) Record(produced time.Time, err error) {
+	atomic.AddUint64(&m.attempted, 1)
+	switch {
+	case errors.Is(err, errSlowClientDropped):
+		atomic.AddUint64(&m.dropped, 1)
+	case err != nil:
+		atomic.AddUint64(&m.writeFailures, 1)
+	default:
+		atomic.AddUint64(&m.delivered, 1)
+		atomic.AddUint64(&m.totalDelayNs, uint64(time.Since(produced)))
+	}
+}
+
+// RecordDropped accounts for count notifications that were never written because the
+// chain's broadcast queue was full
+func (m *NotificationMetrics) RecordDropped(count int) {
+	atomic.AddUint64(&m.attempted, uint64(count))
+	atomic.AddUint64(&m.dropped, uint64(count))
+}
+
+// Snapshot returns the current counters
+func (m *NotificationMetrics) Snapshot() NotificationMetricsSnapshot {
+	snapshot := NotificationMetricsSnapshot{
+		Attempted:     atomic.LoadUint64(&m.attempted),
+		Delivered:     atomic.LoadUint64(&m.delivered),
+		Dropped:       atomic.LoadUint64(&m.dropped),
+		WriteFailures: atomic.LoadUint64(&m.writeFailures),
+	}
+	if snapshot.Delivered > 0 {
+		meanNs := atomic.LoadUint64(&m.totalDelayNs) / snapshot.Delivered
+		snapshot.MeanDeliveryDelayMs = float64(meanNs) / float64(time.Millisecond)
+	}
+	return snapshot
+}
+
+// NotificationMetricsRegistry holds head notification metrics per chain ID
+type NotificationMetricsRegistry struct {
+	chains sync.Map // chainId -> *NotificationMetrics
+}
+
+var headNotificationMetrics = &NotificationMetricsRegistry{}
+
+// For returns the metrics of a chain, creating them on first use
+func (r *NotificationMetricsRegistry) For(chainId string) *NotificationMetrics {
+	metrics, _ := r.chains.LoadOrStore(chainId, &NotificationMetrics{})
+	return metrics.(*NotificationMetrics)
+}
+
+// Snapshot returns the metrics of every chain that has sent notifications, keyed by chain ID
+func (r *NotificationMetricsRegistry) Snapshot() map[string]NotificationMetricsSnapshot {
+	snapshots := make(map[string]NotificationMetricsSnapshot)
+	r.chains.Range(func(key, value interface{}) bool {
+		snapshots[key.(string)] = value.(*NotificationMetrics).Snapshot()
+		return true
+	})
+	return snapshots
+}
+
+// Reset clears the metrics of all chains
+func (r *NotificationMetricsRegistry) Reset() {
+	r.chains.Range(func(key, value interface{}) bool {
+		r.chains.Delete(key)
+		return true
+	})
+}
+
+// handleNotificationMetrics returns head notification fan-out metrics per chain ID
+func handleNotificationMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	jsonResponse(w, http.StatusOK, headNotificationMetrics.Snapshot())
+}
+
+// handleResetNotificationMetrics clears head notification fan-out metrics
+func handleResetNotificationMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	headNotificationMetrics.Reset()
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHeadNotificationMetrics(t *testing.T) {
+	headNotificationMetrics.Reset()
+	defer headNotificationMetrics.Reset()
+
+	sm := NewSubscriptionManager()
+	live := NewMockWSConn()
+	dead := NewMockWSConn()
+	dead.Close()
+	sm.Subscribe("250", live, "newHeads")
+	sm.Subscribe("250", dead, "newHeads")
+
+	sm.BroadcastNewBlock("250", 10)
+	sm.Flush("250")
+	sm.BroadcastNewBlock("250", 11)
+	sm.Flush("250")
+
+	snapshot := headNotificationMetrics.For("250").Snapshot()
+	// The dead connection fails once and its subscription is removed
+	if snapshot.Attempted != 3 || snapshot.Delivered != 2 || snapshot.WriteFailures != 1 || snapshot.Dropped != 0 {
+		t.Errorf("Unexpected metrics: %+v", snapshot)
+	}
+	if snapshot.MeanDeliveryDelayMs < 0 {
+		t.Errorf("Expected non-negative mean delivery delay, got %f", snapshot.MeanDeliveryDelayMs)
+	}
+
+	mux := http.NewServeMux()
+	handleControlEndpoints(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/control/metrics/notifications", nil))
+	var metrics map[string]NotificationMetricsSnapshot
+	if err := json.NewDecoder(w.Body).Decode(&metrics); err != nil {
+		t.Fatalf("Failed to decode metrics: %v", err)
+	}
+	if metrics["250"].Delivered != 2 {
+		t.Errorf("Expected 2 delivered notifications for chain 250, got %+v", metrics["250"])
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/control/metrics/notifications/reset", nil))
+	if got := headNotificationMetrics.For("250").Snapshot(); got.Attempted != 0 {
+		t.Errorf("Expected metrics to be reset, got %+v", got)
+	}
+}
+
+func TestHeadNotificationQueueOverflow(t *testing.T) {
+	headNotificationMetrics.Reset()
+	defer headNotificationMetrics.Reset()
+
+	sm := NewSubscriptionManager()
+	sm.Subscribe("250", NewMockWSConn(), "newHeads")
+	sm.Subscribe("250", NewMockWSConn(), "newHeads")
+
+	// Stall the broadcast worker and fill its queue
+	release := make(chan struct{})
+	sm.enqueue("250", 0, func() { <-release })
+	for i := 0; i < broadcastQueueSize; i++ {
+		sm.enqueue("250", 0, func() {})
+	}
+
+	sm.BroadcastNewBlock("250", 10)
+	close(release)
+	sm.Flush("250")
+
+	snapshot := headNotificationMetrics.For("250").Snapshot()
+	if snapshot.Attempted != 2 || snapshot.Dropped != 2 || snapshot.Delivered != 0 {
+		t.Errorf("Expected the head dropped for both subscribers, got %+v", snapshot)
+	}
+}
+
+func TestLogNotificationsDroppedWhenQueueFull(t *testing.T) {
+	headNotificationMetrics.Reset()
+	defer headNotificationMetrics.Reset()
+
+	sm := NewSubscriptionManager()
+	conn := NewMockWSConn()
+	sm.Subscribe("250", conn, "logs")
+	sm.Subscribe("250", NewMockWSConn(), "newHeads")
+
+	// Stall the broadcast worker and fill its queue; the log must not block the producer
+	release := make(chan struct{})
+	sm.enqueue("250", 0, func() { <-release })
+	for i := 0; i < broadcastQueueSize; i++ {
+		sm.enqueue("250", 0, func() {})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		sm.BroadcastNewLog("250", LogEvent{BlockNumber: 10})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected BroadcastNewLog not to block on a full queue")
+	}
+	close(release)
+	sm.Flush("250")
+
+	if messages := conn.GetMessages(); len(messages) != 0 {
+		t.Errorf("Expected the log to be dropped, got %d messages", len(messages))
+	}
+	snapshot := headNotificationMetrics.For("250").Snapshot()
+	if snapshot.Attempted != 1 || snapshot.Dropped != 1 {
+		t.Errorf("Expected the log dropped for its one subscriber, got %+v", snapshot)
+	}
+
+	sm.BroadcastNewLog("250", LogEvent{BlockNumber: 11})
+	sm.Flush("250")
+	if snapshot := headNotificationMetrics.For("250").Snapshot(); snapshot.Attempted != 2 || snapshot.Delivered != 1 {
+		t.Errorf("Expected the next log delivered and counted, got %+v", snapshot)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const maxNotificationPadding = 16 << 20 // Upper bound for NotificationPadding in bytes
+
+// validateNotificationPadding checks a padding size in bytes
+func validateNotificationPadding(size int) error {
+	if size < 0 || size > maxNotificationPadding {
+		return fmt.Errorf("notification padding must be between 0 and %d bytes", maxNotificationPadding)
+	}
+	return nil
+}
+
+// notificationPadding is a chain's padding size with its prebuilt filler. It is replaced as a
+// whole, so broadcast workers read both without locking.
+type notificationPadding struct {
+	size   int
+	filler string
+}
+
+// setNotificationPadding changes the chain's padding size, building the filler once
+func (c *EVMChain) setNotificationPadding(size int) {
+	padding := &notificationPadding{size: size}
+	if size > 0 {
+		padding.filler = "0x" + strings.Repeat("00", size)
+	}
+	c.padding.Store(padding)
+}
+
+// notificationPadding returns the chain's current padding, which is empty until set
+func (c *EVMChain) notificationPadding() notificationPadding {
+	if padding, ok := c.padding.Load().(*notificationPadding); ok {
+		return *padding
+	}
+	return notificationPadding{}
+}
+
+// notificationFiller returns the hex filler for an EVM chain's newHeads and logs
+// notifications, or "" without padding. It carries no meaning: heads report it as their
+// extraData and logs as an extra extraData field that clients ignore.
+func notificationFiller(chainId string) string {
+	chain, ok := supportedChains[chainIdToName[chainId]]
+	if !ok {
+		return ""
+	}
+	return chain.notificationPadding().filler
+}
+
+// handleSetNotificationPadding configures the filler size of an EVM chain's notifications
+func handleSetNotificationPadding(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain string `json:"chain"`
+		Bytes int    `json:"bytes"` // 0 disables padding
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validateNotificationPadding(request.Bytes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	chain, ok := supportedChains[request.Chain]
+	if !ok {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+
+	chain.setNotificationPadding(request.Bytes)
+	log.Printf("Set notification padding to %d bytes for chain %s", request.Bytes, request.Chain)
+	persistChainConfig()
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
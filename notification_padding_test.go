@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationPadding(t *testing.T) {
+	withTempConfig(t)
+
+	chain := supportedChains["linea"]
+	defer chain.setNotificationPadding(0)
+
+	setPadding := func(body string) int {
+		w := httptest.NewRecorder()
+		handleSetNotificationPadding(w, httptest.NewRequest(http.MethodPost, "/control/chain/notification-padding", bytes.NewBufferString(body)))
+		return w.Code
+	}
+
+	conn := NewMockWSConn()
+	defer subManager.CleanupConnection(conn)
+	subManager.Subscribe("59144", conn, "newHeads")
+	subManager.Subscribe("59144", conn, "logs")
+
+	if code := setPadding(`{"chain": "linea", "bytes": 4096}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	chain.produceBlock("59144", nil)
+	subManager.broadcastNewLog("59144", chain.logEventForBlock(1, 0, 0), time.Now())
+	subManager.Flush("59144")
+
+	filler := "0x" + strings.Repeat("00", 4096)
+	var head, logEvent map[string]interface{}
+	for _, message := range conn.GetMessages() {
+		var notification struct {
+			Params struct {
+				Result map[string]interface{} `json:"result"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(message, &notification) != nil || notification.Params.Result == nil {
+			continue
+		}
+		if _, ok := notification.Params.Result["hash"]; ok {
+			head = notification.Params.Result
+		} else if _, ok := notification.Params.Result["logIndex"]; ok {
+			logEvent = notification.Params.Result
+		}
+	}
+	if head == nil || head["extraData"] != filler {
+		t.Errorf("Expected the head's extraData to carry the filler")
+	}
+	if logEvent == nil || logEvent["extraData"] != filler || logEvent["data"] != "0x"+strings.Repeat("00", 32) {
+		t.Errorf("Expected the log to carry the filler without changing its data, got %v", logEvent)
+	}
+	if faults := evmActiveFaults(chain); !containsString(faults, "notification_padding 4096") {
+		t.Errorf("Expected the padding in active faults, got %v", faults)
+	}
+
+	for body, want := range map[string]int{
+		`{"chain": "linea", "bytes": -1}`:        http.StatusBadRequest,
+		`{"chain": "linea", "bytes": 999999999}`: http.StatusBadRequest,
+		`{"chain": "solana", "bytes": 10}`:       http.StatusNotFound,
+	} {
+		if code := setPadding(body); code != want {
+			t.Errorf("%s: expected status %d, got %d", body, want, code)
+		}
+	}
+}
+
+func TestNotificationPaddingIsPersisted(t *testing.T) {
+	path := withTempConfig(t)
+	chain := supportedChains["linea"]
+	defer chain.setNotificationPadding(0)
+
+	chain.setNotificationPadding(128)
+	if filler := notificationFiller("59144"); filler != "0x"+strings.Repeat("00", 128) {
+		t.Errorf("Expected a 128 byte filler, got %d characters", len(filler))
+	}
+	persistChainConfig()
+	config, err := LoadChainConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load persisted config: %v", err)
+	}
+	if padding := config.EVMChains["linea"].NotificationPadding; padding != 128 {
+		t.Errorf("Expected the current padding to be persisted, got %d", padding)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// notificationHistory keeps the last notifications delivered to a subscription so they can be replayed
+type notificationHistory struct {
+	mu       sync.Mutex
+	messages [][]byte
+}
+
+func (h *notificationHistory) add(data []byte) {
+	limit := retentionLimit(RetentionStoreNotificationHistory)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, data)
+	if over := len(h.messages) - limit; over > 0 {
+		copy(h.messages, h.messages[over:])
+		h.messages = h.messages[:limit]
+		recordEvictions(RetentionStoreNotificationHistory, over)
+	}
+}
+
+// last returns up to n of the most recent notifications, oldest first
+func (h *notificationHistory) last(n int) [][]byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if n > len(h.messages) {
+		n = len(h.messages)
+	}
+	return append([][]byte(nil), h.messages[len(h.messages)-n:]...)
+}
+
+func (h *notificationHistory) len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.messages)
+}
+
+// deliver writes a notification to the subscription's connection and records it for replays
+func (s *Subscription) deliver(data []byte) error {
+	schemaCheck.checkNotification(s.Type, s.Method, data)
+	err := s.Conn.WriteMessage(websocket.TextMessage, data)
+	if err == nil && s.history != nil {
+		s.history.add(data)
+	}
+	return err
+}
+
+// findSubscription returns the active subscription with the given id on any chain
+func (sm *SubscriptionManager) findSubscription(id uint64) *Subscription {
+	for _, shard := range sm.allShards() {
+		shard.mu.RLock()
+		sub, ok := shard.subscriptions[id]
+		shard.mu.RUnlock()
+		if ok {
+			return sub
+		}
+	}
+	return nil
+}
+
+// notificationHistorySize returns the number of notifications kept across all subscriptions
+func (sm *SubscriptionManager) notificationHistorySize() int {
+	size := 0
+	for _, shard := range sm.allShards() {
+		shard.mu.RLock()
+		for _, sub := range shard.subscriptions {
+			if sub.history != nil {
+				size += sub.history.len()
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	return size
+}
+
+// ReplayNotifications re-sends the last count notifications of a subscription, oldest first.
+// Replayed messages are not recorded again.
+func (sm *SubscriptionManager) ReplayNotifications(id uint64, count int) (int, error) {
+	sub := sm.findSubscription(id)
+	if sub == nil {
+		return 0, fmt.Errorf("subscription %d not found", id)
+	}
+	if sub.history == nil {
+		return 0, nil
+	}
+	replayed := 0
+	for _, data := range sub.history.last(count) {
+		if err := sub.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// handleReplayNotifications serves POST /control/subscriptions/{id}/replay. The id is the
+// subscription id as returned to the client, decimal or 0x-prefixed hex.
+func handleReplayNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/control/subscriptions/")
+	idPart, ok := strings.CutSuffix(rest, "/replay")
+	if !ok || idPart == "" || strings.Contains(idPart, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.ParseUint(idPart, 0, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid subscription id: %s", idPart), http.StatusBadRequest)
+		return
+	}
+
+	var request struct {
+		Count int `json:"count"` // Notifications to replay (default 1, capped by the retained history)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Count < 0 {
+		http.Error(w, "count must be non-negative", http.StatusBadRequest)
+		return
+	}
+	if request.Count == 0 {
+		request.Count = 1
+	}
+
+	if subManager.findSubscription(id) == nil {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+	replayed, err := subManager.ReplayNotifications(id, request.Count)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Replay failed after %d notifications: %v", replayed, err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Replayed %d notifications to subscription %d", replayed, id)
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":   "ok",
+		"replayed": replayed,
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func postReplay(path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handleReplayNotifications(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body)))
+	return w
+}
+
+func TestReplayNotifications(t *testing.T) {
+	conn := NewMockWSConn()
+	defer subManager.CleanupConnection(conn)
+
+	request := JSONRPCRequest{JsonRPC: "2.0", Method: "eth_subscribe", Params: []interface{}{"newHeads"}, ID: 1}
+	data, _ := json.Marshal(request)
+	response, err := handleEVMRequest(context.Background(), data, conn, "100")
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	var subscribed JSONRPCResponse
+	json.Unmarshal(response, &subscribed)
+	subID := subscribed.Result.(string)
+
+	for block := uint64(1000); block < 1003; block++ {
+		subManager.BroadcastNewBlock("100", block)
+	}
+	subManager.Flush("100")
+	delivered := conn.GetMessages()
+	if len(delivered) != 3 {
+		t.Fatalf("Expected 3 notifications, got %d", len(delivered))
+	}
+	conn.ClearMessages()
+
+	w := postReplay(fmt.Sprintf("/control/subscriptions/%s/replay", subID), `{"count": 2}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	replayed := conn.GetMessages()
+	if len(replayed) != 2 || string(replayed[0]) != string(delivered[1]) || string(replayed[1]) != string(delivered[2]) {
+		t.Errorf("Expected the last 2 notifications replayed in order, got %q", replayed)
+	}
+
+	// Asking for more than the history replays everything, and replays are not recorded again
+	conn.ClearMessages()
+	w = postReplay(fmt.Sprintf("/control/subscriptions/%s/replay", subID), `{"count": 100}`)
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["replayed"] != float64(3) || len(conn.GetMessages()) != 3 {
+		t.Errorf("Expected 3 replayed notifications, got %v", resp["replayed"])
+	}
+}
+
+func TestReplayNotificationsHistoryCap(t *testing.T) {
+	original := retentionConfig
+	retentionConfig = &RetentionConfig{NotificationHistory: 2}
+	defer func() { retentionConfig = original }()
+
+	history := &notificationHistory{}
+	for i := 0; i < 5; i++ {
+		history.add([]byte{byte(i)})
+	}
+	if got := history.last(10); len(got) != 2 || got[0][0] != 3 || got[1][0] != 4 {
+		t.Errorf("Expected the 2 most recent notifications, got %v", got)
+	}
+}
+
+func TestReplayNotificationsErrors(t *testing.T) {
+	tests := []struct {
+		path string
+		body string
+		want int
+	}{
+		{"/control/subscriptions/0xffffffff/replay", `{}`, http.StatusNotFound},
+		{"/control/subscriptions/abc/replay", `{}`, http.StatusBadRequest},
+		{"/control/subscriptions/1/replay", `{"count": -1}`, http.StatusBadRequest},
+		{"/control/subscriptions/1", `{}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := postReplay(tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.want, w.Code)
+		}
+	}
+}
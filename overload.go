@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// OverloadConfig controls the overload self-protection mode. When producing and fanning out
+// a block takes more than Budget of the block interval, the simulator signals degradation
+// the way an overloaded provider does, instead of silently falling behind its own tickers:
+// requests of the chain get slower, and while the overload lasts, subscribed connections
+// are shed with close code 1013 (try again later).
+type OverloadConfig struct {
+	Enabled           bool    `json:"enabled"`
+	Budget            float64 `json:"budget"`               // Share of the block interval a block may take (0 < budget <= 1)
+	MaxAddedLatencyMs int     `json:"max_added_latency_ms"` // Cap on the latency added to requests while overloaded
+	ShedAfterTicks    int     `json:"shed_after_ticks"`     // Consecutive overloaded blocks before connections are shed (0 = never shed)
+}
+
+// validate checks the config's ranges
+func (c OverloadConfig) validate() error {
+	if c.Budget <= 0 || c.Budget > 1 {
+		return fmt.Errorf("budget must be greater than 0 and at most 1")
+	}
+	if c.MaxAddedLatencyMs < 0 {
+		return fmt.Errorf("max_added_latency_ms must be non-negative")
+	}
+	if c.ShedAfterTicks < 0 {
+		return fmt.Errorf("shed_after_ticks must be non-negative")
+	}
+	return nil
+}
+
+// overloadProtection holds the current config; runtime only, enabled at start by OVERLOAD_PROTECTION
+var overloadProtection = struct {
+	sync.RWMutex
+	config OverloadConfig
+}{config: OverloadConfig{Budget: 0.5, MaxAddedLatencyMs: 1000, ShedAfterTicks: 3}}
+
+// overloadConfig returns the current overload protection config
+func overloadConfig() OverloadConfig {
+	overloadProtection.RLock()
+	defer overloadProtection.RUnlock()
+	return overloadProtection.config
+}
+
+// ChainLoad tracks how much of its block interval a chain needs, and counts injected and
+// overload-induced faults separately so tests can tell them apart
+type ChainLoad struct {
+	mu              sync.Mutex
+	pressure        float64       // Share of the interval the last block took
+	overloadedTicks int           // Consecutive blocks over budget
+	interval        time.Duration // Interval of the last block
+	lastFanOut      time.Duration // Queueing and delivery time of the last head fan-out
+
+	injectedLatencyRequests uint64
+	injectedLatencyNs       uint64
+	injectedErrors          uint64
+	overloadLatencyRequests uint64
+	overloadLatencyNs       uint64
+	overloadTicks           uint64
+	shedConnections         uint64
+}
+
+// ChainLoadSnapshot is the JSON representation of ChainLoad
+type ChainLoadSnapshot struct {
+	Pressure        float64             `json:"pressure"`
+	Overloaded      bool                `json:"overloaded"`
+	OverloadedTicks int                 `json:"overloaded_ticks"` // Consecutive blocks over budget
+	Injected        InjectedFaultCounts `json:"injected"`
+	Overload        OverloadFaultCounts `json:"overload"`
+}
+
+// InjectedFaultCounts counts faults configured through the control API
+type InjectedFaultCounts struct {
+	LatencyRequests uint64 `json:"latency_requests"`
+	LatencyMs       uint64 `json:"latency_ms"`
+	Errors          uint64 `json:"errors"`
+}
+
+// OverloadFaultCounts counts degradation caused by overload protection
+type OverloadFaultCounts struct {
+	Ticks           uint64 `json:"ticks"` // Blocks over budget
+	LatencyRequests uint64 `json:"latency_requests"`
+	LatencyMs       uint64 `json:"latency_ms"`
+	ShedConnections uint64 `json:"shed_connections"`
+}
+
+// ChainLoadRegistry holds the load of every chain by chain ID
+type ChainLoadRegistry struct {
+	chains sync.Map // chainId -> *ChainLoad
+}
+
+var chainLoads = &ChainLoadRegistry{}
+
+// For returns the load of a chain, creating it on first use
+func (r *ChainLoadRegistry) For(chainId string) *ChainLoad {
+	load, _ := r.chains.LoadOrStore(chainId, &ChainLoad{})
+	return load.(*ChainLoad)
+}
+
+// Snapshot returns the load of every chain that produced blocks or served requests
+func (r *ChainLoadRegistry) Snapshot() map[string]ChainLoadSnapshot {
+	budget := overloadConfig().Budget
+	snapshots := make(map[string]ChainLoadSnapshot)
+	r.chains.Range(func(key, value interface{}) bool {
+		snapshots[key.(string)] = value.(*ChainLoad).Snapshot(budget)
+		return true
+	})
+	return snapshots
+}
+
+// Reset clears the load and counters of all chains
+func (r *ChainLoadRegistry) Reset() {
+	r.chains.Range(func(key, value interface{}) bool {
+		r.chains.Delete(key)
+		return true
+	})
+}
+
+// Snapshot returns the chain's current load and counters
+func (l *ChainLoad) Snapshot(budget float64) ChainLoadSnapshot {
+	l.mu.Lock()
+	pressure, overloadedTicks := l.pressure, l.overloadedTicks
+	l.mu.Unlock()
+	return ChainLoadSnapshot{
+		Pressure:        pressure,
+		Overloaded:      pressure > budget,
+		OverloadedTicks: overloadedTicks,
+		Injected: InjectedFaultCounts{
+			LatencyRequests: atomic.LoadUint64(&l.injectedLatencyRequests),
+			LatencyMs:       atomic.LoadUint64(&l.injectedLatencyNs) / uint64(time.Millisecond),
+			Errors:          atomic.LoadUint64(&l.injectedErrors),
+		},
+		Overload: OverloadFaultCounts{
+			Ticks:           atomic.LoadUint64(&l.overloadTicks),
+			LatencyRequests: atomic.LoadUint64(&l.overloadLatencyRequests),
+			LatencyMs:       atomic.LoadUint64(&l.overloadLatencyNs) / uint64(time.Millisecond),
+			ShedConnections: atomic.LoadUint64(&l.shedConnections),
+		},
+	}
+}
+
+// recordFanOut records how long a head notification waited for and took to fan out
+func (l *ChainLoad) recordFanOut(d time.Duration) {
+	l.mu.Lock()
+	l.lastFanOut = d
+	l.mu.Unlock()
+}
+
+// currentPressure returns the share of the interval the chain's last block took
+func (l *ChainLoad) currentPressure() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pressure
+}
+
+// recordTick records one produced block: how late the tick fired and how long producing
+// took. The pressure is the larger of that and the last fan-out, relative to the interval.
+// It returns whether the chain has been over budget for long enough to shed a connection.
+func (l *ChainLoad) recordTick(interval, lag, work time.Duration, config OverloadConfig) bool {
+	if interval <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cost := lag + work
+	if l.lastFanOut > cost {
+		cost = l.lastFanOut
+	}
+	l.interval = interval
+	l.pressure = float64(cost) / float64(interval)
+	if l.pressure <= config.Budget {
+		l.overloadedTicks = 0
+		return false
+	}
+	l.overloadedTicks++
+	atomic.AddUint64(&l.overloadTicks, 1)
+	return config.Enabled && config.ShedAfterTicks > 0 && l.overloadedTicks >= config.ShedAfterTicks
+}
+
+// requestLatency records a request's injected latency and returns the latency overload
+// protection adds to it: the part of the interval by which the budget is exceeded, capped
+func (l *ChainLoad) requestLatency(injected time.Duration) time.Duration {
+	if injected > 0 {
+		atomic.AddUint64(&l.injectedLatencyRequests, 1)
+		atomic.AddUint64(&l.injectedLatencyNs, uint64(injected))
+	}
+	config := overloadConfig()
+	if !config.Enabled {
+		return 0
+	}
+	l.mu.Lock()
+	excess := l.pressure - config.Budget
+	interval := l.interval
+	l.mu.Unlock()
+	if excess <= 0 {
+		return 0
+	}
+	added := time.Duration(math.Min(excess*float64(interval), float64(time.Duration(config.MaxAddedLatencyMs)*time.Millisecond)))
+	if added <= 0 {
+		return 0
+	}
+	atomic.AddUint64(&l.overloadLatencyRequests, 1)
+	atomic.AddUint64(&l.overloadLatencyNs, uint64(added))
+	return added
+}
+
+// injectedError records an error returned from the chain's error configs
+func (l *ChainLoad) injectedError() {
+	atomic.AddUint64(&l.injectedErrors, 1)
+}
+
+// produceMeasured runs one scheduled block production and accounts for its cost. A chain
+// that stays over budget sheds one subscribed connection per block.
+func produceMeasured(chainId string, interval time.Duration, scheduled time.Time, produce func()) {
+	start := time.Now()
+	produce()
+	lag := start.Sub(scheduled)
+	if lag < 0 {
+		lag = 0
+	}
+	config := overloadConfig()
+	load := chainLoads.For(chainId)
+	if load.recordTick(interval, lag, time.Since(start), config) {
+		if subManager.shedConnection(chainId) {
+			atomic.AddUint64(&load.shedConnections, 1)
+		}
+	}
+}
+
+// shedConnection closes the connection holding the chain's newest subscription with close
+// code 1013, so the client retries later, and returns whether there was one
+func (sm *SubscriptionManager) shedConnection(chainId string) bool {
+	shard := sm.shard(chainId)
+	shard.mu.RLock()
+	ids := make([]uint64, 0, len(shard.subscriptions))
+	for id := range shard.subscriptions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+	var conn WSConn
+	if len(ids) > 0 {
+		conn = shard.subscriptions[ids[0]].Conn
+	}
+	shard.mu.RUnlock()
+	if conn == nil {
+		return false
+	}
+
+	log.Printf("Shedding a connection of chain %s under overload", chainId)
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "overloaded"))
+	conn.Close()
+	sm.CleanupConnection(conn)
+	return true
+}
+
+// handleOverload returns the overload protection config and the load of every chain (GET),
+// or updates the config (POST); reset clears the load and counters
+func handleOverload(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"config": overloadConfig(),
+			"chains": chainLoads.Snapshot(),
+		})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Enabled           *bool    `json:"enabled"`
+		Budget            *float64 `json:"budget"`
+		MaxAddedLatencyMs *int     `json:"max_added_latency_ms"`
+		ShedAfterTicks    *int     `json:"shed_after_ticks"`
+		Reset             bool     `json:"reset"` // Clear the load and counters of all chains
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	overloadProtection.Lock()
+	config := overloadProtection.config
+	if request.Enabled != nil {
+		config.Enabled = *request.Enabled
+	}
+	if request.Budget != nil {
+		config.Budget = *request.Budget
+	}
+	if request.MaxAddedLatencyMs != nil {
+		config.MaxAddedLatencyMs = *request.MaxAddedLatencyMs
+	}
+	if request.ShedAfterTicks != nil {
+		config.ShedAfterTicks = *request.ShedAfterTicks
+	}
+	if err := config.validate(); err != nil {
+		overloadProtection.Unlock()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	overloadProtection.config = config
+	overloadProtection.Unlock()
+	if request.Reset {
+		chainLoads.Reset()
+	}
+
+	log.Printf("Overload protection: enabled=%v, budget=%.2f, max added latency=%dms, shed after %d blocks",
+		config.Enabled, config.Budget, config.MaxAddedLatencyMs, config.ShedAfterTicks)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func setOverloadConfig(t *testing.T, config OverloadConfig) {
+	t.Helper()
+	original := overloadConfig()
+	chainLoads.Reset()
+	overloadProtection.Lock()
+	overloadProtection.config = config
+	overloadProtection.Unlock()
+	t.Cleanup(func() {
+		overloadProtection.Lock()
+		overloadProtection.config = original
+		overloadProtection.Unlock()
+		chainLoads.Reset()
+	})
+}
+
+func TestOverloadLatency(t *testing.T) {
+	setOverloadConfig(t, OverloadConfig{Enabled: true, Budget: 0.5, MaxAddedLatencyMs: 300, ShedAfterTicks: 0})
+	load := &ChainLoad{}
+
+	if load.recordTick(time.Second, 0, 100*time.Millisecond, overloadConfig()) || load.requestLatency(0) != 0 {
+		t.Error("Expected no degradation within the budget")
+	}
+
+	// 800ms of a 1s interval exceeds the 50% budget by 300ms; fan-outs count as well
+	load.recordFanOut(800 * time.Millisecond)
+	load.recordTick(time.Second, 0, 0, overloadConfig())
+	if added := load.requestLatency(50 * time.Millisecond); added != 300*time.Millisecond {
+		t.Errorf("Expected 300ms added latency, got %v", added)
+	}
+	load.recordFanOut(2 * time.Second)
+	load.recordTick(time.Second, 0, 0, overloadConfig())
+	if added := load.requestLatency(0); added != 300*time.Millisecond {
+		t.Errorf("Expected the added latency capped at 300ms, got %v", added)
+	}
+
+	snapshot := load.Snapshot(0.5)
+	if !snapshot.Overloaded || snapshot.OverloadedTicks != 2 || snapshot.Overload.Ticks != 2 {
+		t.Errorf("Expected two overloaded blocks, got %+v", snapshot)
+	}
+	if snapshot.Injected.LatencyRequests != 1 || snapshot.Injected.LatencyMs != 50 {
+		t.Errorf("Expected one request with 50ms injected latency, got %+v", snapshot.Injected)
+	}
+	if snapshot.Overload.LatencyRequests != 2 || snapshot.Overload.LatencyMs != 600 {
+		t.Errorf("Expected two requests with 600ms overload latency, got %+v", snapshot.Overload)
+	}
+
+	// Disabled protection still measures, but degrades nothing
+	setOverloadConfig(t, OverloadConfig{Budget: 0.5, MaxAddedLatencyMs: 300})
+	if added := load.requestLatency(0); added != 0 {
+		t.Errorf("Expected no added latency while disabled, got %v", added)
+	}
+}
+
+func TestOverloadShedsConnections(t *testing.T) {
+	setOverloadConfig(t, OverloadConfig{Enabled: true, Budget: 0.5, MaxAddedLatencyMs: 1000, ShedAfterTicks: 2})
+	first, second := NewMockWSConn(), NewMockWSConn()
+	subManager.Subscribe("10", first, "newHeads")
+	subManager.Subscribe("10", second, "newHeads")
+	defer subManager.CleanupConnection(first)
+	defer subManager.CleanupConnection(second)
+
+	slow := func() { time.Sleep(30 * time.Millisecond) }
+	produceMeasured("10", 40*time.Millisecond, time.Now(), slow)
+	if second.IsClosed() {
+		t.Fatal("Expected no shedding before shed_after_ticks overloaded blocks")
+	}
+	produceMeasured("10", 40*time.Millisecond, time.Now(), slow)
+	if !second.IsClosed() || first.IsClosed() {
+		t.Fatal("Expected the connection with the newest subscription to be shed")
+	}
+	messages := second.GetMessages()
+	if len(messages) == 0 || !bytes.Equal(messages[len(messages)-1], websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "overloaded")) {
+		t.Errorf("Expected a 1013 close frame, got %q", messages)
+	}
+
+	// A block within budget ends the overload
+	produceMeasured("10", time.Second, time.Now(), func() {})
+	if snapshot := chainLoads.For("10").Snapshot(0.5); snapshot.Overloaded || snapshot.Overload.ShedConnections != 1 {
+		t.Errorf("Expected the overload to end after one shed connection, got %+v", snapshot)
+	}
+}
+
+func TestOverloadCountsInjectedErrors(t *testing.T) {
+	setOverloadConfig(t, overloadConfig())
+	chain := supportedChains["optimism"]
+	defer func() { chain.ErrorConfigs = nil }()
+	chain.ErrorConfigs = []ErrorConfig{{Code: -32000, Message: "boom", Probability: 1}}
+
+	handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`), NewMockWSConn(), "10")
+	snapshot := chainLoads.For("10").Snapshot(0.5)
+	if snapshot.Injected.Errors != 1 || snapshot.Overload != (OverloadFaultCounts{}) {
+		t.Errorf("Expected one injected error and no overload faults, got %+v", snapshot)
+	}
+}
+
+func TestHandleOverload(t *testing.T) {
+	setOverloadConfig(t, overloadConfig())
+	post := func(body string) int {
+		w := httptest.NewRecorder()
+		handleOverload(w, httptest.NewRequest(http.MethodPost, "/control/overload", bytes.NewBufferString(body)))
+		return w.Code
+	}
+
+	if code := post(`{"enabled": true, "budget": 0.7}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if config := overloadConfig(); !config.Enabled || config.Budget != 0.7 || config.ShedAfterTicks != 3 {
+		t.Errorf("Expected a partial update, got %+v", config)
+	}
+	for _, body := range []string{`{"budget": 0}`, `{"budget": 1.5}`, `{"max_added_latency_ms": -1}`, `{"shed_after_ticks": -1}`} {
+		if code := post(body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, code)
+		}
+	}
+	if overloadConfig().Budget != 0.7 {
+		t.Error("Expected an invalid update to change nothing")
+	}
+
+	w := httptest.NewRecorder()
+	handleOverload(w, httptest.NewRequest(http.MethodGet, "/control/overload", nil))
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"budget":0.7`)) {
+		t.Errorf("Expected the config in the response, got %d %s", w.Code, w.Body.String())
+	}
+}
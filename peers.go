@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPeerCount    = 25               // net_peerCount without a peer simulation
+	defaultPeerInterval = 10 * time.Second // How often a fluctuating peer count changes by default
+)
+
+// PeerSimulation makes net_peerCount fluctuate and takes the node off the network in dropout
+// windows, during which net_peerCount is 0 and net_listening is false
+type PeerSimulation struct {
+	Base            int           `yaml:"base" json:"base"`                                             // Typical peer count
+	Fluctuation     int           `yaml:"fluctuation,omitempty" json:"fluctuation,omitempty"`           // Peer count varies by up to this many around Base
+	Interval        time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`                 // How often the peer count changes (0 = 10s)
+	DropoutEvery    time.Duration `yaml:"dropout_every,omitempty" json:"dropout_every,omitempty"`       // Period of recurring dropout windows (0 = none)
+	DropoutDuration time.Duration `yaml:"dropout_duration,omitempty" json:"dropout_duration,omitempty"` // Length of each dropout window, at the end of the period
+
+	mu        sync.Mutex
+	count     int
+	changedAt time.Time
+	started   time.Time // Start of the first dropout period
+	dropUntil time.Time // End of a one-off dropout started via the control API
+}
+
+// validate checks the counts and that dropout windows fit their period
+func (p *PeerSimulation) validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Base < 0 || p.Fluctuation < 0 || p.Interval < 0 || p.DropoutEvery < 0 || p.DropoutDuration < 0 {
+		return fmt.Errorf("peer simulation values must be non-negative")
+	}
+	if p.DropoutEvery > 0 && p.DropoutDuration >= p.DropoutEvery {
+		return fmt.Errorf("dropout duration must be shorter than the dropout period")
+	}
+	return nil
+}
+
+// state returns the current peer count and whether the node is listening
+func (p *PeerSimulation) state(now time.Time) (int, bool) {
+	if p == nil {
+		return defaultPeerCount, true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.started.IsZero() {
+		p.started = now
+	}
+	if now.Before(p.dropUntil) {
+		return 0, false
+	}
+	if p.DropoutEvery > 0 && now.Sub(p.started)%p.DropoutEvery >= p.DropoutEvery-p.DropoutDuration {
+		return 0, false
+	}
+
+	interval := p.Interval
+	if interval == 0 {
+		interval = defaultPeerInterval
+	}
+	if p.changedAt.IsZero() || now.Sub(p.changedAt) >= interval {
+		p.count = p.Base
+		if p.Fluctuation > 0 {
+			p.count += rand.Intn(2*p.Fluctuation+1) - p.Fluctuation
+		}
+		if p.count < 0 {
+			p.count = 0
+		}
+		p.changedAt = now
+	}
+	return p.count, true
+}
+
+// dropout takes the node off the network for d, starting now
+func (p *PeerSimulation) dropout(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dropUntil = time.Now().Add(d)
+}
+
+// handleSetPeers configures the simulated peers of an EVM chain. "reset" restores the static
+// default, and "dropout_ms" starts a one-off dropout right away.
+func handleSetPeers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain             string `json:"chain"`
+		Base              int    `json:"base"`
+		Fluctuation       int    `json:"fluctuation"`
+		IntervalMs        int64  `json:"interval_ms"`
+		DropoutEveryMs    int64  `json:"dropout_every_ms"`
+		DropoutDurationMs int64  `json:"dropout_duration_ms"`
+		DropoutMs         int64  `json:"dropout_ms"`
+		Reset             bool   `json:"reset"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	chain, ok := supportedChains[request.Chain]
+	if !ok {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	if request.DropoutMs < 0 {
+		http.Error(w, "Dropout must be non-negative", http.StatusBadRequest)
+		return
+	}
+
+	var peers *PeerSimulation
+	if !request.Reset {
+		peers = &PeerSimulation{
+			Base:            request.Base,
+			Fluctuation:     request.Fluctuation,
+			Interval:        time.Duration(request.IntervalMs) * time.Millisecond,
+			DropoutEvery:    time.Duration(request.DropoutEveryMs) * time.Millisecond,
+			DropoutDuration: time.Duration(request.DropoutDurationMs) * time.Millisecond,
+		}
+		if err := peers.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.DropoutMs > 0 {
+			peers.dropout(time.Duration(request.DropoutMs) * time.Millisecond)
+		}
+	}
+	chain.Peers = peers
+	persistChainConfig()
+
+	if peers == nil {
+		log.Printf("Reset simulated peers for chain %s", request.Chain)
+	} else {
+		log.Printf("Set simulated peers for chain %s: %d ± %d", request.Chain, peers.Base, peers.Fluctuation)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
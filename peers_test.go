@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPeerSimulationState(t *testing.T) {
+	if peers, listening := (*PeerSimulation)(nil).state(time.Now()); peers != defaultPeerCount || !listening {
+		t.Errorf("Expected the static default without a simulation, got %d %t", peers, listening)
+	}
+
+	start := time.Now()
+	p := &PeerSimulation{Base: 20, Fluctuation: 5, Interval: time.Second, DropoutEvery: time.Minute, DropoutDuration: 10 * time.Second}
+	first, _ := p.state(start)
+	for i := 0; i < 100; i++ {
+		now := start.Add(time.Duration(i) * 500 * time.Millisecond)
+		peers, listening := p.state(now)
+		if !listening || peers < 15 || peers > 25 {
+			t.Fatalf("At %v: expected 15-25 listening peers, got %d %t", now.Sub(start), peers, listening)
+		}
+		if now.Sub(start) < time.Second && peers != first {
+			t.Errorf("Expected the peer count to hold for the interval, got %d then %d", first, peers)
+		}
+	}
+
+	// The last 10s of every minute are a dropout window
+	for _, offset := range []time.Duration{50 * time.Second, 59 * time.Second, 110 * time.Second} {
+		if peers, listening := p.state(start.Add(offset)); peers != 0 || listening {
+			t.Errorf("At %v: expected a dropout, got %d %t", offset, peers, listening)
+		}
+	}
+	if _, listening := p.state(start.Add(61 * time.Second)); !listening {
+		t.Error("Expected the node to listen again after the dropout window")
+	}
+}
+
+func TestNetMethodsFollowPeerSimulation(t *testing.T) {
+	withTempConfig(t)
+	defer func() {
+		supportedChains["optimism"].Peers = nil
+	}()
+
+	call := func(method string) string {
+		response, err := handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"`+method+`","params":[],"id":1}`), NewMockWSConn(), "10")
+		if err != nil {
+			t.Fatalf("Handler error: %v", err)
+		}
+		return string(response)
+	}
+	setPeers := func(body string) int {
+		w := httptest.NewRecorder()
+		handleSetPeers(w, httptest.NewRequest(http.MethodPost, "/control/chain/peers", bytes.NewBufferString(body)))
+		return w.Code
+	}
+
+	if code := setPeers(`{"chain": "optimism", "base": 8}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if got := call("net_peerCount"); got != `{"jsonrpc":"2.0","result":"0x8","id":1}` {
+		t.Errorf("Unexpected net_peerCount response: %s", got)
+	}
+
+	setPeers(`{"chain": "optimism", "base": 8, "dropout_ms": 60000}`)
+	if got := call("net_listening"); got != `{"jsonrpc":"2.0","result":false,"id":1}` {
+		t.Errorf("Expected net_listening false during a dropout, got %s", got)
+	}
+	if got := call("net_peerCount"); got != `{"jsonrpc":"2.0","result":"0x0","id":1}` {
+		t.Errorf("Expected no peers during a dropout, got %s", got)
+	}
+
+	setPeers(`{"chain": "optimism", "reset": true}`)
+	if got := call("net_listening"); got != `{"jsonrpc":"2.0","result":true,"id":1}` {
+		t.Errorf("Expected net_listening true after a reset, got %s", got)
+	}
+
+	for _, body := range []string{
+		`{"chain": "optimism", "base": -1}`,
+		`{"chain": "optimism", "base": 8, "dropout_every_ms": 1000, "dropout_duration_ms": 1000}`,
+	} {
+		if code := setPeers(body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, code)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RegionHeader selects a region for requests that don't use a region path prefix
+const RegionHeader = "X-Sim-Region"
+
+// Region is a virtual deployment region: clients that select it receive its latency on top of
+// the chain's own, so geo-aware routing can be tested against one simulator
+type Region struct {
+	LatencyMs          int            `yaml:"latency_ms" json:"latency_ms"`                                         // Base latency of every request in the region
+	ChainAdjustmentsMs map[string]int `yaml:"chain_adjustments_ms,omitempty" json:"chain_adjustments_ms,omitempty"` // Added to the base latency per chain name; may be negative
+}
+
+// regionNamePattern keeps region names usable as a path segment
+var regionNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// reservedRegionNames are first path segments the simulator serves itself
+var reservedRegionNames = []string{"ws", "chain", "control", "sse", "api"}
+
+// validate checks the region's name, latency and chains
+func (r *Region) validate(name string) error {
+	if !regionNamePattern.MatchString(name) || containsString(reservedRegionNames, name) {
+		return fmt.Errorf("invalid region name %q: use lowercase letters, digits and dashes, not one of %v", name, reservedRegionNames)
+	}
+	if r.LatencyMs < 0 {
+		return fmt.Errorf("latency_ms must be non-negative")
+	}
+	for chain := range r.ChainAdjustmentsMs {
+		if getChain(chain) == nil {
+			return fmt.Errorf("unknown chain: %s", chain)
+		}
+	}
+	return nil
+}
+
+// latency returns the region's latency for a chain, never negative
+func (r *Region) latency(chainName string) time.Duration {
+	ms := r.LatencyMs + r.ChainAdjustmentsMs[chainName]
+	if ms < 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// regions holds the defined regions by name; persisted in chains.yaml
+var regions = struct {
+	sync.RWMutex
+	byName map[string]*Region
+}{byName: make(map[string]*Region)}
+
+// configureRegions replaces the defined regions, e.g. from chains.yaml
+func configureRegions(defined map[string]*Region) error {
+	for name, region := range defined {
+		if region == nil {
+			return fmt.Errorf("region %s: empty definition", name)
+		}
+		if err := region.validate(name); err != nil {
+			return fmt.Errorf("region %s: %v", name, err)
+		}
+	}
+	regions.Lock()
+	defer regions.Unlock()
+	regions.byName = make(map[string]*Region, len(defined))
+	for name, region := range defined {
+		regions.byName[name] = region
+	}
+	return nil
+}
+
+// lookupRegion returns a defined region by name
+func lookupRegion(name string) (*Region, bool) {
+	regions.RLock()
+	defer regions.RUnlock()
+	region, ok := regions.byName[name]
+	return region, ok
+}
+
+type regionContextKey struct{}
+
+// regionLatency returns the latency of the region a request selected for a chain
+func regionLatency(ctx context.Context, chainName string) time.Duration {
+	name, _ := ctx.Value(regionContextKey{}).(string)
+	if name == "" {
+		return 0
+	}
+	region, ok := lookupRegion(name)
+	if !ok {
+		return 0 // Removed while the connection was open
+	}
+	return region.latency(chainName)
+}
+
+// regionRouter selects a region for chain requests, by a path prefix (/eu/ws/chain/1,
+// /eu/chain/1) or the X-Sim-Region header, and strips the prefix before passing the request
+// on. An unknown region in the header is rejected; an unknown path prefix falls through.
+func regionRouter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(RegionHeader)
+		path := r.URL.Path
+		if segment, rest, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/"); ok {
+			if _, defined := lookupRegion(segment); defined && (strings.HasPrefix(rest, "ws/chain/") || strings.HasPrefix(rest, "chain/")) {
+				name, path = segment, "/"+rest
+			}
+		}
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := lookupRegion(name); !ok {
+			http.Error(w, fmt.Sprintf("Unknown region: %s", name), http.StatusBadRequest)
+			return
+		}
+
+		routed := r.Clone(context.WithValue(r.Context(), regionContextKey{}, name))
+		routed.URL.Path = path
+		next.ServeHTTP(w, routed)
+	})
+}
+
+// handleRegions lists (GET) or defines and replaces (POST) virtual regions
+func handleRegions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		regions.RLock()
+		defined := make(map[string]*Region, len(regions.byName))
+		for name, region := range regions.byName {
+			defined[name] = region
+		}
+		regions.RUnlock()
+		jsonResponse(w, http.StatusOK, map[string]interface{}{"regions": defined})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Name string `json:"name"`
+		Region
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := request.Region.validate(request.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	region := request.Region
+	regions.Lock()
+	regions.byName[request.Name] = &region
+	regions.Unlock()
+	persistChainConfig()
+
+	log.Printf("Defined region %s with %dms latency", request.Name, region.LatencyMs)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleRemoveRegion removes a region; its open connections lose the region's latency
+func handleRemoveRegion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	regions.Lock()
+	_, ok := regions.byName[request.Name]
+	delete(regions.byName, request.Name)
+	regions.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("Region not found: %s", request.Name), http.StatusNotFound)
+		return
+	}
+	persistChainConfig()
+
+	log.Printf("Removed region %s", request.Name)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
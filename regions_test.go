@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gopkg.in/yaml.v3"
+)
+
+func regionRequest(t *testing.T, handler http.HandlerFunc, body string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/control/regions", bytes.NewBufferString(body)))
+	return w.Code
+}
+
+func TestRegions(t *testing.T) {
+	path := withTempConfig(t)
+	defer func() {
+		configureRegions(nil)
+	}()
+
+	define := `{"name": "ap-south", "latency_ms": 150, "chain_adjustments_ms": {"polygon": -100, "base": -200}}`
+	if code := regionRequest(t, handleRegions, define); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	region, _ := lookupRegion("ap-south")
+	for chain, want := range map[string]time.Duration{"ethereum": 150 * time.Millisecond, "polygon": 50 * time.Millisecond, "base": 0} {
+		if got := region.latency(chain); got != want {
+			t.Errorf("%s: expected %v, got %v", chain, want, got)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	var persisted ChainConfig
+	if err := yaml.Unmarshal(data, &persisted); err != nil || persisted.Regions["ap-south"] == nil || persisted.Regions["ap-south"].LatencyMs != 150 {
+		t.Errorf("Expected the region to be persisted, got %v", err)
+	}
+
+	for _, body := range []string{
+		`{"name": "ws", "latency_ms": 10}`,
+		`{"name": "EU West", "latency_ms": 10}`,
+		`{"name": "eu", "latency_ms": -1}`,
+		`{"name": "eu", "chain_adjustments_ms": {"unknown": 1}}`,
+	} {
+		if code := regionRequest(t, handleRegions, body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, code)
+		}
+	}
+
+	if code := regionRequest(t, handleRemoveRegion, `{"name": "ap-south"}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if code := regionRequest(t, handleRemoveRegion, `{"name": "ap-south"}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a removed region, got %d", code)
+	}
+	ctx := context.WithValue(context.Background(), regionContextKey{}, "ap-south")
+	if latency := regionLatency(ctx, "ethereum"); latency != 0 {
+		t.Errorf("Expected no latency from a removed region, got %v", latency)
+	}
+}
+
+func TestRegionRouter(t *testing.T) {
+	defer configureRegions(nil)
+	configureRegions(map[string]*Region{
+		"eu": {LatencyMs: 0},
+		"us": {LatencyMs: 120},
+	})
+
+	mux, _ := newServeMuxes(false)
+	server := httptest.NewServer(regionRouter(hostRouter(mux)))
+	defer server.Close()
+
+	post := func(path, region string) (int, time.Duration) {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+path, bytes.NewBufferString(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`))
+		if region != "" {
+			req.Header.Set(RegionHeader, region)
+		}
+		start := time.Now()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode, time.Since(start)
+	}
+
+	if code, elapsed := post("/us/chain/1", ""); code != http.StatusOK || elapsed < 120*time.Millisecond {
+		t.Errorf("Expected the us path prefix to add 120ms, got %d after %v", code, elapsed)
+	}
+	if code, elapsed := post("/chain/1", "us"); code != http.StatusOK || elapsed < 120*time.Millisecond {
+		t.Errorf("Expected the us header to add 120ms, got %d after %v", code, elapsed)
+	}
+	if code, elapsed := post("/eu/chain/1", ""); code != http.StatusOK || elapsed >= 120*time.Millisecond {
+		t.Errorf("Expected the eu path prefix without latency, got %d after %v", code, elapsed)
+	}
+	if code, _ := post("/chain/1", "mars"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown region header, got %d", code)
+	}
+	if code, _ := post("/mars/chain/1", ""); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown region prefix, got %d", code)
+	}
+
+	// WebSocket connections keep their region for every request
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/us/ws/chain/137", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	start := time.Now()
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`))
+	if _, _, err := conn.ReadMessage(); err != nil || time.Since(start) < 120*time.Millisecond {
+		t.Errorf("Expected the response after the region latency, got %v after %v", err, time.Since(start))
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stores with retention caps
+const (
+	RetentionStoreProgramLogTransactions = "program_log_transactions" // Generated Solana transactions for getTransaction
+	RetentionStoreSlowClients            = "slow_clients"             // Slow client records
+	RetentionStoreScenarios              = "scenarios"                // Finished (completed or cancelled) scenarios
+	RetentionStoreGraceSubscriptions     = "grace_subscriptions"      // Unsubscribed Solana subscriptions in their grace period
+	RetentionStoreNotificationHistory    = "notification_history"     // Notifications kept per subscription for replays
+	RetentionStoreMessageLogs            = "message_logs"             // Message logs of closed connections
+)
+
+// RetentionConfig caps the in-memory stores so long soak runs don't grow without bound
+type RetentionConfig struct {
+	ProgramLogTransactions int           `yaml:"program_log_transactions,omitempty"` // Default 1024
+	SlowClients            int           `yaml:"slow_clients,omitempty"`             // Default 1024
+	Scenarios              int           `yaml:"scenarios,omitempty"`                // Finished scenarios kept, default 100
+	NotificationHistory    int           `yaml:"notification_history,omitempty"`     // Notifications kept per subscription, default 32
+	MessageLogs            int           `yaml:"message_logs,omitempty"`             // Message logs of closed connections, default 100
+	CompactionInterval     time.Duration `yaml:"compaction_interval,omitempty"`      // Default 1m
+}
+
+var defaultRetention = RetentionConfig{
+	ProgramLogTransactions: 1024,
+	SlowClients:            1024,
+	Scenarios:              100,
+	NotificationHistory:    32,
+	MessageLogs:            100,
+	CompactionInterval:     time.Minute,
+}
+
+// retentionConfig is the retention section of the config file (nil = defaults)
+var retentionConfig *RetentionConfig
+
+// retentionLimit returns the configured cap of a store
+func retentionLimit(store string) int {
+	limits := defaultRetention
+	if retentionConfig != nil {
+		if retentionConfig.ProgramLogTransactions > 0 {
+			limits.ProgramLogTransactions = retentionConfig.ProgramLogTransactions
+		}
+		if retentionConfig.SlowClients > 0 {
+			limits.SlowClients = retentionConfig.SlowClients
+		}
+		if retentionConfig.Scenarios > 0 {
+			limits.Scenarios = retentionConfig.Scenarios
+		}
+		if retentionConfig.NotificationHistory > 0 {
+			limits.NotificationHistory = retentionConfig.NotificationHistory
+		}
+		if retentionConfig.MessageLogs > 0 {
+			limits.MessageLogs = retentionConfig.MessageLogs
+		}
+	}
+	switch store {
+	case RetentionStoreProgramLogTransactions:
+		return limits.ProgramLogTransactions
+	case RetentionStoreSlowClients:
+		return limits.SlowClients
+	case RetentionStoreScenarios:
+		return limits.Scenarios
+	case RetentionStoreNotificationHistory:
+		return limits.NotificationHistory
+	case RetentionStoreMessageLogs:
+		return limits.MessageLogs
+	}
+	return 0
+}
+
+// compactionInterval returns how often compactStores runs
+func compactionInterval() time.Duration {
+	if retentionConfig != nil && retentionConfig.CompactionInterval > 0 {
+		return retentionConfig.CompactionInterval
+	}
+	return defaultRetention.CompactionInterval
+}
+
+var (
+	retentionEvictions sync.Map // store -> *uint64
+	compactions        uint64
+)
+
+// recordEvictions counts entries removed from a store to respect its cap or expiry
+func recordEvictions(store string, n int) {
+	if n <= 0 {
+		return
+	}
+	counter, _ := retentionEvictions.LoadOrStore(store, new(uint64))
+	atomic.AddUint64(counter.(*uint64), uint64(n))
+}
+
+// evictions returns the number of entries evicted from a store
+func evictions(store string) uint64 {
+	if counter, ok := retentionEvictions.Load(store); ok {
+		return atomic.LoadUint64(counter.(*uint64))
+	}
+	return 0
+}
+
+// compactStores trims every store to its cap and drops expired entries
+func compactStores() {
+	recordEvictions(RetentionStoreProgramLogTransactions, compactProgramLogTransactions(retentionLimit(RetentionStoreProgramLogTransactions)))
+	recordEvictions(RetentionStoreSlowClients, slowClients.compact(retentionLimit(RetentionStoreSlowClients)))
+	recordEvictions(RetentionStoreScenarios, scenarioManager.compact(retentionLimit(RetentionStoreScenarios)))
+	recordEvictions(RetentionStoreGraceSubscriptions, subManager.compactGraceSubscriptions())
+	recordEvictions(RetentionStoreMessageLogs, compactMessageLogs(retentionLimit(RetentionStoreMessageLogs)))
+	atomic.AddUint64(&compactions, 1)
+}
+
+// runRetentionCompaction compacts the stores periodically for the lifetime of the process
+func runRetentionCompaction() {
+	for {
+		time.Sleep(compactionInterval())
+		compactStores()
+	}
+}
+
+// RetentionStoreStats describes the size and cap of a store
+type RetentionStoreStats struct {
+	Size      int    `json:"size"`
+	Limit     int    `json:"limit,omitempty"` // 0 for stores bounded by expiry
+	Evictions uint64 `json:"evictions"`
+}
+
+// RetentionMetrics is the document returned by GET /control/metrics/retention
+type RetentionMetrics struct {
+	Stores         map[string]RetentionStoreStats `json:"stores"`
+	Compactions    uint64                         `json:"compactions"`
+	HeapAllocBytes uint64                         `json:"heap_alloc_bytes"`
+	HeapObjects    uint64                         `json:"heap_objects"`
+	Goroutines     int                            `json:"goroutines"`
+}
+
+// currentRetentionMetrics collects store sizes, evictions and heap usage
+func currentRetentionMetrics() RetentionMetrics {
+	sizes := map[string]int{
+		RetentionStoreProgramLogTransactions: programLogTransactionCount(),
+		RetentionStoreSlowClients:            slowClients.Len(),
+		RetentionStoreScenarios:              scenarioManager.Len(),
+		RetentionStoreGraceSubscriptions:     subManager.graceSubscriptionCount(),
+		RetentionStoreNotificationHistory:    subManager.notificationHistorySize(),
+		RetentionStoreMessageLogs:            closedMessageLogCount(),
+	}
+	stores := make(map[string]RetentionStoreStats, len(sizes))
+	for store, size := range sizes {
+		stores[store] = RetentionStoreStats{
+			Size:      size,
+			Limit:     retentionLimit(store),
+			Evictions: evictions(store),
+		}
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return RetentionMetrics{
+		Stores:         stores,
+		Compactions:    atomic.LoadUint64(&compactions),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapObjects:    mem.HeapObjects,
+		Goroutines:     runtime.NumGoroutine(),
+	}
+}
+
+// handleRetentionMetrics returns store sizes, evictions and heap usage
+func handleRetentionMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	jsonResponse(w, http.StatusOK, currentRetentionMetrics())
+}
+
+// logRetentionConfig logs the effective caps at startup
+func logRetentionConfig() {
+	log.Printf("Retention caps: %d program log transactions, %d slow clients, %d finished scenarios, %d notifications per subscription (compaction every %v)",
+		retentionLimit(RetentionStoreProgramLogTransactions), retentionLimit(RetentionStoreSlowClients),
+		retentionLimit(RetentionStoreScenarios), retentionLimit(RetentionStoreNotificationHistory), compactionInterval())
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetentionCaps(t *testing.T) {
+	originalConfig := retentionConfig
+	retentionConfig = &RetentionConfig{ProgramLogTransactions: 5, SlowClients: 3, Scenarios: 2}
+	defer func() {
+		retentionConfig = originalConfig
+		slowClients.Reset()
+	}()
+
+	// Generated transactions keep only the newest ones
+	template := SolanaProgramLog{ProgramID: testProgramID, Instruction: "Transfer"}
+	evictedBefore := evictions(RetentionStoreProgramLogTransactions)
+	var signatures []string
+	for slot := uint64(500000); slot < 500010; slot++ {
+		signature, tx := programLogTransaction(slot, template)
+		rememberProgramLogTransaction(signature, tx)
+		signatures = append(signatures, signature)
+	}
+	if programLogTransactionCount() != 5 {
+		t.Errorf("Expected 5 retained transactions, got %d", programLogTransactionCount())
+	}
+	if _, ok := lookupSolanaTransaction(signatures[0]); ok {
+		t.Error("Expected the oldest transaction to be evicted")
+	}
+	if _, ok := lookupSolanaTransaction(signatures[9]); !ok {
+		t.Error("Expected the newest transaction to be retained")
+	}
+	if evictions(RetentionStoreProgramLogTransactions)-evictedBefore < 5 {
+		t.Error("Expected evictions to be counted")
+	}
+
+	// Blocked slow clients are evicted last
+	slowClients.Reset()
+	for id := uint64(1); id <= 5; id++ {
+		slowClients.update(&wsConnWrapper{id: id, chainId: "1"}, SlowClientPolicyWait, func(c *SlowClient) { c.Blocked = id == 1 })
+	}
+	list := slowClients.Snapshot()
+	if len(list) != 3 || list[0].ConnID != 1 || list[1].ConnID != 4 {
+		t.Errorf("Expected blocked conn 1 and the newest conns to be retained, got %+v", list)
+	}
+
+	// Finished scenarios are evicted, running ones are kept
+	manager := NewScenarioManager()
+	for i := 0; i < 4; i++ {
+		manager.Start("finished", nil)
+	}
+	running, _ := manager.Start("running", []ScenarioStep{{AtSeconds: 3600, Action: ScenarioActionDrop}})
+	defer manager.Cancel(running.ID)
+	if manager.compact(2) != 0 || manager.Len() != 3 {
+		t.Errorf("Expected 2 finished and 1 running scenario, got %d", manager.Len())
+	}
+}
+
+func TestCompactStoresRemovesExpiredGraceSubscriptions(t *testing.T) {
+	sm := NewSubscriptionManager()
+	sm.SetSolanaIDReuse(false, 10*time.Millisecond)
+	id, _ := sm.Subscribe("501", NewMockWSConn(), "slotNotification")
+	sm.Unsubscribe(id)
+	if sm.graceSubscriptionCount() != 1 {
+		t.Fatalf("Expected 1 grace subscription, got %d", sm.graceSubscriptionCount())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if removed := sm.compactGraceSubscriptions(); removed != 1 || sm.graceSubscriptionCount() != 0 {
+		t.Errorf("Expected the expired grace subscription to be removed, removed %d", removed)
+	}
+}
+
+func TestRetentionMetrics(t *testing.T) {
+	compactStores()
+
+	w := httptest.NewRecorder()
+	handleRetentionMetrics(w, httptest.NewRequest(http.MethodGet, "/control/metrics/retention", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	for _, field := range []string{`"program_log_transactions"`, `"slow_clients"`, `"scenarios"`, `"grace_subscriptions"`, `"heap_alloc_bytes"`} {
+		if !strings.Contains(w.Body.String(), field) {
+			t.Errorf("Expected %s in retention metrics, got %s", field, w.Body.String())
+		}
+	}
+}
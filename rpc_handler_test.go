@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 	"time"
@@ -17,7 +18,7 @@ func TestEVMHandler(t *testing.T) {
 		ID:      1,
 	}
 	subRequestData, _ := json.Marshal(subRequest)
-	subResponse, _ := handleEVMRequest(subRequestData, conn, "1")
+	subResponse, _ := handleEVMRequest(context.Background(), subRequestData, conn, "1")
 	var subResp JSONRPCResponse
 	json.Unmarshal(subResponse, &subResp)
 	subscriptionID := subResp.Result.(string)
@@ -183,7 +184,7 @@ func TestEVMHandler(t *testing.T) {
 				t.Fatalf("Failed to marshal request: %v", err)
 			}
 
-			response, err := handleEVMRequest(requestData, conn, tt.chainId)
+			response, err := handleEVMRequest(context.Background(), requestData, conn, tt.chainId)
 			if err != nil {
 				t.Fatalf("Handler failed: %v", err)
 			}
@@ -204,7 +205,7 @@ func TestSolanaHandler(t *testing.T) {
 		ID:      1,
 	}
 	subRequestData, _ := json.Marshal(subRequest)
-	subResponse, _ := handleSolanaRequest(subRequestData, conn)
+	subResponse, _ := handleSolanaRequest(context.Background(), subRequestData, conn)
 	var subResp JSONRPCResponse
 	json.Unmarshal(subResponse, &subResp)
 	subscriptionID := uint64(subResp.Result.(float64))
@@ -315,7 +316,7 @@ func TestSolanaHandler(t *testing.T) {
 				t.Fatalf("Failed to marshal request: %v", err)
 			}
 
-			response, err := handleSolanaRequest(requestData, conn)
+			response, err := handleSolanaRequest(context.Background(), requestData, conn)
 			if err != nil {
 				t.Fatalf("Handler failed: %v", err)
 			}
@@ -437,9 +438,9 @@ func TestUnifiedChainEndpoints(t *testing.T) {
 
 			var response []byte
 			if tt.chainId == "501" {
-				response, err = handleSolanaRequest(requestData, conn)
+				response, err = handleSolanaRequest(context.Background(), requestData, conn)
 			} else {
-				response, err = handleEVMRequest(requestData, conn, tt.chainId)
+				response, err = handleEVMRequest(context.Background(), requestData, conn, tt.chainId)
 			}
 			if err != nil {
 				t.Fatalf("Handler failed: %v", err)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"strconv"
 )
 
 type JSONRPCRequest struct {
@@ -11,6 +12,26 @@ type JSONRPCRequest struct {
 	ID      interface{}   `json:"id"`
 }
 
+// UnmarshalJSON keeps the request id as raw JSON so it is echoed back byte-identically.
+// Decoding into interface{} would turn every number into a float64 and corrupt ids above 2^53.
+func (r *JSONRPCRequest) UnmarshalJSON(data []byte) error {
+	type requestAlias JSONRPCRequest
+	aux := struct {
+		*requestAlias
+		ID json.RawMessage `json:"id"`
+	}{
+		requestAlias: (*requestAlias)(r),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.ID = nil
+	if len(aux.ID) > 0 {
+		r.ID = aux.ID
+	}
+	return nil
+}
+
 type JSONRPCResponse struct {
 	JsonRPC string      `json:"jsonrpc"`
 	Result  interface{} `json:"result,omitempty"`
@@ -42,3 +63,72 @@ func createErrorResponse(code int, message string, data interface{}, id interfac
 	}
 	return json.Marshal(response)
 }
+
+// ID mangling modes used to simulate providers that re-type or lose request ids
+const (
+	IDMangleNone      = ""
+	IDMangleStringify = "stringify" // numeric ids are echoed back as strings
+	IDMangleFloat64   = "float64"   // numeric ids lose precision above 2^53
+	IDMangleNull      = "null"      // ids are replaced with null
+	IDMangleIncrement = "increment" // numeric ids are off by one, string ids get a suffix
+)
+
+// IDMangleModes lists the supported id mangling modes
+var IDMangleModes = []string{IDMangleNone, IDMangleStringify, IDMangleFloat64, IDMangleNull, IDMangleIncrement}
+
+// isValidIDMangleMode returns true if the mode is one of IDMangleModes
+func isValidIDMangleMode(mode string) bool {
+	for _, m := range IDMangleModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// mangleID intentionally alters a request id according to the given mode
+func mangleID(mode string, id interface{}) interface{} {
+	if mode == IDMangleNone || id == nil {
+		return id
+	}
+	if mode == IDMangleNull {
+		return nil
+	}
+
+	raw, ok := id.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(id); err != nil {
+			return id
+		}
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		// String ids
+		if mode == IDMangleIncrement {
+			return str + "-1"
+		}
+		return id
+	}
+
+	// Numeric ids
+	switch mode {
+	case IDMangleStringify:
+		return string(raw)
+	case IDMangleFloat64:
+		f, err := strconv.ParseFloat(string(raw), 64)
+		if err != nil {
+			return id
+		}
+		return f
+	case IDMangleIncrement:
+		if n, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+			return n + 1
+		}
+		if f, err := strconv.ParseFloat(string(raw), 64); err == nil {
+			return f + 1
+		}
+	}
+	return id
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// RunState is the block/slot production state of a chain
+type RunState uint32
+
+const (
+	RunStateRunning     RunState = iota // Blocks are produced and broadcast
+	RunStatePaused                      // Block production is paused by an operator
+	RunStateInterrupted                 // Block emissions are interrupted, typically for a limited time
+)
+
+func (s RunState) String() string {
+	switch s {
+	case RunStateRunning:
+		return "running"
+	case RunStatePaused:
+		return "paused"
+	case RunStateInterrupted:
+		return "interrupted"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint32(s))
+	}
+}
+
+// RunEvent triggers a transition between run states
+type RunEvent string
+
+const (
+	RunEventPause        RunEvent = "pause"         // Operator pause
+	RunEventResume       RunEvent = "resume"        // Operator resume, clears pauses and interrupts
+	RunEventPauseEnd     RunEvent = "pause_end"     // A timed pause elapsed, applied through EndPause
+	RunEventInterrupt    RunEvent = "interrupt"     // Start an interruption
+	RunEventInterruptEnd RunEvent = "interrupt_end" // A timed interruption elapsed
+)
+
+// runStateTransitions lists every allowed transition; anything else is rejected
+var runStateTransitions = map[RunState]map[RunEvent]RunState{
+	RunStateRunning: {
+		RunEventPause:     RunStatePaused,
+		RunEventResume:    RunStateRunning,
+		RunEventInterrupt: RunStateInterrupted,
+	},
+	RunStatePaused: {
+		RunEventPause:    RunStatePaused,
+		RunEventResume:   RunStateRunning,
+		RunEventPauseEnd: RunStateRunning,
+	},
+	RunStateInterrupted: {
+		RunEventPause:        RunStatePaused,
+		RunEventResume:       RunStateRunning,
+		RunEventInterrupt:    RunStateInterrupted,
+		RunEventInterruptEnd: RunStateRunning,
+	},
+}
+
+// RunStateMachine holds a chain's run state and applies transitions atomically. Every pause
+// gets a new generation, so a timed pause can tell whether it is still the current one.
+type RunStateMachine struct {
+	state uint64 // RunState in the low 32 bits, pause generation in the high 32 bits
+}
+
+// load returns the current run state and pause generation
+func (m *RunStateMachine) load() (uint64, RunState, uint32) {
+	packed := atomic.LoadUint64(&m.state)
+	return packed, RunState(uint32(packed)), uint32(packed >> 32)
+}
+
+// State returns the current run state
+func (m *RunStateMachine) State() RunState {
+	_, state, _ := m.load()
+	return state
+}
+
+// IsRunning returns true if blocks should be produced
+func (m *RunStateMachine) IsRunning() bool {
+	return m.State() == RunStateRunning
+}
+
+// Transition applies an event and returns the new state, or an error if the
+// event is not allowed in the current state. A pause_end needs the token of its pause, so
+// it is only applied through EndPause.
+func (m *RunStateMachine) Transition(event RunEvent) (RunState, error) {
+	state, _, err := m.transition(event, 0)
+	return state, err
+}
+
+// Pause pauses block production and returns the token of this pause for EndPause
+func (m *RunStateMachine) Pause() (uint32, error) {
+	_, token, err := m.transition(RunEventPause, 0)
+	return token, err
+}
+
+// EndPause ends the pause the token belongs to once its time elapsed. After a resume or a
+// later pause, e.g. an operator's untimed one, the token is stale and the state is kept.
+func (m *RunStateMachine) EndPause(token uint32) (RunState, error) {
+	state, _, err := m.transition(RunEventPauseEnd, token)
+	return state, err
+}
+
+// transition applies an event, checking the pause token of a pause_end, and returns the new
+// state and pause generation
+func (m *RunStateMachine) transition(event RunEvent, token uint32) (RunState, uint32, error) {
+	for {
+		packed, current, generation := m.load()
+		next, ok := runStateTransitions[current][event]
+		if !ok {
+			return current, generation, fmt.Errorf("cannot %s while %s", event, current)
+		}
+		if event == RunEventPauseEnd && token != generation {
+			return current, generation, fmt.Errorf("cannot %s a pause that was superseded", event)
+		}
+		if event == RunEventPause {
+			generation++
+		}
+		if atomic.CompareAndSwapUint64(&m.state, packed, uint64(generation)<<32|uint64(next)) {
+			return next, generation, nil
+		}
+	}
+}
+
+// Reset forces the state back to running
+func (m *RunStateMachine) Reset() {
+	for {
+		packed, _, generation := m.load()
+		if atomic.CompareAndSwapUint64(&m.state, packed, uint64(generation)<<32|uint64(RunStateRunning)) {
+			return
+		}
+	}
+}
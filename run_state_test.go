@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRunStateTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []RunEvent
+		want    RunState
+		wantErr bool
+	}{
+		{"pause", []RunEvent{RunEventPause}, RunStatePaused, false},
+		{"pause then resume", []RunEvent{RunEventPause, RunEventResume}, RunStateRunning, false},
+		{"interrupt then elapse", []RunEvent{RunEventInterrupt, RunEventInterruptEnd}, RunStateRunning, false},
+		{"pause during interrupt survives interrupt end", []RunEvent{RunEventInterrupt, RunEventPause, RunEventInterruptEnd}, RunStatePaused, true},
+		{"interrupt while paused", []RunEvent{RunEventPause, RunEventInterrupt}, RunStatePaused, true},
+		{"resume clears interrupt", []RunEvent{RunEventInterrupt, RunEventResume}, RunStateRunning, false},
+		{"pause end needs its pause token", []RunEvent{RunEventPause, RunEventPauseEnd}, RunStatePaused, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m RunStateMachine
+			var lastErr error
+			for _, event := range tt.events {
+				if _, err := m.Transition(event); err != nil {
+					lastErr = err
+				}
+			}
+			if got := m.State(); got != tt.want {
+				t.Errorf("Expected state %s, got %s", tt.want, got)
+			}
+			if (lastErr != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, lastErr)
+			}
+		})
+	}
+}
+
+func TestTimedPauseEndsOnlyItsOwnPause(t *testing.T) {
+	var m RunStateMachine
+	timed, _ := m.Pause()
+	if _, err := m.EndPause(timed); err != nil || m.State() != RunStateRunning {
+		t.Errorf("Expected the timed pause to end, got %s (%v)", m.State(), err)
+	}
+
+	// An operator pauses again after a resume while the first timer is still pending
+	timed, _ = m.Pause()
+	m.Transition(RunEventResume)
+	m.Transition(RunEventPause)
+	if _, err := m.EndPause(timed); err == nil || m.State() != RunStatePaused {
+		t.Errorf("Expected the later pause to survive the stale timer, got %s", m.State())
+	}
+
+	// A pause on top of a timed pause takes it over as well
+	m.Reset()
+	timed, _ = m.Pause()
+	m.Pause()
+	if m.EndPause(timed); m.State() != RunStatePaused {
+		t.Errorf("Expected the chain to stay paused, got %s", m.State())
+	}
+	if _, err := m.EndPause(0); err == nil {
+		t.Error("Expected a pause end without a token to be rejected")
+	}
+}
+
+func TestChainStateEndpoint(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer chain.runState.Reset()
+
+	mux := http.NewServeMux()
+	handleControlEndpoints(mux)
+
+	chain.runState.Transition(RunEventPause)
+
+	for _, id := range []string{"1", "ethereum"} {
+		req := httptest.NewRequest(http.MethodGet, "/control/chain/"+id+"/state", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", id, w.Code)
+		}
+		var resp map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp["chain"] != "ethereum" || resp["chain_id"] != "1" || resp["state"] != "paused" {
+			t.Errorf("Unexpected state response for %s: %v", id, resp)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/control/chain/999/state", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown chain, got %d", w.Code)
+	}
+}
+
+func TestInterruptPausedChainConflicts(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer chain.runState.Reset()
+
+	chain.runState.Transition(RunEventPause)
+	if err := chain.InterruptBlocks(); err == nil {
+		t.Error("Expected interrupting a paused chain to fail")
+	}
+	if chain.runState.State() != RunStatePaused {
+		t.Errorf("Expected chain to stay paused, got %s", chain.runState.State())
+	}
+}
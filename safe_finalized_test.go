@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync/atomic"
@@ -84,7 +85,7 @@ func TestSafeAndFinalizedBlocks(t *testing.T) {
 			}
 
 			// Call handleEVMRequest
-			response, err := handleEVMRequest(requestBytes, nil, "1")
+			response, err := handleEVMRequest(context.Background(), requestBytes, nil, "1")
 			if err != nil {
 				t.Fatalf("handleEVMRequest returned error: %v", err)
 			}
@@ -229,7 +230,7 @@ func TestEthGetLogsWithSafeAndFinalized(t *testing.T) {
 			}
 
 			// Call handleEVMRequest
-			response, err := handleEVMRequest(requestBytes, nil, "1")
+			response, err := handleEVMRequest(context.Background(), requestBytes, nil, "1")
 			if err != nil {
 				t.Fatalf("handleEVMRequest returned error: %v", err)
 			}
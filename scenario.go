@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Scenario step actions
+const (
+	ScenarioActionReorg     = "reorg"     // Trigger a reorg of Blocks blocks
+	ScenarioActionPause     = "pause"     // Pause block production (for DurationSeconds if set)
+	ScenarioActionResume    = "resume"    // Resume block production
+	ScenarioActionInterrupt = "interrupt" // Interrupt block emissions for DurationSeconds
+	ScenarioActionSetBlock  = "set_block" // Set the block/slot number to BlockNumber
+	ScenarioActionLatency   = "latency"   // Set response latency to LatencyMs
+	ScenarioActionDrop      = "drop"      // Drop all connections
+)
+
+// ScenarioStep is a single action executed at an offset from the scenario start
+type ScenarioStep struct {
+	AtSeconds       float64 `json:"at_seconds"`
+	Chain           string  `json:"chain"` // Chain name; ignored for drop
+	Action          string  `json:"action"`
+	Blocks          int     `json:"blocks,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	BlockNumber     uint64  `json:"block_number,omitempty"`
+	LatencyMs       int64   `json:"latency_ms,omitempty"`
+}
+
+// Scenario coordinates time-correlated events across multiple chains
+type Scenario struct {
+	ID        uint64         `json:"id"`
+	Name      string         `json:"name"`
+	Steps     []ScenarioStep `json:"steps"`
+	StartedAt time.Time      `json:"started_at"`
+	Executed  int            `json:"executed"`
+	Cancelled bool           `json:"cancelled"`
+
+	timers []*time.Timer
+}
+
+// ScenarioManager schedules and tracks running scenarios
+type ScenarioManager struct {
+	mu        sync.Mutex
+	scenarios map[uint64]*Scenario
+	nextID    uint64
+}
+
+func NewScenarioManager() *ScenarioManager {
+	return &ScenarioManager{
+		scenarios: make(map[uint64]*Scenario),
+	}
+}
+
+var scenarioManager = NewScenarioManager()
+
+// validateScenarioStep checks that a step refers to a known chain and action
+func validateScenarioStep(step ScenarioStep) error {
+	if step.AtSeconds < 0 {
+		return fmt.Errorf("at_seconds must be non-negative")
+	}
+	if step.Action == ScenarioActionDrop {
+		return nil
+	}
+	if getChain(step.Chain) == nil {
+		return fmt.Errorf("unsupported chain: %s", step.Chain)
+	}
+	switch step.Action {
+	case ScenarioActionReorg:
+		if step.Blocks <= 0 {
+			return fmt.Errorf("reorg requires blocks > 0")
+		}
+	case ScenarioActionInterrupt:
+		if step.DurationSeconds <= 0 {
+			return fmt.Errorf("interrupt requires duration_seconds > 0")
+		}
+	case ScenarioActionLatency:
+		if step.LatencyMs < 0 {
+			return fmt.Errorf("latency_ms must be non-negative")
+		}
+	case ScenarioActionPause, ScenarioActionResume, ScenarioActionSetBlock:
+	default:
+		return fmt.Errorf("unsupported action: %s", step.Action)
+	}
+	return nil
+}
+
+// Start validates and schedules all steps of a scenario relative to now
+func (m *ScenarioManager) Start(name string, steps []ScenarioStep) (*Scenario, error) {
+	for i, step := range steps {
+		if err := validateScenarioStep(step); err != nil {
+			return nil, fmt.Errorf("step %d: %v", i, err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	scenario := &Scenario{
+		ID:        m.nextID,
+		Name:      name,
+		Steps:     steps,
+		StartedAt: time.Now(),
+	}
+	for _, step := range steps {
+		step := step
+		delay := time.Duration(step.AtSeconds * float64(time.Second))
+		scenario.timers = append(scenario.timers, time.AfterFunc(delay, func() {
+			m.execute(scenario, step)
+		}))
+	}
+	m.scenarios[scenario.ID] = scenario
+	recordEvictions(RetentionStoreScenarios, m.trim(retentionLimit(RetentionStoreScenarios)))
+
+	log.Printf("Started scenario %d (%s) with %d steps", scenario.ID, name, len(steps))
+	return scenario, nil
+}
+
+// Cancel stops all pending steps of a scenario
+func (m *ScenarioManager) Cancel(id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	scenario, ok := m.scenarios[id]
+	if !ok {
+		return fmt.Errorf("scenario %d not found", id)
+	}
+	for _, timer := range scenario.timers {
+		timer.Stop()
+	}
+	scenario.Cancelled = true
+	log.Printf("Cancelled scenario %d (%s)", id, scenario.Name)
+	return nil
+}
+
+// List returns a snapshot of all scenarios ordered by id
+func (m *ScenarioManager) List() []Scenario {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]Scenario, 0, len(m.scenarios))
+	for _, scenario := range m.scenarios {
+		snapshot := *scenario
+		snapshot.timers = nil
+		list = append(list, snapshot)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// finished returns true once a scenario was cancelled or ran all its steps
+func (s *Scenario) finished() bool {
+	return s.Cancelled || s.Executed >= len(s.Steps)
+}
+
+// trim evicts the oldest finished scenarios beyond limit; running scenarios are never evicted
+// Must be called with m.mu held
+func (m *ScenarioManager) trim(limit int) int {
+	var finished []uint64
+	for id, scenario := range m.scenarios {
+		if scenario.finished() {
+			finished = append(finished, id)
+		}
+	}
+	excess := len(finished) - limit
+	if excess <= 0 {
+		return 0
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i] < finished[j]
+	})
+	for _, id := range finished[:excess] {
+		delete(m.scenarios, id)
+	}
+	return excess
+}
+
+// compact trims the finished scenarios to limit and returns the number evicted
+func (m *ScenarioManager) compact(limit int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.trim(limit)
+}
+
+// Len returns the number of retained scenarios
+func (m *ScenarioManager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.scenarios)
+}
+
+// execute runs a single scenario step
+func (m *ScenarioManager) execute(scenario *Scenario, step ScenarioStep) {
+	m.mu.Lock()
+	if scenario.Cancelled {
+		m.mu.Unlock()
+		return
+	}
+	scenario.Executed++
+	m.mu.Unlock()
+
+	log.Printf("Scenario %d (%s): %s on %s at T+%.1fs", scenario.ID, scenario.Name, step.Action, step.Chain, step.AtSeconds)
+
+	if step.Action == ScenarioActionDrop {
+		subManager.DropAllConnections()
+		reconnects.startCooldown(time.Now())
+		return
+	}
+
+	chain := getChain(step.Chain)
+	switch step.Action {
+	case ScenarioActionReorg:
+		chain.TriggerReorg(step.Blocks)
+	case ScenarioActionPause:
+		pause, _ := chain.RunState().Pause()
+		if step.DurationSeconds > 0 {
+			time.AfterFunc(time.Duration(step.DurationSeconds*float64(time.Second)), func() {
+				chain.RunState().EndPause(pause)
+			})
+		}
+	case ScenarioActionResume:
+		chain.RunState().Transition(RunEventResume)
+	case ScenarioActionInterrupt:
+		if err := interruptChain(step.Chain, time.Duration(step.DurationSeconds*float64(time.Second))); err != nil {
+			log.Printf("Scenario %d (%s): %v", scenario.ID, scenario.Name, err)
+		}
+	case ScenarioActionSetBlock:
+		setBlockNumber(step.Chain, step.BlockNumber)
+	case ScenarioActionLatency:
+		setLatency(chainIDForName(step.Chain), time.Duration(step.LatencyMs)*time.Millisecond, nil)
+	}
+}
+
+// chainIDForName returns the chain ID for a chain name, or an empty string if unknown
+func chainIDForName(name string) string {
+	for id, n := range chainIdToName {
+		if n == name {
+			return id
+		}
+	}
+	return ""
+}
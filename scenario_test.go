@@ -0,0 +1,83 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScenarioCoordinatesChains(t *testing.T) {
+	ethereum := supportedChains["ethereum"]
+	polygon := supportedChains["polygon"]
+	originalBlock := atomic.LoadUint64(&ethereum.BlockNumber)
+	defer func() {
+		atomic.StoreUint64(&ethereum.BlockNumber, originalBlock)
+		polygon.runState.Reset()
+	}()
+
+	atomic.StoreUint64(&ethereum.BlockNumber, 100)
+
+	sm := NewScenarioManager()
+	scenario, err := sm.Start("eth reorg while polygon stalls", []ScenarioStep{
+		{AtSeconds: 0.05, Chain: "ethereum", Action: ScenarioActionReorg, Blocks: 3},
+		{AtSeconds: 0.05, Chain: "polygon", Action: ScenarioActionPause, DurationSeconds: 0.1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start scenario: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadUint64(&ethereum.BlockNumber); got != 97 {
+		t.Errorf("Expected ethereum block 97 after reorg, got %d", got)
+	}
+	if polygon.runState.State() != RunStatePaused {
+		t.Error("Expected polygon to be paused")
+	}
+
+	// Polygon resumes once the pause duration elapses
+	time.Sleep(100 * time.Millisecond)
+	if polygon.runState.State() != RunStateRunning {
+		t.Error("Expected polygon to resume after pause duration")
+	}
+
+	list := sm.List()
+	if len(list) != 1 || list[0].ID != scenario.ID || list[0].Executed != 2 {
+		t.Errorf("Expected 1 scenario with 2 executed steps, got %+v", list)
+	}
+}
+
+func TestScenarioCancelAndValidation(t *testing.T) {
+	ethereum := supportedChains["ethereum"]
+	originalBlock := atomic.LoadUint64(&ethereum.BlockNumber)
+	defer atomic.StoreUint64(&ethereum.BlockNumber, originalBlock)
+
+	sm := NewScenarioManager()
+	atomic.StoreUint64(&ethereum.BlockNumber, 100)
+	scenario, err := sm.Start("cancelled", []ScenarioStep{
+		{AtSeconds: 0.05, Chain: "ethereum", Action: ScenarioActionReorg, Blocks: 3},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start scenario: %v", err)
+	}
+	if err := sm.Cancel(scenario.ID); err != nil {
+		t.Fatalf("Failed to cancel scenario: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadUint64(&ethereum.BlockNumber); got != 100 {
+		t.Errorf("Expected cancelled scenario not to reorg, got block %d", got)
+	}
+
+	invalid := [][]ScenarioStep{
+		{{Chain: "unknown", Action: ScenarioActionPause}},
+		{{Chain: "ethereum", Action: "explode"}},
+		{{Chain: "ethereum", Action: ScenarioActionReorg}},
+		{{Chain: "ethereum", Action: ScenarioActionInterrupt}},
+		{{AtSeconds: -1, Chain: "ethereum", Action: ScenarioActionPause}},
+	}
+	for _, steps := range invalid {
+		if _, err := sm.Start("invalid", steps); err == nil {
+			t.Errorf("Expected validation error for %+v", steps)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func postSetBlock(body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/control/block/set", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handleSetBlock(w, req)
+	return w
+}
+
+func TestScheduledBlockSet(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	original := atomic.LoadUint64(&chain.BlockNumber)
+	defer atomic.StoreUint64(&chain.BlockNumber, original)
+	atomic.StoreUint64(&chain.BlockNumber, 10)
+
+	if w := postSetBlock(`{"chain": "ethereum", "block_number": 5000, "in_seconds": 0.1}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := atomic.LoadUint64(&chain.BlockNumber); got != 10 {
+		t.Errorf("Expected block to stay at 10 until the scheduled time, got %d", got)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := atomic.LoadUint64(&chain.BlockNumber); got != 5000 {
+		t.Errorf("Expected scheduled block 5000, got %d", got)
+	}
+
+	at := float64(time.Now().Add(100*time.Millisecond).UnixNano()) / float64(time.Second)
+	body := fmt.Sprintf(`{"chain": "ethereum", "block_number": 6000, "at_timestamp": %f}`, at)
+	if w := postSetBlock(body); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := atomic.LoadUint64(&chain.BlockNumber); got != 6000 {
+		t.Errorf("Expected block 6000 at timestamp, got %d", got)
+	}
+}
+
+func TestScheduledBlockSetValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"both delays", `{"chain": "ethereum", "block_number": 1, "in_seconds": 1, "at_timestamp": 4102444800}`},
+		{"negative delay", `{"chain": "ethereum", "block_number": 1, "in_seconds": -1}`},
+		{"past timestamp", `{"chain": "ethereum", "block_number": 1, "at_timestamp": 1000}`},
+		{"unknown chain", `{"chain": "unknown", "block_number": 1, "in_seconds": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := postSetBlock(tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Bundled result schemas: evm.json follows ethereum/execution-apis, solana.json the Solana JSON RPC API
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// recentViolationsPerKey bounds the example violations kept per method
+const recentViolationsPerKey = 10
+
+// schemaBundle holds the result schemas of one chain family
+type schemaBundle struct {
+	Definitions   map[string]*jsonSchema `json:"definitions"`
+	Methods       map[string]*jsonSchema `json:"methods"`
+	Notifications map[string]*jsonSchema `json:"notifications"`
+}
+
+// jsonSchema is the subset of JSON Schema used by the bundled schemas
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 interface{}            `json:"type"` // A type name or a list of them
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+	Pattern              string                 `json:"pattern"`
+	Minimum              *float64               `json:"minimum"`
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+	AllOf                []*jsonSchema          `json:"allOf"`
+
+	pattern *regexp.Regexp
+}
+
+// compile resolves patterns ahead of validation
+func (s *jsonSchema) compile() error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	children := append(append([]*jsonSchema{s.Items}, s.AnyOf...), s.AllOf...)
+	for _, property := range s.Properties {
+		children = append(children, property)
+	}
+	for _, child := range children {
+		if err := child.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate returns the first violation of value against s, described with its JSON path
+func (b *schemaBundle) validate(s *jsonSchema, value interface{}, path string) error {
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/definitions/")
+		def, ok := b.Definitions[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema reference %s", path, s.Ref)
+		}
+		return b.validate(def, value, path)
+	}
+	for _, sub := range s.AllOf {
+		if err := b.validate(sub, value, path); err != nil {
+			return err
+		}
+	}
+	if len(s.AnyOf) > 0 {
+		var lastErr error
+		for _, sub := range s.AnyOf {
+			if lastErr = b.validate(sub, value, path); lastErr == nil {
+				break
+			}
+		}
+		if lastErr != nil {
+			return lastErr
+		}
+	}
+	if s.Type != nil && !matchesType(s.Type, value) {
+		return fmt.Errorf("%s: expected %v, got %s", path, s.Type, jsonTypeOf(value))
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, s.Enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Errorf("%s: %q does not match %s", path, v, s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return fmt.Errorf("%s: %v is below the minimum %v", path, v, *s.Minimum)
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := b.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required field %s", path, name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected field %s", path, name)
+				}
+				continue
+			}
+			if err := b.validate(property, v[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func matchesType(schemaType interface{}, value interface{}) bool {
+	switch t := schemaType.(type) {
+	case string:
+		return jsonTypeOf(value) == t || (t == "number" && jsonTypeOf(value) == "integer")
+	case []interface{}:
+		for _, name := range t {
+			if matchesType(name, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// schemaFamily returns the bundle name used for a chain
+func schemaFamily(chainId string) string {
+	if chainId == "501" {
+		return "solana"
+	}
+	return "evm"
+}
+
+// loadSchemaBundles parses and compiles the bundled schemas
+func loadSchemaBundles() (map[string]*schemaBundle, error) {
+	bundles := make(map[string]*schemaBundle)
+	for _, family := range []string{"evm", "solana"} {
+		data, err := schemaFiles.ReadFile("schemas/" + family + ".json")
+		if err != nil {
+			return nil, err
+		}
+		bundle := &schemaBundle{}
+		if err := json.Unmarshal(data, bundle); err != nil {
+			return nil, fmt.Errorf("%s schemas: %v", family, err)
+		}
+		for _, group := range []map[string]*jsonSchema{bundle.Definitions, bundle.Methods, bundle.Notifications} {
+			for name, schema := range group {
+				if err := schema.compile(); err != nil {
+					return nil, fmt.Errorf("%s schema %s: %v", family, name, err)
+				}
+			}
+		}
+		bundles[family] = bundle
+	}
+	return bundles, nil
+}
+
+// selfCheck validates outbound responses and notifications against the bundled schemas.
+// Violations are logged and counted; messages are always delivered unchanged.
+type selfCheck struct {
+	enabled int32
+	checked uint64
+
+	bundlesOnce sync.Once
+	bundles     map[string]*schemaBundle
+
+	mu         sync.Mutex
+	violations map[string]*schemaViolations // Keyed by <family>:<method>
+}
+
+// schemaViolations counts the violations of one method and keeps the most recent ones
+type schemaViolations struct {
+	Count  uint64   `json:"count"`
+	Recent []string `json:"recent"`
+}
+
+var schemaCheck = &selfCheck{violations: make(map[string]*schemaViolations)}
+
+func (c *selfCheck) active() bool {
+	return atomic.LoadInt32(&c.enabled) == 1
+}
+
+func (c *selfCheck) setEnabled(enabled bool) {
+	if enabled {
+		c.loadBundles()
+		atomic.StoreInt32(&c.enabled, 1)
+	} else {
+		atomic.StoreInt32(&c.enabled, 0)
+	}
+}
+
+func (c *selfCheck) loadBundles() map[string]*schemaBundle {
+	c.bundlesOnce.Do(func() {
+		bundles, err := loadSchemaBundles()
+		if err != nil {
+			log.Fatalf("Invalid bundled schemas: %v", err)
+		}
+		c.bundles = bundles
+	})
+	return c.bundles
+}
+
+func (c *selfCheck) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.violations = make(map[string]*schemaViolations)
+	atomic.StoreUint64(&c.checked, 0)
+}
+
+// checkResponse validates a response to a request for method on a chain
+func (c *selfCheck) checkResponse(chainId, method string, response []byte) {
+	if !c.active() || len(response) == 0 {
+		return
+	}
+	family := schemaFamily(chainId)
+	c.record(family, method, response, c.validateResponse(family, method, response))
+}
+
+// checkNotification validates a notification of a subscription kind (newHeads, slotNotification...)
+func (c *selfCheck) checkNotification(chainId, kind string, notification []byte) {
+	if !c.active() {
+		return
+	}
+	family := schemaFamily(chainId)
+	c.record(family, kind, notification, c.validateNotification(family, kind, notification))
+}
+
+func (c *selfCheck) validateResponse(family, method string, response []byte) error {
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(response, &envelope); err != nil {
+		return fmt.Errorf("response is not a JSON object: %v", err)
+	}
+	if envelope["jsonrpc"] != "2.0" {
+		return fmt.Errorf("jsonrpc must be \"2.0\"")
+	}
+	if _, ok := envelope["id"]; !ok {
+		return fmt.Errorf("missing id")
+	}
+	result, hasResult := envelope["result"]
+	rpcErr, hasError := envelope["error"]
+	if hasResult == hasError {
+		return fmt.Errorf("exactly one of result and error is required")
+	}
+	if hasError {
+		fields, ok := rpcErr.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("error must be an object")
+		}
+		if jsonTypeOf(fields["code"]) != "integer" {
+			return fmt.Errorf("error.code must be an integer")
+		}
+		if _, ok := fields["message"].(string); !ok {
+			return fmt.Errorf("error.message must be a string")
+		}
+		return nil
+	}
+
+	schema, ok := c.loadBundles()[family].Methods[method]
+	if !ok {
+		return nil
+	}
+	return c.bundles[family].validate(schema, result, "result")
+}
+
+func (c *selfCheck) validateNotification(family, kind string, notification []byte) error {
+	var envelope struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		Params  *struct {
+			Subscription interface{} `json:"subscription"`
+			Result       interface{} `json:"result"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(notification, &envelope); err != nil {
+		return fmt.Errorf("notification is not a JSON object: %v", err)
+	}
+	if envelope.JSONRPC != "2.0" {
+		return fmt.Errorf("jsonrpc must be \"2.0\"")
+	}
+	expectedMethod := kind
+	if family == "evm" {
+		expectedMethod = "eth_subscription"
+	}
+	if envelope.Method != expectedMethod {
+		return fmt.Errorf("method must be %s, got %q", expectedMethod, envelope.Method)
+	}
+	if envelope.Params == nil {
+		return fmt.Errorf("missing params")
+	}
+	subscriptionType := jsonTypeOf(envelope.Params.Subscription)
+	if family == "evm" && subscriptionType != "string" || family == "solana" && subscriptionType != "integer" {
+		return fmt.Errorf("params.subscription has type %s", subscriptionType)
+	}
+
+	schema, ok := c.loadBundles()[family].Notifications[kind]
+	if !ok {
+		return nil
+	}
+	return c.bundles[family].validate(schema, envelope.Params.Result, "params.result")
+}
+
+func (c *selfCheck) record(family, method string, message []byte, err error) {
+	atomic.AddUint64(&c.checked, 1)
+	if err == nil {
+		return
+	}
+	key := family + ":" + method
+	log.Printf("Schema violation (%s): %v", key, err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.violations[key]
+	if !ok {
+		v = &schemaViolations{}
+		c.violations[key] = v
+	}
+	v.Count++
+	v.Recent = append(v.Recent, fmt.Sprintf("%v: %s", err, message))
+	if len(v.Recent) > recentViolationsPerKey {
+		v.Recent = v.Recent[len(v.Recent)-recentViolationsPerKey:]
+	}
+}
+
+// snapshot returns a copy of the violations
+func (c *selfCheck) snapshot() (map[string]schemaViolations, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	violations := make(map[string]schemaViolations, len(c.violations))
+	var total uint64
+	for key, v := range c.violations {
+		violations[key] = schemaViolations{Count: v.Count, Recent: append([]string(nil), v.Recent...)}
+		total += v.Count
+	}
+	return violations, total
+}
+
+// handleSelfCheck reports (GET) or toggles (POST) schema validation of outbound messages
+func handleSelfCheck(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		violations, total := schemaCheck.snapshot()
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"enabled":    schemaCheck.active(),
+			"checked":    atomic.LoadUint64(&schemaCheck.checked),
+			"total":      total,
+			"violations": violations,
+		})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Enabled *bool `json:"enabled"`
+		Reset   bool  `json:"reset"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.Enabled != nil {
+		schemaCheck.setEnabled(*request.Enabled)
+		log.Printf("Schema self-check enabled: %t", *request.Enabled)
+	}
+	if request.Reset {
+		schemaCheck.reset()
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBundledSchemasLoad(t *testing.T) {
+	bundles, err := loadSchemaBundles()
+	if err != nil {
+		t.Fatalf("Failed to load schemas: %v", err)
+	}
+	if bundles["evm"].Methods["eth_getBlockByNumber"] == nil || bundles["solana"].Notifications["slotNotification"] == nil {
+		t.Error("Expected method and notification schemas for both families")
+	}
+}
+
+func TestSelfCheckValidation(t *testing.T) {
+	c := &selfCheck{}
+	tests := []struct {
+		family, method string
+		notification   bool
+		message        string
+		valid          bool
+	}{
+		{"evm", "eth_chainId", false, `{"jsonrpc":"2.0","result":"0x1","id":1}`, true},
+		{"evm", "eth_chainId", false, `{"jsonrpc":"2.0","result":"0x01","id":1}`, false},
+		{"evm", "eth_chainId", false, `{"jsonrpc":"2.0","result":1,"id":1}`, false},
+		{"evm", "eth_blockNumber", false, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"x"},"id":1}`, true},
+		{"evm", "eth_blockNumber", false, `{"jsonrpc":"2.0","error":{"code":"bad","message":"x"},"id":1}`, false},
+		{"evm", "eth_blockNumber", false, `{"jsonrpc":"2.0","id":1}`, false},
+		{"evm", "eth_blockNumber", false, `{"jsonrpc":"1.0","result":"0x1","id":1}`, false},
+		{"evm", "eth_getBlockByNumber", false, `{"jsonrpc":"2.0","result":null,"id":1}`, true},
+		{"evm", "unknown_method", false, `{"jsonrpc":"2.0","result":{"anything":1},"id":1}`, true},
+		{"evm", "logs", true, `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":{"address":"0x1"}}}`, false},
+		{"evm", "newHeads", true, `{"jsonrpc":"2.0","method":"slotNotification","params":{"subscription":"0x1","result":{}}}`, false},
+		{"solana", "getSlot", false, `{"jsonrpc":"2.0","result":42,"id":1}`, true},
+		{"solana", "getSlot", false, `{"jsonrpc":"2.0","result":-1,"id":1}`, false},
+		{"solana", "getHealth", false, `{"jsonrpc":"2.0","result":"behind","id":1}`, false},
+		{"solana", "slotNotification", true, `{"jsonrpc":"2.0","method":"slotNotification","params":{"subscription":3,"result":{"parent":1,"root":0,"slot":2}}}`, true},
+		{"solana", "slotNotification", true, `{"jsonrpc":"2.0","method":"slotNotification","params":{"subscription":"3","result":{"parent":1,"root":0,"slot":2}}}`, false},
+		{"solana", "slotNotification", true, `{"jsonrpc":"2.0","method":"slotNotification","params":{"subscription":3,"result":{"parent":1,"slot":2}}}`, false},
+	}
+	for _, tt := range tests {
+		var err error
+		if tt.notification {
+			err = c.validateNotification(tt.family, tt.method, []byte(tt.message))
+		} else {
+			err = c.validateResponse(tt.family, tt.method, []byte(tt.message))
+		}
+		if (err == nil) != tt.valid {
+			t.Errorf("%s %s: expected valid=%t, got %v", tt.family, tt.message, tt.valid, err)
+		}
+	}
+}
+
+func TestSelfCheckCountsViolations(t *testing.T) {
+	schemaCheck.reset()
+	schemaCheck.setEnabled(true)
+	defer func() {
+		schemaCheck.setEnabled(false)
+		schemaCheck.reset()
+	}()
+
+	// Generated responses and notifications that follow the spec pass
+	handleRPCRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`), NewMockWSConn(), "1")
+	handleRPCRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"getSlot","id":1}`), NewMockWSConn(), "501")
+	sub := &Subscription{ID: 5, Type: "501", Conn: NewMockWSConn(), Method: "slotNotification"}
+	sub.deliver([]byte(`{"jsonrpc":"2.0","method":"slotNotification","params":{"subscription":5,"result":{"parent":9,"root":0,"slot":10}}}`))
+	if violations, total := schemaCheck.snapshot(); total != 0 {
+		t.Fatalf("Expected no violations, got %v", violations)
+	}
+
+	// A malformed notification is still delivered, and counted
+	conn := NewMockWSConn()
+	sub = &Subscription{ID: 6, Type: "1", Conn: conn, Method: "newHeads"}
+	for i := 0; i < recentViolationsPerKey+2; i++ {
+		sub.deliver([]byte(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x6","result":{"number":"0x1"}}}`))
+	}
+	if len(conn.GetMessages()) != recentViolationsPerKey+2 {
+		t.Errorf("Expected violating notifications to be delivered, got %d", len(conn.GetMessages()))
+	}
+
+	w := httptest.NewRecorder()
+	handleSelfCheck(w, httptest.NewRequest(http.MethodGet, "/control/self-check", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, `"evm:newHeads":{"count":12`) || atomic.LoadUint64(&schemaCheck.checked) < 15 {
+		t.Errorf("Unexpected self-check report: %s", body)
+	}
+	violations, _ := schemaCheck.snapshot()
+	if len(violations["evm:newHeads"].Recent) != recentViolationsPerKey {
+		t.Errorf("Expected the last %d violations, got %d", recentViolationsPerKey, len(violations["evm:newHeads"].Recent))
+	}
+
+	w = httptest.NewRecorder()
+	handleSelfCheck(w, httptest.NewRequest(http.MethodPost, "/control/self-check", bytes.NewBufferString(`{"reset": true}`)))
+	if _, total := schemaCheck.snapshot(); w.Code != http.StatusOK || total != 0 {
+		t.Errorf("Expected the reset to clear violations, got %d %d", w.Code, total)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// simMethodsEnabled exposes the sim_ control methods on the chain endpoints (SIM_METHODS)
+var simMethodsEnabled bool
+
+// isSimMethod returns true for methods of the simulator's sim_ namespace
+func isSimMethod(method string) bool {
+	return strings.HasPrefix(method, "sim_")
+}
+
+// handleSimMethod answers a sim_ method on a chain endpoint. sim_connectionInfo is always
+// available; the control methods below drive faults of the endpoint's own chain and are
+// only available with SIM_METHODS set, for test code that has the RPC URL but no access to
+// the control API. Like control requests, they bypass the chain's faults.
+//
+//	sim_setLatency  [latencyMs]        Set the chain's response latency
+//	sim_injectError [errorConfig]      Add an error configuration (EVM chains)
+//	sim_clearErrors []                 Remove all error configurations (EVM chains)
+//	sim_mine        []                 Produce one block or slot, returns its number
+//	sim_reorg       [blocks]           Reorganize the last blocks
+func handleSimMethod(message []byte, conn WSConn, chainId string) ([]byte, error) {
+	var request JSONRPCRequest
+	if err := json.Unmarshal(message, &request); err != nil {
+		return createErrorResponse(-32700, "Parse error", nil, nil)
+	}
+	if request.Method == "sim_connectionInfo" {
+		return json.Marshal(JSONRPCResponse{JsonRPC: "2.0", Result: connectionInfo(conn, chainId), ID: request.ID})
+	}
+	if !simMethodsEnabled {
+		return createErrorResponse(-32601, "Method not found", nil, request.ID)
+	}
+
+	name := chainIdToName[chainId]
+	chain := getChain(name)
+	evmChain := supportedChains[name]
+
+	var result interface{}
+	switch request.Method {
+	case "sim_setLatency":
+		latencyMs, ok := simIntParam(request.Params, 0)
+		if !ok || latencyMs < 0 {
+			return createErrorResponse(-32602, "Invalid params: expected [latencyMs] with a non-negative latency", nil, request.ID)
+		}
+		setLatency(chainId, time.Duration(latencyMs)*time.Millisecond, nil)
+		log.Printf("Set %s latency to %dms via sim_setLatency", name, latencyMs)
+		persistChainConfig()
+		result = true
+
+	case "sim_injectError":
+		if evmChain == nil {
+			return createErrorResponse(-32601, fmt.Sprintf("%s is not supported on %s", request.Method, name), nil, request.ID)
+		}
+		var config ErrorConfig
+		if len(request.Params) != 1 || remarshal(request.Params[0], &config) != nil {
+			return createErrorResponse(-32602, "Invalid params: expected [errorConfig]", nil, request.ID)
+		}
+		if config.Probability < 0 || config.Probability > 1 || config.DelayMs < 0 {
+			return createErrorResponse(-32602, "Invalid params: probability must be between 0 and 1 and delay_ms non-negative", nil, request.ID)
+		}
+		if err := config.validate(); err != nil {
+			return createErrorResponse(-32602, "Invalid params: "+err.Error(), nil, request.ID)
+		}
+		index := 0
+		evmChain.updateErrorConfigs(nil, func(configs []ErrorConfig) ([]ErrorConfig, error) {
+			index = len(configs)
+			return append(configs, config), nil
+		})
+		log.Printf("Added error config (code: %d, probability: %.2f) to chain %s via sim_injectError", config.Code, config.Probability, name)
+		persistChainConfig()
+		result = index
+
+	case "sim_clearErrors":
+		if evmChain == nil {
+			return createErrorResponse(-32601, fmt.Sprintf("%s is not supported on %s", request.Method, name), nil, request.ID)
+		}
+		evmChain.updateErrorConfigs(nil, func([]ErrorConfig) ([]ErrorConfig, error) {
+			return nil, nil
+		})
+		log.Printf("Cleared error configs of chain %s via sim_clearErrors", name)
+		persistChainConfig()
+		result = true
+
+	case "sim_mine":
+		var number uint64
+		if chainId == "501" {
+			number = mineSolanaSlot()
+		} else {
+			number = evmChain.produceBlock(chainId, nil)
+		}
+		result = fmt.Sprintf("0x%x", number)
+
+	case "sim_reorg":
+		blocks, ok := simIntParam(request.Params, 0)
+		if !ok || blocks <= 0 {
+			return createErrorResponse(-32602, "Invalid params: expected [blocks] with blocks > 0", nil, request.ID)
+		}
+		chain.TriggerReorg(int(blocks))
+		log.Printf("Triggered chain reorganization for %s: %d blocks via sim_reorg", name, blocks)
+		if chainId == "501" {
+			result = fmt.Sprintf("0x%x", atomic.LoadUint64(&solanaNode.SlotNumber))
+		} else {
+			result = fmt.Sprintf("0x%x", atomic.LoadUint64(&evmChain.BlockNumber))
+		}
+
+	default:
+		return createErrorResponse(-32601, "Method not found", nil, request.ID)
+	}
+	return json.Marshal(JSONRPCResponse{JsonRPC: "2.0", Result: result, ID: request.ID})
+}
+
+// simIntParam returns the i-th parameter if it is an integer
+func simIntParam(params []interface{}, i int) (int64, bool) {
+	if len(params) <= i {
+		return 0, false
+	}
+	f, ok := params[i].(float64)
+	if !ok || f != float64(int64(f)) {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// remarshal converts a decoded JSON value into a typed value
+func remarshal(value interface{}, target interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
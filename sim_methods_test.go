@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func simCall(t *testing.T, chainId, method, params string) JSONRPCResponse {
+	t.Helper()
+	message := fmt.Sprintf(`{"jsonrpc":"2.0","method":"%s","params":%s,"id":1}`, method, params)
+	response, err := handleRPCRequest(context.Background(), []byte(message), NewMockWSConn(), chainId)
+	if err != nil {
+		t.Fatalf("%s failed: %v", method, err)
+	}
+	var resp JSONRPCResponse
+	if err := json.Unmarshal(response, &resp); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	return resp
+}
+
+func TestSimMethodsDisabled(t *testing.T) {
+	if resp := simCall(t, "100", "sim_mine", "[]"); resp.Error == nil || resp.Error.Code != -32601 {
+		t.Errorf("Expected method not found without SIM_METHODS, got %+v", resp)
+	}
+	if resp := simCall(t, "100", "sim_connectionInfo", "[]"); resp.Error != nil {
+		t.Errorf("Expected sim_connectionInfo to stay available, got %+v", resp.Error)
+	}
+}
+
+func TestSimMethods(t *testing.T) {
+	withTempConfig(t)
+	simMethodsEnabled = true
+	chain := supportedChains["gnosis"]
+	defer func() {
+		simMethodsEnabled = false
+		setLatency("100", 0, nil)
+		chain.ErrorConfigs = nil
+		setLatency("501", 0, nil)
+	}()
+
+	resp := simCall(t, "100", "sim_setLatency", "[250]")
+	if latency, _, _ := latencySettings("100"); resp.Error != nil || latency != 250*time.Millisecond {
+		t.Errorf("Expected latency 250ms, got %v (%+v)", latency, resp.Error)
+	}
+	simCall(t, "501", "sim_setLatency", "[50]")
+	if latency, _, _ := latencySettings("501"); latency != 50*time.Millisecond {
+		t.Errorf("Expected Solana latency 50ms, got %v", latency)
+	}
+
+	// Errors apply to regular methods but not to the sim_ methods themselves
+	resp = simCall(t, "100", "sim_injectError", `[{"code": -32005, "message": "limit exceeded", "probability": 1}]`)
+	if resp.Error != nil || resp.Result != float64(0) {
+		t.Fatalf("Expected error config index 0, got %+v", resp)
+	}
+	setLatency("100", 0, nil)
+	if resp := simCall(t, "100", "eth_blockNumber", "[]"); resp.Error == nil || resp.Error.Code != -32005 {
+		t.Errorf("Expected the injected error, got %+v", resp)
+	}
+
+	before := atomic.LoadUint64(&chain.BlockNumber)
+	if resp := simCall(t, "100", "sim_mine", "[]"); resp.Result != fmt.Sprintf("0x%x", before+1) {
+		t.Errorf("Expected block 0x%x, got %+v", before+1, resp)
+	}
+	if resp := simCall(t, "100", "sim_reorg", "[1]"); resp.Result != fmt.Sprintf("0x%x", before) {
+		t.Errorf("Expected head 0x%x after the reorg, got %+v", before, resp)
+	}
+
+	if simCall(t, "100", "sim_clearErrors", "[]"); len(chain.ErrorConfigs) != 0 {
+		t.Errorf("Expected error configs to be cleared, got %d", len(chain.ErrorConfigs))
+	}
+
+	for _, call := range [][3]string{
+		{"100", "sim_setLatency", `[-1]`},
+		{"100", "sim_setLatency", `["fast"]`},
+		{"100", "sim_injectError", `[{"code": -32000, "probability": 2}]`},
+		{"100", "sim_reorg", `[0]`},
+	} {
+		if resp := simCall(t, call[0], call[1], call[2]); resp.Error == nil || resp.Error.Code != -32602 {
+			t.Errorf("%s %s: expected invalid params, got %+v", call[1], call[2], resp)
+		}
+	}
+	if resp := simCall(t, "501", "sim_injectError", `[{"code": -32000, "probability": 1}]`); resp.Error == nil || resp.Error.Code != -32601 {
+		t.Errorf("Expected sim_injectError to be unsupported on Solana, got %+v", resp)
+	}
+	if resp := simCall(t, "100", "sim_unknown", "[]"); resp.Error == nil || resp.Error.Code != -32601 {
+		t.Errorf("Expected method not found, got %+v", resp)
+	}
+}
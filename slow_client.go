@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Slow client policies, applied when a write to a client is blocked longer than the chain's slow client timeout
+const (
+	SlowClientPolicyWait  = "wait"  // Keep waiting for the client; only record it (default)
+	SlowClientPolicyDrop  = "drop"  // Queue messages, dropping them while the queue is full
+	SlowClientPolicyClose = "close" // Close the connection with 1008 (policy violation)
+)
+
+// errSlowClientDropped is returned for a message dropped under the drop policy
+var errSlowClientDropped = errors.New("message dropped for slow client")
+
+// SlowClientPolicies lists all supported slow client policies
+var SlowClientPolicies = []string{SlowClientPolicyWait, SlowClientPolicyDrop, SlowClientPolicyClose}
+
+// isValidSlowClientPolicy returns true for a supported policy or an empty string (wait)
+func isValidSlowClientPolicy(policy string) bool {
+	if policy == "" {
+		return true
+	}
+	for _, p := range SlowClientPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// slowClientSettings returns the slow client timeout and policy of a chain
+func slowClientSettings(chainId string) (time.Duration, string) {
+	var timeout time.Duration
+	var policy string
+	if chainId == "501" {
+		timeout, policy = solanaNode.SlowClientTimeout, solanaNode.SlowClientPolicy
+	} else if chain, ok := supportedChains[chainIdToName[chainId]]; ok {
+		timeout, policy = chain.SlowClientTimeout, chain.SlowClientPolicy
+	}
+	if policy == "" {
+		policy = SlowClientPolicyWait
+	}
+	return timeout, policy
+}
+
+// SlowClient describes a connection that stopped reading
+type SlowClient struct {
+	ConnID          uint64    `json:"conn_id"`
+	ChainID         string    `json:"chain_id"`
+	Policy          string    `json:"policy"`
+	DetectedAt      time.Time `json:"detected_at"`
+	Blocked         bool      `json:"blocked"`          // A write to the client is currently blocked
+	DroppedMessages uint64    `json:"dropped_messages"` // Messages dropped under the drop policy
+	Closed          bool      `json:"closed"`           // Closed under the close policy
+}
+
+// SlowClientRegistry records connections detected as slow
+type SlowClientRegistry struct {
+	mu      sync.Mutex
+	clients map[uint64]*SlowClient
+}
+
+var slowClients = &SlowClientRegistry{clients: make(map[uint64]*SlowClient)}
+
+// update applies fn to the record of a connection, creating it on first detection
+func (r *SlowClientRegistry) update(conn *wsConnWrapper, policy string, fn func(*SlowClient)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[conn.id]
+	if !ok {
+		client = &SlowClient{ConnID: conn.id, ChainID: conn.chainId, DetectedAt: time.Now()}
+		r.clients[conn.id] = client
+		recordEvictions(RetentionStoreSlowClients, r.trim(retentionLimit(RetentionStoreSlowClients)))
+	}
+	client.Policy = policy
+	fn(client)
+}
+
+// unblocked marks a previously detected connection as reading again
+func (r *SlowClientRegistry) unblocked(connID uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if client, ok := r.clients[connID]; ok {
+		client.Blocked = false
+	}
+}
+
+// Snapshot returns all recorded slow clients ordered by connection id
+func (r *SlowClientRegistry) Snapshot() []SlowClient {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]SlowClient, 0, len(r.clients))
+	for _, client := range r.clients {
+		list = append(list, *client)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ConnID < list[j].ConnID
+	})
+	return list
+}
+
+// trim evicts records beyond limit, oldest connections first, keeping blocked ones as long as possible
+// Must be called with r.mu held
+func (r *SlowClientRegistry) trim(limit int) int {
+	excess := len(r.clients) - limit
+	if excess <= 0 {
+		return 0
+	}
+	ids := make([]uint64, 0, len(r.clients))
+	for id := range r.clients {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		bi, bj := r.clients[ids[i]].Blocked, r.clients[ids[j]].Blocked
+		if bi != bj {
+			return !bi
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids[:excess] {
+		delete(r.clients, id)
+	}
+	return excess
+}
+
+// compact trims the records to limit and returns the number evicted
+func (r *SlowClientRegistry) compact(limit int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.trim(limit)
+}
+
+// Len returns the number of recorded slow clients
+func (r *SlowClientRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.clients)
+}
+
+// Reset forgets all recorded slow clients
+func (r *SlowClientRegistry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients = make(map[uint64]*SlowClient)
+}
+
+// slowClientQueueSize is how many messages a connection under the drop policy buffers
+// while a write is blocked; further messages are dropped
+const slowClientQueueSize = 64
+
+// sendQueue buffers the writes of a connection under the drop policy, so a client that
+// stopped reading blocks only the queue's writer, not the chain's broadcast worker
+type sendQueue struct {
+	once     sync.Once
+	messages chan wsMessage
+	stopOnce sync.Once
+	stop     chan struct{} // Closed when the connection closes
+}
+
+// init creates the queue's channels, starting the writer of conn unless it is nil
+func (q *sendQueue) init(conn *wsConnWrapper) {
+	q.once.Do(func() {
+		q.stop = make(chan struct{})
+		if conn != nil {
+			q.messages = make(chan wsMessage, slowClientQueueSize)
+			go conn.runSendQueue()
+		}
+	})
+}
+
+// close stops the queue's writer; pending messages are discarded
+func (q *sendQueue) close() {
+	q.init(nil)
+	q.stopOnce.Do(func() { close(q.stop) })
+}
+
+// runSendQueue writes the queued messages of a connection until it is closed
+func (w *wsConnWrapper) runSendQueue() {
+	for {
+		select {
+		case <-w.queue.stop:
+			return
+		case msg := <-w.queue.messages:
+			timeout, policy := slowClientSettings(w.chainId)
+			if timeout > 0 {
+				w.writeTimed(msg.messageType, msg.data, timeout, policy)
+				continue
+			}
+			w.writeMu.Lock()
+			w.write(msg.messageType, msg.data)
+			w.writeMu.Unlock()
+		}
+	}
+}
+
+// writeDetectingSlowClient writes a message, applying the slow client policy if the
+// write blocks longer than timeout because the client stopped reading. Under the drop
+// policy the message is queued instead and dropped with errSlowClientDropped if the
+// queue is full.
+func (w *wsConnWrapper) writeDetectingSlowClient(messageType int, data []byte, timeout time.Duration, policy string) error {
+	if policy != SlowClientPolicyDrop {
+		return w.writeTimed(messageType, data, timeout, policy)
+	}
+	w.queue.init(w)
+	select {
+	case w.queue.messages <- wsMessage{messageType: messageType, data: data}:
+		return nil
+	default:
+		slowClients.update(w, policy, func(c *SlowClient) { c.DroppedMessages++ })
+		return errSlowClientDropped
+	}
+}
+
+// writeTimed writes a message, calling onSlowWrite if the write blocks longer than timeout
+func (w *wsConnWrapper) writeTimed(messageType int, data []byte, timeout time.Duration, policy string) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	timer := time.AfterFunc(timeout, func() {
+		w.onSlowWrite(timeout, policy)
+	})
+	err := w.write(messageType, data)
+	if timer.Stop() && atomic.CompareAndSwapInt32(&w.slow, 1, 0) {
+		slowClients.unblocked(w.id)
+	}
+	return err
+}
+
+// onSlowWrite runs when a write has been blocked for the slow client timeout
+func (w *wsConnWrapper) onSlowWrite(timeout time.Duration, policy string) {
+	atomic.StoreInt32(&w.slow, 1)
+	log.Printf("Slow client detected: conn %d on chain %s blocked a write for %v (policy %s)", w.id, w.chainId, timeout, policy)
+	slowClients.update(w, policy, func(c *SlowClient) { c.Blocked = true })
+	if policy != SlowClientPolicyClose {
+		return
+	}
+
+	// WriteControl and Close may run concurrently with the blocked write; the close
+	// frame is best effort since the client's receive buffer is full
+	w.Conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "slow client"),
+		time.Now().Add(100*time.Millisecond))
+	w.Conn.Close()
+	slowClients.update(w, policy, func(c *SlowClient) { c.Closed = true })
+}
+
+// handleSlowClients returns the connections detected as slow
+func handleSlowClients(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"connections": slowClients.Snapshot(),
+	})
+}
+
+// handleResetSlowClients forgets the recorded slow clients
+func handleResetSlowClients(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	slowClients.Reset()
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleSetSlowClient configures slow client detection for a chain
+func handleSetSlowClient(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain     string `json:"chain"`
+		TimeoutMs int64  `json:"timeout_ms"` // 0 disables detection
+		Policy    string `json:"policy"`     // wait (default), drop or close
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.TimeoutMs < 0 {
+		http.Error(w, "Timeout must be non-negative", http.StatusBadRequest)
+		return
+	}
+	if !isValidSlowClientPolicy(request.Policy) {
+		http.Error(w, "Invalid policy", http.StatusBadRequest)
+		return
+	}
+
+	timeout := time.Duration(request.TimeoutMs) * time.Millisecond
+	if request.Chain == "solana" {
+		solanaNode.SlowClientTimeout = timeout
+		solanaNode.SlowClientPolicy = request.Policy
+	} else if chain, ok := supportedChains[request.Chain]; ok {
+		chain.SlowClientTimeout = timeout
+		chain.SlowClientPolicy = request.Policy
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Set slow client detection to %v (policy %q) for chain %s", timeout, request.Policy, request.Chain)
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
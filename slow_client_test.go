@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// stalledClient returns a server-side connection whose client never reads
+func stalledClient(t *testing.T, connID uint64) *wsConnWrapper {
+	serverConns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade failed: %v", err)
+			return
+		}
+		serverConns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return &wsConnWrapper{Conn: <-serverConns, id: connID, chainId: "1"}
+}
+
+// fillUntilBlocked writes large messages in the background until a write blocks or fails
+func fillUntilBlocked(conn *wsConnWrapper) chan error {
+	done := make(chan error, 1)
+	payload := make([]byte, 1<<20)
+	go func() {
+		for i := 0; i < 512; i++ {
+			if err := conn.WriteMessage(websocket.BinaryMessage, payload); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	return done
+}
+
+// waitForSlowClient polls the registry until the connection matches cond
+func waitForSlowClient(t *testing.T, connID uint64, cond func(SlowClient) bool) SlowClient {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, client := range slowClients.Snapshot() {
+			if client.ConnID == connID && cond(client) {
+				return client
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("Connection %d did not reach the expected slow client state: %+v", connID, slowClients.Snapshot())
+	return SlowClient{}
+}
+
+func TestSlowClientDropPolicy(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	chain.SlowClientTimeout = 100 * time.Millisecond
+	chain.SlowClientPolicy = SlowClientPolicyDrop
+	defer func() {
+		chain.SlowClientTimeout = 0
+		chain.SlowClientPolicy = ""
+		slowClients.Reset()
+	}()
+
+	conn := stalledClient(t, 1001)
+	defer func() {
+		conn.Conn.Close() // Unblocks the queued write
+		conn.Close()
+	}()
+	// Writes are queued, so the filler gets a dropped error instead of blocking
+	select {
+	case err := <-fillUntilBlocked(conn):
+		if err != errSlowClientDropped {
+			t.Fatalf("Expected writes to be dropped once the queue is full, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected writes to a slow client not to block")
+	}
+	waitForSlowClient(t, 1001, func(c SlowClient) bool { return c.Blocked })
+	// The queue may have drained a little until the write blocked
+	if err := <-fillUntilBlocked(conn); err != errSlowClientDropped {
+		t.Fatalf("Expected writes to be dropped while the write is blocked, got %v", err)
+	}
+
+	// Notifications are dropped without stalling the chain's broadcast worker
+	headNotificationMetrics.Reset()
+	defer headNotificationMetrics.Reset()
+	sm := NewSubscriptionManager()
+	sm.Subscribe("1", conn, "newHeads")
+	start := time.Now()
+	sm.BroadcastNewBlock("1", 10)
+	sm.Flush("1")
+	if time.Since(start) > 50*time.Millisecond {
+		t.Error("Expected the notification to a slow client to be dropped without blocking")
+	}
+	if snapshot := headNotificationMetrics.For("1").Snapshot(); snapshot.Dropped != 1 || snapshot.WriteFailures != 0 {
+		t.Errorf("Expected the notification counted as dropped, got %+v", snapshot)
+	}
+	if sm.Count() != 1 {
+		t.Error("Expected the subscription to stay after a dropped notification")
+	}
+
+	client := waitForSlowClient(t, 1001, func(c SlowClient) bool { return c.DroppedMessages >= 3 })
+	if client.ChainID != "1" || client.Policy != SlowClientPolicyDrop || client.Closed {
+		t.Errorf("Unexpected slow client record %+v", client)
+	}
+}
+
+func TestSlowClientClosePolicy(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	chain.SlowClientTimeout = 100 * time.Millisecond
+	chain.SlowClientPolicy = SlowClientPolicyClose
+	defer func() {
+		chain.SlowClientTimeout = 0
+		chain.SlowClientPolicy = ""
+		slowClients.Reset()
+	}()
+
+	conn := stalledClient(t, 1002)
+	done := fillUntilBlocked(conn)
+	waitForSlowClient(t, 1002, func(c SlowClient) bool { return c.Closed })
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected the blocked write to fail once the connection is closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the blocked write to return after the close")
+	}
+
+	w := httptest.NewRecorder()
+	handleSlowClients(w, httptest.NewRequest(http.MethodGet, "/control/connections/slow", nil))
+	if !strings.Contains(w.Body.String(), `"conn_id":1002`) {
+		t.Errorf("Expected the slow connection to be listed, got %s", w.Body.String())
+	}
+}
+
+func TestSetSlowClientValidation(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleSetSlowClient(w, httptest.NewRequest(http.MethodPost, "/control/chain/slow-client", bytes.NewBufferString(`{"chain": "ethereum", "timeout_ms": 500, "policy": "ignore"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown policy, got %d", w.Code)
+	}
+}
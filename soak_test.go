@@ -0,0 +1,124 @@
+//go:build soak
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestSoak runs the simulator's stores under sustained load and fails if the heap keeps growing.
+//
+//	SOAK_DURATION=72h go test -tags soak -run TestSoak -timeout 0 .
+//
+// SOAK_DURATION defaults to 72h and SOAK_SAMPLE_INTERVAL to 1m.
+func TestSoak(t *testing.T) {
+	duration := soakEnvDuration(t, "SOAK_DURATION", 72*time.Hour)
+	sampleInterval := soakEnvDuration(t, "SOAK_SAMPLE_INTERVAL", time.Minute)
+
+	originalTemplates := solanaNode.ProgramLogs
+	solanaNode.ProgramLogs = []SolanaProgramLog{{ProgramID: testProgramID, Instruction: "Transfer"}}
+	subManager.SetSolanaIDReuse(true, 50*time.Millisecond)
+	defer func() {
+		solanaNode.ProgramLogs = originalTemplates
+		subManager.SetSolanaIDReuse(false, 0)
+	}()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go soakLoad(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Second):
+				compactStores()
+			}
+		}
+	}()
+
+	// Let the stores fill up to their caps before taking the baseline
+	warmup := duration / 10
+	if warmup > 10*time.Minute {
+		warmup = 10 * time.Minute
+	}
+	time.Sleep(warmup)
+	baseline := soakHeapAlloc()
+	t.Logf("Baseline heap after %v warm-up: %d bytes", warmup, baseline)
+
+	deadline := time.Now().Add(duration - warmup)
+	for time.Now().Before(deadline) {
+		time.Sleep(sampleInterval)
+		heap := soakHeapAlloc()
+		metrics := currentRetentionMetrics()
+		t.Logf("heap=%d goroutines=%d stores=%+v", heap, metrics.Goroutines, metrics.Stores)
+
+		for store, stats := range metrics.Stores {
+			if stats.Limit > 0 && stats.Size > stats.Limit {
+				t.Fatalf("Store %s exceeds its cap: %d > %d", store, stats.Size, stats.Limit)
+			}
+		}
+		if heap > 2*baseline+16<<20 {
+			t.Fatalf("Heap grew from %d to %d bytes", baseline, heap)
+		}
+	}
+}
+
+// soakLoad drives subscriptions, notifications, scenarios and slow client records until stopped
+func soakLoad(stop chan struct{}) {
+	slot := uint64(1)
+	connID := uint64(1 << 32) // Above ids of real connections
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		// Connection churn with subscriptions on every transport
+		conn := NewMockWSConn()
+		subManager.Subscribe("1", conn, "newHeads")
+		subManager.Subscribe("1", conn, "logs")
+		solanaSub, _ := subManager.Subscribe("501", conn, "slotNotification")
+		logsSub, _ := subManager.Subscribe("501", conn, "logsNotification")
+
+		subManager.broadcastNewBlock("1", slot, time.Now())
+		subManager.broadcastNewLog("1", LogEvent{BlockNumber: slot}, time.Now())
+		subManager.broadcastNewBlock("501", slot, time.Now())
+		subManager.BroadcastSolanaLogs(slot)
+
+		subManager.Unsubscribe(solanaSub)
+		subManager.Unsubscribe(logsSub)
+		subManager.CleanupConnection(conn)
+
+		connID++
+		slowClients.update(&wsConnWrapper{id: connID, chainId: "1"}, SlowClientPolicyWait, func(c *SlowClient) {})
+		scenarioManager.Start("soak", nil)
+
+		slot++
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// soakHeapAlloc returns the live heap after a full collection
+func soakHeapAlloc() uint64 {
+	runtime.GC()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return mem.HeapAlloc
+}
+
+func soakEnvDuration(t *testing.T, name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		t.Fatalf("Invalid %s: %v", name, err)
+	}
+	return d
+}
@@ -24,6 +24,7 @@ func TestSolanaSlotNotificationBroadcast(t *testing.T) {
 	// Broadcast a new block/slot
 	slotNumber := uint64(123)
 	sm.BroadcastNewBlock("501", slotNumber)
+	sm.Flush("501")
 
 	// Wait a bit for the message to be sent
 	time.Sleep(100 * time.Millisecond)
@@ -108,6 +109,7 @@ func TestSolanaVsEVMBroadcast(t *testing.T) {
 
 	// Broadcast to Solana
 	sm.BroadcastNewBlock("501", 200)
+	sm.Flush("501")
 	time.Sleep(50 * time.Millisecond)
 
 	// Verify Solana conn received message
@@ -129,6 +131,7 @@ func TestSolanaVsEVMBroadcast(t *testing.T) {
 	// Broadcast to EVM
 	atomic.StoreUint64(&testChain.BlockNumber, 300)
 	sm.BroadcastNewBlock("1", 300)
+	sm.Flush("1")
 	time.Sleep(50 * time.Millisecond)
 
 	// Verify EVM conn received message
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"sync/atomic"
 	"testing"
@@ -11,10 +12,10 @@ func TestSolanaGetSlotWithCommitment(t *testing.T) {
 	atomic.StoreUint64(&solanaNode.SlotNumber, 100)
 
 	tests := []struct {
-		name           string
-		commitment     string
-		expectedSlot   uint64
-		withParams     bool
+		name         string
+		commitment   string
+		expectedSlot uint64
+		withParams   bool
 	}{
 		{
 			name:         "No params - defaults to processed (latest)",
@@ -62,7 +63,7 @@ func TestSolanaGetSlotWithCommitment(t *testing.T) {
 				t.Fatalf("Failed to marshal request: %v", err)
 			}
 
-			response, err := handleSolanaRequest(requestBytes, nil)
+			response, err := handleSolanaRequest(context.Background(), requestBytes, nil)
 			if err != nil {
 				t.Fatalf("handleSolanaRequest failed: %v", err)
 			}
@@ -132,7 +133,7 @@ func TestSolanaGetSlotWithLowSlotNumber(t *testing.T) {
 				t.Fatalf("Failed to marshal request: %v", err)
 			}
 
-			response, err := handleSolanaRequest(requestBytes, nil)
+			response, err := handleSolanaRequest(context.Background(), requestBytes, nil)
 			if err != nil {
 				t.Fatalf("handleSolanaRequest failed: %v", err)
 			}
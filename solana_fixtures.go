@@ -0,0 +1,567 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Well-known Solana program IDs
+const (
+	systemProgramID = "11111111111111111111111111111111"
+	tokenProgramID  = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+	stakeProgramID  = "Stake11111111111111111111111111111111111111"
+)
+
+// Mainnet genesis time and target slot duration, from which fixture block times are derived
+const (
+	solanaGenesisUnix = 1584368940
+	solanaSlotMs      = 400
+)
+
+// solanaBlockTime returns the block time of a slot, so the same slot always reports the same time
+func solanaBlockTime(slot uint64) int64 {
+	return solanaGenesisUnix + int64(slot*solanaSlotMs/1000)
+}
+
+// Fixture accounts used by getAccountInfo and getTransaction
+const (
+	fixtureWalletPubkey       = "4Nd1mBQtrMJVYVfKf2PJy9NZUZdTAsp7D4xWLs4gDB4T"
+	fixtureRecipientPubkey    = "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM"
+	fixtureMintPubkey         = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	fixtureTokenAccountPubkey = "3emsAVdmGKERbHjmGfQ6oZ1e35dkf5iYcS6U4CPKFVaa"
+	fixtureTokenDestPubkey    = "7UX2i7SucgLMQcfZ75s3VXmZZY4YRUyJN9X1RgfMoDUi"
+	fixtureStakePubkey        = "CyZuD7RPDcrqCGbNvLCyqk6Py9cEZTKmNKujfPi3ynDd"
+	fixtureVotePubkey         = "J1to1yufRnoWn81KYg1XkTWzmKjnYSnmE2VY8DGUJ9Qv"
+)
+
+// Fixture transaction signatures used by getTransaction
+const (
+	fixtureTransferSignature      = "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW"
+	fixtureTokenTransferSignature = "2nBhEBYYvfaAe16UMNqRHre4YNSskvuYgx3M6E4JP1oDYvZEJHvoPzyUidNgNX5r9sTyN1J9UxtbCXy2rqYcuyuv"
+	fixtureStakeDelegateSignature = "4hXTCkRzt9WyecNzV1XPgCDfGAZzQKNxLXgynz5QDuWWPSAZBZSHptvWRL3BjCvzUXRdKvHLUQCjeR5Fs3Ngxpq8"
+)
+
+// solanaAccountFixture describes an account returned by getAccountInfo
+type solanaAccountFixture struct {
+	Owner      string
+	Lamports   uint64
+	Space      int
+	Executable bool
+	Program    string                 // Program name used in jsonParsed output (empty = not parseable)
+	Parsed     map[string]interface{} // Parsed account data for jsonParsed encoding
+}
+
+// solanaInstructionFixture describes a single instruction in a fixture transaction
+type solanaInstructionFixture struct {
+	ProgramIDIndex int
+	Accounts       []int
+	Data           string // Base58 encoded instruction data
+	Program        string
+	Parsed         map[string]interface{}
+}
+
+// solanaAccountKeyFixture describes an account key in a fixture transaction message
+type solanaAccountKeyFixture struct {
+	Pubkey   string
+	Signer   bool
+	Writable bool
+}
+
+// solanaTransactionFixture describes a transaction returned by getTransaction
+type solanaTransactionFixture struct {
+	AccountKeys  []solanaAccountKeyFixture
+	Instructions []solanaInstructionFixture
+	Fee          uint64
+	PreBalances  []uint64
+	PostBalances []uint64
+	LogMessages  []string
+	Slot         uint64 // Slot the transaction landed in, which also fixes its block time
+}
+
+// staticFixtureSlots pins each static fixture transaction to the slot it was first looked
+// up at, three slots behind the head then, so repeated lookups report the same slot
+var staticFixtureSlots sync.Map // signature -> uint64
+
+// staticFixtureSlot returns the pinned slot of a static fixture transaction, recording it on
+// first use
+func staticFixtureSlot(signature string) uint64 {
+	if slot, ok := staticFixtureSlots.Load(signature); ok {
+		return slot.(uint64)
+	}
+	currentSlot := atomic.LoadUint64(&solanaNode.SlotNumber)
+	slot := uint64(0)
+	if currentSlot > 3 {
+		slot = currentSlot - 3
+	}
+	pinned, _ := staticFixtureSlots.LoadOrStore(signature, slot)
+	return pinned.(uint64)
+}
+
+var solanaAccountFixtures = map[string]solanaAccountFixture{
+	fixtureWalletPubkey: {
+		Owner:    systemProgramID,
+		Lamports: 2_500_000_000,
+	},
+	fixtureMintPubkey: {
+		Owner:    tokenProgramID,
+		Lamports: 1_461_600,
+		Space:    82,
+		Program:  "spl-token",
+		Parsed: map[string]interface{}{
+			"type": "mint",
+			"info": map[string]interface{}{
+				"decimals":        6,
+				"freezeAuthority": fixtureWalletPubkey,
+				"isInitialized":   true,
+				"mintAuthority":   fixtureWalletPubkey,
+				"supply":          "5034943397418498",
+			},
+		},
+	},
+	fixtureTokenAccountPubkey: {
+		Owner:    tokenProgramID,
+		Lamports: 2_039_280,
+		Space:    165,
+		Program:  "spl-token",
+		Parsed: map[string]interface{}{
+			"type": "account",
+			"info": map[string]interface{}{
+				"isNative": false,
+				"mint":     fixtureMintPubkey,
+				"owner":    fixtureWalletPubkey,
+				"state":    "initialized",
+				"tokenAmount": map[string]interface{}{
+					"amount":         "1250000000",
+					"decimals":       6,
+					"uiAmount":       1250.0,
+					"uiAmountString": "1250",
+				},
+			},
+		},
+	},
+	fixtureStakePubkey: {
+		Owner:    stakeProgramID,
+		Lamports: 10_002_282_880,
+		Space:    200,
+		Program:  "stake",
+		Parsed: map[string]interface{}{
+			"type": "delegated",
+			"info": map[string]interface{}{
+				"meta": map[string]interface{}{
+					"authorized": map[string]interface{}{
+						"staker":     fixtureWalletPubkey,
+						"withdrawer": fixtureWalletPubkey,
+					},
+					"lockup": map[string]interface{}{
+						"custodian":     systemProgramID,
+						"epoch":         0,
+						"unixTimestamp": 0,
+					},
+					"rentExemptReserve": "2282880",
+				},
+				"stake": map[string]interface{}{
+					"creditsObserved": 123456,
+					"delegation": map[string]interface{}{
+						"activationEpoch":    "512",
+						"deactivationEpoch":  "18446744073709551615",
+						"stake":              "10000000000",
+						"voter":              fixtureVotePubkey,
+						"warmupCooldownRate": 0.25,
+					},
+				},
+			},
+		},
+	},
+}
+
+var solanaTransactionFixtures = map[string]solanaTransactionFixture{
+	fixtureTransferSignature: {
+		AccountKeys: []solanaAccountKeyFixture{
+			{Pubkey: fixtureWalletPubkey, Signer: true, Writable: true},
+			{Pubkey: fixtureRecipientPubkey, Signer: false, Writable: true},
+			{Pubkey: systemProgramID, Signer: false, Writable: false},
+		},
+		Instructions: []solanaInstructionFixture{
+			{
+				ProgramIDIndex: 2,
+				Accounts:       []int{0, 1},
+				Data:           "3Bxs4NN8M2Yn4TLb",
+				Program:        "system",
+				Parsed: map[string]interface{}{
+					"type": "transfer",
+					"info": map[string]interface{}{
+						"destination": fixtureRecipientPubkey,
+						"lamports":    100_000_000,
+						"source":      fixtureWalletPubkey,
+					},
+				},
+			},
+		},
+		Fee:          5000,
+		PreBalances:  []uint64{2_500_000_000, 0, 1},
+		PostBalances: []uint64{2_399_995_000, 100_000_000, 1},
+		LogMessages: []string{
+			"Program 11111111111111111111111111111111 invoke [1]",
+			"Program 11111111111111111111111111111111 success",
+		},
+	},
+	fixtureTokenTransferSignature: {
+		AccountKeys: []solanaAccountKeyFixture{
+			{Pubkey: fixtureWalletPubkey, Signer: true, Writable: true},
+			{Pubkey: fixtureTokenAccountPubkey, Signer: false, Writable: true},
+			{Pubkey: fixtureTokenDestPubkey, Signer: false, Writable: true},
+			{Pubkey: fixtureMintPubkey, Signer: false, Writable: false},
+			{Pubkey: tokenProgramID, Signer: false, Writable: false},
+		},
+		Instructions: []solanaInstructionFixture{
+			{
+				ProgramIDIndex: 4,
+				Accounts:       []int{1, 3, 2, 0},
+				Data:           "g7wVGgvJzVTpz",
+				Program:        "spl-token",
+				Parsed: map[string]interface{}{
+					"type": "transferChecked",
+					"info": map[string]interface{}{
+						"authority":   fixtureWalletPubkey,
+						"destination": fixtureTokenDestPubkey,
+						"mint":        fixtureMintPubkey,
+						"source":      fixtureTokenAccountPubkey,
+						"tokenAmount": map[string]interface{}{
+							"amount":         "25000000",
+							"decimals":       6,
+							"uiAmount":       25.0,
+							"uiAmountString": "25",
+						},
+					},
+				},
+			},
+		},
+		Fee:          5000,
+		PreBalances:  []uint64{2_399_995_000, 2_039_280, 2_039_280, 1_461_600, 934_087_680},
+		PostBalances: []uint64{2_399_990_000, 2_039_280, 2_039_280, 1_461_600, 934_087_680},
+		LogMessages: []string{
+			"Program TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA invoke [1]",
+			"Program log: Instruction: TransferChecked",
+			"Program TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA consumed 6200 of 200000 compute units",
+			"Program TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA success",
+		},
+	},
+	fixtureStakeDelegateSignature: {
+		AccountKeys: []solanaAccountKeyFixture{
+			{Pubkey: fixtureWalletPubkey, Signer: true, Writable: true},
+			{Pubkey: fixtureStakePubkey, Signer: false, Writable: true},
+			{Pubkey: fixtureVotePubkey, Signer: false, Writable: false},
+			{Pubkey: stakeProgramID, Signer: false, Writable: false},
+		},
+		Instructions: []solanaInstructionFixture{
+			{
+				ProgramIDIndex: 3,
+				Accounts:       []int{1, 2, 0},
+				Data:           "3xyZh",
+				Program:        "stake",
+				Parsed: map[string]interface{}{
+					"type": "delegate",
+					"info": map[string]interface{}{
+						"stakeAccount":   fixtureStakePubkey,
+						"stakeAuthority": fixtureWalletPubkey,
+						"voteAccount":    fixtureVotePubkey,
+					},
+				},
+			},
+		},
+		Fee:          5000,
+		PreBalances:  []uint64{2_399_990_000, 10_002_282_880, 27_074_400, 1},
+		PostBalances: []uint64{2_399_985_000, 10_002_282_880, 27_074_400, 1},
+		LogMessages: []string{
+			"Program Stake11111111111111111111111111111111111111 invoke [1]",
+			"Program Stake11111111111111111111111111111111111111 success",
+		},
+	},
+}
+
+// LargestAccount is an entry returned by getLargestAccounts
+type LargestAccount struct {
+	Address        string `json:"address" yaml:"address"`
+	Lamports       uint64 `json:"lamports" yaml:"lamports"`
+	NonCirculating bool   `json:"non_circulating,omitempty" yaml:"non_circulating,omitempty"`
+}
+
+// TokenLargestAccount is an entry returned by getTokenLargestAccounts
+type TokenLargestAccount struct {
+	Address  string `json:"address" yaml:"address"`
+	Amount   uint64 `json:"amount" yaml:"amount"`
+	Decimals int    `json:"decimals" yaml:"decimals"`
+}
+
+// defaultLargestAccountsLimit matches the 20 entry cap of real Solana nodes
+const defaultLargestAccountsLimit = 20
+
+var defaultLargestAccounts = []LargestAccount{
+	{Address: fixtureStakePubkey, Lamports: 10_002_282_880},
+	{Address: fixtureWalletPubkey, Lamports: 2_500_000_000},
+	{Address: fixtureRecipientPubkey, Lamports: 100_000_000},
+	{Address: "GK2zqSsXLA2rwVZk347RYhh6jJpRsCA69FjLW93ZGi3B", Lamports: 500_000_000_000_000, NonCirculating: true},
+}
+
+var defaultTokenLargestAccounts = map[string][]TokenLargestAccount{
+	fixtureMintPubkey: {
+		{Address: fixtureTokenAccountPubkey, Amount: 1_250_000_000, Decimals: 6},
+		{Address: fixtureTokenDestPubkey, Amount: 25_000_000, Decimals: 6},
+	},
+}
+
+// largestAccountsLimit returns the configured page size for the largest accounts methods
+func largestAccountsLimit() int {
+	if solanaNode.LargestAccountsLimit > 0 {
+		return solanaNode.LargestAccountsLimit
+	}
+	return defaultLargestAccountsLimit
+}
+
+// pageBounds returns the slice bounds of a page starting at offset
+func pageBounds(total, offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return offset, end
+}
+
+// renderLargestAccounts returns a page of getLargestAccounts entries sorted by balance
+func renderLargestAccounts(filter string, offset int) []map[string]interface{} {
+	accounts := solanaNode.LargestAccounts
+	if len(accounts) == 0 {
+		accounts = defaultLargestAccounts
+	}
+
+	filtered := make([]LargestAccount, 0, len(accounts))
+	for _, account := range accounts {
+		switch filter {
+		case "circulating":
+			if account.NonCirculating {
+				continue
+			}
+		case "nonCirculating":
+			if !account.NonCirculating {
+				continue
+			}
+		}
+		filtered = append(filtered, account)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Lamports > filtered[j].Lamports
+	})
+
+	start, end := pageBounds(len(filtered), offset, largestAccountsLimit())
+	page := make([]map[string]interface{}, 0, end-start)
+	for _, account := range filtered[start:end] {
+		page = append(page, map[string]interface{}{
+			"address":  account.Address,
+			"lamports": account.Lamports,
+		})
+	}
+	return page
+}
+
+// renderTokenLargestAccounts returns a page of getTokenLargestAccounts entries sorted by amount
+func renderTokenLargestAccounts(mint string, offset int) ([]map[string]interface{}, bool) {
+	holders := defaultTokenLargestAccounts
+	if len(solanaNode.TokenLargestAccounts) > 0 {
+		holders = solanaNode.TokenLargestAccounts
+	}
+	accounts, ok := holders[mint]
+	if !ok {
+		return nil, false
+	}
+
+	sorted := make([]TokenLargestAccount, len(accounts))
+	copy(sorted, accounts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Amount > sorted[j].Amount
+	})
+
+	start, end := pageBounds(len(sorted), offset, largestAccountsLimit())
+	page := make([]map[string]interface{}, 0, end-start)
+	for _, account := range sorted[start:end] {
+		uiAmount := float64(account.Amount) / math.Pow10(account.Decimals)
+		page = append(page, map[string]interface{}{
+			"address":        account.Address,
+			"amount":         strconv.FormatUint(account.Amount, 10),
+			"decimals":       account.Decimals,
+			"uiAmount":       uiAmount,
+			"uiAmountString": strconv.FormatFloat(uiAmount, 'f', -1, 64),
+		})
+	}
+	return page, true
+}
+
+// fixtureBytes returns deterministic pseudo-random bytes for a fixture key
+func fixtureBytes(key string, length int) []byte {
+	data := make([]byte, 0, length)
+	for counter := 0; len(data) < length; counter++ {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("%s-%d", key, counter)))
+		data = append(data, hash[:]...)
+	}
+	return data[:length]
+}
+
+// renderAccountInfo renders a fixture account in the requested encoding
+func renderAccountInfo(pubkey string, account solanaAccountFixture, encoding string) map[string]interface{} {
+	var data interface{}
+	raw := base64.StdEncoding.EncodeToString(fixtureBytes(pubkey, account.Space))
+	if encoding == "jsonParsed" && account.Program != "" {
+		data = map[string]interface{}{
+			"program": account.Program,
+			"parsed":  account.Parsed,
+			"space":   account.Space,
+		}
+	} else {
+		// Accounts that can't be parsed fall back to base64, as real nodes do
+		data = []string{raw, "base64"}
+	}
+
+	return map[string]interface{}{
+		"data":       data,
+		"executable": account.Executable,
+		"lamports":   account.Lamports,
+		"owner":      account.Owner,
+		"rentEpoch":  uint64(18446744073709551615),
+		"space":      account.Space,
+	}
+}
+
+// renderTransaction renders a fixture transaction in the requested encoding
+func renderTransaction(signature string, tx solanaTransactionFixture, encoding string, includeVersion bool) map[string]interface{} {
+	slot := tx.Slot
+
+	var transaction interface{}
+	switch encoding {
+	case "base64":
+		transaction = []string{base64.StdEncoding.EncodeToString(fixtureBytes(signature, 215)), "base64"}
+	case "jsonParsed":
+		accountKeys := make([]map[string]interface{}, len(tx.AccountKeys))
+		for i, key := range tx.AccountKeys {
+			accountKeys[i] = map[string]interface{}{
+				"pubkey":   key.Pubkey,
+				"signer":   key.Signer,
+				"writable": key.Writable,
+				"source":   "transaction",
+			}
+		}
+		instructions := make([]map[string]interface{}, len(tx.Instructions))
+		for i, ix := range tx.Instructions {
+			if ix.Parsed == nil {
+				// Instructions of programs the node can't parse keep their raw accounts and data
+				accounts := make([]string, len(ix.Accounts))
+				for j, index := range ix.Accounts {
+					accounts[j] = tx.AccountKeys[index].Pubkey
+				}
+				instructions[i] = map[string]interface{}{
+					"programId":   tx.AccountKeys[ix.ProgramIDIndex].Pubkey,
+					"accounts":    accounts,
+					"data":        ix.Data,
+					"stackHeight": nil,
+				}
+				continue
+			}
+			instructions[i] = map[string]interface{}{
+				"program":     ix.Program,
+				"programId":   tx.AccountKeys[ix.ProgramIDIndex].Pubkey,
+				"parsed":      ix.Parsed,
+				"stackHeight": nil,
+			}
+		}
+		transaction = map[string]interface{}{
+			"signatures": []string{signature},
+			"message": map[string]interface{}{
+				"accountKeys":     accountKeys,
+				"instructions":    instructions,
+				"recentBlockhash": generateSolanaBlockhash(slot),
+			},
+		}
+	default:
+		accountKeys := make([]string, len(tx.AccountKeys))
+		numSigners, numReadonlyUnsigned := 0, 0
+		for i, key := range tx.AccountKeys {
+			accountKeys[i] = key.Pubkey
+			if key.Signer {
+				numSigners++
+			} else if !key.Writable {
+				numReadonlyUnsigned++
+			}
+		}
+		instructions := make([]map[string]interface{}, len(tx.Instructions))
+		for i, ix := range tx.Instructions {
+			instructions[i] = map[string]interface{}{
+				"programIdIndex": ix.ProgramIDIndex,
+				"accounts":       ix.Accounts,
+				"data":           ix.Data,
+				"stackHeight":    nil,
+			}
+		}
+		transaction = map[string]interface{}{
+			"signatures": []string{signature},
+			"message": map[string]interface{}{
+				"header": map[string]interface{}{
+					"numRequiredSignatures":       numSigners,
+					"numReadonlySignedAccounts":   0,
+					"numReadonlyUnsignedAccounts": numReadonlyUnsigned,
+				},
+				"accountKeys":     accountKeys,
+				"instructions":    instructions,
+				"recentBlockhash": generateSolanaBlockhash(slot),
+			},
+		}
+	}
+
+	result := map[string]interface{}{
+		"slot":      slot,
+		"blockTime": solanaBlockTime(slot),
+		"meta": map[string]interface{}{
+			"err":                  nil,
+			"status":               map[string]interface{}{"Ok": nil},
+			"fee":                  tx.Fee,
+			"preBalances":          tx.PreBalances,
+			"postBalances":         tx.PostBalances,
+			"innerInstructions":    []interface{}{},
+			"logMessages":          tx.LogMessages,
+			"preTokenBalances":     []interface{}{},
+			"postTokenBalances":    []interface{}{},
+			"rewards":              []interface{}{},
+			"computeUnitsConsumed": 150 * len(tx.LogMessages),
+		},
+		"transaction": transaction,
+	}
+	if includeVersion {
+		result["version"] = "legacy"
+	}
+	return result
+}
+
+// generateSolanaBlockhash creates a deterministic base58-looking blockhash for a slot
+func generateSolanaBlockhash(slot uint64) string {
+	return fixtureBase58(hashInput(fmt.Sprintf("blockhash-%d", slot)), 44)
+}
+
+// fixtureBase58 returns a deterministic base58-looking string for a fixture key
+func fixtureBase58(key string, length int) string {
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	hash := fixtureBytes(key, length)
+	out := make([]byte, len(hash))
+	for i, b := range hash {
+		out[i] = alphabet[int(b)%len(alphabet)]
+	}
+	return string(out)
+}
@@ -1,24 +1,58 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"strconv"
 	"sync/atomic"
 	"time"
 )
 
-func handleSolanaRequest(message []byte, conn WSConn) ([]byte, error) {
-	// Simulate network latency if configured
-	if solanaNode.Latency > 0 {
-		time.Sleep(solanaNode.Latency)
-	}
+func handleSolanaRequest(ctx context.Context, message []byte, conn WSConn) ([]byte, error) {
+	// Fault changes may reach this request's transport late (see FaultPropagation)
+	faults := effectiveFaults("501", conn)
 
 	var request JSONRPCRequest
-	if err := json.Unmarshal(message, &request); err != nil {
-		log.Printf("Error unmarshalling message: %s", err)
-		log.Printf("Message: %s", string(message))
-		return createErrorResponse(-32700, "Parse error", nil, nil)
+	rpcErr := parseRequest(message, connStrictness(conn, solanaNode.ProtocolStrictness), &request)
+	override := methodOverride(faults.MethodOverrides, request.Method)
+
+	// Isolated health checks bypass the node's latency and faults, and so do identity methods
+	// on the fast path; method overrides still apply to both
+	healthCheck := faults.HealthCheck.covers(request.Method)
+	fastPath := !healthCheck && faults.IdentityFastPath && isIdentityMethod("501", request.Method)
+
+	// Requests arriving during slot import are slower and may hit import-only errors
+	importing := !healthCheck && !fastPath && solanaNode.BlockPhase.importing("501")
+
+	// Simulate network latency if configured, before and/or after handling
+	latency := methodLatency(faults.Latency, faults.LatencyDistribution, override)
+	if healthCheck {
+		latency = faults.HealthCheck.latency(override)
+	} else if fastPath {
+		latency = methodLatency(0, nil, override)
+	}
+	preLatency, postLatency := splitLatency(latency, faults.LatencyPlacement)
+	if importing {
+		preLatency += solanaNode.BlockPhase.Latency
+	}
+	load := chainLoads.For("501")
+	if !fastPath {
+		preLatency += regionLatency(ctx, "solana")
+		preLatency += load.requestLatency(preLatency + postLatency)
+	}
+	if preLatency > 0 {
+		if err := sleepContext(ctx, preLatency); err != nil {
+			return nil, err
+		}
+	}
+	if postLatency > 0 {
+		defer sleepContext(ctx, postLatency)
+	}
+
+	if rpcErr != nil {
+		return createErrorResponse(rpcErr.Code, rpcErr.Message, rpcErr.Data, request.ID)
 	}
 
 	// Only log non-health check messages
@@ -26,9 +60,38 @@ func handleSolanaRequest(message []byte, conn WSConn) ([]byte, error) {
 		log.Printf("Incoming Solana message: %s", string(message))
 	}
 
-	// Validate JSON-RPC version
-	if request.JsonRPC != "2.0" {
-		return createErrorResponse(-32600, "Invalid Request", nil, request.ID)
+	// Apply id mangling fault if configured
+	request.ID = mangleID(faults.IDMangleMode, request.ID)
+
+	// Method override errors take precedence over block phase errors
+	var errorConfig *ErrorConfig
+	if override != nil {
+		errorConfig = ShouldSimulateError(override.errors, request.Method)
+	}
+	if errorConfig == nil && importing {
+		errorConfig = ShouldSimulateError(solanaNode.BlockPhase.ErrorConfigs, request.Method)
+	}
+	if errorConfig == nil && healthCheck {
+		errorConfig = ShouldSimulateError(faults.HealthCheck.ErrorConfigs, request.Method)
+	}
+	if errorConfig != nil {
+		load.injectedError()
+		if errorConfig.DelayMs > 0 {
+			if err := sleepContext(ctx, time.Duration(errorConfig.DelayMs)*time.Millisecond); err != nil {
+				return nil, err
+			}
+		}
+		message, data := errorConfig.render(request.Method)
+		return createErrorResponse(errorConfig.Code, message, data, request.ID)
+	}
+	if override != nil && override.CustomResponse != "" {
+		log.Printf("Returning method override response for Solana method %s", request.Method)
+		return []byte(override.CustomResponse), nil
+	}
+
+	// Custom methods implemented by WASM modules
+	if method := lookupWasmMethod("501", request.Method); method != nil {
+		return method.respond(ctx, message, request.ID)
 	}
 
 	var result interface{}
@@ -77,6 +140,118 @@ func handleSolanaRequest(message []byte, conn WSConn) ([]byte, error) {
 		}
 	case "getHealth":
 		result = "ok"
+	case "getAccountInfo":
+		pubkey, ok := solanaStringParam(request.Params, 0)
+		if !ok {
+			return createErrorResponse(-32602, "Invalid params: pubkey must be a string", nil, request.ID)
+		}
+		encoding := solanaEncodingParam(request.Params, 1, "base64")
+		if encoding != "base64" && encoding != "jsonParsed" {
+			return createErrorResponse(-32602, fmt.Sprintf("Invalid params: unsupported encoding %s", encoding), nil, request.ID)
+		}
+
+		var value interface{}
+		if account, ok := solanaAccountFixtures[pubkey]; ok {
+			value = renderAccountInfo(pubkey, account, encoding)
+		}
+		result = map[string]interface{}{
+			"context": solanaContext(),
+			"value":   value,
+		}
+	case "getTransaction":
+		signature, ok := solanaStringParam(request.Params, 0)
+		if !ok {
+			return createErrorResponse(-32602, "Invalid params: signature must be a string", nil, request.ID)
+		}
+		encoding := solanaEncodingParam(request.Params, 1, "json")
+		if encoding != "json" && encoding != "base64" && encoding != "jsonParsed" {
+			return createErrorResponse(-32602, fmt.Sprintf("Invalid params: unsupported encoding %s", encoding), nil, request.ID)
+		}
+
+		includeVersion := false
+		if config, ok := solanaConfigParam(request.Params, 1); ok {
+			_, includeVersion = config["maxSupportedTransactionVersion"]
+		}
+
+		if tx, ok := lookupSolanaTransaction(signature); ok {
+			result = renderTransaction(signature, tx, encoding, includeVersion)
+		} else {
+			result = nil // Unknown transactions return null
+		}
+	case "getLargestAccounts":
+		filter := ""
+		offset := 0
+		if config, ok := solanaConfigParam(request.Params, 0); ok {
+			filter, _ = config["filter"].(string)
+			if v, ok := config["offset"].(float64); ok {
+				offset = int(v)
+			}
+		}
+		if filter != "" && filter != "circulating" && filter != "nonCirculating" {
+			return createErrorResponse(-32602, fmt.Sprintf("Invalid params: unknown filter %s", filter), nil, request.ID)
+		}
+		result = map[string]interface{}{
+			"context": solanaContext(),
+			"value":   renderLargestAccounts(filter, offset),
+		}
+	case "getTokenLargestAccounts":
+		mint, ok := solanaStringParam(request.Params, 0)
+		if !ok {
+			return createErrorResponse(-32602, "Invalid params: mint must be a string", nil, request.ID)
+		}
+		offset := 0
+		if config, ok := solanaConfigParam(request.Params, 1); ok {
+			if v, ok := config["offset"].(float64); ok {
+				offset = int(v)
+			}
+		}
+		accounts, ok := renderTokenLargestAccounts(mint, offset)
+		if !ok {
+			return createErrorResponse(-32602, "Invalid param: could not find mint", nil, request.ID)
+		}
+		result = map[string]interface{}{
+			"context": solanaContext(),
+			"value":   accounts,
+		}
+	case "sendTransaction", "simulateTransaction":
+		encodedTx, ok := solanaStringParam(request.Params, 0)
+		if !ok {
+			return createErrorResponse(-32602, "Invalid params: transaction must be a string", nil, request.ID)
+		}
+
+		txError := ShouldSimulateTransactionError(solanaNode.TransactionErrors, request.Method)
+		if request.Method == "simulateTransaction" {
+			// Simulation failures are reported in the result rather than as an RPC error
+			value := map[string]interface{}{
+				"err":           nil,
+				"logs":          []string{"Program " + systemProgramID + " invoke [1]", "Program " + systemProgramID + " success"},
+				"accounts":      nil,
+				"unitsConsumed": 150,
+				"returnData":    nil,
+			}
+			if txError != nil {
+				value["err"] = txError.TransactionError()
+				value["logs"] = txError.Logs()
+				value["unitsConsumed"] = 0
+			}
+			result = map[string]interface{}{
+				"context": solanaContext(),
+				"value":   value,
+			}
+			break
+		}
+
+		if txError != nil {
+			log.Printf("Injecting %s transaction error for sendTransaction", txError.Type)
+			return createErrorResponse(-32002, "Transaction simulation failed: "+txError.Message(), map[string]interface{}{
+				"err":           txError.TransactionError(),
+				"logs":          txError.Logs(),
+				"accounts":      nil,
+				"unitsConsumed": 0,
+				"returnData":    nil,
+			}, request.ID)
+		}
+		result = fixtureBase58(hashInput("signature-"+encodedTx), 88)
 	case "slotSubscribe":
 		subID, err := subManager.Subscribe("501", conn, "slotNotification")
 		if err != nil {
@@ -93,7 +268,20 @@ func handleSolanaRequest(message []byte, conn WSConn) ([]byte, error) {
 		log.Printf("New Solana root subscription created: ID=%d", subID)
 		result = subID // Solana uses numeric IDs
 
-	case "slotUnsubscribe", "rootUnsubscribe":
+	case "logsSubscribe":
+		mentions, filterErr := solanaLogsFilter(request.Params)
+		if filterErr != nil {
+			return createErrorResponse(-32602, filterErr.Error(), nil, request.ID)
+		}
+		subID, err := subManager.Subscribe("501", conn, "logsNotification")
+		if err != nil {
+			return createErrorResponse(-32603, err.Error(), nil, request.ID)
+		}
+		subManager.SetMentions(subID, mentions)
+		log.Printf("New Solana logs subscription created: ID=%d", subID)
+		result = subID // Solana uses numeric IDs
+
+	case "slotUnsubscribe", "rootUnsubscribe", "logsUnsubscribe":
 		if len(request.Params) < 1 {
 			return createErrorResponse(-32602, "Invalid params", nil, request.ID)
 		}
@@ -126,6 +314,15 @@ func handleSolanaRequest(message []byte, conn WSConn) ([]byte, error) {
 		return createErrorResponse(-32603, err.Error(), nil, request.ID)
 	}
 
+	if result == nil {
+		// JSONRPCResponse omits a nil result, so null results are encoded explicitly
+		return json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"result":  nil,
+			"id":      request.ID,
+		})
+	}
+
 	response := JSONRPCResponse{
 		JsonRPC: "2.0",
 		Result:  result,
@@ -134,3 +331,39 @@ func handleSolanaRequest(message []byte, conn WSConn) ([]byte, error) {
 
 	return json.Marshal(response)
 }
+
+// solanaContext returns the context object included in Solana RpcResponse results
+func solanaContext() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": solanaNode.Version,
+		"slot":       atomic.LoadUint64(&solanaNode.SlotNumber),
+	}
+}
+
+// solanaStringParam returns the string parameter at the given index
+func solanaStringParam(params []interface{}, index int) (string, bool) {
+	if len(params) <= index {
+		return "", false
+	}
+	value, ok := params[index].(string)
+	return value, ok
+}
+
+// solanaConfigParam returns the configuration object at the given index
+func solanaConfigParam(params []interface{}, index int) (map[string]interface{}, bool) {
+	if len(params) <= index {
+		return nil, false
+	}
+	config, ok := params[index].(map[string]interface{})
+	return config, ok
+}
+
+// solanaEncodingParam returns the encoding from the configuration object at the given index
+func solanaEncodingParam(params []interface{}, index int, defaultEncoding string) string {
+	if config, ok := solanaConfigParam(params, index); ok {
+		if encoding, ok := config["encoding"].(string); ok {
+			return encoding
+		}
+	}
+	return defaultEncoding
+}
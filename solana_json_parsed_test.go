@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+)
+
+// solanaCall sends a request to the Solana handler and returns the decoded result
+func solanaCall(t *testing.T, method string, params ...interface{}) interface{} {
+	t.Helper()
+	request := JSONRPCRequest{
+		JsonRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      1,
+	}
+	data, _ := json.Marshal(request)
+	response, err := handleSolanaRequest(context.Background(), data, NewMockWSConn())
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+
+	var resp JSONRPCResponse
+	if err := json.Unmarshal(response, &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+	return resp.Result
+}
+
+func TestSolanaGetAccountInfoJsonParsed(t *testing.T) {
+	tests := []struct {
+		pubkey  string
+		program string
+		typ     string
+	}{
+		{fixtureTokenAccountPubkey, "spl-token", "account"},
+		{fixtureMintPubkey, "spl-token", "mint"},
+		{fixtureStakePubkey, "stake", "delegated"},
+	}
+
+	for _, tt := range tests {
+		result := solanaCall(t, "getAccountInfo", tt.pubkey, map[string]interface{}{"encoding": "jsonParsed"})
+		value := result.(map[string]interface{})["value"].(map[string]interface{})
+		data, ok := value["data"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected parsed data object for %s, got %v", tt.pubkey, value["data"])
+		}
+		if data["program"] != tt.program {
+			t.Errorf("Expected program %s, got %v", tt.program, data["program"])
+		}
+		parsed := data["parsed"].(map[string]interface{})
+		if parsed["type"] != tt.typ {
+			t.Errorf("Expected type %s, got %v", tt.typ, parsed["type"])
+		}
+
+		// Default encoding returns base64 data
+		result = solanaCall(t, "getAccountInfo", tt.pubkey)
+		value = result.(map[string]interface{})["value"].(map[string]interface{})
+		raw, ok := value["data"].([]interface{})
+		if !ok || len(raw) != 2 || raw[1] != "base64" {
+			t.Errorf("Expected base64 data for %s, got %v", tt.pubkey, value["data"])
+		}
+	}
+
+	// System accounts without data can't be parsed and fall back to base64
+	result := solanaCall(t, "getAccountInfo", fixtureWalletPubkey, map[string]interface{}{"encoding": "jsonParsed"})
+	value := result.(map[string]interface{})["value"].(map[string]interface{})
+	if _, ok := value["data"].([]interface{}); !ok {
+		t.Errorf("Expected base64 fallback for system account, got %v", value["data"])
+	}
+
+	// Unknown accounts return a null value
+	result = solanaCall(t, "getAccountInfo", "UnknownAccount1111111111111111111111111111")
+	if result.(map[string]interface{})["value"] != nil {
+		t.Errorf("Expected null value for unknown account")
+	}
+}
+
+func TestSolanaGetTransactionJsonParsed(t *testing.T) {
+	tests := []struct {
+		signature string
+		program   string
+		typ       string
+	}{
+		{fixtureTransferSignature, "system", "transfer"},
+		{fixtureTokenTransferSignature, "spl-token", "transferChecked"},
+		{fixtureStakeDelegateSignature, "stake", "delegate"},
+	}
+
+	for _, tt := range tests {
+		result := solanaCall(t, "getTransaction", tt.signature, map[string]interface{}{
+			"encoding":                       "jsonParsed",
+			"maxSupportedTransactionVersion": 0,
+		})
+		tx := result.(map[string]interface{})
+		if tx["version"] != "legacy" {
+			t.Errorf("Expected version legacy, got %v", tx["version"])
+		}
+
+		message := tx["transaction"].(map[string]interface{})["message"].(map[string]interface{})
+		accountKeys := message["accountKeys"].([]interface{})
+		if _, ok := accountKeys[0].(map[string]interface{})["pubkey"]; !ok {
+			t.Errorf("Expected parsed account keys, got %v", accountKeys[0])
+		}
+
+		instruction := message["instructions"].([]interface{})[0].(map[string]interface{})
+		if instruction["program"] != tt.program {
+			t.Errorf("Expected program %s, got %v", tt.program, instruction["program"])
+		}
+		if instruction["parsed"].(map[string]interface{})["type"] != tt.typ {
+			t.Errorf("Expected instruction type %s, got %v", tt.typ, instruction["parsed"])
+		}
+
+		// Default json encoding returns compiled instructions
+		result = solanaCall(t, "getTransaction", tt.signature)
+		message = result.(map[string]interface{})["transaction"].(map[string]interface{})["message"].(map[string]interface{})
+		instruction = message["instructions"].([]interface{})[0].(map[string]interface{})
+		if _, ok := instruction["programIdIndex"]; !ok {
+			t.Errorf("Expected programIdIndex in json encoding, got %v", instruction)
+		}
+	}
+
+	// The block time is derived from the slot, not the wall clock
+	tx := solanaCall(t, "getTransaction", fixtureTransferSignature).(map[string]interface{})
+	if slot := uint64(tx["slot"].(float64)); tx["blockTime"] != float64(solanaBlockTime(slot)) {
+		t.Errorf("Expected the block time of slot %d, got %v", slot, tx["blockTime"])
+	}
+
+	// The fixture stays in the slot it was first seen at while slots advance
+	atomic.AddUint64(&solanaNode.SlotNumber, 10)
+	again := solanaCall(t, "getTransaction", fixtureTransferSignature).(map[string]interface{})
+	if again["slot"] != tx["slot"] || again["blockTime"] != tx["blockTime"] {
+		t.Errorf("Expected slot %v and block time %v again, got %v and %v", tx["slot"], tx["blockTime"], again["slot"], again["blockTime"])
+	}
+	if solanaBlockTime(1000) != solanaGenesisUnix+400 {
+		t.Errorf("Expected 400ms slots, got %d", solanaBlockTime(1000)-solanaGenesisUnix)
+	}
+
+	if result := solanaCall(t, "getTransaction", "unknown"); result != nil {
+		t.Errorf("Expected null result for unknown transaction, got %v", result)
+	}
+}
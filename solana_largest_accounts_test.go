@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSolanaGetLargestAccounts(t *testing.T) {
+	originalAccounts := solanaNode.LargestAccounts
+	originalLimit := solanaNode.LargestAccountsLimit
+	defer func() {
+		solanaNode.LargestAccounts = originalAccounts
+		solanaNode.LargestAccountsLimit = originalLimit
+	}()
+
+	solanaNode.LargestAccounts = []LargestAccount{
+		{Address: "A", Lamports: 10},
+		{Address: "B", Lamports: 30},
+		{Address: "C", Lamports: 20, NonCirculating: true},
+		{Address: "D", Lamports: 40},
+	}
+	solanaNode.LargestAccountsLimit = 2
+
+	addresses := func(result interface{}) []string {
+		value := result.(map[string]interface{})["value"].([]interface{})
+		out := make([]string, len(value))
+		for i, entry := range value {
+			out[i] = entry.(map[string]interface{})["address"].(string)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		expected []string
+	}{
+		{"first page sorted by balance", nil, []string{"D", "B"}},
+		{"second page", map[string]interface{}{"offset": 2}, []string{"C", "A"}},
+		{"offset past end", map[string]interface{}{"offset": 10}, []string{}},
+		{"circulating filter", map[string]interface{}{"filter": "circulating"}, []string{"D", "B"}},
+		{"non-circulating filter", map[string]interface{}{"filter": "nonCirculating"}, []string{"C"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result interface{}
+			if tt.config == nil {
+				result = solanaCall(t, "getLargestAccounts")
+			} else {
+				result = solanaCall(t, "getLargestAccounts", tt.config)
+			}
+			got := addresses(result)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSolanaGetTokenLargestAccounts(t *testing.T) {
+	result := solanaCall(t, "getTokenLargestAccounts", fixtureMintPubkey)
+	value := result.(map[string]interface{})["value"].([]interface{})
+	if len(value) != 2 {
+		t.Fatalf("Expected 2 token accounts, got %d", len(value))
+	}
+
+	first := value[0].(map[string]interface{})
+	if first["address"] != fixtureTokenAccountPubkey {
+		t.Errorf("Expected largest holder %s, got %v", fixtureTokenAccountPubkey, first["address"])
+	}
+	if first["amount"] != "1250000000" {
+		t.Errorf("Expected amount 1250000000, got %v", first["amount"])
+	}
+	if first["uiAmountString"] != "1250" {
+		t.Errorf("Expected uiAmountString 1250, got %v", first["uiAmountString"])
+	}
+
+	// Unknown mints are rejected
+	request := []byte(`{"jsonrpc":"2.0","method":"getTokenLargestAccounts","params":["unknown"],"id":1}`)
+	response, err := handleSolanaRequest(context.Background(), request, NewMockWSConn())
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	if got := string(response); !strings.Contains(got, "could not find mint") {
+		t.Errorf("Expected mint not found error, got %s", got)
+	}
+}
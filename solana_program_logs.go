@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SolanaProgramLog is a log-line template for a registered program, typically generated from an Anchor IDL with cmd/genfixtures
+type SolanaProgramLog struct {
+	ProgramID   string   `yaml:"program_id" json:"program_id"`
+	Instruction string   `yaml:"instruction,omitempty" json:"instruction,omitempty"` // Logged as "Program log: Instruction: <name>"
+	Logs        []string `yaml:"logs,omitempty" json:"logs,omitempty"`               // Explicit log lines; {program} and {instruction} are substituted
+}
+
+// programLogTransactions remembers recently generated program log transactions for getTransaction
+var programLogTransactions = struct {
+	sync.Mutex
+	bySignature map[string]solanaTransactionFixture
+	order       []string
+}{bySignature: make(map[string]solanaTransactionFixture)}
+
+// loadSolanaProgramLogs reads a YAML or JSON list of program log templates
+func loadSolanaProgramLogs(filename string) ([]SolanaProgramLog, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read program logs: %v", err)
+	}
+
+	var templates []SolanaProgramLog
+	if err := yaml.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse program logs: %v", err)
+	}
+	for i, template := range templates {
+		if err := validateSolanaProgramLog(template); err != nil {
+			return nil, fmt.Errorf("program log %d: %v", i, err)
+		}
+	}
+	return templates, nil
+}
+
+// validateSolanaProgramLog checks that a template has a base58 program id and something to log
+func validateSolanaProgramLog(template SolanaProgramLog) error {
+	if !isBase58Pubkey(template.ProgramID) {
+		return fmt.Errorf("program_id must be a base58 public key")
+	}
+	if template.Instruction == "" && len(template.Logs) == 0 {
+		return fmt.Errorf("instruction or logs is required")
+	}
+	return nil
+}
+
+// isBase58Pubkey returns true if s looks like a base58 encoded 32 byte public key
+func isBase58Pubkey(s string) bool {
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	if len(s) < 32 || len(s) > 44 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(alphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// render returns the log messages of a template; seed makes compute unit figures deterministic
+func (t SolanaProgramLog) render(seed string) []string {
+	if len(t.Logs) > 0 {
+		logs := make([]string, len(t.Logs))
+		replacer := strings.NewReplacer("{program}", t.ProgramID, "{instruction}", t.Instruction)
+		for i, line := range t.Logs {
+			logs[i] = replacer.Replace(line)
+		}
+		return logs
+	}
+
+	b := fixtureBytes(seed, 2)
+	consumed := 2000 + (int(b[0])<<8|int(b[1]))%40000
+	return []string{
+		fmt.Sprintf("Program %s invoke [1]", t.ProgramID),
+		fmt.Sprintf("Program log: Instruction: %s", t.Instruction),
+		fmt.Sprintf("Program %s consumed %d of 200000 compute units", t.ProgramID, consumed),
+		fmt.Sprintf("Program %s success", t.ProgramID),
+	}
+}
+
+// programLogs returns the node's runtime templates followed by those loaded from ProgramLogsFile
+func (n *SolanaNode) programLogs() []SolanaProgramLog {
+	if len(n.fileProgramLogs) == 0 {
+		return n.ProgramLogs
+	}
+	return append(append([]SolanaProgramLog{}, n.ProgramLogs...), n.fileProgramLogs...)
+}
+
+// programLogTransaction builds the transaction generated for a slot from a template
+func programLogTransaction(slot uint64, template SolanaProgramLog) (string, solanaTransactionFixture) {
+	signature := fixtureBase58(hashInput(fmt.Sprintf("program-log-%d-%s", slot, template.ProgramID)), 88)
+	tx := solanaTransactionFixture{
+		AccountKeys: []solanaAccountKeyFixture{
+			{Pubkey: fixtureWalletPubkey, Signer: true, Writable: true},
+			{Pubkey: template.ProgramID, Signer: false, Writable: false},
+		},
+		Instructions: []solanaInstructionFixture{
+			{
+				ProgramIDIndex: 1,
+				Accounts:       []int{0},
+				Data:           fixtureBase58("program-log-data-"+signature, 12),
+			},
+		},
+		Fee:          5000,
+		PreBalances:  []uint64{2_500_000_000, 1_141_440},
+		PostBalances: []uint64{2_499_995_000, 1_141_440},
+		LogMessages:  template.render(signature),
+		Slot:         slot,
+	}
+	return signature, tx
+}
+
+// rememberProgramLogTransaction makes a generated transaction available to getTransaction
+func rememberProgramLogTransaction(signature string, tx solanaTransactionFixture) {
+	programLogTransactions.Lock()
+	defer programLogTransactions.Unlock()
+
+	if _, ok := programLogTransactions.bySignature[signature]; ok {
+		return
+	}
+	programLogTransactions.bySignature[signature] = tx
+	programLogTransactions.order = append(programLogTransactions.order, signature)
+	recordEvictions(RetentionStoreProgramLogTransactions, trimProgramLogTransactions(retentionLimit(RetentionStoreProgramLogTransactions)))
+}
+
+// trimProgramLogTransactions evicts the oldest transactions beyond limit
+// Must be called with programLogTransactions locked
+func trimProgramLogTransactions(limit int) int {
+	evicted := len(programLogTransactions.order) - limit
+	if evicted <= 0 {
+		return 0
+	}
+	for _, signature := range programLogTransactions.order[:evicted] {
+		delete(programLogTransactions.bySignature, signature)
+	}
+	// Copy so the evicted prefix of the backing array can be freed
+	programLogTransactions.order = append([]string(nil), programLogTransactions.order[evicted:]...)
+	return evicted
+}
+
+// compactProgramLogTransactions trims the generated transactions to limit
+func compactProgramLogTransactions(limit int) int {
+	programLogTransactions.Lock()
+	defer programLogTransactions.Unlock()
+	return trimProgramLogTransactions(limit)
+}
+
+// programLogTransactionCount returns the number of generated transactions available to getTransaction
+func programLogTransactionCount() int {
+	programLogTransactions.Lock()
+	defer programLogTransactions.Unlock()
+	return len(programLogTransactions.order)
+}
+
+// lookupSolanaTransaction returns a static fixture or a recently generated program log transaction
+func lookupSolanaTransaction(signature string) (solanaTransactionFixture, bool) {
+	if tx, ok := solanaTransactionFixtures[signature]; ok {
+		tx.Slot = staticFixtureSlot(signature)
+		return tx, true
+	}
+	programLogTransactions.Lock()
+	defer programLogTransactions.Unlock()
+	tx, ok := programLogTransactions.bySignature[signature]
+	return tx, ok
+}
+
+// mentionsProgramLog returns true if a logsSubscribe filter matches a generated transaction
+func (s *Subscription) mentionsProgramLog(tx solanaTransactionFixture) bool {
+	if s.mentions == "" {
+		return true
+	}
+	for _, key := range tx.AccountKeys {
+		if key.Pubkey == s.mentions {
+			return true
+		}
+	}
+	return false
+}
+
+// BroadcastSolanaLogs generates the program log transaction of a slot and notifies logsSubscribe subscribers
+func (sm *SubscriptionManager) BroadcastSolanaLogs(slot uint64) {
+	templates := solanaNode.programLogs()
+	if len(templates) == 0 {
+		return
+	}
+	signature, tx := programLogTransaction(slot, templates[slot%uint64(len(templates))])
+	rememberProgramLogTransaction(signature, tx)
+
+	sm.enqueue("501", notificationLatency("501"), func() {
+		sm.broadcastSolanaLogs(slot, signature, tx)
+	})
+}
+
+func (sm *SubscriptionManager) broadcastSolanaLogs(slot uint64, signature string, tx solanaTransactionFixture) {
+	now := time.Now()
+	shard := sm.shard("501")
+	shard.mu.RLock()
+	subs := make([]*Subscription, 0)
+	for _, sub := range shard.subscriptions {
+		if sub.Method == "logsNotification" && sub.attached(now) && sub.mentionsProgramLog(tx) {
+			subs = append(subs, sub)
+		}
+	}
+	shard.mu.RUnlock()
+
+	for _, sub := range subs {
+		notification := JSONRPCNotification{
+			JsonRPC: "2.0",
+			Method:  "logsNotification",
+			Params: SubscriptionParams{
+				Subscription: sub.ID,
+				Result: map[string]interface{}{
+					"context": map[string]interface{}{"slot": slot},
+					"value": map[string]interface{}{
+						"signature": signature,
+						"err":       nil,
+						"logs":      tx.LogMessages,
+					},
+				},
+			},
+		}
+
+		data, err := json.Marshal(notification)
+		if err != nil {
+			continue
+		}
+		if err := sub.deliver(data); err != nil {
+			log.Printf("Error sending Solana logs notification: %v", err)
+			sm.Unsubscribe(sub.ID)
+		}
+	}
+}
+
+// SetMentions sets the address filter of a logsSubscribe subscription
+func (sm *SubscriptionManager) SetMentions(id uint64, mentions string) {
+	shard := sm.shard("501")
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if sub, ok := shard.subscriptions[id]; ok {
+		sub.mentions = mentions
+	}
+}
+
+// solanaLogsFilter parses the logsSubscribe filter: "all", "allWithVotes" or {"mentions": [pubkey]}
+func solanaLogsFilter(params []interface{}) (string, error) {
+	if len(params) == 0 {
+		return "", fmt.Errorf("Invalid params: filter is required")
+	}
+	switch filter := params[0].(type) {
+	case string:
+		if filter != "all" && filter != "allWithVotes" {
+			return "", fmt.Errorf("Invalid params: unknown filter %s", filter)
+		}
+		return "", nil
+	case map[string]interface{}:
+		mentions, ok := filter["mentions"].([]interface{})
+		if !ok || len(mentions) != 1 {
+			return "", fmt.Errorf("Invalid Request: Only 1 address supported")
+		}
+		pubkey, ok := mentions[0].(string)
+		if !ok || !isBase58Pubkey(pubkey) {
+			return "", fmt.Errorf("Invalid params: invalid pubkey")
+		}
+		return pubkey, nil
+	default:
+		return "", fmt.Errorf("Invalid params: invalid filter")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProgramID = "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"
+
+// logsNotificationsForSlot returns the logs notifications a connection received for a slot
+func logsNotificationsForSlot(t *testing.T, conn *MockWSConn, slot uint64) []map[string]interface{} {
+	var values []map[string]interface{}
+	for _, message := range conn.GetMessages() {
+		var notification struct {
+			Method string `json:"method"`
+			Params struct {
+				Result struct {
+					Context struct {
+						Slot uint64 `json:"slot"`
+					} `json:"context"`
+					Value map[string]interface{} `json:"value"`
+				} `json:"result"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(message, &notification); err != nil {
+			t.Fatalf("Failed to unmarshal notification: %v", err)
+		}
+		if notification.Method == "logsNotification" && notification.Params.Result.Context.Slot == slot {
+			values = append(values, notification.Params.Result.Value)
+		}
+	}
+	return values
+}
+
+func TestSolanaProgramLogs(t *testing.T) {
+	original := solanaNode.ProgramLogs
+	solanaNode.ProgramLogs = []SolanaProgramLog{{ProgramID: testProgramID, Instruction: "Transfer"}}
+	defer func() { solanaNode.ProgramLogs = original }()
+
+	allConn := NewMockWSConn()
+	mentionsConn := NewMockWSConn()
+	otherConn := NewMockWSConn()
+	subscriptions := []struct {
+		conn   *MockWSConn
+		params string
+	}{
+		{allConn, `["all"]`},
+		{mentionsConn, `[{"mentions": ["` + testProgramID + `"]}]`},
+		{otherConn, `[{"mentions": ["` + fixtureVotePubkey + `"]}]`},
+	}
+	for _, sub := range subscriptions {
+		response, _ := handleSolanaRequest(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"logsSubscribe","params":`+sub.params+`}`), sub.conn)
+		if strings.Contains(string(response), "error") {
+			t.Fatalf("logsSubscribe failed: %s", response)
+		}
+		defer subManager.CleanupConnection(sub.conn)
+	}
+
+	slot := uint64(987654)
+	subManager.BroadcastSolanaLogs(slot)
+	subManager.Flush("501")
+
+	values := logsNotificationsForSlot(t, allConn, slot)
+	if len(values) != 1 {
+		t.Fatalf("Expected 1 logs notification, got %d", len(values))
+	}
+	logs, _ := values[0]["logs"].([]interface{})
+	if len(logs) != 4 || logs[0] != "Program "+testProgramID+" invoke [1]" || logs[1] != "Program log: Instruction: Transfer" {
+		t.Errorf("Unexpected logs: %v", logs)
+	}
+	if len(logsNotificationsForSlot(t, mentionsConn, slot)) != 1 {
+		t.Error("Expected the mentions subscription to receive the program's logs")
+	}
+	if len(logsNotificationsForSlot(t, otherConn, slot)) != 0 {
+		t.Error("Expected a subscription mentioning another address to receive nothing")
+	}
+
+	// The notified transaction can be fetched with getTransaction
+	signature := values[0]["signature"].(string)
+	response, _ := handleSolanaRequest(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"getTransaction","params":["`+signature+`"]}`), nil)
+	var tx struct {
+		Result struct {
+			Meta struct {
+				LogMessages []string `json:"logMessages"`
+			} `json:"meta"`
+		} `json:"result"`
+	}
+	json.Unmarshal(response, &tx)
+	if len(tx.Result.Meta.LogMessages) != 4 || tx.Result.Meta.LogMessages[2] != logs[2] {
+		t.Errorf("Expected getTransaction to return the notified logs, got %s", response)
+	}
+}
+
+func TestSolanaProgramLogTemplates(t *testing.T) {
+	template := SolanaProgramLog{
+		ProgramID:   testProgramID,
+		Instruction: "Swap",
+		Logs:        []string{"Program {program} invoke [1]", "Program log: Instruction: {instruction}", "Program {program} failed: custom program error: 0x1771"},
+	}
+	logs := template.render("seed")
+	if logs[0] != "Program "+testProgramID+" invoke [1]" || logs[1] != "Program log: Instruction: Swap" {
+		t.Errorf("Expected placeholders to be substituted, got %v", logs)
+	}
+
+	filename := filepath.Join(t.TempDir(), "program_logs.yaml")
+	os.WriteFile(filename, []byte("- program_id: "+testProgramID+"\n  instruction: Initialize\n"), 0644)
+	templates, err := loadSolanaProgramLogs(filename)
+	if err != nil || len(templates) != 1 {
+		t.Fatalf("Failed to load program logs: %v", err)
+	}
+
+	os.WriteFile(filename, []byte("- program_id: not-base58!\n  instruction: Initialize\n"), 0644)
+	if _, err := loadSolanaProgramLogs(filename); err == nil {
+		t.Error("Expected error for invalid program id")
+	}
+}
+
+func TestLogsSubscribeInvalidFilter(t *testing.T) {
+	conn := NewMockWSConn()
+	response, _ := handleSolanaRequest(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"logsSubscribe","params":[{"mentions": ["a", "b"]}]}`), conn)
+	if !strings.Contains(string(response), "Only 1 address supported") {
+		t.Errorf("Expected mentions error, got %s", response)
+	}
+}
+
+func TestSetSolanaProgramLogs(t *testing.T) {
+	withTempConfig(t)
+	original := solanaNode.ProgramLogs
+	defer func() {
+		solanaNode.ProgramLogs = original
+	}()
+
+	body := `{"programs": [{"program_id": "` + testProgramID + `", "instruction": "Initialize"}]}`
+	w := httptest.NewRecorder()
+	handleSetSolanaProgramLogs(w, httptest.NewRequest(http.MethodPost, "/control/solana/program-logs", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(solanaNode.ProgramLogs) != 1 {
+		t.Fatalf("Expected 1 template, got %d", len(solanaNode.ProgramLogs))
+	}
+
+	w = httptest.NewRecorder()
+	handleSetSolanaProgramLogs(w, httptest.NewRequest(http.MethodPost, "/control/solana/program-logs", bytes.NewBufferString(`{"programs": [{"program_id": "`+testProgramID+`"}]}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
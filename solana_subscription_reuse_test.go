@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSolanaSubscriptionIDReuse(t *testing.T) {
+	sm := NewSubscriptionManager()
+	sm.SetSolanaIDReuse(true, 0)
+	conn := NewMockWSConn()
+
+	first, _ := sm.Subscribe("501", conn, "slotNotification")
+	second, _ := sm.Subscribe("501", conn, "slotNotification")
+	third, _ := sm.Subscribe("501", conn, "slotNotification")
+
+	// Free the two smallest ids out of order
+	if err := sm.Unsubscribe(second); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	if err := sm.Unsubscribe(first); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+
+	// The smallest freed id is handed out first
+	reused, _ := sm.Subscribe("501", conn, "slotNotification")
+	if reused != first {
+		t.Errorf("Expected reused id %d, got %d", first, reused)
+	}
+	reused, _ = sm.Subscribe("501", conn, "slotNotification")
+	if reused != second {
+		t.Errorf("Expected reused id %d, got %d", second, reused)
+	}
+
+	// No free ids left, a new id is allocated
+	fresh, _ := sm.Subscribe("501", conn, "slotNotification")
+	if fresh <= third {
+		t.Errorf("Expected a new id above %d, got %d", third, fresh)
+	}
+
+	// EVM subscriptions never reuse ids
+	if err := sm.Unsubscribe(fresh); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	evmID, _ := sm.Subscribe("1", conn, "newHeads")
+	if evmID == fresh {
+		t.Errorf("EVM subscription should not reuse Solana id %d", fresh)
+	}
+}
+
+func TestSolanaUnsubscribeGracePeriod(t *testing.T) {
+	sm := NewSubscriptionManager()
+	sm.SetSolanaIDReuse(false, 100*time.Millisecond)
+	conn := NewMockWSConn()
+
+	subID, _ := sm.Subscribe("501", conn, "slotNotification")
+	if err := sm.Unsubscribe(subID); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+
+	// Notifications still arrive during the grace period
+	sm.BroadcastNewBlock("501", 10)
+	sm.Flush("501")
+	if len(conn.GetMessages()) != 1 {
+		t.Fatalf("Expected 1 notification during grace period, got %d", len(conn.GetMessages()))
+	}
+
+	// And stop once it expires
+	time.Sleep(150 * time.Millisecond)
+	conn.ClearMessages()
+	sm.BroadcastNewBlock("501", 11)
+	sm.Flush("501")
+	if len(conn.GetMessages()) != 0 {
+		t.Errorf("Expected no notifications after grace period, got %d", len(conn.GetMessages()))
+	}
+
+	// Unsubscribing again is still an error
+	if err := sm.Unsubscribe(subID); err == nil {
+		t.Error("Expected error unsubscribing an already removed subscription")
+	}
+}
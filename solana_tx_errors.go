@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Solana TransactionError variants that can be injected
+const (
+	TxErrorInstructionError        = "InstructionError"
+	TxErrorInsufficientFundsForFee = "InsufficientFundsForFee"
+	TxErrorAlreadyProcessed        = "AlreadyProcessed"
+	TxErrorBlockhashNotFound       = "BlockhashNotFound"
+)
+
+// SolanaTxErrorConfig defines a TransactionError that sendTransaction/simulateTransaction can fail with
+type SolanaTxErrorConfig struct {
+	Type             string   `json:"type" yaml:"type"`                                               // TransactionError variant
+	InstructionIndex int      `json:"instruction_index,omitempty" yaml:"instruction_index,omitempty"` // Failing instruction (InstructionError only)
+	CustomCode       uint32   `json:"custom_code,omitempty" yaml:"custom_code,omitempty"`             // Custom program error code (InstructionError only)
+	Probability      float64  `json:"probability" yaml:"probability"`                                 // 0.0 to 1.0
+	Methods          []string `json:"methods,omitempty" yaml:"methods,omitempty"`                     // If empty, applies to sendTransaction and simulateTransaction
+}
+
+// solanaTxErrorMessages contains the preflight failure messages reported by real nodes
+var solanaTxErrorMessages = map[string]string{
+	TxErrorInsufficientFundsForFee: "Insufficient funds for fee",
+	TxErrorAlreadyProcessed:        "This transaction has already been processed",
+	TxErrorBlockhashNotFound:       "Blockhash not found",
+}
+
+// isValidSolanaTxErrorType returns true if the type is a supported TransactionError variant
+func isValidSolanaTxErrorType(errType string) bool {
+	if errType == TxErrorInstructionError {
+		return true
+	}
+	_, ok := solanaTxErrorMessages[errType]
+	return ok
+}
+
+// TransactionError returns the TransactionError JSON value as serialized by Solana nodes
+func (c SolanaTxErrorConfig) TransactionError() interface{} {
+	if c.Type == TxErrorInstructionError {
+		return map[string]interface{}{
+			TxErrorInstructionError: []interface{}{c.InstructionIndex, map[string]interface{}{"Custom": c.CustomCode}},
+		}
+	}
+	return c.Type
+}
+
+// Message returns the human readable preflight failure message
+func (c SolanaTxErrorConfig) Message() string {
+	if c.Type == TxErrorInstructionError {
+		return fmt.Sprintf("Error processing Instruction %d: custom program error: 0x%x", c.InstructionIndex, c.CustomCode)
+	}
+	return solanaTxErrorMessages[c.Type]
+}
+
+// Logs returns the program logs accompanying the failure
+func (c SolanaTxErrorConfig) Logs() []string {
+	if c.Type == TxErrorInstructionError {
+		return []string{
+			"Program " + tokenProgramID + " invoke [1]",
+			fmt.Sprintf("Program %s failed: custom program error: 0x%x", tokenProgramID, c.CustomCode),
+		}
+	}
+	return []string{}
+}
+
+// ShouldSimulateTransactionError checks if a TransactionError should be injected for the given method
+// Returns the error config to use, or nil if the transaction should succeed
+func ShouldSimulateTransactionError(configs []SolanaTxErrorConfig, method string) *SolanaTxErrorConfig {
+	var applicable []SolanaTxErrorConfig
+	for _, config := range configs {
+		if len(config.Methods) == 0 {
+			applicable = append(applicable, config)
+			continue
+		}
+		for _, m := range config.Methods {
+			if m == method {
+				applicable = append(applicable, config)
+				break
+			}
+		}
+	}
+
+	// Roll once and pick an error by weighted probability
+	roll := rand.Float64()
+	cumulative := 0.0
+	for i := range applicable {
+		cumulative += applicable[i].Probability
+		if roll < cumulative {
+			return &applicable[i]
+		}
+	}
+	return nil
+}
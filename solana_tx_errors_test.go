@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestSolanaTransactionErrorInjection(t *testing.T) {
+	original := solanaNode.TransactionErrors
+	defer func() { solanaNode.TransactionErrors = original }()
+
+	tests := []struct {
+		name            string
+		config          SolanaTxErrorConfig
+		expectedErr     string // JSON encoding of the TransactionError
+		expectedMessage string
+	}{
+		{
+			name:            "InstructionError with custom code",
+			config:          SolanaTxErrorConfig{Type: TxErrorInstructionError, InstructionIndex: 1, CustomCode: 6001, Probability: 1},
+			expectedErr:     `{"InstructionError":[1,{"Custom":6001}]}`,
+			expectedMessage: "Transaction simulation failed: Error processing Instruction 1: custom program error: 0x1771",
+		},
+		{
+			name:            "InsufficientFundsForFee",
+			config:          SolanaTxErrorConfig{Type: TxErrorInsufficientFundsForFee, Probability: 1},
+			expectedErr:     `"InsufficientFundsForFee"`,
+			expectedMessage: "Transaction simulation failed: Insufficient funds for fee",
+		},
+		{
+			name:            "AlreadyProcessed",
+			config:          SolanaTxErrorConfig{Type: TxErrorAlreadyProcessed, Probability: 1},
+			expectedErr:     `"AlreadyProcessed"`,
+			expectedMessage: "Transaction simulation failed: This transaction has already been processed",
+		},
+		{
+			name:            "BlockhashNotFound",
+			config:          SolanaTxErrorConfig{Type: TxErrorBlockhashNotFound, Probability: 1},
+			expectedErr:     `"BlockhashNotFound"`,
+			expectedMessage: "Transaction simulation failed: Blockhash not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solanaNode.TransactionErrors = []SolanaTxErrorConfig{tt.config}
+
+			// sendTransaction fails with a preflight RPC error
+			request := []byte(`{"jsonrpc":"2.0","method":"sendTransaction","params":["AQAB"],"id":1}`)
+			response, err := handleSolanaRequest(context.Background(), request, NewMockWSConn())
+			if err != nil {
+				t.Fatalf("Handler error: %v", err)
+			}
+			var resp struct {
+				Error struct {
+					Code    int    `json:"code"`
+					Message string `json:"message"`
+					Data    struct {
+						Err json.RawMessage `json:"err"`
+					} `json:"data"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(response, &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if resp.Error.Code != -32002 {
+				t.Errorf("Expected error code -32002, got %d", resp.Error.Code)
+			}
+			if resp.Error.Message != tt.expectedMessage {
+				t.Errorf("Expected message %q, got %q", tt.expectedMessage, resp.Error.Message)
+			}
+			if string(resp.Error.Data.Err) != tt.expectedErr {
+				t.Errorf("Expected err %s, got %s", tt.expectedErr, resp.Error.Data.Err)
+			}
+
+			// simulateTransaction reports the error in the result
+			request = []byte(`{"jsonrpc":"2.0","method":"simulateTransaction","params":["AQAB"],"id":1}`)
+			response, err = handleSolanaRequest(context.Background(), request, NewMockWSConn())
+			if err != nil {
+				t.Fatalf("Handler error: %v", err)
+			}
+			var simResp struct {
+				Result struct {
+					Value struct {
+						Err json.RawMessage `json:"err"`
+					} `json:"value"`
+				} `json:"result"`
+			}
+			if err := json.Unmarshal(response, &simResp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if string(simResp.Result.Value.Err) != tt.expectedErr {
+				t.Errorf("Expected simulation err %s, got %s", tt.expectedErr, simResp.Result.Value.Err)
+			}
+		})
+	}
+}
+
+func TestSolanaTransactionErrorMethodFilter(t *testing.T) {
+	original := solanaNode.TransactionErrors
+	defer func() { solanaNode.TransactionErrors = original }()
+
+	solanaNode.TransactionErrors = []SolanaTxErrorConfig{
+		{Type: TxErrorBlockhashNotFound, Probability: 1, Methods: []string{"simulateTransaction"}},
+	}
+
+	result := solanaCall(t, "sendTransaction", "AQAB")
+	if _, ok := result.(string); !ok {
+		t.Errorf("Expected signature for sendTransaction, got %v", result)
+	}
+
+	result = solanaCall(t, "simulateTransaction", "AQAB")
+	value := result.(map[string]interface{})["value"].(map[string]interface{})
+	if value["err"] != TxErrorBlockhashNotFound {
+		t.Errorf("Expected BlockhashNotFound, got %v", value["err"])
+	}
+}
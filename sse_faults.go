@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SSE streams that faults can be configured for
+const (
+	SSEStreamConnections = "connections" // /sse/connections
+	SSEStreamBlocks      = "blocks"      // /sse/blocks
+)
+
+// SSEStreams lists all SSE streams
+var SSEStreams = []string{SSEStreamConnections, SSEStreamBlocks}
+
+// SSE fault modes
+const (
+	SSEFaultStall      = "stall"      // Keep the stream open but send nothing
+	SSEFaultMalformed  = "malformed"  // Send events that break parsers
+	SSEFaultDisconnect = "disconnect" // Close the stream
+)
+
+// SSEFaultModes lists all supported SSE fault modes
+var SSEFaultModes = []string{SSEFaultStall, SSEFaultMalformed, SSEFaultDisconnect}
+
+// SSEFault disturbs one of the simulator's own SSE streams. Stall and malformed faults are
+// active during the last Duration of every Every period of a stream, or always with Every 0.
+// Disconnect closes each stream Every after it was opened.
+type SSEFault struct {
+	Mode     string        `json:"mode"`
+	Every    time.Duration `json:"every"`
+	Duration time.Duration `json:"duration"`
+}
+
+// validate checks the mode and the period
+func (f *SSEFault) validate() error {
+	switch f.Mode {
+	case SSEFaultStall, SSEFaultMalformed:
+		if f.Every < 0 || f.Duration < 0 || (f.Every > 0 && (f.Duration == 0 || f.Duration > f.Every)) {
+			return fmt.Errorf("duration must be between 1ms and every")
+		}
+	case SSEFaultDisconnect:
+		if f.Every <= 0 {
+			return fmt.Errorf("every is required for %s", SSEFaultDisconnect)
+		}
+	default:
+		return fmt.Errorf("invalid mode: %s (supported: %v)", f.Mode, SSEFaultModes)
+	}
+	return nil
+}
+
+// active returns true if the fault applies at elapsed time since the stream was opened
+func (f *SSEFault) active(elapsed time.Duration) bool {
+	if f.Mode == SSEFaultDisconnect {
+		return elapsed >= f.Every
+	}
+	if f.Every == 0 {
+		return true
+	}
+	return elapsed%f.Every >= f.Every-f.Duration
+}
+
+// sseFaults holds the fault of each stream; runtime-only
+var sseFaults = struct {
+	sync.RWMutex
+	byStream map[string]*SSEFault
+}{byStream: make(map[string]*SSEFault)}
+
+func sseFaultFor(stream string) *SSEFault {
+	sseFaults.RLock()
+	defer sseFaults.RUnlock()
+	return sseFaults.byStream[stream]
+}
+
+// sseStream writes the events of one SSE response, applying the stream's fault
+type sseStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	name    string
+	opened  time.Time
+	events  int
+}
+
+// newSSEStream sends the response headers right away, so clients see an open stream even
+// while it stalls
+func newSSEStream(w http.ResponseWriter, flusher http.Flusher, name string) *sseStream {
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &sseStream{w: w, flusher: flusher, name: name, opened: time.Now()}
+}
+
+// send writes one event. It returns false when the stream must be closed.
+func (s *sseStream) send(data []byte) bool {
+	fault := sseFaultFor(s.name)
+	if fault != nil && fault.active(time.Since(s.opened)) {
+		switch fault.Mode {
+		case SSEFaultStall:
+			return true
+		case SSEFaultDisconnect:
+			log.Printf("Closing /sse/%s stream (disconnect fault)", s.name)
+			return false
+		case SSEFaultMalformed:
+			s.writeMalformed(data)
+			return true
+		}
+	}
+	fmt.Fprintf(s.w, "data: %s\n\n", data)
+	s.flusher.Flush()
+	s.events++
+	return true
+}
+
+// writeMalformed rotates through truncated JSON, a missing event terminator (the event
+// merges with the next one) and data that is not JSON
+func (s *sseStream) writeMalformed(data []byte) {
+	switch s.events % 3 {
+	case 0:
+		fmt.Fprintf(s.w, "data: %s\n\n", data[:len(data)/2])
+	case 1:
+		fmt.Fprintf(s.w, "data: %s\n", data)
+	case 2:
+		fmt.Fprint(s.w, "event: garbage\ndata: \x00not json\n\n")
+	}
+	s.flusher.Flush()
+	s.events++
+}
+
+// handleSSEFaults reports (GET) or configures (POST) faults on the SSE streams. An empty
+// stream applies to all streams and an empty mode removes the fault.
+func handleSSEFaults(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sseFaults.RLock()
+		faults := make(map[string]*SSEFault, len(sseFaults.byStream))
+		for stream, fault := range sseFaults.byStream {
+			faults[stream] = fault
+		}
+		sseFaults.RUnlock()
+		jsonResponse(w, http.StatusOK, faults)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Stream     string `json:"stream"`
+		Mode       string `json:"mode"`
+		EveryMs    int64  `json:"every_ms"`
+		DurationMs int64  `json:"duration_ms"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	streams := SSEStreams
+	if request.Stream != "" {
+		if request.Stream != SSEStreamConnections && request.Stream != SSEStreamBlocks {
+			http.Error(w, fmt.Sprintf("Invalid stream: %s (supported: %v)", request.Stream, SSEStreams), http.StatusBadRequest)
+			return
+		}
+		streams = []string{request.Stream}
+	}
+
+	var fault *SSEFault
+	if request.Mode != "" {
+		fault = &SSEFault{
+			Mode:     request.Mode,
+			Every:    time.Duration(request.EveryMs) * time.Millisecond,
+			Duration: time.Duration(request.DurationMs) * time.Millisecond,
+		}
+		if err := fault.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sseFaults.Lock()
+	for _, stream := range streams {
+		if fault == nil {
+			delete(sseFaults.byStream, stream)
+		} else {
+			sseFaults.byStream[stream] = fault
+		}
+	}
+	sseFaults.Unlock()
+
+	if fault == nil {
+		log.Printf("Cleared SSE faults on %v", streams)
+	} else {
+		log.Printf("Set SSE fault %s on %v", fault.Mode, streams)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
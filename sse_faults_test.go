@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setSSEFault(t *testing.T, body string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleSSEFaults(w, httptest.NewRequest(http.MethodPost, "/control/sse/faults", bytes.NewBufferString(body)))
+	return w.Code
+}
+
+// readSSELines collects lines from an SSE stream until it closes or the timeout expires
+func readSSELines(t *testing.T, url string, timeout time.Duration) (lines []string, closed bool) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err == io.EOF {
+				closed = true
+			}
+			if err != nil {
+				return
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		resp.Body.Close()
+		<-done
+		closed = false
+	}
+	return lines, closed
+}
+
+func TestSSEFaultWindow(t *testing.T) {
+	stall := &SSEFault{Mode: SSEFaultStall, Every: 10 * time.Second, Duration: 2 * time.Second}
+	for elapsed, want := range map[time.Duration]bool{0: false, 7 * time.Second: false, 8 * time.Second: true, 19 * time.Second: true, 21 * time.Second: false} {
+		if got := stall.active(elapsed); got != want {
+			t.Errorf("At %v: expected active=%t, got %t", elapsed, want, got)
+		}
+	}
+	disconnect := &SSEFault{Mode: SSEFaultDisconnect, Every: time.Second}
+	if disconnect.active(999*time.Millisecond) || !disconnect.active(time.Second) {
+		t.Error("Expected the disconnect fault to apply from every on")
+	}
+}
+
+func TestSSEFaults(t *testing.T) {
+	defer setSSEFault(t, `{"mode": ""}`)
+
+	mux := http.NewServeMux()
+	registerAdminRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Healthy streams send valid JSON events
+	lines, _ := readSSELines(t, server.URL+"/sse/blocks", 200*time.Millisecond)
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "data: ") || !json.Valid([]byte(strings.TrimPrefix(lines[0], "data: "))) || lines[1] != "" {
+		t.Fatalf("Expected a valid event, got %q", lines)
+	}
+
+	if code := setSSEFault(t, `{"stream": "blocks", "mode": "malformed"}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	lines, _ = readSSELines(t, server.URL+"/sse/blocks", 200*time.Millisecond)
+	if len(lines) == 0 || json.Valid([]byte(strings.TrimPrefix(lines[0], "data: "))) {
+		t.Errorf("Expected a malformed event, got %q", lines)
+	}
+
+	setSSEFault(t, `{"stream": "blocks", "mode": "stall"}`)
+	if lines, closed := readSSELines(t, server.URL+"/sse/blocks", 700*time.Millisecond); len(lines) != 0 || closed {
+		t.Errorf("Expected a stalled open stream, got %q closed=%t", lines, closed)
+	}
+
+	setSSEFault(t, `{"stream": "blocks", "mode": "disconnect", "every_ms": 100}`)
+	if lines, closed := readSSELines(t, server.URL+"/sse/blocks", 2*time.Second); !closed || len(lines) != 2 {
+		t.Errorf("Expected the stream to close after the first event, got %q closed=%t", lines, closed)
+	}
+
+	// Other streams are unaffected
+	if lines, _ := readSSELines(t, server.URL+"/sse/connections", 200*time.Millisecond); len(lines) < 2 || !json.Valid([]byte(strings.TrimPrefix(lines[0], "data: "))) {
+		t.Errorf("Expected a valid connections event, got %q", lines)
+	}
+
+	for _, body := range []string{
+		`{"stream": "other", "mode": "stall"}`,
+		`{"mode": "explode"}`,
+		`{"mode": "disconnect"}`,
+		`{"mode": "stall", "every_ms": 1000, "duration_ms": 2000}`,
+	} {
+		if code := setSSEFault(t, body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, code)
+		}
+	}
+}
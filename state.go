@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ChainState is a snapshot of everything the simulator is doing for one chain
+type ChainState struct {
+	Chain            string         `json:"chain"`
+	ChainID          string         `json:"chain_id"`
+	Head             uint64         `json:"head"`
+	Safe             uint64         `json:"safe"`
+	Finalized        uint64         `json:"finalized"`
+	RunState         string         `json:"run_state"`
+	LatencyMs        int64          `json:"latency_ms"`
+	LatencyPlacement string         `json:"latency_placement"`
+	ActiveFaults     []string       `json:"active_faults"`
+	Subscriptions    map[string]int `json:"subscriptions"` // Active subscription count by method
+	Connections      int            `json:"connections"`
+	Warnings         []string       `json:"warnings"` // Guardrail warnings of the watchdog
+
+	HeadNotifications NotificationMetricsSnapshot `json:"head_notifications"`
+}
+
+// SimulatorState is the document returned by GET /control/state
+type SimulatorState struct {
+	Chains             []ChainState `json:"chains"`
+	ConnectionsBlocked bool         `json:"connections_blocked"`
+	Scenarios          []Scenario   `json:"scenarios"`
+}
+
+// SubscriptionCounts returns the number of active subscriptions per method for a chain
+func (sm *SubscriptionManager) SubscriptionCounts(chainId string) map[string]int {
+	shard := sm.shard(chainId)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, sub := range shard.subscriptions {
+		counts[sub.Method]++
+	}
+	return counts
+}
+
+// evmActiveFaults describes the faults currently configured on an EVM chain
+func evmActiveFaults(c *EVMChain) []string {
+	faults := []string{}
+	if c.ResponseTimeout > 0 {
+		faults = append(faults, fmt.Sprintf("timeout %v", c.ResponseTimeout))
+	}
+	if c.ErrorProbability > 0 {
+		faults = append(faults, fmt.Sprintf("error_probability %.2f", c.ErrorProbability))
+	}
+	if len(c.ErrorConfigs) > 0 {
+		faults = append(faults, fmt.Sprintf("error_configs %d", len(c.ErrorConfigs)))
+	}
+	if c.CustomResponseEnabled && c.CustomResponse != "" {
+		faults = append(faults, "custom_response")
+	}
+	if len(c.MethodOverrides) > 0 {
+		faults = append(faults, fmt.Sprintf("method_overrides %d", len(c.MethodOverrides)))
+	}
+	if c.DisableNewHeadsWithTx {
+		faults = append(faults, "new_heads_with_tx disabled")
+	}
+	if c.StrictSubscribeParams {
+		faults = append(faults, "strict_subscribe_params")
+	}
+	if pending := atomic.LoadInt32(&c.discontinuities); pending > 0 {
+		faults = append(faults, fmt.Sprintf("discontinuity %d", pending))
+	}
+	if fault := timestampFaultFor(c.Name); fault != nil {
+		faults = append(faults, "timestamp "+fault.Mode)
+	}
+	if padding := c.notificationPadding().size; padding > 0 {
+		faults = append(faults, fmt.Sprintf("notification_padding %d", padding))
+	}
+	if c.BlockPhase != nil {
+		faults = append(faults, fmt.Sprintf("block_phase %v", c.BlockPhase.Window))
+	}
+	if c.Peers != nil {
+		faults = append(faults, fmt.Sprintf("peers %d±%d", c.Peers.Base, c.Peers.Fluctuation))
+	}
+	if c.HealthCheck != nil {
+		faults = append(faults, "health_check_isolation")
+	}
+	if c.IdentityFastPath {
+		faults = append(faults, "identity_fast_path")
+	}
+	if p, ok := faultPropagationFor(chainIDForName(c.Name)); ok {
+		faults = append(faults, fmt.Sprintf("fault_propagation %s +%v", p.Lagging, p.Delay))
+	}
+	return append(faults, commonActiveFaults(c.IDMangleMode, c.EnvelopeFault, c.ProtocolStrictness, c.FirstNotificationDelay, c.CompressionBombSize, c.IdleTimeout)...)
+}
+
+// solanaActiveFaults describes the faults currently configured on the Solana node
+func solanaActiveFaults(n *SolanaNode) []string {
+	faults := []string{}
+	if n.ResponseTimeout > 0 {
+		faults = append(faults, fmt.Sprintf("timeout %v", n.ResponseTimeout))
+	}
+	if len(n.TransactionErrors) > 0 {
+		faults = append(faults, fmt.Sprintf("transaction_errors %d", len(n.TransactionErrors)))
+	}
+	if len(n.MethodOverrides) > 0 {
+		faults = append(faults, fmt.Sprintf("method_overrides %d", len(n.MethodOverrides)))
+	}
+	if n.SubscriptionIDReuse {
+		faults = append(faults, "subscription_id_reuse")
+	}
+	if n.UnsubscribeGrace > 0 {
+		faults = append(faults, fmt.Sprintf("unsubscribe_grace %v", n.UnsubscribeGrace))
+	}
+	if n.BlockPhase != nil {
+		faults = append(faults, fmt.Sprintf("block_phase %v", n.BlockPhase.Window))
+	}
+	if n.HealthCheck != nil {
+		faults = append(faults, "health_check_isolation")
+	}
+	if n.IdentityFastPath {
+		faults = append(faults, "identity_fast_path")
+	}
+	if p, ok := faultPropagationFor("501"); ok {
+		faults = append(faults, fmt.Sprintf("fault_propagation %s +%v", p.Lagging, p.Delay))
+	}
+	return append(faults, commonActiveFaults(n.IDMangleMode, n.EnvelopeFault, n.ProtocolStrictness, n.FirstNotificationDelay, n.CompressionBombSize, n.IdleTimeout)...)
+}
+
+// commonActiveFaults describes faults shared by EVM chains and the Solana node
+func commonActiveFaults(idMangleMode, envelopeFault, strictness string, firstNotificationDelay time.Duration, compressionBombSize int64, idleTimeout time.Duration) []string {
+	var faults []string
+	if idMangleMode != "" {
+		faults = append(faults, "id_mangle "+idMangleMode)
+	}
+	if envelopeFault != "" {
+		faults = append(faults, "envelope "+envelopeFault)
+	}
+	if strictness != "" {
+		faults = append(faults, "strictness "+strictness)
+	}
+	if firstNotificationDelay > 0 {
+		faults = append(faults, fmt.Sprintf("first_notification_delay %v", firstNotificationDelay))
+	}
+	if compressionBombSize > 0 {
+		faults = append(faults, fmt.Sprintf("compression_bomb %d", compressionBombSize))
+	}
+	if idleTimeout > 0 {
+		faults = append(faults, fmt.Sprintf("idle_timeout %v", idleTimeout))
+	}
+	return faults
+}
+
+// currentState collects a snapshot of all chains, ordered by name
+func currentState() SimulatorState {
+	state := SimulatorState{
+		Chains:             []ChainState{},
+		ConnectionsBlocked: IsBlocked(),
+		Scenarios:          scenarioManager.List(),
+	}
+	guardrails := watchdogConfig()
+
+	for _, name := range allChainNames() {
+		chainId := chainIDForName(name)
+		cs := ChainState{
+			Chain:         name,
+			ChainID:       chainId,
+			Subscriptions: subManager.SubscriptionCounts(chainId),
+			Connections:   connTracker.GetConnectionCount(chainId),
+
+			HeadNotifications: headNotificationMetrics.For(chainId).Snapshot(),
+		}
+
+		if name == "solana" {
+			slot := atomic.LoadUint64(&solanaNode.SlotNumber)
+			cs.Head = slot
+			if slot > 1 {
+				cs.Safe = slot - 1 // confirmed
+			}
+			if slot > 3 {
+				cs.Finalized = slot - 3
+			}
+			cs.RunState = solanaNode.runState.State().String()
+			cs.ActiveFaults = solanaActiveFaults(solanaNode)
+		} else {
+			chain := supportedChains[name]
+			cs.Head = atomic.LoadUint64(&chain.BlockNumber)
+			cs.Safe = atomic.LoadUint64(&chain.SafeBlockNumber)
+			cs.Finalized = atomic.LoadUint64(&chain.FinalizedBlockNumber)
+			cs.RunState = chain.runState.State().String()
+			cs.ActiveFaults = evmActiveFaults(chain)
+		}
+		latency, _, placement := latencySettings(chainId)
+		cs.LatencyMs, cs.LatencyPlacement = latency.Milliseconds(), placement
+		if cs.Warnings = guardrailWarnings(name, guardrails); cs.Warnings == nil {
+			cs.Warnings = []string{}
+		}
+		if cs.LatencyPlacement == "" {
+			cs.LatencyPlacement = LatencyPlacementPre
+		}
+		state.Chains = append(state.Chains, cs)
+	}
+	return state
+}
+
+// handleState returns the full simulator state in one document
+func handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	jsonResponse(w, http.StatusOK, currentState())
+}
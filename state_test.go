@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStateEndpoint(t *testing.T) {
+	chain := supportedChains["polygon"]
+	defer func() {
+		setLatency("137", 0, nil)
+		chain.IDMangleMode = ""
+		chain.runState.Reset()
+	}()
+	setLatency("137", 150*time.Millisecond, nil)
+	chain.IDMangleMode = IDMangleStringify
+	chain.runState.Transition(RunEventPause)
+
+	conn := NewMockWSConn()
+	subManager.Subscribe("137", conn, "newHeads")
+	subManager.Subscribe("137", conn, "logs")
+	defer subManager.CleanupConnection(conn)
+
+	mux := http.NewServeMux()
+	handleControlEndpoints(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/control/state", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var state SimulatorState
+	if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+	if len(state.Chains) != len(supportedChains)+1 {
+		t.Errorf("Expected %d chains, got %d", len(supportedChains)+1, len(state.Chains))
+	}
+
+	var polygon *ChainState
+	for i := range state.Chains {
+		if state.Chains[i].Chain == "polygon" {
+			polygon = &state.Chains[i]
+		}
+	}
+	if polygon == nil {
+		t.Fatal("Expected polygon in state")
+	}
+	if polygon.ChainID != "137" || polygon.RunState != "paused" || polygon.LatencyMs != 150 {
+		t.Errorf("Unexpected polygon state: %+v", polygon)
+	}
+	if polygon.Subscriptions["newHeads"] != 1 || polygon.Subscriptions["logs"] != 1 {
+		t.Errorf("Expected subscription counts by method, got %v", polygon.Subscriptions)
+	}
+	if len(polygon.ActiveFaults) != 1 || polygon.ActiveFaults[0] != "id_mangle "+IDMangleStringify {
+		t.Errorf("Expected id mangle fault, got %v", polygon.ActiveFaults)
+	}
+}
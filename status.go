@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Chain statuses reported by /api/status, from best to worst
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusDown        = "down"
+)
+
+// ChainStatuses lists the supported chain statuses, from best to worst
+var ChainStatuses = []string{StatusOperational, StatusDegraded, StatusDown}
+
+// statusRank orders statuses by severity; -1 for unknown statuses
+func statusRank(status string) int {
+	for i, s := range ChainStatuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// ChainStatus is one chain on the status page
+type ChainStatus struct {
+	Chain   string `json:"chain"`
+	ChainID string `json:"chain_id"`
+	Status  string `json:"status"`
+}
+
+// StatusPage is the document returned by /api/status
+type StatusPage struct {
+	Status    string        `json:"status"` // Worst status of all chains
+	UpdatedAt time.Time     `json:"updated_at"`
+	Chains    []ChainStatus `json:"chains"`
+}
+
+// statusOverrides make the status page report a fixed status per chain regardless of
+// faults, e.g. operational during an outage. They are runtime-only.
+var statusOverrides = struct {
+	sync.RWMutex
+	byChain map[string]string
+}{byChain: make(map[string]string)}
+
+// actualStatus derives a chain's status from its state: down when requests cannot succeed
+// (blocked connections, interrupted production, response timeouts or errors on every
+// request), degraded with any other active fault or paused production.
+func actualStatus(cs ChainState, connectionsBlocked bool) string {
+	if connectionsBlocked || cs.RunState == RunStateInterrupted.String() || chainRejectsAllRequests(cs.Chain) {
+		return StatusDown
+	}
+	if len(cs.ActiveFaults) > 0 || cs.RunState == RunStatePaused.String() {
+		return StatusDegraded
+	}
+	return StatusOperational
+}
+
+// chainRejectsAllRequests returns true if every request to the chain times out or fails
+func chainRejectsAllRequests(name string) bool {
+	if name == "solana" {
+		return solanaNode.ResponseTimeout > 0
+	}
+	chain := supportedChains[name]
+	if chain.ResponseTimeout > 0 {
+		return true
+	}
+	for _, config := range chain.ErrorConfigs {
+		if config.Probability >= 1 && len(config.Methods) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// currentStatusPage builds the status page, applying overrides unless actual is set
+func currentStatusPage(actual bool) StatusPage {
+	state := currentState()
+	page := StatusPage{Status: StatusOperational, UpdatedAt: time.Now().UTC(), Chains: []ChainStatus{}}
+
+	statusOverrides.RLock()
+	defer statusOverrides.RUnlock()
+	for _, cs := range state.Chains {
+		status := actualStatus(cs, state.ConnectionsBlocked)
+		if override, ok := statusOverrides.byChain[cs.Chain]; ok && !actual {
+			status = override
+		}
+		page.Chains = append(page.Chains, ChainStatus{Chain: cs.Chain, ChainID: cs.ChainID, Status: status})
+		if statusRank(status) > statusRank(page.Status) {
+			page.Status = status
+		}
+	}
+	return page
+}
+
+// handleStatusPage serves GET /api/status, a provider-style status page
+func handleStatusPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	jsonResponse(w, http.StatusOK, currentStatusPage(false))
+}
+
+// handleStatusOverride reports the actual statuses and overrides (GET) or sets an override
+// (POST); an empty chain applies to all chains and an empty status removes the override
+func handleStatusOverride(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		statusOverrides.RLock()
+		overrides := make(map[string]string, len(statusOverrides.byChain))
+		for chain, status := range statusOverrides.byChain {
+			overrides[chain] = status
+		}
+		statusOverrides.RUnlock()
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"actual":    currentStatusPage(true),
+			"overrides": overrides,
+		})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain  string `json:"chain"`
+		Status string `json:"status"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Status != "" && statusRank(request.Status) < 0 {
+		http.Error(w, fmt.Sprintf("Invalid status: %s (supported: %v)", request.Status, ChainStatuses), http.StatusBadRequest)
+		return
+	}
+
+	chains := []string{request.Chain}
+	if request.Chain == "" {
+		chains = allChainNames()
+	} else if getChain(request.Chain) == nil {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+
+	statusOverrides.Lock()
+	for _, chain := range chains {
+		if request.Status == "" {
+			delete(statusOverrides.byChain, chain)
+		} else {
+			statusOverrides.byChain[chain] = request.Status
+		}
+	}
+	statusOverrides.Unlock()
+
+	if request.Status == "" {
+		log.Printf("Cleared status page overrides for %v", chains)
+	} else {
+		log.Printf("Status page reports %s for %v", request.Status, chains)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func fetchStatusPage(t *testing.T) map[string]string {
+	t.Helper()
+	mux, _ := newServeMuxes(true)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on the RPC port, got %d", w.Code)
+	}
+	var page StatusPage
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode status page: %v", err)
+	}
+	statuses := map[string]string{"": page.Status}
+	for _, chain := range page.Chains {
+		statuses[chain.Chain] = chain.Status
+	}
+	return statuses
+}
+
+func setStatusOverride(t *testing.T, body string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleStatusOverride(w, httptest.NewRequest(http.MethodPost, "/control/status", bytes.NewBufferString(body)))
+	return w.Code
+}
+
+func TestStatusPageDerivedFromFaults(t *testing.T) {
+	polygon, gnosis := supportedChains["polygon"], supportedChains["gnosis"]
+	defer func() {
+		polygon.IDMangleMode = ""
+		gnosis.ResponseTimeout = 0
+	}()
+
+	if statuses := fetchStatusPage(t); statuses["polygon"] != StatusOperational || statuses["gnosis"] != StatusOperational {
+		t.Fatalf("Expected operational chains without faults, got %v", statuses)
+	}
+
+	polygon.IDMangleMode = IDMangleStringify
+	gnosis.ResponseTimeout = time.Minute
+	statuses := fetchStatusPage(t)
+	if statuses["polygon"] != StatusDegraded || statuses["gnosis"] != StatusDown || statuses[""] != StatusDown {
+		t.Errorf("Expected polygon degraded, gnosis and the page down, got %v", statuses)
+	}
+	if statuses["ethereum"] != StatusOperational {
+		t.Errorf("Expected unaffected chains to stay operational, got %s", statuses["ethereum"])
+	}
+}
+
+func TestStatusPageOverrides(t *testing.T) {
+	gnosis := supportedChains["gnosis"]
+	defer func() {
+		gnosis.ResponseTimeout = 0
+		setStatusOverride(t, `{"status": ""}`)
+	}()
+	gnosis.ResponseTimeout = time.Minute
+
+	// The page lies about the outage
+	if code := setStatusOverride(t, `{"chain": "gnosis", "status": "operational"}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if statuses := fetchStatusPage(t); statuses["gnosis"] != StatusOperational {
+		t.Errorf("Expected the override to report operational, got %s", statuses["gnosis"])
+	}
+
+	// The control endpoint still tells the truth
+	w := httptest.NewRecorder()
+	handleStatusOverride(w, httptest.NewRequest(http.MethodGet, "/control/status", nil))
+	var report struct {
+		Actual    StatusPage        `json:"actual"`
+		Overrides map[string]string `json:"overrides"`
+	}
+	json.NewDecoder(w.Body).Decode(&report)
+	if report.Overrides["gnosis"] != StatusOperational || report.Actual.Status != StatusDown {
+		t.Errorf("Unexpected override report: %+v", report)
+	}
+
+	setStatusOverride(t, `{"status": "down"}`)
+	if statuses := fetchStatusPage(t); statuses["ethereum"] != StatusDown || statuses["solana"] != StatusDown {
+		t.Errorf("Expected all chains reported down, got %v", statuses)
+	}
+	setStatusOverride(t, `{"status": ""}`)
+	if statuses := fetchStatusPage(t); statuses["gnosis"] != StatusDown || statuses["ethereum"] != StatusOperational {
+		t.Errorf("Expected actual statuses after clearing overrides, got %v", statuses)
+	}
+
+	if code := setStatusOverride(t, `{"chain": "gnosis", "status": "maintenance"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid status, got %d", code)
+	}
+	if code := setStatusOverride(t, `{"chain": "unknown", "status": "down"}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown chain, got %d", code)
+	}
+}
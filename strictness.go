@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Protocol strictness levels controlling how rigorously JSON-RPC 2.0 is enforced
+const (
+	StrictnessDefault = ""        // Require jsonrpc "2.0", otherwise forgiving
+	StrictnessStrict  = "strict"  // Enforce the JSON-RPC 2.0 specification rigorously
+	StrictnessLenient = "lenient" // Mirror forgiving providers that accept sloppy requests
+)
+
+// StrictnessLevels lists the supported protocol strictness levels
+var StrictnessLevels = []string{StrictnessDefault, StrictnessStrict, StrictnessLenient}
+
+// isValidStrictness returns true if the level is one of StrictnessLevels
+func isValidStrictness(level string) bool {
+	for _, l := range StrictnessLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// strictnessConn is implemented by connections that carry a per-connection strictness override
+type strictnessConn interface {
+	Strictness() string
+}
+
+// strictnessOverrideConn attaches a per-connection strictness override to a WSConn
+type strictnessOverrideConn struct {
+	WSConn
+	strictness string
+}
+
+func (c *strictnessOverrideConn) Strictness() string {
+	return c.strictness
+}
+
+// connStrictness returns the connection's strictness override, or the chain level if none is set
+func connStrictness(conn WSConn, chainLevel string) string {
+	if sc, ok := conn.(strictnessConn); ok && sc.Strictness() != "" {
+		return sc.Strictness()
+	}
+	return chainLevel
+}
+
+// parseRequest decodes a JSON-RPC request according to the given strictness level.
+// The request id is populated whenever it can be recovered so errors can echo it.
+func parseRequest(message []byte, strictness string, request *JSONRPCRequest) *RPCError {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(message, &fields); err != nil {
+		log.Printf("Error unmarshalling message: %s", err)
+		log.Printf("Message: %s", string(message))
+		return &RPCError{Code: -32700, Message: "Parse error"}
+	}
+
+	if id, ok := fields["id"]; ok && isValidIDValue(id) {
+		request.ID = id
+	}
+
+	switch strictness {
+	case StrictnessStrict:
+		if rpcErr := validateStrictRequest(fields); rpcErr != nil {
+			return rpcErr
+		}
+		// By-name params are passed to the method as its only positional argument
+		if params, ok := fields["params"]; ok && jsonType(params) == "object" {
+			fields["params"] = json.RawMessage("[" + string(params) + "]")
+			message, _ = json.Marshal(fields)
+		}
+	case StrictnessLenient:
+		// Forgiving providers accept any jsonrpc value and non-array params
+		fields["jsonrpc"] = json.RawMessage(`"2.0"`)
+		if params, ok := fields["params"]; ok && jsonType(params) != "array" {
+			if jsonType(params) == "null" {
+				delete(fields, "params")
+			} else {
+				fields["params"] = json.RawMessage("[" + string(params) + "]")
+			}
+		}
+		if id, ok := fields["id"]; ok && !isValidIDValue(id) {
+			delete(fields, "id")
+		}
+		message, _ = json.Marshal(fields)
+	}
+
+	if err := json.Unmarshal(message, request); err != nil {
+		log.Printf("Error unmarshalling message: %s", err)
+		log.Printf("Message: %s", string(message))
+		return &RPCError{Code: -32700, Message: "Parse error"}
+	}
+
+	// Validate JSON-RPC version
+	if request.JsonRPC != "2.0" {
+		return &RPCError{Code: -32600, Message: "Invalid Request"}
+	}
+
+	return nil
+}
+
+// validateStrictRequest rejects anything the JSON-RPC 2.0 specification does not allow
+func validateStrictRequest(fields map[string]json.RawMessage) *RPCError {
+	invalid := func(data string) *RPCError {
+		return &RPCError{Code: -32600, Message: "Invalid Request", Data: data}
+	}
+
+	version, ok := fields["jsonrpc"]
+	if !ok {
+		return invalid("missing jsonrpc member")
+	}
+	if string(version) != `"2.0"` {
+		return invalid(`jsonrpc member must be exactly "2.0"`)
+	}
+
+	method, ok := fields["method"]
+	if !ok || jsonType(method) != "string" {
+		return invalid("method member must be a string")
+	}
+
+	if id, ok := fields["id"]; ok {
+		if !isValidIDValue(id) {
+			return invalid("id member must be a string or number")
+		}
+		if jsonType(id) == "null" {
+			return invalid("id member must not be null")
+		}
+	}
+
+	if params, ok := fields["params"]; ok {
+		switch jsonType(params) {
+		case "array", "object":
+		default:
+			return invalid("params member must be an array or object")
+		}
+	}
+
+	var unknown []string
+	for key := range fields {
+		switch key {
+		case "jsonrpc", "method", "params", "id":
+		default:
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return invalid(fmt.Sprintf("unknown members: %v", unknown))
+	}
+
+	return nil
+}
+
+// isStrictNotification returns true if the message is a valid JSON-RPC 2.0 notification under
+// strict mode: a request without an id member, which the specification says gets no response
+func isStrictNotification(message []byte, strictness string) bool {
+	if strictness != StrictnessStrict {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(message, &fields); err != nil {
+		return false
+	}
+	_, hasID := fields["id"]
+	return !hasID && validateStrictRequest(fields) == nil
+}
+
+// chainStrictness returns the protocol strictness level configured for a chain
+func chainStrictness(chainId string) string {
+	if chainId == "501" {
+		return solanaNode.ProtocolStrictness
+	}
+	if chain, ok := supportedChains[chainIdToName[chainId]]; ok {
+		return chain.ProtocolStrictness
+	}
+	return StrictnessDefault
+}
+
+// isValidIDValue returns true if the raw JSON is a string, number or null
+func isValidIDValue(raw json.RawMessage) bool {
+	switch jsonType(raw) {
+	case "string", "number", "null":
+		return true
+	}
+	return false
+}
+
+// jsonType returns the JSON type of a raw value
+func jsonType(raw json.RawMessage) string {
+	for _, c := range raw {
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '{':
+			return "object"
+		case '[':
+			return "array"
+		case '"':
+			return "string"
+		case 't', 'f':
+			return "boolean"
+		case 'n':
+			return "null"
+		default:
+			return "number"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProtocolStrictness(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	defer func() { chain.ProtocolStrictness = StrictnessDefault }()
+
+	tests := []struct {
+		name         string
+		strictness   string
+		message      string
+		expectedCode int // 0 means success
+	}{
+		{"default accepts valid request", StrictnessDefault, `{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`, 0},
+		{"default rejects missing jsonrpc", StrictnessDefault, `{"method":"eth_chainId","id":1}`, -32600},
+		{"default tolerates unknown members", StrictnessDefault, `{"jsonrpc":"2.0","method":"eth_chainId","id":1,"extra":true}`, 0},
+		{"strict accepts valid request", StrictnessStrict, `{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`, 0},
+		{"strict rejects missing jsonrpc", StrictnessStrict, `{"method":"eth_chainId","id":1}`, -32600},
+		{"strict rejects wrong jsonrpc", StrictnessStrict, `{"jsonrpc":2.0,"method":"eth_chainId","id":1}`, -32600},
+		{"strict rejects null id", StrictnessStrict, `{"jsonrpc":"2.0","method":"eth_chainId","id":null}`, -32600},
+		{"strict rejects object id", StrictnessStrict, `{"jsonrpc":"2.0","method":"eth_chainId","id":{}}`, -32600},
+		{"strict rejects scalar params", StrictnessStrict, `{"jsonrpc":"2.0","method":"eth_chainId","params":"x","id":1}`, -32600},
+		{"strict accepts by-name params", StrictnessStrict, `{"jsonrpc":"2.0","method":"eth_chainId","params":{},"id":1}`, 0},
+		{"strict rejects unknown members", StrictnessStrict, `{"jsonrpc":"2.0","method":"eth_chainId","id":1,"extra":true}`, -32600},
+		{"lenient accepts missing jsonrpc", StrictnessLenient, `{"method":"eth_chainId","id":1}`, 0},
+		{"lenient accepts wrong jsonrpc", StrictnessLenient, `{"jsonrpc":"1.0","method":"eth_chainId","id":1}`, 0},
+		{"lenient wraps scalar params", StrictnessLenient, `{"jsonrpc":"2.0","method":"eth_getBlockByNumber","params":"latest","id":1}`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain.ProtocolStrictness = tt.strictness
+			response, err := handleEVMRequest(context.Background(), []byte(tt.message), NewMockWSConn(), "1")
+			if err != nil {
+				t.Fatalf("Handler error: %v", err)
+			}
+
+			var resp JSONRPCResponse
+			if err := json.Unmarshal(response, &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if tt.expectedCode == 0 {
+				if resp.Error != nil {
+					t.Errorf("Expected success, got error %d: %s", resp.Error.Code, resp.Error.Message)
+				}
+				return
+			}
+			if resp.Error == nil {
+				t.Fatalf("Expected error %d, got result %v", tt.expectedCode, resp.Result)
+			}
+			if resp.Error.Code != tt.expectedCode {
+				t.Errorf("Expected error code %d, got %d", tt.expectedCode, resp.Error.Code)
+			}
+		})
+	}
+}
+
+func TestProtocolStrictnessConnectionOverride(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	chain.ProtocolStrictness = StrictnessStrict
+	defer func() { chain.ProtocolStrictness = StrictnessDefault }()
+
+	conn := &strictnessOverrideConn{WSConn: NewMockWSConn(), strictness: StrictnessLenient}
+	response, err := handleEVMRequest(context.Background(), []byte(`{"method":"eth_chainId","id":1}`), conn, "1")
+	if err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+
+	var resp JSONRPCResponse
+	if err := json.Unmarshal(response, &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Error != nil {
+		t.Errorf("Expected connection-level lenient mode to override strict chain, got error: %s", resp.Error.Message)
+	}
+}
+
+func TestStrictNotificationsGetNoResponse(t *testing.T) {
+	chain := supportedChains["ethereum"]
+	chain.ProtocolStrictness = StrictnessStrict
+	defer func() { chain.ProtocolStrictness = StrictnessDefault }()
+
+	response, err := handleRPCRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[]}`), NewMockWSConn(), "1")
+	if err != nil || response != nil {
+		t.Errorf("Expected no response to a notification, got %s (%v)", response, err)
+	}
+
+	// An invalid notification is still answered with an error
+	response, _ = handleRPCRequest(context.Background(), []byte(`{"jsonrpc":"1.0","method":"eth_chainId"}`), NewMockWSConn(), "1")
+	var resp JSONRPCResponse
+	if err := json.Unmarshal(response, &resp); err != nil || resp.Error == nil || resp.Error.Code != -32600 {
+		t.Errorf("Expected an invalid request error, got %s", response)
+	}
+
+	w := httptest.NewRecorder()
+	handleChainHTTP(w, httptest.NewRequest(http.MethodPost, "/chain/1", bytes.NewBufferString(`{"jsonrpc":"2.0","method":"eth_blockNumber"}`)))
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("Expected 204 without a body over HTTP, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Other levels answer requests without an id as before
+	chain.ProtocolStrictness = StrictnessDefault
+	if response, _ := handleRPCRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[]}`), NewMockWSConn(), "1"); response == nil {
+		t.Error("Expected a response outside strict mode")
+	}
+}
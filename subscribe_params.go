@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// subscriptionOptionFields lists the option fields accepted per subscription type in strict mode
+var subscriptionOptionFields = map[string][]string{
+	"newHeads": {"includeTransactions"},
+	"logs":     {"address", "topics"},
+}
+
+// validateStrictSubscribeParams rejects eth_subscribe params a strict node would refuse:
+// more than one options argument, or option fields the subscription type does not know.
+// Without strict params, extra arguments and unknown fields are ignored.
+func validateStrictSubscribeParams(subscriptionType string, params []interface{}) error {
+	if len(params) > 2 {
+		return fmt.Errorf("too many arguments, want at most 2")
+	}
+	if len(params) < 2 {
+		return nil
+	}
+	options, ok := params[1].(map[string]interface{})
+	if !ok {
+		return nil // Non-object options are rejected regardless of strictness
+	}
+
+	fields := make([]string, 0, len(options))
+	for field := range options {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if !containsString(subscriptionOptionFields[subscriptionType], field) {
+			return fmt.Errorf("unknown field %q in %s subscription options", field, subscriptionType)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSetSubscribeParams toggles strict eth_subscribe parameter validation for a chain
+func handleSetSubscribeParams(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain  string `json:"chain"`
+		Strict bool   `json:"strict"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if chain, ok := supportedChains[request.Chain]; ok {
+		chain.StrictSubscribeParams = request.Strict
+		log.Printf("Set strict subscription params=%t for chain %s", request.Strict, request.Chain)
+		persistChainConfig()
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	} else {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStrictSubscribeParams(t *testing.T) {
+	withTempConfig(t)
+	defer func() {
+		supportedChains["ethereum"].StrictSubscribeParams = false
+	}()
+
+	subscribe := func(params string) *RPCError {
+		conn := NewMockWSConn()
+		defer subManager.CleanupConnection(conn)
+		response, err := handleEVMRequest(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_subscribe","params":`+params+`,"id":1}`), conn, "1")
+		if err != nil {
+			t.Fatalf("Handler error: %v", err)
+		}
+		var resp JSONRPCResponse
+		json.Unmarshal(response, &resp)
+		return resp.Error
+	}
+	setStrict := func(strict bool) {
+		body, _ := json.Marshal(map[string]interface{}{"chain": "ethereum", "strict": strict})
+		w := httptest.NewRecorder()
+		handleSetSubscribeParams(w, httptest.NewRequest(http.MethodPost, "/control/chain/subscribe-params", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+	}
+
+	extra := []string{
+		`["newHeads", {}, "extra"]`,
+		`["newHeads", {"includeTransactions": false, "fullTx": true}]`,
+		`["logs", {"address": "0x0000000000000000000000000000000000000001", "fromBlock": "latest"}]`,
+	}
+	standard := []string{
+		`["newHeads"]`,
+		`["newHeads", {"includeTransactions": true}]`,
+		`["logs", {"address": "0x0000000000000000000000000000000000000001", "topics": []}]`,
+	}
+
+	// Lenient by default: extra params are ignored
+	for _, params := range append(extra, standard...) {
+		if rpcErr := subscribe(params); rpcErr != nil {
+			t.Errorf("%s: expected the subscription to succeed, got %s", params, rpcErr.Message)
+		}
+	}
+
+	setStrict(true)
+	for _, params := range extra {
+		if rpcErr := subscribe(params); rpcErr == nil || rpcErr.Code != -32602 {
+			t.Errorf("%s: expected -32602 in strict mode, got %+v", params, rpcErr)
+		}
+	}
+	for _, params := range standard {
+		if rpcErr := subscribe(params); rpcErr != nil {
+			t.Errorf("%s: expected standard params to pass in strict mode, got %s", params, rpcErr.Message)
+		}
+	}
+
+	setStrict(false)
+	if rpcErr := subscribe(extra[0]); rpcErr != nil {
+		t.Errorf("Expected lenient params again, got %s", rpcErr.Message)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Outcomes of a subscription request racing a connection drop
+const (
+	SubscribeRaceCleanup = "cleanup" // The subscription id is returned, but the subscription is cleaned up right away
+	SubscribeRaceError   = "error"   // The request fails with errSubscribeDuringDrop
+)
+
+// errSubscribeDuringDrop is returned for subscriptions whose connection was dropped while subscribing
+var errSubscribeDuringDrop = errors.New("connection closed while subscribing")
+
+// subscribeRaceHook runs in Subscribe before the subscription is registered, so tests can
+// drop connections at exactly that point; nil outside tests
+var subscribeRaceHook func(conn WSConn)
+
+// subscribeRace decides how subscriptions racing a connection drop end, and counts which
+// outcome occurred. Subscriptions and drops take the chain's shard lock, so a subscription
+// registered first is dropped with the connection, and one registered after the drop gets
+// the configured outcome instead of lingering on a closed connection. Runtime only.
+var subscribeRace = struct {
+	sync.Mutex
+	policy       string
+	armed        map[string]bool // Chain IDs whose next subscription races a drop
+	pendingDrops map[WSConn]bool // Connections to drop once the raced response is written
+	cleanedUp    uint64          // (atomic)
+	errored      uint64          // (atomic)
+}{policy: SubscribeRaceCleanup, armed: make(map[string]bool), pendingDrops: make(map[WSConn]bool)}
+
+// triggerSubscribeRace consumes the chain's armed race, if any, and returns whether this
+// subscription races a drop. A WebSocket connection is dropped after the response is written.
+func triggerSubscribeRace(chainId string, conn WSConn) bool {
+	subscribeRace.Lock()
+	defer subscribeRace.Unlock()
+	if !subscribeRace.armed[chainId] {
+		return false
+	}
+	delete(subscribeRace.armed, chainId)
+	if _, ok := conn.(*wsConnWrapper); ok {
+		subscribeRace.pendingDrops[conn] = true
+	}
+	return true
+}
+
+// resolveSubscribeRace counts and returns the outcome of a subscription that raced a drop
+func resolveSubscribeRace(chainId, method string) string {
+	subscribeRace.Lock()
+	policy := subscribeRace.policy
+	subscribeRace.Unlock()
+	if policy == SubscribeRaceError {
+		atomic.AddUint64(&subscribeRace.errored, 1)
+	} else {
+		atomic.AddUint64(&subscribeRace.cleanedUp, 1)
+	}
+	log.Printf("Subscription raced a connection drop: Type=%s, Method=%s, outcome=%s", chainId, method, policy)
+	return policy
+}
+
+// dropAfterResponse returns true once for a connection whose raced subscription response
+// has been written, and the connection should now be dropped
+func dropAfterResponse(conn WSConn) bool {
+	subscribeRace.Lock()
+	defer subscribeRace.Unlock()
+	if !subscribeRace.pendingDrops[conn] {
+		return false
+	}
+	delete(subscribeRace.pendingDrops, conn)
+	return true
+}
+
+// handleSubscribeRace returns the policy and outcome counts (GET), or sets the policy, arms
+// a race for a chain's next subscription and resets the counts (POST)
+func handleSubscribeRace(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		subscribeRace.Lock()
+		armed := []string{}
+		for chainId := range subscribeRace.armed {
+			armed = append(armed, chainIdToName[chainId])
+		}
+		policy := subscribeRace.policy
+		subscribeRace.Unlock()
+		sort.Strings(armed)
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"policy": policy,
+			"armed":  armed,
+			"outcomes": map[string]uint64{
+				SubscribeRaceCleanup: atomic.LoadUint64(&subscribeRace.cleanedUp),
+				SubscribeRaceError:   atomic.LoadUint64(&subscribeRace.errored),
+			},
+		})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Policy string `json:"policy"` // cleanup or error; empty keeps the current policy
+		Arm    string `json:"arm"`    // Chain whose next subscription races a drop of its connection
+		Reset  bool   `json:"reset"`  // Clear the outcome counts and armed races
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Policy != "" && request.Policy != SubscribeRaceCleanup && request.Policy != SubscribeRaceError {
+		http.Error(w, fmt.Sprintf("Invalid policy: %s (supported: %s, %s)", request.Policy, SubscribeRaceCleanup, SubscribeRaceError), http.StatusBadRequest)
+		return
+	}
+	chainId := ""
+	if request.Arm != "" {
+		chainId = chainIDForName(request.Arm)
+		if chainId == "" || getChain(request.Arm) == nil {
+			http.Error(w, "Chain not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	subscribeRace.Lock()
+	if request.Reset {
+		subscribeRace.armed = make(map[string]bool)
+		atomic.StoreUint64(&subscribeRace.cleanedUp, 0)
+		atomic.StoreUint64(&subscribeRace.errored, 0)
+	}
+	if request.Policy != "" {
+		subscribeRace.policy = request.Policy
+	}
+	if chainId != "" {
+		subscribeRace.armed[chainId] = true
+	}
+	policy := subscribeRace.policy
+	subscribeRace.Unlock()
+
+	log.Printf("Subscribe race: policy=%s, armed=%q, reset=%v", policy, request.Arm, request.Reset)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
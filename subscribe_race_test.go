@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func setSubscribeRace(t *testing.T, body string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleSubscribeRace(w, httptest.NewRequest(http.MethodPost, "/control/connections/subscribe-race", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: expected status 200, got %d", body, w.Code)
+	}
+}
+
+func connSubscriptionCount(conn WSConn) int {
+	return len(subManager.ConnectionSubscriptions(conn))
+}
+
+func TestSubscribeDuringDropOrdering(t *testing.T) {
+	defer func() {
+		subscribeRaceHook = nil
+		setSubscribeRace(t, `{"policy": "cleanup", "reset": true}`)
+	}()
+	setSubscribeRace(t, `{"policy": "cleanup", "reset": true}`)
+
+	// Subscribed before the drop: the drop removes the subscription with the connection
+	conn := NewMockWSConn()
+	if _, err := subManager.Subscribe("100", conn, "newHeads"); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	subManager.DropAllConnections()
+	if !conn.IsClosed() || connSubscriptionCount(conn) != 0 {
+		t.Error("Expected the drop to close the connection and remove its subscription")
+	}
+
+	// Subscribed after the drop: the id is returned, but nothing lingers on the closed connection
+	conn = NewMockWSConn()
+	if _, err := subManager.Subscribe("100", conn, "newHeads"); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	subscribeRaceHook = func(WSConn) { subManager.DropAllConnections() }
+	id, err := subManager.Subscribe("100", conn, "logs")
+	if err != nil || id == 0 {
+		t.Errorf("Expected a subscription id with the cleanup policy, got %d, %v", id, err)
+	}
+	if connSubscriptionCount(conn) != 0 {
+		t.Error("Expected the raced subscription to be cleaned up immediately")
+	}
+
+	// With the error policy the request fails cleanly instead
+	setSubscribeRace(t, `{"policy": "error"}`)
+	subscribeRaceHook = nil
+	conn = NewMockWSConn()
+	if _, err := subManager.Subscribe("100", conn, "newHeads"); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	subscribeRaceHook = func(WSConn) { subManager.DropAllConnections() }
+	if _, err := subManager.Subscribe("100", conn, "logs"); err != errSubscribeDuringDrop {
+		t.Errorf("Expected errSubscribeDuringDrop, got %v", err)
+	}
+	if connSubscriptionCount(conn) != 0 {
+		t.Error("Expected no subscription on the dropped connection")
+	}
+
+	if subscribeRace.cleanedUp != 1 || subscribeRace.errored != 1 {
+		t.Errorf("Expected one outcome of each kind, got cleanup=%d error=%d", subscribeRace.cleanedUp, subscribeRace.errored)
+	}
+}
+
+func TestArmedSubscribeRaceOverWebSocket(t *testing.T) {
+	defer setSubscribeRace(t, `{"policy": "cleanup", "reset": true}`)
+	setSubscribeRace(t, `{"reset": true}`)
+
+	server := httptest.NewServer(http.HandlerFunc(handleChainWebSocket))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/chain/100"
+
+	// subscribe sends eth_subscribe on a new connection, returning the response and
+	// whether the connection was dropped afterwards
+	subscribe := func() (JSONRPCResponse, bool) {
+		client, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer client.Close()
+		client.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+		client.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"],"id":1}`))
+		var response JSONRPCResponse
+		if err := client.ReadJSON(&response); err != nil {
+			t.Fatalf("Expected a response before the drop: %v", err)
+		}
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				netErr, timedOut := err.(net.Error)
+				return response, !timedOut || !netErr.Timeout()
+			}
+		}
+	}
+
+	setSubscribeRace(t, `{"policy": "cleanup", "arm": "gnosis"}`)
+	if response, dropped := subscribe(); response.Error != nil || response.Result == nil || !dropped {
+		t.Errorf("Expected a subscription id followed by a drop, got %+v (dropped: %v)", response, dropped)
+	}
+
+	setSubscribeRace(t, `{"policy": "error", "arm": "gnosis"}`)
+	if response, dropped := subscribe(); response.Error == nil || !dropped {
+		t.Errorf("Expected an error followed by a drop, got %+v (dropped: %v)", response, dropped)
+	}
+
+	w := httptest.NewRecorder()
+	handleSubscribeRace(w, httptest.NewRequest(http.MethodGet, "/control/connections/subscribe-race", nil))
+	if body := w.Body.String(); !strings.Contains(body, `"cleanup":1`) || !strings.Contains(body, `"error":1`) || !strings.Contains(body, `"armed":[]`) {
+		t.Errorf("Expected one outcome of each kind and no armed race, got %s", body)
+	}
+}
+
+func TestSubscribeRaceValidation(t *testing.T) {
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"policy": "ignore"}`, http.StatusBadRequest},
+		{`{"arm": "unknown"}`, http.StatusNotFound},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handleSubscribeRace(w, httptest.NewRequest(http.MethodPost, "/control/connections/subscribe-race", bytes.NewBufferString(tt.body)))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.code, w.Code)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
@@ -13,8 +14,6 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
 type Subscription struct {
@@ -22,30 +21,199 @@ type Subscription struct {
 	Type   string
 	Conn   WSConn
 	Method string
+
+	inGrace   bool      // Unsubscribed but still receiving notifications during the grace period
+	notBefore time.Time // No notifications are delivered before this time (slow subscription start)
+	mentions  string    // Solana logsSubscribe address filter (empty = all)
+	history   *notificationHistory
 }
 
-type SubscriptionManager struct {
+// firstNotificationDelay returns how long a new subscription on a chain waits for its first notification
+func firstNotificationDelay(chainId string) time.Duration {
+	if chainId == "501" {
+		return solanaNode.FirstNotificationDelay
+	}
+	if chain, ok := supportedChains[chainIdToName[chainId]]; ok {
+		return chain.FirstNotificationDelay
+	}
+	return 0
+}
+
+// attached returns false while a new subscription is still withholding notifications
+func (s *Subscription) attached(now time.Time) bool {
+	return !now.Before(s.notBefore)
+}
+
+// graceSubscription is an unsubscribed Solana subscription that keeps receiving notifications until it expires
+type graceSubscription struct {
+	sub     *Subscription
+	expires time.Time
+}
+
+// broadcastQueueSize is how many pending fan-outs a chain's broadcast worker buffers; further
+// head fan-outs are dropped, other producers block
+const broadcastQueueSize = 1024
+
+// subscriptionShard holds the subscriptions of one chain behind its own lock, so
+// chains don't contend with each other, and runs the chain's notification fan-outs
+// in order on a dedicated broadcast worker
+type subscriptionShard struct {
 	mu            sync.RWMutex
 	subscriptions map[uint64]*Subscription
-	nextSubID     uint64
+	jobs          chan func()     // Pending fan-outs for the broadcast worker
+	dropped       map[WSConn]bool // Connections closed by DropAllConnections, until CleanupConnection
+
+	// Solana subscription id reuse simulation (Solana shard only)
+	reuseIDs         bool                // Hand out freed subscription ids again, smallest first
+	freeIDs          []uint64            // Freed subscription ids available for reuse
+	unsubscribeGrace time.Duration       // How long notifications keep arriving after an unsubscribe
+	graceSubs        []graceSubscription // Unsubscribed subscriptions still within the grace period
+}
+
+// runBroadcastWorker runs the shard's fan-outs one at a time for the lifetime of the process
+func (s *subscriptionShard) runBroadcastWorker() {
+	for job := range s.jobs {
+		job()
+	}
+}
+
+type SubscriptionManager struct {
+	shardsMu  sync.RWMutex
+	shards    map[string]*subscriptionShard // Chain ID -> shard
+	nextSubID uint64                        // Subscription ids are unique across all shards
 }
 
 func NewSubscriptionManager() *SubscriptionManager {
 	return &SubscriptionManager{
+		shards: make(map[string]*subscriptionShard),
+	}
+}
+
+// shard returns the shard of a chain, creating it and starting its broadcast worker on first use
+func (sm *SubscriptionManager) shard(chainId string) *subscriptionShard {
+	sm.shardsMu.RLock()
+	shard, ok := sm.shards[chainId]
+	sm.shardsMu.RUnlock()
+	if ok {
+		return shard
+	}
+
+	sm.shardsMu.Lock()
+	defer sm.shardsMu.Unlock()
+	if shard, ok := sm.shards[chainId]; ok {
+		return shard
+	}
+	shard = &subscriptionShard{
 		subscriptions: make(map[uint64]*Subscription),
+		jobs:          make(chan func(), broadcastQueueSize),
+		dropped:       make(map[WSConn]bool),
+	}
+	sm.shards[chainId] = shard
+	go shard.runBroadcastWorker()
+	return shard
+}
+
+// allShards returns a snapshot of the existing shards
+func (sm *SubscriptionManager) allShards() []*subscriptionShard {
+	sm.shardsMu.RLock()
+	defer sm.shardsMu.RUnlock()
+
+	shards := make([]*subscriptionShard, 0, len(sm.shards))
+	for _, shard := range sm.shards {
+		shards = append(shards, shard)
+	}
+	return shards
+}
+
+// enqueue hands a fan-out to the chain's broadcast worker, after delay if one is set
+func (sm *SubscriptionManager) enqueue(chainId string, delay time.Duration, job func()) {
+	shard := sm.shard(chainId)
+	if delay > 0 {
+		time.AfterFunc(delay, func() {
+			shard.jobs <- job
+		})
+		return
+	}
+	shard.jobs <- job
+}
+
+// enqueueOrDrop hands a fan-out to the chain's broadcast worker like enqueue, but calls
+// dropped instead of blocking when the worker's queue is full
+func (sm *SubscriptionManager) enqueueOrDrop(chainId string, delay time.Duration, job func(), dropped func()) {
+	shard := sm.shard(chainId)
+	send := func() {
+		select {
+		case shard.jobs <- job:
+		default:
+			dropped()
+		}
+	}
+	if delay > 0 {
+		time.AfterFunc(delay, send)
+		return
 	}
+	send()
+}
+
+// Flush waits until the chain's broadcast worker has delivered every notification queued so far
+func (sm *SubscriptionManager) Flush(chainId string) {
+	done := make(chan struct{})
+	sm.shard(chainId).jobs <- func() { close(done) }
+	<-done
+}
+
+// Count returns the number of active subscriptions across all chains
+func (sm *SubscriptionManager) Count() int {
+	count := 0
+	for _, shard := range sm.allShards() {
+		shard.mu.RLock()
+		count += len(shard.subscriptions)
+		shard.mu.RUnlock()
+	}
+	return count
 }
 
 func (sm *SubscriptionManager) Subscribe(subType string, conn WSConn, method string) (uint64, error) {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	if subscribeRaceHook != nil {
+		subscribeRaceHook(conn)
+	}
+	raced := triggerSubscribeRace(subType, conn)
+
+	shard := sm.shard(subType)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// Drops close connections under the shard lock, so a dropped connection here lost the race
+	closed := !raced && shard.dropped[conn]
+	if (raced || closed) && resolveSubscribeRace(subType, method) == SubscribeRaceError {
+		return 0, errSubscribeDuringDrop
+	}
 
-	id := atomic.AddUint64(&sm.nextSubID, 1)
-	sm.subscriptions[id] = &Subscription{
-		ID:     id,
-		Type:   subType,
-		Conn:   conn,
-		Method: method,
+	var id uint64
+	if shard.reuseIDs && len(shard.freeIDs) > 0 {
+		// Reuse the smallest freed id, like real Solana nodes may do
+		sort.Slice(shard.freeIDs, func(i, j int) bool {
+			return shard.freeIDs[i] < shard.freeIDs[j]
+		})
+		id = shard.freeIDs[0]
+		shard.freeIDs = shard.freeIDs[1:]
+	} else {
+		id = atomic.AddUint64(&sm.nextSubID, 1)
+	}
+	if closed {
+		shard.releaseID(&Subscription{ID: id})
+		log.Printf("Subscription cleaned up on closed connection: ID=%d, Type=%s, Method=%s", id, subType, method)
+		return id, nil
+	}
+	shard.subscriptions[id] = &Subscription{
+		ID:      id,
+		Type:    subType,
+		Conn:    conn,
+		Method:  method,
+		history: &notificationHistory{},
+	}
+	if delay := firstNotificationDelay(subType); delay > 0 {
+		shard.subscriptions[id].notBefore = time.Now().Add(delay)
 	}
 
 	log.Printf("Created subscription: ID=%d, Type=%s, Method=%s", id, subType, method)
@@ -53,57 +221,141 @@ func (sm *SubscriptionManager) Subscribe(subType string, conn WSConn, method str
 }
 
 func (sm *SubscriptionManager) Unsubscribe(id uint64) error {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
 	log.Printf("Looking for subscription with ID: %d", id)
-	log.Printf("Current subscriptions: %d", len(sm.subscriptions))
 
-	sub, exists := sm.subscriptions[id]
-	if !exists {
-		log.Printf("Subscription %d not found", id)
-		return fmt.Errorf("subscription %d not found", id)
+	for _, shard := range sm.allShards() {
+		shard.mu.Lock()
+		sub, exists := shard.subscriptions[id]
+		if !exists {
+			shard.mu.Unlock()
+			continue
+		}
+
+		log.Printf("Found subscription: ID=%d, Type=%s, Method=%s", id, sub.Type, sub.Method)
+		delete(shard.subscriptions, id)
+		if shard.unsubscribeGrace > 0 {
+			graceSub := *sub
+			graceSub.inGrace = true
+			shard.graceSubs = append(shard.graceSubs, graceSubscription{
+				sub:     &graceSub,
+				expires: time.Now().Add(shard.unsubscribeGrace),
+			})
+		}
+		shard.releaseID(sub)
+		shard.mu.Unlock()
+		log.Printf("Subscription removed: ID=%d, Type=%s, Method=%s", id, sub.Type, sub.Method)
+		return nil
 	}
 
-	log.Printf("Found subscription: ID=%d, Type=%s, Method=%s", id, sub.Type, sub.Method)
-	delete(sm.subscriptions, id)
-	log.Printf("Subscription removed: ID=%d, Type=%s, Method=%s", id, sub.Type, sub.Method)
-	return nil
+	log.Printf("Subscription %d not found", id)
+	return fmt.Errorf("subscription %d not found", id)
+}
+
+// releaseID makes a subscription id available for reuse if reuse is enabled
+// Must be called with s.mu held
+func (s *subscriptionShard) releaseID(sub *Subscription) {
+	if s.reuseIDs {
+		s.freeIDs = append(s.freeIDs, sub.ID)
+	}
+}
+
+// SetSolanaIDReuse configures Solana subscription id reuse and the post-unsubscribe grace period
+func (sm *SubscriptionManager) SetSolanaIDReuse(enabled bool, grace time.Duration) {
+	shard := sm.shard("501")
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.reuseIDs = enabled
+	shard.unsubscribeGrace = grace
+	if !enabled {
+		shard.freeIDs = nil
+	}
+	if grace <= 0 {
+		shard.graceSubs = nil
+	}
+}
+
+// activeGraceSubscriptions returns unsubscribed subscriptions still within the grace period
+// Must be called with s.mu held
+func (s *subscriptionShard) activeGraceSubscriptions() []*Subscription {
+	now := time.Now()
+	active := s.graceSubs[:0]
+	subs := make([]*Subscription, 0, len(s.graceSubs))
+	for _, gs := range s.graceSubs {
+		if now.Before(gs.expires) {
+			active = append(active, gs)
+			subs = append(subs, gs.sub)
+		}
+	}
+	s.graceSubs = active
+	return subs
+}
+
+// compactGraceSubscriptions drops expired grace subscriptions and returns the number removed
+func (sm *SubscriptionManager) compactGraceSubscriptions() int {
+	removed := 0
+	for _, shard := range sm.allShards() {
+		shard.mu.Lock()
+		before := len(shard.graceSubs)
+		shard.activeGraceSubscriptions()
+		removed += before - len(shard.graceSubs)
+		shard.mu.Unlock()
+	}
+	return removed
+}
+
+// graceSubscriptionCount returns the number of grace subscriptions, including expired ones not yet compacted
+func (sm *SubscriptionManager) graceSubscriptionCount() int {
+	count := 0
+	for _, shard := range sm.allShards() {
+		shard.mu.RLock()
+		count += len(shard.graceSubs)
+		shard.mu.RUnlock()
+	}
+	return count
 }
 
 // CleanupConnection removes all subscriptions associated with a specific connection
 func (sm *SubscriptionManager) CleanupConnection(conn WSConn) int {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
 	count := 0
-	for id, sub := range sm.subscriptions {
-		if sub.Conn == conn {
-			delete(sm.subscriptions, id)
-			log.Printf("Subscription cleaned up on connection close: ID=%d, Type=%s, Method=%s", id, sub.Type, sub.Method)
-			count++
+	for _, shard := range sm.allShards() {
+		shard.mu.Lock()
+		delete(shard.dropped, conn)
+		for id, sub := range shard.subscriptions {
+			if sub.Conn == conn {
+				delete(shard.subscriptions, id)
+				shard.releaseID(sub)
+				log.Printf("Subscription cleaned up on connection close: ID=%d, Type=%s, Method=%s", id, sub.Type, sub.Method)
+				count++
+			}
 		}
+		shard.mu.Unlock()
 	}
 	return count
 }
 
 func (sm *SubscriptionManager) DropAllConnections() int {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
-	count := len(sm.subscriptions)
-	for id, sub := range sm.subscriptions {
-		log.Printf("Subscription dropped: ID=%d, Type=%s, Method=%s", id, sub.Type, sub.Method)
-		sub.Conn.Close()
+	count := 0
+	for _, shard := range sm.allShards() {
+		shard.mu.Lock()
+		count += len(shard.subscriptions)
+		for id, sub := range shard.subscriptions {
+			log.Printf("Subscription dropped: ID=%d, Type=%s, Method=%s", id, sub.Type, sub.Method)
+			sub.Conn.Close()
+			shard.dropped[sub.Conn] = true
+			shard.releaseID(sub)
+		}
+		shard.subscriptions = make(map[uint64]*Subscription)
+		shard.graceSubs = nil
+		shard.mu.Unlock()
 	}
-	sm.subscriptions = make(map[uint64]*Subscription)
 	return count
 }
 
 // generateBlockHashForSubscription creates a deterministic hash based on block number and chain ID
 func generateBlockHashForSubscription(blockNumber uint64, chainID string, seed string) string {
 	// Create a unique input combining block number, chain ID, and seed
-	input := fmt.Sprintf("%s-%d-%s", chainID, blockNumber, seed)
+	input := hashInput(fmt.Sprintf("%s-%d-%s", chainID, blockNumber, seed))
 	hash := sha256.Sum256([]byte(input))
 	return "0x" + hex.EncodeToString(hash[:])
 }
@@ -242,27 +494,89 @@ type Transaction struct {
 	S                string `json:"s"`
 }
 
+// BroadcastNewBlock notifies block subscribers of a chain, after the chain's
+// response-path latency if one is configured
 func (sm *SubscriptionManager) BroadcastNewBlock(chain string, blockNumber uint64) {
+	produced := time.Now()
+	markNewHead(chain)
+	delay := notificationLatency(chain)
+	sm.enqueueOrDrop(chain, delay, func() {
+		sm.broadcastNewBlock(chain, blockNumber, produced)
+		chainLoads.For(chain).recordFanOut(time.Since(produced) - delay)
+	}, func() {
+		headNotificationMetrics.For(chain).RecordDropped(sm.headSubscriberCount(chain))
+	})
+}
+
+// isHeadSubscription returns true for subscriptions notified of every new block
+func isHeadSubscription(sub *Subscription) bool {
+	switch sub.Method {
+	case "newHeads", "newHeadsWithTx", "logs", "slotNotification", "rootNotification":
+		return true
+	}
+	return false
+}
+
+// headSubscriberCount returns how many subscriptions a chain's next head would be sent to
+func (sm *SubscriptionManager) headSubscriberCount(chain string) int {
+	now := time.Now()
+	shard := sm.shard(chain)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	count := 0
+	for _, sub := range shard.subscriptions {
+		if sub.attached(now) && isHeadSubscription(sub) {
+			count++
+		}
+	}
+	return count
+}
+
+// broadcastNewBlock sends notifications for a block produced at the given time
+func (sm *SubscriptionManager) broadcastNewBlock(chain string, blockNumber uint64, produced time.Time) {
+	metrics := headNotificationMetrics.For(chain)
 	// First, get all relevant subscriptions under a read lock
-	sm.mu.RLock()
+	now := time.Now()
+	shard := sm.shard(chain)
+	shard.mu.RLock()
 	subs := make([]*Subscription, 0)
-	for _, sub := range sm.subscriptions {
-		if sub.Type == chain && (sub.Method == "newHeads" || sub.Method == "newHeadsWithTx" || sub.Method == "logs" || sub.Method == "slotNotification" || sub.Method == "rootNotification") {
+	for _, sub := range shard.subscriptions {
+		if sub.attached(now) && isHeadSubscription(sub) {
 			subs = append(subs, sub)
 		}
 	}
-	sm.mu.RUnlock()
+	hasGraceSubs := len(shard.graceSubs) > 0
+	shard.mu.RUnlock()
+
+	// Just-unsubscribed Solana subscriptions keep receiving notifications during the grace period
+	if hasGraceSubs {
+		shard.mu.Lock()
+		subs = append(subs, shard.activeGraceSubscriptions()...)
+		shard.mu.Unlock()
+	}
 
 	// Sort subscriptions by ID to ensure deterministic order
 	sort.Slice(subs, func(i, j int) bool {
 		return subs[i].ID < subs[j].ID
 	})
 
+	// A pending discontinuity fault breaks this head's parentHash for all subscribers; it
+	// waits for a head that someone receives
+	brokenParent := ""
+	for _, sub := range subs {
+		if sub.Method == "newHeads" || sub.Method == "newHeadsWithTx" {
+			brokenParent = takeDiscontinuity(chain, blockNumber)
+			break
+		}
+	}
+
+	filler := notificationFiller(chain)
+
 	// Process each subscription outside the lock
 	for _, sub := range subs {
 		var notification interface{}
-		switch chain {
-		case "1", "10", "56", "100", "130", "137", "146", "250", "324", "8217", "8453", "42161", "43114", "59144":
+		switch {
+		case isEVMChainID(chain):
 			// Generate unique hashes for this block
 			blockHash := generateBlockHashForSubscription(blockNumber, chain, "block")
 			var parentHash string
@@ -271,6 +585,13 @@ func (sm *SubscriptionManager) BroadcastNewBlock(chain string, blockNumber uint6
 			} else {
 				parentHash = "0x" + hex.EncodeToString(make([]byte, 32))
 			}
+			if brokenParent != "" {
+				parentHash = brokenParent
+			}
+			extraData := "0x" + hex.EncodeToString(make([]byte, 32))
+			if filler != "" {
+				extraData = filler
+			}
 
 			// Generate deterministic hashes for required fields
 			sha3Uncles := generateBlockHashForSubscription(blockNumber, chain, "sha3Uncles")
@@ -292,7 +613,7 @@ func (sm *SubscriptionManager) BroadcastNewBlock(chain string, blockNumber uint6
 				ParentHash:       parentHash,
 				Number:           fmt.Sprintf("0x%x", blockNumber),
 				Hash:             blockHash,
-				Timestamp:        fmt.Sprintf("0x%x", time.Now().Unix()),
+				Timestamp:        blockTimestamp(chain, blockNumber),
 				GasLimit:         generateValidHexString(32),
 				GasUsed:          generateValidHexString(32),
 				Miner:            "0x" + hex.EncodeToString(make([]byte, 20)),
@@ -300,7 +621,7 @@ func (sm *SubscriptionManager) BroadcastNewBlock(chain string, blockNumber uint6
 				TotalDifficulty:  generateValidHexString(32),
 				Size:             generateValidHexString(32),
 				Nonce:            "0x" + hex.EncodeToString(make([]byte, 8)),
-				ExtraData:        "0x" + hex.EncodeToString(make([]byte, 32)),
+				ExtraData:        extraData,
 				BaseFeePerGas:    generateValidHexString(32),
 				Sha3Uncles:       sha3Uncles,
 				LogsBloom:        logsBloom,
@@ -310,29 +631,10 @@ func (sm *SubscriptionManager) BroadcastNewBlock(chain string, blockNumber uint6
 				Uncles:           []string{},
 			}
 
-			// Add transactions if subscription type is newHeadsWithTx
+			// Add transactions if subscription type is newHeadsWithTx, the same ones
+			// eth_getBlockByNumber reports for the block
 			if sub.Method == "newHeadsWithTx" {
-				// Generate a random number of transactions (1-5)
-				numTx := rand.Intn(5) + 1
-				transactions := make([]Transaction, numTx)
-				for i := 0; i < numTx; i++ {
-					transactions[i] = Transaction{
-						Hash:             "0x" + hex.EncodeToString(make([]byte, 32)),
-						Nonce:            fmt.Sprintf("0x%x", rand.Uint64()),
-						BlockHash:        blockHash, // Use the deterministic block hash
-						BlockNumber:      fmt.Sprintf("0x%x", blockNumber),
-						TransactionIndex: fmt.Sprintf("0x%x", i),
-						From:             "0x" + hex.EncodeToString(make([]byte, 20)),
-						To:               "0x" + hex.EncodeToString(make([]byte, 20)),
-						Value:            "0x" + hex.EncodeToString(make([]byte, 32)),
-						Gas:              "0x" + hex.EncodeToString(make([]byte, 32)),
-						GasPrice:         "0x" + hex.EncodeToString(make([]byte, 32)),
-						Input:            "0x" + hex.EncodeToString(make([]byte, 32)),
-						V:                "0x" + hex.EncodeToString(make([]byte, 1)),
-						R:                "0x" + hex.EncodeToString(make([]byte, 32)),
-						S:                "0x" + hex.EncodeToString(make([]byte, 32)),
-					}
-				}
+				transactions := blockTransactionList(chain, blockNumber)
 				block.Transactions = make([]interface{}, len(transactions))
 				for i, tx := range transactions {
 					block.Transactions[i] = tx
@@ -350,7 +652,7 @@ func (sm *SubscriptionManager) BroadcastNewBlock(chain string, blockNumber uint6
 				},
 			}
 
-		case "501":
+		case chain == "501":
 			// Calculate root as a few blocks behind the current slot
 			root := uint64(0)
 			if blockNumber > 3 {
@@ -406,7 +708,9 @@ func (sm *SubscriptionManager) BroadcastNewBlock(chain string, blockNumber uint6
 			continue
 		}
 
-		if err := sub.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		err = sub.deliver(data)
+		metrics.Record(produced, err)
+		if err != nil && !errors.Is(err, errSlowClientDropped) && !sub.inGrace {
 			// If we can't write to the connection, remove the subscription
 			sm.Unsubscribe(sub.ID)
 		}
@@ -429,19 +733,53 @@ type LogEvent struct {
 	BlockHash   string   `json:"blockHash"`
 	LogIndex    uint64   `json:"logIndex"`
 	Removed     bool     `json:"removed"`
+	ExtraData   string   `json:"extraData,omitempty"` // Notification padding filler, never part of eth_getLogs
 }
 
-// BroadcastNewLog broadcasts a new log event to all subscribers
+// BroadcastNewLog broadcasts a new log event to all subscribers. Like heads, logs arriving
+// while the chain's broadcast queue is full are dropped and counted in the chain's metrics.
 func (sm *SubscriptionManager) BroadcastNewLog(chainId string, logEvent LogEvent) {
+	produced := time.Now()
+	sm.enqueueOrDrop(chainId, notificationLatency(chainId), func() {
+		sm.broadcastNewLog(chainId, logEvent, produced)
+	}, func() {
+		headNotificationMetrics.For(chainId).RecordDropped(sm.logSubscriberCount(chainId))
+	})
+}
+
+// logSubscriberCount returns how many subscriptions a chain's next log would be sent to
+func (sm *SubscriptionManager) logSubscriberCount(chainId string) int {
+	now := time.Now()
+	shard := sm.shard(chainId)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	count := 0
+	for _, sub := range shard.subscriptions {
+		if sub.Method == "logs" && sub.attached(now) {
+			count++
+		}
+	}
+	return count
+}
+
+// broadcastNewLog sends notifications for a log emitted at the given time
+func (sm *SubscriptionManager) broadcastNewLog(chainId string, logEvent LogEvent, produced time.Time) {
+	metrics := headNotificationMetrics.For(chainId)
 	// First, get all relevant subscriptions under a read lock
-	sm.mu.RLock()
+	now := time.Now()
+	shard := sm.shard(chainId)
+	shard.mu.RLock()
 	subs := make([]*Subscription, 0)
-	for _, sub := range sm.subscriptions {
-		if sub.Type == chainId && sub.Method == "logs" {
+	for _, sub := range shard.subscriptions {
+		if sub.Method == "logs" && sub.attached(now) {
 			subs = append(subs, sub)
 		}
 	}
-	sm.mu.RUnlock()
+	shard.mu.RUnlock()
+
+	if filler := notificationFiller(chainId); filler != "" {
+		logEvent.ExtraData = filler
+	}
 
 	// Process each subscription outside the lock
 	for _, sub := range subs {
@@ -465,7 +803,9 @@ func (sm *SubscriptionManager) BroadcastNewLog(chainId string, logEvent LogEvent
 			continue
 		}
 
-		if err := sub.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+		err = sub.deliver(message)
+		metrics.Record(produced, err)
+		if err != nil && !errors.Is(err, errSlowClientDropped) {
 			log.Printf("Error sending log notification: %v", err)
 			// If we can't write to the connection, remove the subscription
 			sm.Unsubscribe(sub.ID)
@@ -475,11 +815,12 @@ func (sm *SubscriptionManager) BroadcastNewLog(chainId string, logEvent LogEvent
 
 // getSubscriptionID returns the subscription ID for a given chain and type
 func (sm *SubscriptionManager) getSubscriptionID(chainId, subType string) uint64 {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
+	shard := sm.shard(chainId)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
 
-	for _, sub := range sm.subscriptions {
-		if sub.Type == chainId && sub.Method == subType {
+	for _, sub := range shard.subscriptions {
+		if sub.Method == subType {
 			return sub.ID
 		}
 	}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stuckConn is a WSConn whose writes block until released, like a client that stopped reading
+type stuckConn struct {
+	*MockWSConn
+	release chan struct{}
+}
+
+func (c *stuckConn) WriteMessage(messageType int, data []byte) error {
+	<-c.release
+	return c.MockWSConn.WriteMessage(messageType, data)
+}
+
+func TestShardsBroadcastIndependently(t *testing.T) {
+	sm := NewSubscriptionManager()
+	stuck := &stuckConn{MockWSConn: NewMockWSConn(), release: make(chan struct{})}
+	sm.Subscribe("1", stuck, "newHeads")
+	sm.BroadcastNewBlock("1", 1) // Blocks Ethereum's broadcast worker
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Neither Solana broadcasts nor Ethereum subscription changes wait for the stuck write
+		solanaConn := NewMockWSConn()
+		sm.Subscribe("501", solanaConn, "slotNotification")
+		sm.BroadcastNewBlock("501", 10)
+		sm.Flush("501")
+		if len(solanaConn.GetMessages()) != 1 {
+			t.Errorf("Expected 1 Solana notification, got %d", len(solanaConn.GetMessages()))
+		}
+		ethID, _ := sm.Subscribe("1", NewMockWSConn(), "newHeads")
+		sm.Unsubscribe(ethID)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected other chains to proceed while one chain's broadcast is blocked")
+	}
+
+	close(stuck.release)
+	sm.Flush("1")
+	if len(stuck.GetMessages()) != 1 {
+		t.Errorf("Expected the stuck notification to be delivered once released, got %d", len(stuck.GetMessages()))
+	}
+}
+
+func TestShardBroadcastOrder(t *testing.T) {
+	sm := NewSubscriptionManager()
+	conn := NewMockWSConn()
+	sm.Subscribe("1", conn, "logs")
+
+	// Blocks and logs of a chain are delivered in the order they were produced
+	for i := uint64(1); i <= 20; i++ {
+		sm.BroadcastNewBlock("1", i)
+		sm.BroadcastNewLog("1", LogEvent{BlockNumber: i})
+	}
+	sm.Flush("1")
+
+	messages := conn.GetMessages()
+	if len(messages) != 40 {
+		t.Fatalf("Expected 40 notifications, got %d", len(messages))
+	}
+	for i := 0; i < 40; i += 2 {
+		if !strings.Contains(string(messages[i]), fmt.Sprintf(`"number":"0x%x"`, i/2+1)) {
+			t.Errorf("Expected block %d at position %d, got %s", i/2+1, i, messages[i])
+		}
+	}
+}
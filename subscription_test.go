@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"sync"
 	"testing"
-	"time"
 )
 
 func TestSubscriptionManager(t *testing.T) {
@@ -32,12 +31,13 @@ func TestSubscriptionManager(t *testing.T) {
 	}
 
 	// Verify subscriptions exist
-	if len(sm.subscriptions) != 3 {
-		t.Errorf("Expected 3 subscriptions, got %d", len(sm.subscriptions))
+	if sm.Count() != 3 {
+		t.Errorf("Expected 3 subscriptions, got %d", sm.Count())
 	}
 
 	// Test EVM notification format
 	sm.BroadcastNewBlock("1", 100)
+	sm.Flush("1")
 	messages := conn.GetMessages()
 	if len(messages) != 2 { // Both newHeads and logs subscriptions receive block notifications
 		t.Fatalf("Expected 2 EVM messages, got %d", len(messages))
@@ -78,6 +78,7 @@ func TestSubscriptionManager(t *testing.T) {
 
 	// Test Solana notification format
 	sm.BroadcastNewBlock("501", 100)
+	sm.Flush("501")
 	messages = conn.GetMessages()
 	if len(messages) != 1 {
 		t.Fatalf("Expected 1 Solana message, got %d", len(messages))
@@ -140,6 +141,7 @@ func TestSubscriptionManager(t *testing.T) {
 		Removed:     false,
 	}
 	sm.BroadcastNewLog("1", logEvent)
+	sm.Flush("1")
 	messages = conn.GetMessages()
 	if len(messages) != 1 {
 		t.Fatalf("Expected 1 Log message, got %d", len(messages))
@@ -202,8 +204,8 @@ func TestSubscriptionManager(t *testing.T) {
 		t.Errorf("Failed to unsubscribe from Log: %v", err)
 	}
 
-	if len(sm.subscriptions) != 0 {
-		t.Errorf("Expected 0 subscriptions after unsubscribe, got %d", len(sm.subscriptions))
+	if sm.Count() != 0 {
+		t.Errorf("Expected 0 subscriptions after unsubscribe, got %d", sm.Count())
 	}
 }
 
@@ -256,8 +258,8 @@ func TestSubscriptionManagerConcurrent(t *testing.T) {
 		t.Errorf("Expected 5 Solana subscriptions, got %d", len(solSubs))
 	}
 
-	if len(sm.subscriptions) != 10 {
-		t.Errorf("Expected 10 total subscriptions, got %d", len(sm.subscriptions))
+	if sm.Count() != 10 {
+		t.Errorf("Expected 10 total subscriptions, got %d", sm.Count())
 	}
 
 	// Use channels to synchronize broadcasts
@@ -291,8 +293,9 @@ func TestSubscriptionManagerConcurrent(t *testing.T) {
 		t.Errorf("Expected 5 Solana broadcasts, got %d", len(solBroadcasts))
 	}
 
-	// Give a small amount of time for all messages to be processed
-	time.Sleep(50 * time.Millisecond)
+	// Wait for both broadcast workers to deliver all messages
+	sm.Flush("1")
+	sm.Flush("501")
 
 	messages := conn.GetMessages()
 	// Each broadcast should go to 5 subscriptions of its chain type
@@ -347,6 +350,7 @@ func TestSubscriptionManagerWithTransactions(t *testing.T) {
 
 	// Test EVM notification format with transactions
 	sm.BroadcastNewBlock("1", 101)
+	sm.Flush("1")
 	messages := conn.GetMessages()
 	if len(messages) != 2 { // Both newHeads and logs subscriptions receive block notifications
 		t.Fatalf("Expected 2 EVM messages, got %d", len(messages))
@@ -413,6 +417,7 @@ func TestSubscriptionManagerWithTransactions(t *testing.T) {
 
 	// Test Solana notification format
 	sm.BroadcastNewBlock("501", 100)
+	sm.Flush("501")
 	messages = conn.GetMessages()
 	if len(messages) != 1 {
 		t.Fatalf("Expected 1 Solana message, got %d", len(messages))
@@ -475,6 +480,7 @@ func TestSubscriptionManagerWithTransactions(t *testing.T) {
 		Removed:     false,
 	}
 	sm.BroadcastNewLog("1", logEvent)
+	sm.Flush("1")
 	messages = conn.GetMessages()
 	if len(messages) != 1 {
 		t.Fatalf("Expected 1 Log message, got %d", len(messages))
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timestamp fault modes
+const (
+	TimestampFreeze  = "freeze"  // New blocks keep the timestamp of the block the fault started at
+	TimestampRegress = "regress" // Each new block is Step seconds older than the previous one
+)
+
+// TimestampModes lists all supported timestamp fault modes
+var TimestampModes = []string{TimestampFreeze, TimestampRegress}
+
+const defaultTimestampStep = 12 // Seconds a regressing timestamp goes back per block by default
+
+// TimestampFault makes block timestamps stale while block numbers keep increasing, to test
+// staleness detectors that look at timestamps instead of numbers
+type TimestampFault struct {
+	Mode       string `json:"mode"`
+	Step       int64  `json:"step_seconds,omitempty"` // Seconds per block for regress
+	StartBlock uint64 `json:"start_block"`            // First affected block
+	StartTime  int64  `json:"start_time"`             // Timestamp of StartBlock
+}
+
+// timestamp returns the faulty timestamp of a block, or now for blocks before the fault
+func (f *TimestampFault) timestamp(blockNumber uint64) int64 {
+	if f == nil || blockNumber < f.StartBlock {
+		return time.Now().Unix()
+	}
+	if f.Mode == TimestampRegress {
+		return f.StartTime - f.Step*int64(blockNumber-f.StartBlock)
+	}
+	return f.StartTime
+}
+
+// timestampFaults holds the timestamp fault of each EVM chain; runtime-only
+var timestampFaults = struct {
+	sync.RWMutex
+	byChain map[string]*TimestampFault
+}{byChain: make(map[string]*TimestampFault)}
+
+func timestampFaultFor(name string) *TimestampFault {
+	timestampFaults.RLock()
+	defer timestampFaults.RUnlock()
+	return timestampFaults.byChain[name]
+}
+
+// blockTimestamp returns the timestamp reported for a block in heads and block queries
+func blockTimestamp(chainId string, blockNumber uint64) string {
+	return fmt.Sprintf("0x%x", timestampFaultFor(chainIdToName[chainId]).timestamp(blockNumber))
+}
+
+// handleSetTimestampFault freezes or regresses the timestamps of an EVM chain's new blocks;
+// an empty mode restores wall clock timestamps
+func handleSetTimestampFault(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain       string `json:"chain"`
+		Mode        string `json:"mode"`
+		StepSeconds int64  `json:"step_seconds"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	chain, ok := supportedChains[request.Chain]
+	if !ok {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+
+	var fault *TimestampFault
+	switch request.Mode {
+	case "":
+	case TimestampFreeze, TimestampRegress:
+		if request.StepSeconds < 0 {
+			http.Error(w, "step_seconds must be non-negative", http.StatusBadRequest)
+			return
+		}
+		// The fault starts with the next block; the current head keeps its timestamp
+		fault = &TimestampFault{
+			Mode:       request.Mode,
+			StartBlock: atomic.LoadUint64(&chain.BlockNumber) + 1,
+			StartTime:  time.Now().Unix(),
+		}
+		if request.Mode == TimestampRegress {
+			fault.Step = request.StepSeconds
+			if fault.Step == 0 {
+				fault.Step = defaultTimestampStep
+			}
+		}
+	default:
+		http.Error(w, fmt.Sprintf("Invalid mode: %s (supported: %v)", request.Mode, TimestampModes), http.StatusBadRequest)
+		return
+	}
+
+	timestampFaults.Lock()
+	if fault == nil {
+		delete(timestampFaults.byChain, request.Chain)
+	} else {
+		timestampFaults.byChain[request.Chain] = fault
+	}
+	timestampFaults.Unlock()
+
+	if fault == nil {
+		log.Printf("Restored block timestamps on chain %s", request.Chain)
+	} else {
+		log.Printf("Set timestamp fault %s from block %d on chain %s", fault.Mode, fault.StartBlock, request.Chain)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setTimestampFault(t *testing.T, body string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleSetTimestampFault(w, httptest.NewRequest(http.MethodPost, "/control/chain/timestamp-fault", bytes.NewBufferString(body)))
+	return w.Code
+}
+
+// blockTimestampOf returns the timestamp eth_getBlockByNumber reports for a block
+func blockTimestampOf(t *testing.T, number uint64) int64 {
+	t.Helper()
+	response, _ := handleEVMRequest(context.Background(), []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getBlockByNumber","params":["0x%x"],"id":1}`, number)), NewMockWSConn(), "59144")
+	var resp struct {
+		Result struct {
+			Timestamp string `json:"timestamp"`
+		} `json:"result"`
+	}
+	json.Unmarshal(response, &resp)
+	timestamp, err := strconv.ParseInt(resp.Result.Timestamp[2:], 16, 64)
+	if err != nil {
+		t.Fatalf("Invalid timestamp %q", resp.Result.Timestamp)
+	}
+	return timestamp
+}
+
+func TestTimestampFaultValues(t *testing.T) {
+	freeze := &TimestampFault{Mode: TimestampFreeze, StartBlock: 10, StartTime: 1000}
+	regress := &TimestampFault{Mode: TimestampRegress, Step: 5, StartBlock: 10, StartTime: 1000}
+	if freeze.timestamp(10) != 1000 || freeze.timestamp(15) != 1000 {
+		t.Error("Expected frozen timestamps from the start block on")
+	}
+	if regress.timestamp(10) != 1000 || regress.timestamp(12) != 990 {
+		t.Errorf("Expected regressing timestamps, got %d and %d", regress.timestamp(10), regress.timestamp(12))
+	}
+	if now := time.Now().Unix(); freeze.timestamp(9) < now {
+		t.Error("Expected blocks before the fault to keep wall clock timestamps")
+	}
+}
+
+func TestTimestampFault(t *testing.T) {
+	chain := supportedChains["linea"]
+	defer setTimestampFault(t, `{"chain": "linea", "mode": ""}`)
+
+	if code := setTimestampFault(t, `{"chain": "linea", "mode": "freeze"}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if faults := evmActiveFaults(chain); faults[len(faults)-1] != "timestamp freeze" {
+		t.Errorf("Expected the timestamp fault in active faults, got %v", faults)
+	}
+	start := atomic.LoadUint64(&chain.BlockNumber) + 1
+	if first, later := blockTimestampOf(t, start), blockTimestampOf(t, start+5); first != later {
+		t.Errorf("Expected frozen timestamps, got %d and %d", first, later)
+	}
+
+	setTimestampFault(t, `{"chain": "linea", "mode": "regress", "step_seconds": 30}`)
+	if first, later := blockTimestampOf(t, start), blockTimestampOf(t, start+2); first-later != 60 {
+		t.Errorf("Expected timestamps to regress 30s per block, got %d and %d", first, later)
+	}
+
+	// newHeads carry the same faulty timestamps
+	conn := NewMockWSConn()
+	defer subManager.CleanupConnection(conn)
+	subManager.Subscribe("59144", conn, "newHeads")
+	chain.produceBlock("59144", nil)
+	chain.produceBlock("59144", nil)
+	subManager.Flush("59144")
+	var timestamps []int64
+	for _, message := range conn.GetMessages() {
+		var head struct {
+			Params struct {
+				Result struct {
+					Timestamp string `json:"timestamp"`
+				} `json:"result"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(message, &head); err == nil && head.Params.Result.Timestamp != "" {
+			timestamp, _ := strconv.ParseInt(head.Params.Result.Timestamp[2:], 16, 64)
+			timestamps = append(timestamps, timestamp)
+		}
+	}
+	if len(timestamps) != 2 || timestamps[0]-timestamps[1] != 30 {
+		t.Errorf("Expected two heads 30s apart going backwards, got %v", timestamps)
+	}
+
+	for body, want := range map[string]int{
+		`{"chain": "linea", "mode": "rewind"}`:                      http.StatusBadRequest,
+		`{"chain": "linea", "mode": "regress", "step_seconds": -1}`: http.StatusBadRequest,
+		`{"chain": "solana", "mode": "freeze"}`:                     http.StatusNotFound,
+	} {
+		if code := setTimestampFault(t, body); code != want {
+			t.Errorf("%s: expected status %d, got %d", body, want, code)
+		}
+	}
+}
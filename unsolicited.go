@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/gorilla/websocket"
+)
+
+// Kinds of unsolicited server-initiated messages
+const (
+	UnsolicitedJunk                = "junk"                 // Text frame that is not valid JSON
+	UnsolicitedBinary              = "binary"               // Binary frame with random bytes
+	UnsolicitedUnrelatedJSON       = "unrelated_json"       // Valid JSON that is not a JSON-RPC message
+	UnsolicitedUnknownSubscription = "unknown_subscription" // Notification for a subscription id that was never issued
+)
+
+// UnsolicitedKinds lists all supported unsolicited message kinds
+var UnsolicitedKinds = []string{UnsolicitedJunk, UnsolicitedBinary, UnsolicitedUnrelatedJSON, UnsolicitedUnknownSubscription}
+
+// isValidUnsolicitedKind returns true if the kind is supported
+func isValidUnsolicitedKind(kind string) bool {
+	for _, k := range UnsolicitedKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// unsolicitedMessage builds a message of the given kind for a connection on chainId
+func unsolicitedMessage(kind, chainId string) (int, []byte) {
+	switch kind {
+	case UnsolicitedBinary:
+		data := make([]byte, 16+rand.Intn(48))
+		rand.Read(data)
+		return websocket.BinaryMessage, data
+	case UnsolicitedUnrelatedJSON:
+		data, _ := json.Marshal(map[string]interface{}{
+			"type":    "announcement",
+			"message": "scheduled maintenance",
+			"seq":     rand.Intn(1000),
+		})
+		return websocket.TextMessage, data
+	case UnsolicitedUnknownSubscription:
+		var notification JSONRPCNotification
+		if chainId == "501" {
+			notification = JSONRPCNotification{
+				JsonRPC: "2.0",
+				Method:  "slotNotification",
+				Params: SubscriptionParams{
+					Subscription: uint64(1<<40) + uint64(rand.Int63n(1<<20)), // Far beyond issued ids
+					Result:       map[string]interface{}{"parent": 0, "root": 0, "slot": 1},
+				},
+			}
+		} else {
+			id := make([]byte, 16)
+			rand.Read(id)
+			notification = JSONRPCNotification{
+				JsonRPC: "2.0",
+				Method:  "eth_subscription",
+				Params: SubscriptionParams{
+					Subscription: "0x" + hex.EncodeToString(id),
+					Result:       map[string]interface{}{"number": "0x1"},
+				},
+			}
+		}
+		data, _ := json.Marshal(notification)
+		return websocket.TextMessage, data
+	default:
+		return websocket.TextMessage, []byte(fmt.Sprintf("}{not json %d\x00", rand.Intn(1000)))
+	}
+}
+
+// SendUnsolicitedMessages sends count messages of each kind to every open WebSocket
+// connection of a chain (all chains if chainId is empty) and returns the number of messages sent
+func SendUnsolicitedMessages(chainId string, kinds []string, count int) int {
+	sent := 0
+	for conn, connChain := range connTracker.Conns(chainId) {
+	connLoop:
+		for i := 0; i < count; i++ {
+			for _, kind := range kinds {
+				messageType, data := unsolicitedMessage(kind, connChain)
+				if err := conn.WriteMessage(messageType, data); err != nil {
+					break connLoop
+				}
+				sent++
+			}
+		}
+	}
+	return sent
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSendUnsolicitedMessages(t *testing.T) {
+	evmConn := NewMockWSConn()
+	solanaConn := NewMockWSConn()
+	connTracker.TrackConn(evmConn, "1")
+	connTracker.TrackConn(solanaConn, "501")
+	defer connTracker.UntrackConn(evmConn)
+	defer connTracker.UntrackConn(solanaConn)
+
+	sent := SendUnsolicitedMessages("1", UnsolicitedKinds, 2)
+	if sent != 2*len(UnsolicitedKinds) {
+		t.Errorf("Expected %d messages sent, got %d", 2*len(UnsolicitedKinds), sent)
+	}
+	if n := len(solanaConn.GetMessages()); n != 0 {
+		t.Errorf("Expected no messages on other chains, got %d", n)
+	}
+
+	messages := evmConn.GetMessages()
+	if len(messages) != 2*len(UnsolicitedKinds) {
+		t.Fatalf("Expected %d messages, got %d", 2*len(UnsolicitedKinds), len(messages))
+	}
+	var invalid, unknownSubs int
+	for _, msg := range messages {
+		var notification JSONRPCNotification
+		if err := json.Unmarshal(msg, &notification); err != nil {
+			invalid++
+			continue
+		}
+		if notification.Method == "eth_subscription" {
+			unknownSubs++
+		}
+	}
+	if invalid != 4 || unknownSubs != 2 {
+		t.Errorf("Expected 4 non-JSON and 2 unknown subscription messages, got %d and %d", invalid, unknownSubs)
+	}
+}
+
+func TestUnsolicitedUnknownSubscriptionSolana(t *testing.T) {
+	messageType, data := unsolicitedMessage(UnsolicitedUnknownSubscription, "501")
+	if messageType != websocket.TextMessage {
+		t.Errorf("Expected text message, got %d", messageType)
+	}
+	var notification struct {
+		Method string `json:"method"`
+		Params struct {
+			Subscription uint64 `json:"subscription"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(data, &notification); err != nil {
+		t.Fatalf("Failed to parse notification: %v", err)
+	}
+	if notification.Method != "slotNotification" || notification.Params.Subscription < 1<<40 {
+		t.Errorf("Unexpected notification: %s", data)
+	}
+}
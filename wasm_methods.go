@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"rpc-simulator/internal/wasm"
+)
+
+// WasmMethod is a custom RPC method implemented by a WebAssembly module. The module exports
+// its memory, alloc(size i32) i32 and handle(ptr i32, len i32) i64. handle receives the raw
+// JSON-RPC request and returns ptr<<32|len of a JSON reply, {"result": ...} or
+// {"error": {"code": ..., "message": ..., "data": ...}}.
+type WasmMethod struct {
+	Module   string `yaml:"module" json:"module,omitempty"`                     // Path of the .wasm file; empty for modules uploaded at runtime
+	Fuel     int64  `yaml:"fuel,omitempty" json:"fuel"`                         // Instructions per call (0 = 16M)
+	MaxPages uint32 `yaml:"max_memory_pages,omitempty" json:"max_memory_pages"` // Memory limit in 64 KiB pages (0 = 256)
+	Size     int    `yaml:"-" json:"size"`                                      // Module size in bytes
+	compiled *wasm.Module
+}
+
+// wasmMethods holds the custom methods of every chain by chain id and method name
+var wasmMethods = struct {
+	sync.RWMutex
+	methods map[string]map[string]*WasmMethod
+}{methods: make(map[string]map[string]*WasmMethod)}
+
+// compileWasmMethod decodes the module and checks it implements the method ABI
+func compileWasmMethod(method *WasmMethod, binary []byte) error {
+	if method.Fuel < 0 {
+		return fmt.Errorf("fuel must be non-negative")
+	}
+	module, err := wasm.Decode(binary)
+	if err != nil {
+		return err
+	}
+	for name, want := range map[string]wasm.FuncType{
+		"alloc":  {Params: []byte{wasm.I32}, Results: []byte{wasm.I32}},
+		"handle": {Params: []byte{wasm.I32, wasm.I32}, Results: []byte{wasm.I64}},
+	} {
+		if typ, ok := module.ExportedFunc(name); !ok || !typ.Equal(want) {
+			return fmt.Errorf("module must export function %s with the custom method signature", name)
+		}
+	}
+	if !module.ExportsMemory("memory") {
+		return fmt.Errorf("module must export its memory as \"memory\"")
+	}
+	method.compiled = module
+	method.Size = len(binary)
+	return nil
+}
+
+// loadWasmMethods compiles the custom methods configured for a chain and registers them
+func loadWasmMethods(chainId string, methods map[string]*WasmMethod) error {
+	for name, method := range methods {
+		binary, err := os.ReadFile(method.Module)
+		if err != nil {
+			return fmt.Errorf("custom method %s: %v", name, err)
+		}
+		if err := compileWasmMethod(method, binary); err != nil {
+			return fmt.Errorf("custom method %s: %v", name, err)
+		}
+		registerWasmMethod(chainId, name, method)
+	}
+	return nil
+}
+
+func registerWasmMethod(chainId, name string, method *WasmMethod) {
+	wasmMethods.Lock()
+	defer wasmMethods.Unlock()
+	if wasmMethods.methods[chainId] == nil {
+		wasmMethods.methods[chainId] = make(map[string]*WasmMethod)
+	}
+	wasmMethods.methods[chainId][name] = method
+}
+
+// lookupWasmMethod returns the custom method registered for a chain, nil if there is none
+func lookupWasmMethod(chainId, name string) *WasmMethod {
+	wasmMethods.RLock()
+	defer wasmMethods.RUnlock()
+	return wasmMethods.methods[chainId][name]
+}
+
+// call runs the module in a fresh instance and returns its JSON reply
+func (m *WasmMethod) call(ctx context.Context, request []byte) ([]byte, error) {
+	maxPages, fuel := uint32(wasm.DefaultMaxPages), int64(wasm.DefaultFuel)
+	if m.MaxPages > 0 {
+		maxPages = m.MaxPages
+	}
+	if m.Fuel > 0 {
+		fuel = m.Fuel
+	}
+
+	in, err := m.compiled.Instantiate(ctx, maxPages, fuel)
+	if err != nil {
+		return nil, err
+	}
+	results, err := in.Invoke("alloc", uint64(len(request)))
+	if err != nil {
+		return nil, err
+	}
+	ptr := uint64(uint32(results[0]))
+	memory, _ := in.Memory()
+	if ptr+uint64(len(request)) > uint64(len(memory)) {
+		return nil, fmt.Errorf("alloc returned an out of bounds pointer")
+	}
+	copy(memory[ptr:], request)
+
+	results, err = in.Invoke("handle", ptr, uint64(len(request)))
+	if err != nil {
+		return nil, err
+	}
+	// handle may have grown the memory
+	memory, _ = in.Memory()
+	outPtr, outLen := results[0]>>32, uint64(uint32(results[0]))
+	if outPtr+outLen > uint64(len(memory)) {
+		return nil, fmt.Errorf("handle returned an out of bounds reply")
+	}
+	return memory[outPtr : outPtr+outLen], nil
+}
+
+// respond runs the custom method and builds the JSON-RPC response for the request id
+func (m *WasmMethod) respond(ctx context.Context, request []byte, id interface{}) ([]byte, error) {
+	reply, err := m.call(ctx, request)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return createErrorResponse(-32603, fmt.Sprintf("Custom method failed: %v", err), nil, id)
+	}
+
+	var parsed struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int         `json:"code"`
+			Message string      `json:"message"`
+			Data    interface{} `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(reply, &parsed); err != nil {
+		return createErrorResponse(-32603, "Custom method returned invalid JSON", nil, id)
+	}
+	if parsed.Error != nil {
+		return createErrorResponse(parsed.Error.Code, parsed.Error.Message, parsed.Error.Data, id)
+	}
+	if parsed.Result == nil {
+		return createErrorResponse(-32603, "Custom method returned neither result nor error", nil, id)
+	}
+	return json.Marshal(JSONRPCResponse{JsonRPC: "2.0", Result: parsed.Result, ID: id})
+}
+
+// handleWasmMethods lists (GET) the custom methods of every chain
+func handleWasmMethods(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	wasmMethods.RLock()
+	defer wasmMethods.RUnlock()
+	chains := make(map[string]map[string]*WasmMethod)
+	for chainId, methods := range wasmMethods.methods {
+		if len(methods) > 0 {
+			chains[chainIdToName[chainId]] = methods
+		}
+	}
+	jsonResponse(w, http.StatusOK, chains)
+}
+
+// handleRegisterWasmMethod uploads a WASM module as a custom RPC method of a chain
+func handleRegisterWasmMethod(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain    string `json:"chain"`
+		Method   string `json:"method"`
+		Module   string `json:"module"` // Base64 encoded .wasm binary
+		Fuel     int64  `json:"fuel"`
+		MaxPages uint32 `json:"max_memory_pages"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	chainId := chainIDForName(request.Chain)
+	if _, ok := chainIdToName[chainId]; !ok {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	if request.Method == "" {
+		http.Error(w, "Method name is required", http.StatusBadRequest)
+		return
+	}
+	binary, err := base64.StdEncoding.DecodeString(request.Module)
+	if err != nil {
+		http.Error(w, "Module must be base64 encoded", http.StatusBadRequest)
+		return
+	}
+	method := &WasmMethod{Fuel: request.Fuel, MaxPages: request.MaxPages}
+	if err := compileWasmMethod(method, binary); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid module: %v", err), http.StatusBadRequest)
+		return
+	}
+	registerWasmMethod(chainId, request.Method, method)
+
+	log.Printf("Registered custom method %s (%d bytes) for chain %s", request.Method, len(binary), request.Chain)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleRemoveWasmMethod removes a custom RPC method from a chain
+func handleRemoveWasmMethod(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain  string `json:"chain"`
+		Method string `json:"method"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	chainId := chainIDForName(request.Chain)
+	wasmMethods.Lock()
+	_, ok := wasmMethods.methods[chainId][request.Method]
+	delete(wasmMethods.methods[chainId], request.Method)
+	wasmMethods.Unlock()
+	if !ok {
+		http.Error(w, "Custom method not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Removed custom method %s from chain %s", request.Method, request.Chain)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
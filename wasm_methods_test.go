@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rpc-simulator/internal/wasm"
+	"rpc-simulator/internal/wasm/wasmtest"
+)
+
+// replyModule builds a custom method module whose handle returns reply from a data segment
+func replyModule(reply string) []byte {
+	packed := int64(1024)<<32 | int64(len(reply))
+	return wasmtest.Module([]wasmtest.Func{
+		{Export: "alloc", Params: []byte{wasm.I32}, Results: []byte{wasm.I32}, Code: []byte{0x41, 0x80, 0x10, 0x0B}}, // 2048
+		{Export: "handle", Params: []byte{wasm.I32, wasm.I32}, Results: []byte{wasm.I64}, Code: append(append([]byte{0x42}, wasmtest.SLEB(packed)...), 0x0B)},
+	}, 1, map[uint32]string{1024: reply})
+}
+
+// echoModule builds a custom method module whose handle returns the request unchanged
+func echoModule() []byte {
+	return wasmtest.Module([]wasmtest.Func{
+		{Export: "alloc", Params: []byte{wasm.I32}, Results: []byte{wasm.I32}, Code: []byte{0x41, 0x80, 0x10, 0x0B}},
+		{Export: "handle", Params: []byte{wasm.I32, wasm.I32}, Results: []byte{wasm.I64}, Code: []byte{
+			0x20, 0x00, 0xAD, 0x42, 0x20, 0x86, // i64(ptr) << 32
+			0x20, 0x01, 0xAD, 0x84, 0x0B, // | i64(len)
+		}},
+	}, 1, nil)
+}
+
+func registerTestWasmMethod(t *testing.T, chain, method string, module []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"chain": chain, "method": method, "module": base64.StdEncoding.EncodeToString(module)})
+	w := httptest.NewRecorder()
+	handleRegisterWasmMethod(w, httptest.NewRequest(http.MethodPost, "/control/wasm/methods/register", bytes.NewBuffer(body)))
+	return w
+}
+
+func TestWasmCustomMethod(t *testing.T) {
+	defer func() {
+		delete(wasmMethods.methods["1"], "indexer_status")
+		delete(wasmMethods.methods["501"], "indexer_status")
+	}()
+
+	for _, chain := range []string{"ethereum", "solana"} {
+		if w := registerTestWasmMethod(t, chain, "indexer_status", replyModule(`{"result":{"synced":true}}`)); w.Code != http.StatusOK {
+			t.Fatalf("Failed to register method on %s: %d %s", chain, w.Code, w.Body.String())
+		}
+	}
+
+	data := []byte(`{"jsonrpc":"2.0","method":"indexer_status","params":[],"id":7}`)
+	responses := make([][]byte, 2)
+	var err error
+	if responses[0], err = handleEVMRequest(context.Background(), data, NewMockWSConn(), "1"); err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	if responses[1], err = handleSolanaRequest(context.Background(), data, NewMockWSConn()); err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+	for _, response := range responses {
+		if string(response) != `{"jsonrpc":"2.0","result":{"synced":true},"id":7}` {
+			t.Errorf("Unexpected custom method response: %s", response)
+		}
+	}
+
+	// Other chains don't see the method
+	response, _ := handleEVMRequest(context.Background(), data, NewMockWSConn(), "10")
+	if !strings.Contains(string(response), "Method not found") {
+		t.Errorf("Expected method not found on another chain, got %s", response)
+	}
+}
+
+func TestWasmCustomMethodReplies(t *testing.T) {
+	defer delete(wasmMethods.methods["1"], "custom_reply")
+
+	tests := []struct {
+		module []byte
+		want   string
+	}{
+		{replyModule(`{"error":{"code":-32010,"message":"indexer behind"}}`), `"code":-32010,"message":"indexer behind"`},
+		{replyModule(`not json`), "Custom method returned invalid JSON"},
+		{echoModule(), "Custom method returned neither result nor error"},
+	}
+	data := []byte(`{"jsonrpc":"2.0","method":"custom_reply","params":[],"id":1}`)
+	for _, tt := range tests {
+		if w := registerTestWasmMethod(t, "ethereum", "custom_reply", tt.module); w.Code != http.StatusOK {
+			t.Fatalf("Failed to register method: %d %s", w.Code, w.Body.String())
+		}
+		response, err := handleEVMRequest(context.Background(), data, NewMockWSConn(), "1")
+		if err != nil {
+			t.Fatalf("Handler error: %v", err)
+		}
+		if !strings.Contains(string(response), tt.want) {
+			t.Errorf("Expected %q in response, got %s", tt.want, response)
+		}
+	}
+}
+
+func TestRegisterWasmMethodValidation(t *testing.T) {
+	noHandle := wasmtest.Module([]wasmtest.Func{
+		{Export: "alloc", Params: []byte{wasm.I32}, Results: []byte{wasm.I32}, Code: []byte{0x41, 0x00, 0x0B}},
+	}, 1, nil)
+
+	tests := []struct {
+		chain  string
+		method string
+		module []byte
+		want   int
+	}{
+		{"unknown", "custom", replyModule(`{"result":1}`), http.StatusNotFound},
+		{"ethereum", "", replyModule(`{"result":1}`), http.StatusBadRequest},
+		{"ethereum", "custom", []byte("not wasm"), http.StatusBadRequest},
+		{"ethereum", "custom", noHandle, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := registerTestWasmMethod(t, tt.chain, tt.method, tt.module); w.Code != tt.want {
+			t.Errorf("%s/%s: expected status %d, got %d", tt.chain, tt.method, tt.want, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handleRemoveWasmMethod(w, httptest.NewRequest(http.MethodPost, "/control/wasm/methods/remove", bytes.NewBufferString(`{"chain": "ethereum", "method": "custom"}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected removing an unknown method to fail, got %d", w.Code)
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// watchdogInterval is how often the watchdog checks every chain
+const watchdogInterval = time.Second
+
+// WatchdogConfig controls the guardrails watchdog. It warns when a chain can't keep up with
+// its own settings, e.g. a tiny block interval with many logs per block, and when a chain's
+// configured latency exceeds its guardrail. With AutoClamp it also clamps the offending
+// settings, so one misconfigured chain on a shared instance can't silently skew the results
+// of everyone else's tests.
+type WatchdogConfig struct {
+	AutoClamp            bool           `json:"auto_clamp"`
+	MaxResponseLatencyMs int            `json:"max_response_latency_ms"` // Guardrail of chains without their own (0 = none)
+	Chains               map[string]int `json:"chains"`                  // Guardrail in ms by chain name
+}
+
+// maxResponseLatency returns the latency guardrail of a chain, or 0 if it has none
+func (c WatchdogConfig) maxResponseLatency(chain string) time.Duration {
+	ms, ok := c.Chains[chain]
+	if !ok {
+		ms = c.MaxResponseLatencyMs
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// WatchdogChainSnapshot is what the watchdog found and did on one chain
+type WatchdogChainSnapshot struct {
+	Warnings            []string `json:"warnings"`
+	FallingBehindChecks uint64   `json:"falling_behind_checks"` // Checks that found the chain behind its interval
+	OverGuardrailChecks uint64   `json:"over_guardrail_checks"` // Checks that found latency above the guardrail
+	Clamps              uint64   `json:"clamps"`
+	LastClamp           string   `json:"last_clamp,omitempty"`
+}
+
+// watchdogCounts accumulates the watchdog's findings on one chain
+type watchdogCounts struct {
+	fallingBehind uint64
+	overGuardrail uint64
+	clamps        uint64
+	lastClamp     string
+	clampedTick   uint64 // Overloaded tick count of the chain at its last interval clamp
+}
+
+// watchdog holds the config and per-chain counts by chain name; runtime only
+var watchdog = struct {
+	sync.Mutex
+	config WatchdogConfig
+	chains map[string]*watchdogCounts
+}{config: WatchdogConfig{Chains: make(map[string]int)}, chains: make(map[string]*watchdogCounts)}
+
+// watchdogConfig returns a copy of the current watchdog config
+func watchdogConfig() WatchdogConfig {
+	watchdog.Lock()
+	defer watchdog.Unlock()
+	config := watchdog.config
+	config.Chains = make(map[string]int, len(watchdog.config.Chains))
+	for chain, ms := range watchdog.config.Chains {
+		config.Chains[chain] = ms
+	}
+	return config
+}
+
+// blockProduction returns whether a chain produces blocks on its own, and its interval
+func blockProduction(name string) (bool, time.Duration) {
+	if name == "solana" {
+		return solanaNode.runState.IsRunning() && !solanaNode.ManualMining, solanaNode.SlotInterval
+	}
+	chain := supportedChains[name]
+	return chain.runState.IsRunning() && !chain.ManualMining, chain.BlockInterval
+}
+
+// configuredLatency returns the highest latency a chain's configuration injects into a
+// request: its fixed latency or the upper bound of its latency distribution
+func configuredLatency(faults chainFaults) time.Duration {
+	latency := faults.Latency
+	if d := faults.LatencyDistribution; d != nil && len(d.Buckets) > 0 {
+		if bound := time.Duration(d.Buckets[len(d.Buckets)-1].LeMs * float64(time.Millisecond)); bound > latency {
+			latency = bound
+		}
+	}
+	return latency
+}
+
+// guardrailWarnings returns the watchdog's warnings for a chain: taking longer than the block
+// interval to produce blocks, and configured latency above the chain's guardrail
+func guardrailWarnings(name string, config WatchdogConfig) []string {
+	var warnings []string
+	chainId := chainIDForName(name)
+	if producing, _ := blockProduction(name); producing {
+		if pressure := chainLoads.For(chainId).currentPressure(); pressure > 1 {
+			warnings = append(warnings, fmt.Sprintf("falling_behind: blocks take %.1fx the interval", pressure))
+		}
+	}
+	if guardrail := config.maxResponseLatency(name); guardrail > 0 {
+		if latency := configuredLatency(liveFaults(chainId)); latency > guardrail {
+			warnings = append(warnings, fmt.Sprintf("latency %v above the %v guardrail", latency, guardrail))
+		}
+	}
+	return warnings
+}
+
+// checkWatchdog checks every chain once, counting its findings and, with auto clamp, clamping
+// the settings of chains that exceed their guardrails
+func checkWatchdog() {
+	config := watchdogConfig()
+	for _, name := range allChainNames() {
+		chainId := chainIDForName(name)
+		load := chainLoads.For(chainId)
+		producing, interval := blockProduction(name)
+		behind := producing && load.currentPressure() > 1
+		guardrail := config.maxResponseLatency(name)
+		overGuardrail := guardrail > 0 && configuredLatency(liveFaults(chainId)) > guardrail
+
+		watchdog.Lock()
+		counts, ok := watchdog.chains[name]
+		if !ok {
+			counts = &watchdogCounts{}
+			watchdog.chains[name] = counts
+		}
+		if behind {
+			counts.fallingBehind++
+		}
+		if overGuardrail {
+			counts.overGuardrail++
+		}
+		var clamped []string
+		// Clamp the interval at most once per block, since the pressure only changes per block
+		if ticks := atomic.LoadUint64(&load.overloadTicks); config.AutoClamp && behind && ticks != counts.clampedTick {
+			counts.clampedTick = ticks
+			clamped = append(clamped, clampFallingBehind(name, interval, load.currentPressure()))
+		}
+		if config.AutoClamp && overGuardrail {
+			clamped = append(clamped, clampLatency(name, guardrail))
+		}
+		for _, clamp := range clamped {
+			counts.clamps++
+			counts.lastClamp = clamp
+			log.Printf("Watchdog clamped chain %s: %s", name, clamp)
+		}
+		watchdog.Unlock()
+	}
+}
+
+// clampFallingBehind lightens the block production of a chain that takes pressure times its
+// interval per block: fewer logs per block while there are several, otherwise a longer interval
+func clampFallingBehind(name string, interval time.Duration, pressure float64) string {
+	if chain, ok := supportedChains[name]; ok && chain.LogsPerBlock > 1 {
+		logs := chain.LogsPerBlock
+		chain.LogsPerBlock = int(math.Max(1, float64(logs)/pressure))
+		return fmt.Sprintf("logs_per_block %d -> %d", logs, chain.LogsPerBlock)
+	}
+	clamped := time.Duration(math.Ceil(float64(interval)*pressure/float64(time.Millisecond))) * time.Millisecond
+	if name == "solana" {
+		solanaNode.SlotInterval = clamped
+		return fmt.Sprintf("slot_interval %v -> %v", interval, clamped)
+	}
+	supportedChains[name].BlockInterval = clamped
+	return fmt.Sprintf("block_interval %v -> %v", interval, clamped)
+}
+
+// clampLatency caps a chain's fixed latency at the guardrail and folds the buckets of its
+// latency distribution above the guardrail into one bucket at the guardrail
+func clampLatency(name string, guardrail time.Duration) string {
+	latencyMu.Lock()
+	defer latencyMu.Unlock()
+	latency, distribution, _, _ := latencyFields(chainIDForName(name))
+	before := configuredLatency(chainFaults{Latency: *latency, LatencyDistribution: *distribution})
+	if *latency > guardrail {
+		*latency = guardrail
+	}
+	if d := *distribution; d != nil && configuredLatency(chainFaults{LatencyDistribution: d}) > guardrail {
+		limitMs := float64(guardrail) / float64(time.Millisecond)
+		clamped := &LatencyDistribution{}
+		excess := 0.0
+		for _, bucket := range d.Buckets {
+			if bucket.LeMs < limitMs {
+				clamped.Buckets = append(clamped.Buckets, bucket)
+			} else {
+				excess += bucket.Weight
+			}
+		}
+		clamped.Buckets = append(clamped.Buckets, LatencyBucket{LeMs: limitMs, Weight: excess})
+		*distribution = clamped // Replaced, not modified, since fault snapshots share it
+	}
+	return fmt.Sprintf("latency %v -> %v", before, guardrail)
+}
+
+// watchdogSnapshot returns the current warnings and counts of every chain by name
+func watchdogSnapshot() map[string]WatchdogChainSnapshot {
+	config := watchdogConfig()
+	snapshots := make(map[string]WatchdogChainSnapshot)
+	for _, name := range allChainNames() {
+		snapshot := WatchdogChainSnapshot{Warnings: guardrailWarnings(name, config)}
+		if snapshot.Warnings == nil {
+			snapshot.Warnings = []string{}
+		}
+		watchdog.Lock()
+		if counts, ok := watchdog.chains[name]; ok {
+			snapshot.FallingBehindChecks = counts.fallingBehind
+			snapshot.OverGuardrailChecks = counts.overGuardrail
+			snapshot.Clamps = counts.clamps
+			snapshot.LastClamp = counts.lastClamp
+		}
+		watchdog.Unlock()
+		snapshots[name] = snapshot
+	}
+	return snapshots
+}
+
+// runWatchdog checks all chains periodically
+func runWatchdog() {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		checkWatchdog()
+	}
+}
+
+// handleWatchdog returns the watchdog config and the warnings and counts of every chain (GET),
+// or updates the config (POST). With chain, max_response_latency_ms sets that chain's own
+// guardrail, and a negative value removes it; reset clears the counts.
+func handleWatchdog(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"config": watchdogConfig(),
+			"chains": watchdogSnapshot(),
+		})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Chain                string `json:"chain"`
+		AutoClamp            *bool  `json:"auto_clamp"`
+		MaxResponseLatencyMs *int   `json:"max_response_latency_ms"`
+		Reset                bool   `json:"reset"` // Clear the counts of all chains
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Chain != "" && getChain(request.Chain) == nil {
+		http.Error(w, "Chain not found", http.StatusNotFound)
+		return
+	}
+	if request.Chain == "" && request.MaxResponseLatencyMs != nil && *request.MaxResponseLatencyMs < 0 {
+		http.Error(w, "max_response_latency_ms must be non-negative", http.StatusBadRequest)
+		return
+	}
+
+	watchdog.Lock()
+	if request.AutoClamp != nil {
+		watchdog.config.AutoClamp = *request.AutoClamp
+	}
+	if ms := request.MaxResponseLatencyMs; ms != nil {
+		switch {
+		case request.Chain == "":
+			watchdog.config.MaxResponseLatencyMs = *ms
+		case *ms < 0:
+			delete(watchdog.config.Chains, request.Chain)
+		default:
+			watchdog.config.Chains[request.Chain] = *ms
+		}
+	}
+	if request.Reset {
+		watchdog.chains = make(map[string]*watchdogCounts)
+	}
+	watchdog.Unlock()
+
+	config := watchdogConfig()
+	log.Printf("Watchdog: auto clamp=%v, max response latency=%dms, chain guardrails=%v",
+		config.AutoClamp, config.MaxResponseLatencyMs, config.Chains)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
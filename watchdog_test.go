@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setWatchdog(t *testing.T, body string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleWatchdog(w, httptest.NewRequest(http.MethodPost, "/control/watchdog", bytes.NewBufferString(body)))
+	return w.Code
+}
+
+func resetWatchdog(t *testing.T) {
+	t.Cleanup(func() {
+		watchdog.Lock()
+		watchdog.config = WatchdogConfig{Chains: make(map[string]int)}
+		watchdog.chains = make(map[string]*watchdogCounts)
+		watchdog.Unlock()
+		chainLoads.Reset()
+	})
+}
+
+func chainWarnings(name string) []string {
+	for _, cs := range currentState().Chains {
+		if cs.Chain == name {
+			return cs.Warnings
+		}
+	}
+	return nil
+}
+
+func TestWatchdogClampsChainFallingBehind(t *testing.T) {
+	resetWatchdog(t)
+	chain := supportedChains["linea"]
+	originalLogs, originalInterval := chain.LogsPerBlock, chain.BlockInterval
+	defer func() {
+		chain.LogsPerBlock = originalLogs
+		chain.BlockInterval = originalInterval
+	}()
+	chain.LogsPerBlock = 1000
+	chain.BlockInterval = 10 * time.Millisecond
+
+	// A block took 4 times its interval
+	load := chainLoads.For("59144")
+	load.recordTick(10*time.Millisecond, 0, 40*time.Millisecond, overloadConfig())
+	if warnings := chainWarnings("linea"); len(warnings) != 1 || !strings.Contains(warnings[0], "falling_behind: blocks take 4.0x") {
+		t.Fatalf("Expected a falling behind warning in the state, got %v", warnings)
+	}
+
+	// Without auto clamp the watchdog only counts
+	checkWatchdog()
+	if chain.LogsPerBlock != 1000 || watchdogSnapshot()["linea"].FallingBehindChecks != 1 {
+		t.Fatalf("Expected only a count, got %d logs per block and %+v", chain.LogsPerBlock, watchdogSnapshot()["linea"])
+	}
+
+	if code := setWatchdog(t, `{"auto_clamp": true}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	checkWatchdog()
+	if chain.LogsPerBlock != 250 {
+		t.Errorf("Expected logs per block clamped to 250, got %d", chain.LogsPerBlock)
+	}
+	// The pressure of the same block is not applied twice
+	checkWatchdog()
+	if chain.LogsPerBlock != 250 {
+		t.Errorf("Expected one clamp per block, got %d logs per block", chain.LogsPerBlock)
+	}
+
+	// With one log per block left, the interval is raised to what a block takes
+	chain.LogsPerBlock = 1
+	load.recordTick(10*time.Millisecond, 0, 25*time.Millisecond, overloadConfig())
+	checkWatchdog()
+	if chain.BlockInterval != 25*time.Millisecond {
+		t.Errorf("Expected the block interval clamped to 25ms, got %v", chain.BlockInterval)
+	}
+	if snapshot := watchdogSnapshot()["linea"]; snapshot.Clamps != 2 || snapshot.LastClamp != "block_interval 10ms -> 25ms" {
+		t.Errorf("Expected 2 clamps, got %+v", snapshot)
+	}
+
+	// Paused chains don't produce blocks and can't fall behind
+	chain.runState.Transition(RunEventPause)
+	defer chain.runState.Reset()
+	if warnings := chainWarnings("linea"); len(warnings) != 0 {
+		t.Errorf("Expected no warnings while paused, got %v", warnings)
+	}
+}
+
+func TestWatchdogLatencyGuardrail(t *testing.T) {
+	resetWatchdog(t)
+	chain := supportedChains["avalanche"]
+	originalLatency := chain.Latency
+	defer func() {
+		setLatency("43114", originalLatency, nil)
+		latencyMu.Lock()
+		chain.LatencyDistribution = nil
+		latencyMu.Unlock()
+	}()
+	setLatency("43114", 5*time.Second, nil)
+	latencyMu.Lock()
+	chain.LatencyDistribution = &LatencyDistribution{Buckets: []LatencyBucket{{LeMs: 100, Weight: 9}, {LeMs: 3000, Weight: 0.5}, {LeMs: 60000, Weight: 0.5}}}
+	latencyMu.Unlock()
+
+	if code := setWatchdog(t, `{"max_response_latency_ms": 1000}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if code := setWatchdog(t, `{"chain": "avalanche", "max_response_latency_ms": 2000}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if warnings := chainWarnings("avalanche"); len(warnings) != 1 || warnings[0] != "latency 1m0s above the 2s guardrail" {
+		t.Fatalf("Expected the chain's own guardrail to apply, got %v", warnings)
+	}
+
+	setWatchdog(t, `{"auto_clamp": true}`)
+	checkWatchdog()
+	if chain.Latency != 2*time.Second {
+		t.Errorf("Expected the latency clamped to 2s, got %v", chain.Latency)
+	}
+	buckets := chain.LatencyDistribution.Buckets
+	if len(buckets) != 2 || buckets[1] != (LatencyBucket{LeMs: 2000, Weight: 1}) || chain.LatencyDistribution.validate() != nil {
+		t.Errorf("Expected the slow buckets folded into one at 2000ms, got %+v", buckets)
+	}
+	if warnings := chainWarnings("avalanche"); len(warnings) != 0 {
+		t.Errorf("Expected no warnings after clamping, got %v", warnings)
+	}
+
+	// A negative chain guardrail falls back to the default one
+	setWatchdog(t, `{"chain": "avalanche", "max_response_latency_ms": -1}`)
+	if warnings := chainWarnings("avalanche"); len(warnings) != 1 || !strings.Contains(warnings[0], "1s guardrail") {
+		t.Errorf("Expected the default guardrail, got %v", warnings)
+	}
+}
+
+func TestWatchdogValidation(t *testing.T) {
+	resetWatchdog(t)
+	for body, want := range map[string]int{
+		`{"chain": "unknown", "max_response_latency_ms": 100}`: http.StatusNotFound,
+		`{"max_response_latency_ms": -1}`:                      http.StatusBadRequest,
+		`not json`:                                             http.StatusBadRequest,
+	} {
+		if code := setWatchdog(t, body); code != want {
+			t.Errorf("%s: expected status %d, got %d", body, want, code)
+		}
+	}
+}